@@ -0,0 +1,152 @@
+package gortf
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Annotation is a comment left by a reviewer, from an {\*\annotation} group.
+// The body refers to it with a block of kind BlockKindAnnotation, and may
+// delimit the text it is about with blocks of kinds BlockKindAnnotationStart
+// and BlockKindAnnotationEnd.
+type Annotation struct {
+	// Initials and Author identify the reviewer (\atnid, \atnauthor).
+	Initials string
+	Author   string
+
+	// Date is the time the comment was made (\atndate), if known.
+	Date *time.Time
+
+	Body []StyleBlock
+}
+
+// Text returns the plain text of the comment.
+func (a Annotation) Text() string {
+	var sb strings.Builder
+	for _, block := range a.Body {
+		sb.WriteString(block.Text)
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// addAnnotationAnchor adds the start or end of the text an annotation to
+// come is about. Its index is set once the annotation is read, until which
+// it holds a negative number telling its anchor.
+func (r *RtfParser) addAnnotationAnchor(doc *RtfDocument, g *Group) {
+	name := strings.TrimSpace(r.textFromGroup(g, r.codePage))
+
+	anchor, ok := r.annotationAnchors[name]
+	if !ok {
+		anchor = -1
+		for _, other := range r.annotationAnchors {
+			anchor = min(anchor, other-1)
+		}
+		r.annotationAnchors[name] = anchor
+	}
+
+	kind := BlockKindAnnotationStart
+	if g.Destination == "atrfend" {
+		kind = BlockKindAnnotationEnd
+	}
+
+	doc.pushToBody(StyleBlock{
+		Painter:         *r.lastPainter(),
+		Kind:            kind,
+		AnnotationIndex: anchor,
+	})
+}
+
+func (r *RtfParser) addAnnotation(doc *RtfDocument, g *Group) {
+	annotation := Annotation{
+		Initials: r.annotationInitials,
+		Author:   r.annotationAuthor,
+	}
+	r.annotationInitials, r.annotationAuthor = "", ""
+
+	if date := g.Find("atndate"); date != nil {
+		if value, err := strconv.Atoi(strings.TrimSpace(r.textFromGroup(date, r.codePage))); err == nil {
+			annotation.Date = parseDTTM(value)
+		}
+	}
+
+	annotation.Body = r.parseSubdocument(doc, g)
+	doc.Annotations = append(doc.Annotations, annotation)
+	index := len(doc.Annotations) - 1
+
+	if reference := g.Find("atnref"); reference != nil {
+		name := strings.TrimSpace(r.textFromGroup(reference, r.codePage))
+		if anchor, ok := r.annotationAnchors[name]; ok {
+			delete(r.annotationAnchors, name)
+			for i := range doc.Body {
+				if isAnnotationAnchor(doc.Body[i]) && doc.Body[i].AnnotationIndex == anchor {
+					doc.Body[i].AnnotationIndex = index
+				}
+			}
+		}
+	}
+
+	doc.pushToBody(StyleBlock{
+		Painter:         *r.lastPainter(),
+		Kind:            BlockKindAnnotation,
+		AnnotationIndex: index,
+	})
+}
+
+// removeUnresolvedAnchors removes the anchors of annotations that were never
+// found, joining the text around them.
+func (r *RtfParser) removeUnresolvedAnchors(doc *RtfDocument) {
+	if len(r.annotationAnchors) == 0 {
+		return
+	}
+
+	remove := func(body []StyleBlock) []StyleBlock {
+		var kept []StyleBlock
+		for _, block := range body {
+			if !isAnnotationAnchor(block) || block.AnnotationIndex >= 0 {
+				kept = appendRun(kept, block)
+			}
+		}
+		return kept
+	}
+
+	doc.Body = remove(doc.Body)
+	for i := range doc.Footnotes {
+		doc.Footnotes[i].Body = remove(doc.Footnotes[i].Body)
+	}
+	for i := range doc.HeadersFooters {
+		doc.HeadersFooters[i].Body = remove(doc.HeadersFooters[i].Body)
+	}
+	for i := range doc.Annotations {
+		doc.Annotations[i].Body = remove(doc.Annotations[i].Body)
+	}
+}
+
+func isAnnotationAnchor(block StyleBlock) bool {
+	return block.Kind == BlockKindAnnotationStart || block.Kind == BlockKindAnnotationEnd
+}
+
+// parseDTTM converts a date in the DTTM format of Word, which packs the
+// minute, hour, day, month and year since 1900 in the bits of an integer.
+func parseDTTM(value int) *time.Time {
+	if value == 0 {
+		return nil
+	}
+
+	minute := value & 0x3f
+	hour := (value >> 6) & 0x1f
+	day := (value >> 11) & 0x1f
+	month := (value >> 16) & 0xf
+	year := 1900 + (value>>20)&0x1ff
+
+	t := time.Date(year, time.Month(month), day, hour, minute, 0, 0, time.UTC)
+	return &t
+}
+
+// dttm is the reverse of parseDTTM. The day of the week, kept in the top
+// bits, is left out, which readers do not need.
+func dttm(t time.Time) int {
+	t = t.UTC()
+	return t.Minute() | t.Hour()<<6 | t.Day()<<11 | int(t.Month())<<16 | (t.Year()-1900)<<20
+}
@@ -0,0 +1,105 @@
+package gortf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const annotatedRTF = `{\rtf1\ansi Please {\*\atrfstart 7}check{\*\atrfend 7} this` +
+	`{\*\atnid AS}{\*\atnauthor Ann Smith}\chatn{\*\annotation{\*\atnref 7}{\*\atndate 130239366}\pard\plain {\b Wrong} date.\par}` +
+	` and {\*\atrfstart 9}that{\*\atrfend 9}.\par}`
+
+func TestParseAnnotations(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(annotatedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []StyleBlock{
+		{Kind: BlockKindText, Text: "Please "},
+		{Kind: BlockKindAnnotationStart},
+		{Kind: BlockKindText, Text: "check"},
+		{Kind: BlockKindAnnotationEnd},
+		{Kind: BlockKindText, Text: " this"},
+		{Kind: BlockKindAnnotation},
+		{Kind: BlockKindText, Text: " and that."},
+	}
+	actual := doc.Paragraphs()[0].Runs
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	date := time.Date(2024, time.March, 9, 14, 6, 0, 0, time.UTC)
+	if len(doc.Annotations) != 1 {
+		t.Fatalf("\n\nexpected: %v\n\nactual\t: %v", 1, len(doc.Annotations))
+	}
+	annotation := doc.Annotations[0]
+	if annotation.Initials != "AS" || annotation.Author != "Ann Smith" || annotation.Date == nil || !annotation.Date.Equal(date) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a comment of Ann Smith on "+date.String(), annotation)
+	}
+	if annotation.Text() != "Wrong date." {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Wrong date.", annotation.Text())
+	}
+
+	if text, _ := doc.ToText(); text != "Please check this and that.\n" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Please check this and that.\n", text)
+	}
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(annotatedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Paragraphs()[0].Runs, parsed.Paragraphs()[0].Runs) || !reflect.DeepEqual(doc.Annotations, parsed.Annotations) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc, parsed)
+	}
+}
+
+func TestAnnotationsHTML(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(annotatedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := doc.ToHTMLWithOptions(HTMLOptions{Annotations: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`Please <mark class="annotated">check</mark> this<sup class="annotation-ref"><a href="#annotation-1">[AS1]</a></sup>`,
+		`<aside class="annotations"><p class="annotation" id="annotation-1"><span class="author">Ann Smith</span> ` +
+			`<time datetime="2024-03-09T14:06">2024-03-09 14:06</time> Wrong date.</p></aside>`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+
+	if plain, _ := doc.ToHTML(); strings.Contains(plain, "annotation") {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no annotations", plain)
+	}
+}
+
+func TestDTTM(t *testing.T) {
+	date := time.Date(2024, time.March, 9, 14, 6, 0, 0, time.UTC)
+	if actual := parseDTTM(dttm(date)); actual == nil || !actual.Equal(date) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", date, actual)
+	}
+}
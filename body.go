@@ -0,0 +1,480 @@
+package gortf
+
+// BlockKind tells what a StyleBlock of the body stands for. Besides text,
+// the body holds marks for the breaks of the document; like Word, the
+// formatting of a paragraph, table row or section is carried by the mark
+// that ends it.
+type BlockKind int
+
+const (
+	BlockKindText BlockKind = iota
+	// BlockKindParagraph is the end of a paragraph (\par).
+	BlockKindParagraph
+	// BlockKindLine is a line break within a paragraph (\line).
+	BlockKindLine
+	// BlockKindPage is a page break (\page).
+	BlockKindPage
+	// BlockKindCell is the end of a table cell, and of its last paragraph
+	// (\cell).
+	BlockKindCell
+	// BlockKindRow is the end of a table row (\row).
+	BlockKindRow
+	// BlockKindSection is the end of a section (\sect).
+	BlockKindSection
+	// BlockKindPicture is a picture of RtfDocument.Pictures.
+	BlockKindPicture
+	// BlockKindFootnote is the reference to a note of RtfDocument.Footnotes.
+	BlockKindFootnote
+	// BlockKindAnnotation is the reference to a comment of
+	// RtfDocument.Annotations (\chatn).
+	BlockKindAnnotation
+	// BlockKindAnnotationStart and BlockKindAnnotationEnd delimit the text a
+	// comment of RtfDocument.Annotations is about (\atrfstart, \atrfend).
+	BlockKindAnnotationStart
+	BlockKindAnnotationEnd
+	// BlockKindBookmarkStart and BlockKindBookmarkEnd delimit a bookmark, a
+	// named range of the body (\bkmkstart, \bkmkend).
+	BlockKindBookmarkStart
+	BlockKindBookmarkEnd
+	// BlockKindObject is an object of RtfDocument.Objects.
+	BlockKindObject
+	// BlockKindShape is a drawing object of RtfDocument.Shapes.
+	BlockKindShape
+	// BlockKindNoteNumber is the automatic number of a note within its body
+	// (\chftn). The number marking the note in the body of the document is
+	// its BlockKindFootnote block.
+	BlockKindNoteNumber
+)
+
+func (b BlockKind) String() string {
+	switch b {
+	case BlockKindText:
+		return "Text"
+	case BlockKindParagraph:
+		return "Paragraph"
+	case BlockKindLine:
+		return "Line"
+	case BlockKindPage:
+		return "Page"
+	case BlockKindCell:
+		return "Cell"
+	case BlockKindRow:
+		return "Row"
+	case BlockKindSection:
+		return "Section"
+	case BlockKindPicture:
+		return "Picture"
+	case BlockKindFootnote:
+		return "Footnote"
+	case BlockKindAnnotation:
+		return "Annotation"
+	case BlockKindAnnotationStart:
+		return "AnnotationStart"
+	case BlockKindAnnotationEnd:
+		return "AnnotationEnd"
+	case BlockKindBookmarkStart:
+		return "BookmarkStart"
+	case BlockKindBookmarkEnd:
+		return "BookmarkEnd"
+	case BlockKindObject:
+		return "Object"
+	case BlockKindShape:
+		return "Shape"
+	case BlockKindNoteNumber:
+		return "NoteNumber"
+	default:
+		return "Unknown"
+	}
+}
+
+// blockKindText is the text the marks of the body produce in plain text.
+func blockKindText(kind BlockKind) string {
+	switch kind {
+	case BlockKindCell:
+		return "\t"
+	case BlockKindParagraph, BlockKindLine, BlockKindPage, BlockKindRow, BlockKindSection:
+		return "\n"
+	default:
+		return ""
+	}
+}
+
+// Alignment is the horizontal alignment of a paragraph or table row.
+type Alignment int
+
+const (
+	AlignmentLeft Alignment = iota
+	AlignmentCenter
+	AlignmentRight
+	AlignmentJustify
+	AlignmentDistribute
+)
+
+func (a Alignment) String() string {
+	switch a {
+	case AlignmentLeft:
+		return "Left"
+	case AlignmentCenter:
+		return "Center"
+	case AlignmentRight:
+		return "Right"
+	case AlignmentJustify:
+		return "Justify"
+	case AlignmentDistribute:
+		return "Distribute"
+	default:
+		return "Unknown"
+	}
+}
+
+func alignmentFromToken(tkn token) Alignment {
+	if tkn.tokenType() == tokenTypeControlWord {
+		controlWord := tkn.(controlWordToken)
+
+		switch controlWord.name {
+		case `\qc`, `\trqc`:
+			return AlignmentCenter
+		case `\qr`, `\trqr`:
+			return AlignmentRight
+		case `\qj`:
+			return AlignmentJustify
+		case `\qd`:
+			return AlignmentDistribute
+		}
+	}
+
+	return AlignmentLeft
+}
+
+// ParagraphFormat holds the paragraph formatting properties, reset by \pard.
+// Distances are in twips.
+type ParagraphFormat struct {
+	Style           int       `json:"style,omitempty"`
+	Alignment       Alignment `json:"alignment,omitempty"`
+	LeftIndent      int       `json:"leftIndent,omitempty"`
+	RightIndent     int       `json:"rightIndent,omitempty"`
+	FirstLineIndent int       `json:"firstLineIndent,omitempty"`
+	SpaceBefore     int       `json:"spaceBefore,omitempty"`
+	SpaceAfter      int       `json:"spaceAfter,omitempty"`
+
+	// LineSpacing is the \sl value: positive for at least that spacing,
+	// negative for exactly its opposite and zero for single spacing. When
+	// LineSpacingMultiple is set, it is a multiple of single spacing in
+	// 240ths instead.
+	LineSpacing         int  `json:"lineSpacing,omitempty"`
+	LineSpacingMultiple bool `json:"lineSpacingMultiple,omitempty"`
+
+	KeepTogether    bool `json:"keepTogether,omitempty"`
+	KeepWithNext    bool `json:"keepWithNext,omitempty"`
+	PageBreakBefore bool `json:"pageBreakBefore,omitempty"`
+
+	// InTable is set for paragraphs that are part of a table cell.
+	InTable bool `json:"inTable,omitempty"`
+
+	// List is the entry of the list table of a list paragraph, 0 meaning
+	// none, and ListLevel its level, from 0.
+	List      TableRef `json:"list,omitempty"`
+	ListLevel int      `json:"listLevel,omitempty"`
+
+	// RevisedBy is the index in RtfHeader.RevisionAuthors of the author who
+	// changed the formatting or numbering of the paragraph while revisions
+	// were tracked, and RevisedAt the time of the change.
+	RevisedBy int          `json:"revisedBy,omitempty"`
+	RevisedAt RevisionTime `json:"revisedAt,omitempty"`
+
+	// Tabs are the tab stops of the paragraph, in the order they are given.
+	// Past the last one, tabs stop every RtfHeader.DefaultTab.
+	Tabs []TabStop `json:"tabs,omitempty"`
+
+	// the alignment and leader of the tab stop whose position is to come
+	nextTab TabStop
+}
+
+// CellMerge tells whether a table cell is merged with its neighbours.
+type CellMerge int
+
+const (
+	CellMergeNone CellMerge = iota
+	// CellMergeFirst is the first of a range of merged cells.
+	CellMergeFirst
+	// CellMergePrevious is a cell merged with the one before it.
+	CellMergePrevious
+)
+
+func (c CellMerge) String() string {
+	switch c {
+	case CellMergeNone:
+		return "None"
+	case CellMergeFirst:
+		return "First"
+	case CellMergePrevious:
+		return "Previous"
+	default:
+		return "Unknown"
+	}
+}
+
+// CellFormat holds the properties of a table cell, given before its \cellx.
+type CellFormat struct {
+	// Right is the position of the right boundary of the cell in twips,
+	// relative to the left margin.
+	Right int `json:"right"`
+
+	HorizontalMerge CellMerge `json:"horizontalMerge,omitempty"`
+	VerticalMerge   CellMerge `json:"verticalMerge,omitempty"`
+
+	// Background is the color table entry of the cell shading, 0 meaning
+	// none.
+	Background TableRef `json:"background,omitempty"`
+}
+
+// RowFormat holds the table row properties, reset by \trowd.
+type RowFormat struct {
+	Cells []CellFormat `json:"cells,omitempty"`
+
+	Alignment Alignment `json:"alignment,omitempty"`
+	// LeftIndent is the position of the left edge of the row in twips.
+	LeftIndent int `json:"leftIndent,omitempty"`
+	// Gap is half the space between the text of adjacent cells in twips.
+	Gap int `json:"gap,omitempty"`
+	// Header is set for rows repeated at the top of every page.
+	Header bool `json:"header,omitempty"`
+}
+
+// SectionBreak is the kind of break that starts a section.
+type SectionBreak int
+
+const (
+	SectionBreakPage SectionBreak = iota
+	SectionBreakNone
+	SectionBreakColumn
+	SectionBreakEven
+	SectionBreakOdd
+)
+
+func (s SectionBreak) String() string {
+	switch s {
+	case SectionBreakPage:
+		return "Page"
+	case SectionBreakNone:
+		return "None"
+	case SectionBreakColumn:
+		return "Column"
+	case SectionBreakEven:
+		return "Even"
+	case SectionBreakOdd:
+		return "Odd"
+	default:
+		return "Unknown"
+	}
+}
+
+func sectionBreakFromToken(tkn token) SectionBreak {
+	if tkn.tokenType() == tokenTypeControlWord {
+		controlWord := tkn.(controlWordToken)
+
+		switch controlWord.name {
+		case `\sbknone`:
+			return SectionBreakNone
+		case `\sbkcol`:
+			return SectionBreakColumn
+		case `\sbkeven`:
+			return SectionBreakEven
+		case `\sbkodd`:
+			return SectionBreakOdd
+		}
+	}
+
+	return SectionBreakPage
+}
+
+// SectionFormat holds the section formatting properties, reset by \sectd.
+// Sizes are in twips, zero meaning the document default.
+type SectionFormat struct {
+	Break     SectionBreak `json:"break,omitempty"`
+	Columns   int          `json:"columns,omitempty"`
+	Landscape bool         `json:"landscape,omitempty"`
+
+	PageWidth    int `json:"pageWidth,omitempty"`
+	PageHeight   int `json:"pageHeight,omitempty"`
+	MarginLeft   int `json:"marginLeft,omitempty"`
+	MarginRight  int `json:"marginRight,omitempty"`
+	MarginTop    int `json:"marginTop,omitempty"`
+	MarginBottom int `json:"marginBottom,omitempty"`
+}
+
+func (r RowFormat) clone() RowFormat {
+	r.Cells = append([]CellFormat(nil), r.Cells...)
+	return r
+}
+
+// applyCharacterFormat updates a painter with a character formatting control
+// word.
+func applyCharacterFormat(painter *Painter, controlWord controlWordToken) {
+	switch controlWord.controlWordType {
+	case controlWordTypeFontNumber:
+		painter.FontRef = TableRef(max(controlWord.parameter, 0))
+	case controlWordTypeFontSize:
+		painter.FontSize = controlWord.parameter
+	case controlWordTypeBold:
+		painter.Bold = controlWord.parameter != 0
+	case controlWordTypeItalic:
+		painter.Italic = controlWord.parameter != 0
+	case controlWordTypeUnderline:
+		painter.Underline = controlWord.parameter != 0
+	case controlWordTypeUnderlineNone:
+		painter.Underline = false
+	case controlWordTypeStrikethrough:
+		painter.Strikethrough = controlWord.parameter != 0
+	case controlWordTypeSuperscript:
+		painter.Superscript = controlWord.parameter != 0
+		painter.Subscript = false
+	case controlWordTypeSubscript:
+		painter.Subscript = controlWord.parameter != 0
+		painter.Superscript = false
+	case controlWordTypeNoSuperSub:
+		painter.Superscript = false
+		painter.Subscript = false
+	case controlWordTypeSmallcaps:
+		painter.SmallCaps = controlWord.parameter != 0
+	case controlWordTypeHidden:
+		painter.Hidden = controlWord.parameter != 0
+	case controlWordTypePlain:
+		// \plain resets the formatting, not the field the text is part of
+		*painter = Painter{Link: painter.Link, Field: painter.Field}
+	case controlWordTypeForegroundColor:
+		painter.ForegroundColor = TableRef(max(controlWord.parameter, 0))
+	case controlWordTypeBackgroundColor:
+		painter.BackgroundColor = TableRef(max(controlWord.parameter, 0))
+	case controlWordTypeHighlight:
+		painter.Highlight = TableRef(max(controlWord.parameter, 0))
+	case controlWordTypeRevised:
+		painter.Inserted = controlWord.parameter != 0
+	case controlWordTypeRevisionAuthor:
+		painter.InsertedBy = max(controlWord.parameter, 0)
+	case controlWordTypeRevisionTime:
+		painter.InsertedAt = RevisionTime(controlWord.parameter)
+	case controlWordTypeDeleted:
+		painter.Deleted = controlWord.parameter != 0
+	case controlWordTypeDeletionAuthor:
+		painter.DeletedBy = max(controlWord.parameter, 0)
+	case controlWordTypeDeletionTime:
+		painter.DeletedAt = RevisionTime(controlWord.parameter)
+	}
+}
+
+// applyParagraphFormat updates a paragraph format with a paragraph
+// formatting control word.
+func applyParagraphFormat(paragraph *ParagraphFormat, controlWord controlWordToken) {
+	switch controlWord.controlWordType {
+	case controlWordTypeParagraphDefault:
+		*paragraph = ParagraphFormat{}
+	case controlWordTypeStyleParagraph:
+		paragraph.Style = max(controlWord.parameter, 0)
+	case controlWordTypeParagraphAlignment:
+		paragraph.Alignment = alignmentFromToken(controlWord)
+	case controlWordTypeLeftIndent:
+		paragraph.LeftIndent = controlWord.parameter
+	case controlWordTypeRightIndent:
+		paragraph.RightIndent = controlWord.parameter
+	case controlWordTypeFirstLineIndent:
+		paragraph.FirstLineIndent = controlWord.parameter
+	case controlWordTypeSpaceBefore:
+		paragraph.SpaceBefore = controlWord.parameter
+	case controlWordTypeSpaceAfter:
+		paragraph.SpaceAfter = controlWord.parameter
+	case controlWordTypeLineSpacing:
+		paragraph.LineSpacing = controlWord.parameter
+	case controlWordTypeLineSpacingMultiple:
+		paragraph.LineSpacingMultiple = controlWord.parameter == 1
+	case controlWordTypeKeepTogether:
+		paragraph.KeepTogether = controlWord.parameter != 0
+	case controlWordTypeKeepWithNext:
+		paragraph.KeepWithNext = controlWord.parameter != 0
+	case controlWordTypePageBreakBefore:
+		paragraph.PageBreakBefore = controlWord.parameter != 0
+	case controlWordTypeInTable:
+		paragraph.InTable = true
+	case controlWordTypeParagraphRevisionAuthor, controlWordTypeNumberingRevisionAuthor:
+		paragraph.RevisedBy = max(controlWord.parameter, 0)
+	case controlWordTypeParagraphRevisionTime, controlWordTypeNumberingRevisionTime:
+		paragraph.RevisedAt = RevisionTime(controlWord.parameter)
+	case controlWordTypeListOverrideLs:
+		paragraph.List = TableRef(max(controlWord.parameter, 0))
+	case controlWordTypeParagraphListLevel:
+		paragraph.ListLevel = max(controlWord.parameter, 0)
+	case controlWordTypeTabAlignment, controlWordTypeTabLeader, controlWordTypeTabPosition, controlWordTypeBarTab:
+		applyTabStop(paragraph, controlWord)
+	}
+}
+
+// applyRowFormat updates the current table row with a row or cell
+// formatting control word. Cell properties accumulate until the \cellx that
+// ends the definition of the cell.
+func (r *RtfParser) applyRowFormat(controlWord controlWordToken) {
+	switch controlWord.controlWordType {
+	case controlWordTypeRowDefault:
+		r.row = RowFormat{}
+		r.cell = CellFormat{}
+	case controlWordTypeRowAlignment:
+		r.row.Alignment = alignmentFromToken(controlWord)
+	case controlWordTypeRowLeftIndent:
+		r.row.LeftIndent = controlWord.parameter
+	case controlWordTypeRowGap:
+		r.row.Gap = controlWord.parameter
+	case controlWordTypeRowHeader:
+		r.row.Header = controlWord.parameter != 0
+	case controlWordTypeCellBoundary:
+		r.cell.Right = controlWord.parameter
+		r.row.Cells = append(r.row.Cells, r.cell)
+		r.cell = CellFormat{}
+	case controlWordTypeCellHorizontalMerge:
+		r.cell.HorizontalMerge = cellMergeFromToken(controlWord)
+	case controlWordTypeCellVerticalMerge:
+		r.cell.VerticalMerge = cellMergeFromToken(controlWord)
+	case controlWordTypeCellBackground:
+		r.cell.Background = TableRef(max(controlWord.parameter, 0))
+	}
+}
+
+func cellMergeFromToken(tkn token) CellMerge {
+	if tkn.tokenType() == tokenTypeControlWord {
+		controlWord := tkn.(controlWordToken)
+
+		switch controlWord.name {
+		case `\clmgf`, `\clvmgf`:
+			return CellMergeFirst
+		case `\clmrg`, `\clvmrg`:
+			return CellMergePrevious
+		}
+	}
+
+	return CellMergeNone
+}
+
+// applySectionFormat updates a section format with a section formatting
+// control word.
+func applySectionFormat(section *SectionFormat, controlWord controlWordToken) {
+	switch controlWord.controlWordType {
+	case controlWordTypeSectionDefault:
+		*section = SectionFormat{}
+	case controlWordTypeSectionBreak:
+		section.Break = sectionBreakFromToken(controlWord)
+	case controlWordTypeSectionColumns:
+		section.Columns = controlWord.parameter
+	case controlWordTypeSectionLandscape:
+		section.Landscape = true
+	case controlWordTypeSectionPageWidth:
+		section.PageWidth = controlWord.parameter
+	case controlWordTypeSectionPageHeight:
+		section.PageHeight = controlWord.parameter
+	case controlWordTypeSectionMarginLeft:
+		section.MarginLeft = controlWord.parameter
+	case controlWordTypeSectionMarginRight:
+		section.MarginRight = controlWord.parameter
+	case controlWordTypeSectionMarginTop:
+		section.MarginTop = controlWord.parameter
+	case controlWordTypeSectionMarginBottom:
+		section.MarginBottom = controlWord.parameter
+	}
+}
@@ -0,0 +1,113 @@
+package gortf
+
+import (
+	"strings"
+)
+
+// Bookmark is a named range of the body, delimited by blocks of kinds
+// BlockKindBookmarkStart and BlockKindBookmarkEnd. Start and End are the
+// positions of these blocks; End is missing for bookmarks left open.
+type Bookmark struct {
+	Name  string
+	Start TextPosition
+	End   *TextPosition
+}
+
+func (r *RtfParser) addBookmark(doc *RtfDocument, g *Group) {
+	kind := BlockKindBookmarkStart
+	if g.Destination == "bkmkend" {
+		kind = BlockKindBookmarkEnd
+	}
+
+	doc.pushToBody(StyleBlock{
+		Painter:  *r.lastPainter(),
+		Kind:     kind,
+		Bookmark: strings.TrimSpace(r.textFromGroup(g, r.codePage)),
+	})
+}
+
+// Bookmarks returns the bookmarks of the body, in the order they start.
+func (r *RtfDocument) Bookmarks() []Bookmark {
+	bookmarks := []Bookmark{}
+	open := map[string]int{}
+
+	for i, block := range r.Body {
+		switch block.Kind {
+		case BlockKindBookmarkStart:
+			open[block.Bookmark] = len(bookmarks)
+			bookmarks = append(bookmarks, Bookmark{Name: block.Bookmark, Start: TextPosition{Block: i}})
+		case BlockKindBookmarkEnd:
+			if index, ok := open[block.Bookmark]; ok {
+				bookmarks[index].End = &TextPosition{Block: i}
+				delete(open, block.Bookmark)
+			}
+		}
+	}
+
+	return bookmarks
+}
+
+// Bookmark returns the bookmark of the given name, compared regardless of
+// case like Word does.
+func (r *RtfDocument) Bookmark(name string) (Bookmark, bool) {
+	for _, bookmark := range r.Bookmarks() {
+		if strings.EqualFold(bookmark.Name, name) {
+			return bookmark, true
+		}
+	}
+
+	return Bookmark{}, false
+}
+
+// BookmarkText returns the text of the bookmark of the given name, which a
+// REF field referring to it shows.
+func (r *RtfDocument) BookmarkText(name string) (string, bool) {
+	bookmark, ok := r.Bookmark(name)
+	if !ok {
+		return "", false
+	}
+
+	end := len(r.Body)
+	if bookmark.End != nil {
+		end = bookmark.End.Block
+	}
+
+	var sb strings.Builder
+	for _, block := range r.Body[bookmark.Start.Block:end] {
+		if !block.Painter.Deleted {
+			sb.WriteString(block.Text)
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n"), true
+}
+
+// ReferenceTarget returns the bookmark text with the given formatting refers
+// to: the location of a hyperlink within the document (HYPERLINK \l), or the
+// bookmark of a REF or PAGEREF field.
+func (r *RtfDocument) ReferenceTarget(painter Painter) (Bookmark, bool) {
+	name, ok := referencedBookmark(painter)
+	if !ok {
+		return Bookmark{}, false
+	}
+
+	return r.Bookmark(name)
+}
+
+// referencedBookmark returns the name of the bookmark text with the given
+// formatting refers to.
+func referencedBookmark(painter Painter) (string, bool) {
+	if painter.Link != "" {
+		if strings.HasPrefix(painter.Link, "#") && len(painter.Link) > 1 {
+			return painter.Link[1:], true
+		}
+		return "", false
+	}
+
+	arguments := splitFieldInstruction(painter.Field)
+	if len(arguments) < 2 || !(strings.EqualFold(arguments[0], "REF") || strings.EqualFold(arguments[0], "PAGEREF")) {
+		return "", false
+	}
+
+	return arguments[1], true
+}
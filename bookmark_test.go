@@ -0,0 +1,76 @@
+package gortf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const bookmarkedRTF = `{\rtf1\ansi {\*\bkmkstart _Ref1}Terms{\*\bkmkend _Ref1}\par ` +
+	`See {\field{\*\fldinst HYPERLINK \\l "_Ref1"}{\fldrslt here}} and ` +
+	`{\field{\*\fldinst REF _Ref1 \\h}{\fldrslt Terms}}.{\*\bkmkstart open}\par}`
+
+func TestParseBookmarks(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(bookmarkedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Bookmark{
+		{Name: "_Ref1", Start: TextPosition{Block: 0}, End: &TextPosition{Block: 2}},
+		{Name: "open", Start: TextPosition{Block: 9}},
+	}
+	if actual := doc.Bookmarks(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	if text, ok := doc.BookmarkText("_ref1"); !ok || text != "Terms" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Terms", text)
+	}
+
+	for _, painter := range []Painter{{Link: "#_Ref1"}, {Field: `REF _Ref1 \h`}} {
+		if bookmark, ok := doc.ReferenceTarget(painter); !ok || bookmark.Name != "_Ref1" {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "_Ref1", bookmark)
+		}
+	}
+	if _, ok := doc.ReferenceTarget(Painter{Link: "https://example.com"}); ok {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no bookmark", ok)
+	}
+}
+
+func TestWriteBookmarks(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(bookmarkedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Body, parsed.Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Body, parsed.Body)
+	}
+}
+
+func TestBookmarksHTML(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(bookmarkedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, _ := doc.ToHTML()
+	for _, expected := range []string{`<a id="_Ref1"></a>Terms`, `<a href="#_Ref1">here</a>`, `<a href="#_Ref1">Terms</a>`} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package gortf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder builds documents without dealing with the body, whose formatting
+// is carried by marks, nor with the entries of the font, color and list
+// tables:
+//
+//	b := NewBuilder()
+//	arial := b.Font("Arial")
+//	b.Heading(1, "Report")
+//	b.Paragraph().Text("Sales were ").Bold("up").Text(" this year.")
+//	b.Paragraph().Font(arial).Color(b.Color(Color{R: 255})).Text("See ").Link("https://example.com", "the site")
+//	b.BulletList().Item("One").Item("Two")
+//	b.Table(2000, 4000).HeaderRow("Name", "Value").Row("a", "1")
+//	doc, err := b.Document()
+//
+// Text is set in 12 point Times New Roman unless given other fonts.
+type Builder struct {
+	doc    RtfDocument
+	blocks []interface{}
+	err    error
+}
+
+// NewBuilder returns a builder of an empty document.
+func NewBuilder() *Builder {
+	b := &Builder{}
+	b.doc.Header = RtfHeader{
+		Charset:    CharacterSetAnsi,
+		FontTable:  FontTable{0: {Name: "Times New Roman", FontFamily: FontFamilyRoman}},
+		ColorTable: ColorTable{0: {Auto: true}},
+		Stylesheet: Stylesheet{"Normal": {Name: "Normal", Number: 0, Painter: Painter{FontSize: 24}}},
+		Lists:      ListTable{},
+	}
+
+	return b
+}
+
+// Font returns the font table entry of a font, adding it if needed. Generic
+// families such as "sans-serif" and "monospace" stand for common fonts.
+func (b *Builder) Font(name string) TableRef {
+	return b.doc.Header.FontTable.ref(familyFont(name))
+}
+
+// Color returns the color table entry of a color, adding it if needed.
+func (b *Builder) Color(color Color) TableRef {
+	return b.doc.Header.ColorTable.ref(color)
+}
+
+// Info returns the information group of the document, to be filled in.
+func (b *Builder) Info() *RtfInformationGroup {
+	return &b.doc.InformationGroup
+}
+
+// Paragraph adds a paragraph.
+func (b *Builder) Paragraph() *ParagraphBuilder {
+	p := b.newParagraph(ParagraphFormat{}, Painter{FontSize: 24})
+	b.blocks = append(b.blocks, p.paragraph)
+
+	return p
+}
+
+// Heading adds a paragraph of text in the "heading 1" to "heading 6" style
+// of a level, adding the style to the stylesheet if needed.
+func (b *Builder) Heading(level int, text string) *ParagraphBuilder {
+	style := b.doc.Header.Stylesheet.heading(min(max(level, 1), 6))
+
+	p := b.newParagraph(style.Paragraph, style.Painter)
+	b.blocks = append(b.blocks, p.paragraph)
+
+	return p.Text(text)
+}
+
+// PageBreak starts a new page.
+func (b *Builder) PageBreak() *Builder {
+	p := b.newParagraph(ParagraphFormat{PageBreakBefore: true}, Painter{FontSize: 24})
+	b.blocks = append(b.blocks, p.paragraph)
+
+	return b
+}
+
+// BulletList adds a bulleted list.
+func (b *Builder) BulletList() *ListBuilder {
+	return &ListBuilder{builder: b, list: b.doc.Header.Lists.add(false)}
+}
+
+// NumberedList adds a numbered list, whose levels are numbered 1., a. and
+// i. in turn.
+func (b *Builder) NumberedList() *ListBuilder {
+	return &ListBuilder{builder: b, list: b.doc.Header.Lists.add(true)}
+}
+
+// Table adds a table of columns of the given widths in twips. Columns whose
+// width is not given share the width left of a 6.5 inch table.
+func (b *Builder) Table(widths ...int) *TableBuilder {
+	t := &TableBuilder{builder: b, widths: widths}
+	b.blocks = append(b.blocks, t)
+
+	return t
+}
+
+// Document returns the document built, or the first error met building it,
+// such as pictures of unsupported formats.
+func (b *Builder) Document() (RtfDocument, error) {
+	if b.err != nil {
+		return RtfDocument{}, b.err
+	}
+
+	elements := []BodyElement{}
+	for _, block := range b.blocks {
+		switch block := block.(type) {
+		case *Paragraph:
+			paragraph := *block
+			elements = append(elements, &paragraph)
+		case *TableBuilder:
+			if table := block.table(); len(table.Rows) > 0 {
+				elements = append(elements, table)
+			}
+		}
+	}
+
+	doc := b.doc
+	doc.Header.FontTable = copyTable(b.doc.Header.FontTable)
+	doc.Header.ColorTable = copyTable(b.doc.Header.ColorTable)
+	doc.Header.Lists = copyTable(b.doc.Header.Lists)
+	doc.Header.Stylesheet = Stylesheet{}
+	for name, style := range b.doc.Header.Stylesheet {
+		doc.Header.Stylesheet[name] = style
+	}
+	doc.Pictures = append([]Picture{}, b.doc.Pictures...)
+	doc.SetSections([]Section{{Elements: elements}})
+
+	return doc, nil
+}
+
+func copyTable[V any](table map[TableRef]V) map[TableRef]V {
+	copied := make(map[TableRef]V, len(table))
+	for ref, value := range table {
+		copied[ref] = value
+	}
+
+	return copied
+}
+
+func (b *Builder) newParagraph(format ParagraphFormat, painter Painter) *ParagraphBuilder {
+	mark := painter
+	return &ParagraphBuilder{
+		builder:   b,
+		paragraph: &Paragraph{Format: format, Runs: []StyleBlock{}, Mark: &mark},
+		painter:   painter,
+	}
+}
+
+// ParagraphBuilder adds runs to a paragraph. Runs are set in the font, size
+// and colors last given, and the methods named after a formatting add a run
+// with that formatting as well.
+type ParagraphBuilder struct {
+	builder   *Builder
+	paragraph *Paragraph
+	painter   Painter
+}
+
+// Font sets the font of the following runs.
+func (p *ParagraphBuilder) Font(font TableRef) *ParagraphBuilder {
+	p.painter.FontRef = font
+	return p
+}
+
+// Size sets the font size of the following runs in points.
+func (p *ParagraphBuilder) Size(points float64) *ParagraphBuilder {
+	p.painter.FontSize = int(points*2 + 0.5)
+	return p
+}
+
+// Color sets the color of the text of the following runs.
+func (p *ParagraphBuilder) Color(color TableRef) *ParagraphBuilder {
+	p.painter.ForegroundColor = color
+	return p
+}
+
+// Highlight sets the highlight color of the following runs, 0 for none.
+func (p *ParagraphBuilder) Highlight(color TableRef) *ParagraphBuilder {
+	p.painter.Highlight = color
+	return p
+}
+
+// Align sets the alignment of the paragraph.
+func (p *ParagraphBuilder) Align(alignment Alignment) *ParagraphBuilder {
+	p.paragraph.Format.Alignment = alignment
+	return p
+}
+
+// Indent sets the indents of the paragraph in twips.
+func (p *ParagraphBuilder) Indent(left int, right int, firstLine int) *ParagraphBuilder {
+	p.paragraph.Format.LeftIndent = left
+	p.paragraph.Format.RightIndent = right
+	p.paragraph.Format.FirstLineIndent = firstLine
+	return p
+}
+
+// Spacing sets the space before and after the paragraph in twips.
+func (p *ParagraphBuilder) Spacing(before int, after int) *ParagraphBuilder {
+	p.paragraph.Format.SpaceBefore = before
+	p.paragraph.Format.SpaceAfter = after
+	return p
+}
+
+// Text adds a run of text. Newlines and tabs in it become line breaks and
+// tabs.
+func (p *ParagraphBuilder) Text(text string) *ParagraphBuilder {
+	return p.Run(text, p.painter)
+}
+
+// Run adds a run of text in the formatting of a painter.
+func (p *ParagraphBuilder) Run(text string, painter Painter) *ParagraphBuilder {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			p.paragraph.Runs = append(p.paragraph.Runs, StyleBlock{Kind: BlockKindLine, Text: blockKindText(BlockKindLine), Painter: painter})
+		}
+		if line != "" {
+			p.paragraph.Runs = appendRun(p.paragraph.Runs, StyleBlock{Kind: BlockKindText, Text: line, Painter: painter})
+		}
+	}
+
+	return p
+}
+
+// Bold adds a run of bold text.
+func (p *ParagraphBuilder) Bold(text string) *ParagraphBuilder {
+	painter := p.painter
+	painter.Bold = true
+	return p.Run(text, painter)
+}
+
+// Italic adds a run of italic text.
+func (p *ParagraphBuilder) Italic(text string) *ParagraphBuilder {
+	painter := p.painter
+	painter.Italic = true
+	return p.Run(text, painter)
+}
+
+// Underline adds a run of underlined text.
+func (p *ParagraphBuilder) Underline(text string) *ParagraphBuilder {
+	painter := p.painter
+	painter.Underline = true
+	return p.Run(text, painter)
+}
+
+// Strikethrough adds a run of struck through text.
+func (p *ParagraphBuilder) Strikethrough(text string) *ParagraphBuilder {
+	painter := p.painter
+	painter.Strikethrough = true
+	return p.Run(text, painter)
+}
+
+// Superscript adds a run of superscript text.
+func (p *ParagraphBuilder) Superscript(text string) *ParagraphBuilder {
+	painter := p.painter
+	painter.Superscript = true
+	return p.Run(text, painter)
+}
+
+// Subscript adds a run of subscript text.
+func (p *ParagraphBuilder) Subscript(text string) *ParagraphBuilder {
+	painter := p.painter
+	painter.Subscript = true
+	return p.Run(text, painter)
+}
+
+// Link adds a hyperlink to a URL, or to a bookmark given as "#name".
+func (p *ParagraphBuilder) Link(url string, text string) *ParagraphBuilder {
+	painter := p.painter
+	painter.Link = url
+	return p.Run(text, painter)
+}
+
+// Image adds a picture read from the data of a PNG, JPEG, BMP, EMF or WMF
+// file, of the given extension, displayed at the given size in twips. A size
+// of 0 by 0 displays bitmaps at 96 dots per inch.
+func (p *ParagraphBuilder) Image(data []byte, extension string, width int, height int) *ParagraphBuilder {
+	picture, ok := pictureFromFile(data, extension, width, height)
+	if !ok {
+		if p.builder.err == nil {
+			p.builder.err = fmt.Errorf("gortf: unsupported picture format %q", extension)
+		}
+		return p
+	}
+
+	doc := &p.builder.doc
+	doc.Pictures = append(doc.Pictures, picture)
+	p.paragraph.Runs = append(p.paragraph.Runs, StyleBlock{Kind: BlockKindPicture, PictureIndex: len(doc.Pictures) - 1, Painter: p.painter})
+
+	return p
+}
+
+// ListBuilder adds the items of a list.
+type ListBuilder struct {
+	builder *Builder
+	list    TableRef
+}
+
+// Item adds an item of text at the first level.
+func (l *ListBuilder) Item(text string) *ListBuilder {
+	l.Add(0).Text(text)
+	return l
+}
+
+// Add adds an item at a level from 0 to 8, returning it to add runs to.
+func (l *ListBuilder) Add(level int) *ParagraphBuilder {
+	level = min(max(level, 0), 8)
+	format := l.builder.doc.Header.Lists[l.list].Levels[level]
+
+	p := l.builder.Paragraph()
+	p.paragraph.Format.List = l.list
+	p.paragraph.Format.ListLevel = level
+	p.paragraph.Format.LeftIndent = format.LeftIndent
+	p.paragraph.Format.FirstLineIndent = format.FirstLineIndent
+
+	return p
+}
+
+// TableBuilder adds the rows of a table.
+type TableBuilder struct {
+	builder *Builder
+	widths  []int
+	rows    []*RowBuilder
+}
+
+// Row adds a row of cells of text.
+func (t *TableBuilder) Row(cells ...string) *TableBuilder {
+	row := t.AddRow()
+	for _, text := range cells {
+		row.Cell().Text(text)
+	}
+
+	return t
+}
+
+// HeaderRow adds a row of cells of bold text, repeated at the top of each
+// page the table spans.
+func (t *TableBuilder) HeaderRow(cells ...string) *TableBuilder {
+	row := t.AddRow().Header()
+	for _, text := range cells {
+		row.Cell().Bold(text)
+	}
+
+	return t
+}
+
+// AddRow adds an empty row, returning it to add cells to.
+func (t *TableBuilder) AddRow() *RowBuilder {
+	row := &RowBuilder{builder: t.builder}
+	t.rows = append(t.rows, row)
+
+	return row
+}
+
+// table lays out the rows of the table. Rows with fewer cells than others
+// are given empty ones.
+func (t *TableBuilder) table() *Table {
+	columns := len(t.widths)
+	for _, row := range t.rows {
+		columns = max(columns, len(row.cells))
+	}
+	if columns == 0 {
+		return &Table{}
+	}
+
+	widths := append([]int{}, t.widths...)
+	given := 0
+	for _, width := range widths {
+		given += width
+	}
+	for len(widths) < columns {
+		widths = append(widths, max((htmlTableWidth-given)/(columns-len(t.widths)), 360))
+	}
+
+	table := &Table{}
+	for _, row := range t.rows {
+		format := RowFormat{Gap: 108, LeftIndent: -108, Header: row.header}
+		cells := []TableCell{}
+		right := format.LeftIndent
+
+		for c := 0; c < columns; c++ {
+			right += widths[c]
+			cell := CellFormat{Right: right}
+			paragraph := Paragraph{Format: ParagraphFormat{InTable: true}, Mark: &Painter{FontSize: 24}}
+			if c < len(row.cells) {
+				cell.Background = row.cells[c].background
+				paragraph = *row.cells[c].paragraph
+			}
+
+			format.Cells = append(format.Cells, cell)
+			cells = append(cells, TableCell{Paragraphs: []Paragraph{paragraph}})
+		}
+
+		table.Rows = append(table.Rows, TableRow{Format: format, Cells: cells, Mark: &Painter{FontSize: 24}})
+	}
+
+	return table
+}
+
+// RowBuilder adds the cells of a table row.
+type RowBuilder struct {
+	builder *Builder
+	header  bool
+	cells   []*CellBuilder
+}
+
+// CellBuilder is a table cell, whose text is added to as to a paragraph.
+type CellBuilder struct {
+	*ParagraphBuilder
+	background TableRef
+}
+
+// Header makes the row a header row, repeated at the top of each page the
+// table spans.
+func (r *RowBuilder) Header() *RowBuilder {
+	r.header = true
+	return r
+}
+
+// Cell adds a cell, returning it to add runs to.
+func (r *RowBuilder) Cell() *CellBuilder {
+	cell := &CellBuilder{ParagraphBuilder: r.builder.newParagraph(ParagraphFormat{InTable: true}, Painter{FontSize: 24})}
+	r.cells = append(r.cells, cell)
+
+	return cell
+}
+
+// Background sets the background color of the cell.
+func (c *CellBuilder) Background(color TableRef) *CellBuilder {
+	c.background = color
+	return c
+}
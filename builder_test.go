@@ -0,0 +1,172 @@
+package gortf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder()
+	arial := b.Font("Arial")
+	red := b.Color(Color{R: 255})
+	b.Info().Title = "Report"
+
+	b.Heading(1, "Report")
+	b.Paragraph().Text("Sales were ").Bold("up").Text(" this\nyear")
+	b.Paragraph().Font(arial).Size(11).Color(red).Text("See ").Link("https://example.com", "the site").Align(AlignmentCenter)
+	list := b.BulletList().Item("One")
+	list.Add(1).Italic("Sub")
+	b.PageBreak()
+
+	if b.Font("arial") != arial || b.Font("Courier New") != b.Font("monospace") || b.Color(Color{R: 255}) != red {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "fonts and colors added once", b.doc.Header)
+	}
+
+	doc, err := b.Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := Painter{FontSize: 24}
+	heading := Painter{Bold: true, FontSize: 32}
+	small := Painter{FontRef: arial, FontSize: 22, ForegroundColor: red}
+
+	expected := []Section{{
+		Elements: []BodyElement{
+			&Paragraph{
+				Format: ParagraphFormat{Style: 1, SpaceBefore: 240, SpaceAfter: 60, KeepWithNext: true},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "Report", Painter: heading}},
+				Mark:   &heading,
+			},
+			&Paragraph{
+				Runs: []StyleBlock{
+					{Kind: BlockKindText, Text: "Sales were ", Painter: body},
+					{Kind: BlockKindText, Text: "up", Painter: Painter{FontSize: 24, Bold: true}},
+					{Kind: BlockKindText, Text: " this", Painter: body},
+					{Kind: BlockKindLine, Text: "\n", Painter: body},
+					{Kind: BlockKindText, Text: "year", Painter: body},
+				},
+				Mark: &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{Alignment: AlignmentCenter},
+				Runs: []StyleBlock{
+					{Kind: BlockKindText, Text: "See ", Painter: small},
+					{Kind: BlockKindText, Text: "the site", Painter: Painter{FontRef: arial, FontSize: 22, ForegroundColor: red, Link: "https://example.com"}},
+				},
+				Mark: &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{List: 1, LeftIndent: 720, FirstLineIndent: -360},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "One", Painter: body}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{List: 1, ListLevel: 1, LeftIndent: 1440, FirstLineIndent: -360},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "Sub", Painter: Painter{FontSize: 24, Italic: true}}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{PageBreakBefore: true},
+				Runs:   []StyleBlock{},
+				Mark:   &body,
+			},
+		},
+	}}
+
+	actual := doc.Sections()
+	for _, element := range actual[0].Elements {
+		if paragraph, ok := element.(*Paragraph); ok && paragraph.Runs == nil {
+			paragraph.Runs = []StyleBlock{}
+		}
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	expectedFonts := FontTable{
+		0: {Name: "Times New Roman", FontFamily: FontFamilyRoman},
+		1: {Name: "Arial", FontFamily: FontFamilySwiss},
+		2: {Name: "Courier New", FontFamily: FontFamilyModern, Pitch: FontPitchFixed},
+	}
+	if !reflect.DeepEqual(expectedFonts, doc.Header.FontTable) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedFonts, doc.Header.FontTable)
+	}
+
+	if doc.InformationGroup.Title != "Report" || len(doc.Header.Lists) != 1 || doc.Header.Lists[1].Levels[1].Text != "o" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a titled document with a bulleted list", doc)
+	}
+
+	// documents do not change with the builder
+	b.Font("Verdana")
+	if len(doc.Header.FontTable) != 3 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 3, len(doc.Header.FontTable))
+	}
+}
+
+func TestBuilderTable(t *testing.T) {
+	b := NewBuilder()
+	table := b.Table(2000).HeaderRow("Name", "Value").Row("a", "1", "x")
+	table.AddRow().Cell().Background(b.Color(Color{B: 255})).Text("blue")
+
+	doc, err := b.Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := Painter{FontSize: 24}
+	bold := Painter{FontSize: 24, Bold: true}
+	cell := func(text string, painter Painter) TableCell {
+		paragraph := Paragraph{Format: ParagraphFormat{InTable: true}, Mark: &body}
+		if text != "" {
+			paragraph.Runs = []StyleBlock{{Kind: BlockKindText, Text: text, Painter: painter}}
+		}
+		return TableCell{Paragraphs: []Paragraph{paragraph}}
+	}
+	cells := []CellFormat{{Right: 1892}, {Right: 5572}, {Right: 9252}}
+
+	expected := []Section{{
+		Elements: []BodyElement{&Table{Rows: []TableRow{
+			{
+				Format: RowFormat{Gap: 108, LeftIndent: -108, Header: true, Cells: cells},
+				Cells:  []TableCell{cell("Name", bold), cell("Value", bold), cell("", body)},
+				Mark:   &body,
+			},
+			{
+				Format: RowFormat{Gap: 108, LeftIndent: -108, Cells: cells},
+				Cells:  []TableCell{cell("a", body), cell("1", body), cell("x", body)},
+				Mark:   &body,
+			},
+			{
+				Format: RowFormat{Gap: 108, LeftIndent: -108, Cells: []CellFormat{{Right: 1892, Background: 1}, {Right: 5572}, {Right: 9252}}},
+				Cells:  []TableCell{cell("blue", body), cell("", body), cell("", body)},
+				Mark:   &body,
+			},
+		}}},
+	}}
+
+	actual := doc.Sections()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+}
+
+func TestBuilderImage(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), 0, 0, 0, 20, 0, 0, 0, 10)
+
+	b := NewBuilder()
+	b.Paragraph().Image(png, "png", 0, 0)
+
+	doc, err := b.Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Pictures) != 1 || doc.Pictures[0].GoalWidth != 300 || doc.Pictures[0].GoalHeight != 150 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a 300 by 150 twips picture", doc.Pictures)
+	}
+
+	b.Paragraph().Image([]byte("GIF89a"), "gif", 100, 100)
+	if _, err := b.Document(); err == nil {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "an error", err)
+	}
+}
@@ -0,0 +1,499 @@
+// Command gortf converts RTF documents and inspects their content.
+//
+// Usage:
+//
+//	gortf <command> [flags] [file or directory ...]
+//
+// The commands are:
+//
+//	text      convert to plain text
+//	html      convert to HTML
+//	markdown  convert to Markdown
+//	json      convert to JSON
+//	docx      convert to a Word document
+//	odt       convert to an OpenDocument text document
+//	info      print the information group and header tables
+//	images    extract the pictures
+//	tokens    print the tokens of the lexer
+//	validate  report problems found while parsing
+//
+// Documents are read from the files given as arguments, or from standard
+// input if there are none or the argument is "-". Directories are searched
+// recursively for .rtf files and .rtfd bundles. Converted documents are written to standard
+// output, each after a heading naming it when there are several, or with -o
+// to files named after their source in the given directory. Converting
+// several documents to docx or odt requires -o.
+//
+// The exit status is 0 on success, 1 if a document was read with warnings and
+// 2 if a document could not be read at all or the command line is invalid.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/axispx/gortf"
+)
+
+const (
+	exitOK       = 0
+	exitWarnings = 1
+	exitError    = 2
+)
+
+var commands = []struct {
+	name        string
+	description string
+}{
+	{"text", "convert to plain text"},
+	{"html", "convert to HTML"},
+	{"markdown", "convert to Markdown"},
+	{"json", "convert to JSON"},
+	{"docx", "convert to a Word document"},
+	{"odt", "convert to an OpenDocument text document"},
+	{"info", "print the information group and header tables"},
+	{"images", "extract the pictures"},
+	{"tokens", "print the tokens of the lexer"},
+	{"validate", "report problems found while parsing"},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// input is a document to process.
+type input struct {
+	// name is the path of the document as shown in messages, "-" for
+	// standard input
+	name string
+
+	// relative is the path output files are named after, relative to the
+	// output directory
+	relative string
+
+	// bundle is set for the directories of RTFD bundles
+	bundle bool
+}
+
+type cli struct {
+	command   string
+	outputDir string
+	multiple  bool
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	status int
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || !isCommand(args[0]) {
+		usage(stderr)
+		return exitError
+	}
+
+	c := &cli{command: args[0], stdin: stdin, stdout: stdout, stderr: stderr}
+
+	flags := flag.NewFlagSet("gortf "+c.command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&c.outputDir, "o", "", "write output files to `dir` instead of standard output")
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitError
+	}
+
+	inputs := c.collectInputs(flags.Args())
+	c.multiple = len(inputs) > 1
+
+	// packages cannot be told apart once written one after the other
+	if c.multiple && c.outputDir == "" && isPackageCommand(c.command) {
+		fmt.Fprintf(stderr, "gortf %s: -o is required to convert several documents\n", c.command)
+		return exitError
+	}
+
+	for _, in := range inputs {
+		c.process(in)
+	}
+
+	return c.status
+}
+
+// isPackageCommand reports whether a command writes documents as zip
+// packages rather than text.
+func isPackageCommand(name string) bool {
+	return name == "docx" || name == "odt"
+}
+
+func isCommand(name string) bool {
+	for _, command := range commands {
+		if command.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: gortf <command> [-o dir] [file or directory ...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, command := range commands {
+		fmt.Fprintf(w, "  %-10s%s\n", command.name, command.description)
+	}
+}
+
+// collectInputs expands the arguments into the documents to process.
+func (c *cli) collectInputs(args []string) []input {
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+
+	inputs := []input{}
+	for _, arg := range args {
+		if arg == "-" {
+			inputs = append(inputs, input{name: "-", relative: "stdin"})
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err == nil && info.IsDir() && isBundle(arg) {
+			inputs = append(inputs, input{name: arg, relative: filepath.Base(arg), bundle: true})
+			continue
+		}
+		if err != nil || !info.IsDir() {
+			// errors are reported when the file is read
+			inputs = append(inputs, input{name: arg, relative: filepath.Base(arg)})
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			bundle := entry.IsDir() && isBundle(path)
+			if !bundle && (entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".rtf")) {
+				return nil
+			}
+
+			relative, err := filepath.Rel(arg, path)
+			if err != nil {
+				return err
+			}
+
+			inputs = append(inputs, input{name: path, relative: relative, bundle: bundle})
+			if bundle {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			c.fail(input{name: arg}, err)
+		}
+	}
+
+	return inputs
+}
+
+// isBundle reports whether a directory is an RTFD bundle.
+func isBundle(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".rtfd")
+}
+
+func (c *cli) read(in input) ([]byte, error) {
+	if in.name == "-" {
+		return io.ReadAll(c.stdin)
+	}
+	if in.bundle {
+		return os.ReadFile(filepath.Join(in.name, gortf.RTFDDocumentName))
+	}
+
+	return os.ReadFile(in.name)
+}
+
+func (c *cli) process(in input) {
+	data, err := c.read(in)
+	if err != nil {
+		c.fail(in, err)
+		return
+	}
+
+	if c.command == "tokens" {
+		c.writeTokens(in, data)
+		return
+	}
+
+	parser := gortf.NewRtfParser()
+	var doc gortf.RtfDocument
+	if in.bundle {
+		doc, err = parser.ParseRTFD(in.name)
+	} else {
+		doc, err = parser.ParseContent(string(data))
+	}
+	if err != nil {
+		c.fail(in, err)
+		return
+	}
+
+	warnings := parser.Warnings()
+	if len(warnings) > 0 {
+		c.status = max(c.status, exitWarnings)
+	}
+
+	if c.command == "validate" {
+		for _, warning := range warnings {
+			fmt.Fprintf(c.stdout, "%s: %s\n", in.name, warning)
+		}
+		if len(warnings) == 0 {
+			fmt.Fprintf(c.stdout, "%s: ok\n", in.name)
+		}
+		return
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(c.stderr, "gortf: %s: %s\n", in.name, warning)
+	}
+
+	switch c.command {
+	case "text":
+		c.convert(in, "txt", doc.ToText)
+	case "html":
+		c.convert(in, "html", doc.ToHTML)
+	case "markdown":
+		c.convert(in, "md", doc.ToMarkdown)
+	case "json":
+		c.convert(in, "json", func() (string, error) {
+			return doc.String() + "\n", nil
+		})
+	case "docx":
+		c.convert(in, "docx", func() (string, error) {
+			data, err := doc.ToDOCX()
+			return string(data), err
+		})
+	case "odt":
+		c.convert(in, "odt", func() (string, error) {
+			data, err := doc.ToODT()
+			return string(data), err
+		})
+	case "info":
+		c.writeInfo(in, doc)
+	case "images":
+		c.extractImages(in, doc)
+	}
+}
+
+func (c *cli) fail(in input, err error) {
+	fmt.Fprintf(c.stderr, "gortf: %s: %v\n", in.name, err)
+	c.status = exitError
+}
+
+// convert writes a document converted by render to standard output, after a
+// heading when there are several, or to a file with the given extension in
+// the output directory.
+func (c *cli) convert(in input, extension string, render func() (string, error)) {
+	content, err := render()
+	if err != nil {
+		c.fail(in, err)
+		return
+	}
+
+	if c.outputDir == "" {
+		c.writeHeading(in)
+		if c.multiple && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		io.WriteString(c.stdout, content)
+		return
+	}
+
+	path := filepath.Join(c.outputDir, strings.TrimSuffix(in.relative, filepath.Ext(in.relative))+"."+extension)
+	if err := writeFile(path, []byte(content)); err != nil {
+		c.fail(in, err)
+	}
+}
+
+// extractImages writes every picture of a document to the output directory,
+// or the current directory, and prints the names of the files.
+func (c *cli) extractImages(in input, doc gortf.RtfDocument) {
+	dir := c.outputDir
+	if dir == "" {
+		dir = "."
+	}
+
+	base := strings.TrimSuffix(in.relative, filepath.Ext(in.relative))
+	for i, picture := range doc.Pictures {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%d.%s", base, i+1, picture.Format.Extension()))
+		if err := writeFile(path, picture.Data); err != nil {
+			c.fail(in, err)
+			return
+		}
+
+		fmt.Fprintln(c.stdout, path)
+	}
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (c *cli) writeTokens(in input, data []byte) {
+	c.writeHeading(in)
+
+	lexer := gortf.NewLexer(bytes.NewReader(data))
+	for {
+		tkn, err := lexer.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			c.fail(in, err)
+			return
+		}
+
+		fmt.Fprintf(c.stdout, "%d\t%s\n", tkn.Offset, tkn)
+	}
+}
+
+// writeHeading separates the output of each document when there are several.
+func (c *cli) writeHeading(in input) {
+	if c.multiple {
+		fmt.Fprintf(c.stdout, "==> %s <==\n", in.name)
+	}
+}
+
+func (c *cli) writeInfo(in input, doc gortf.RtfDocument) {
+	c.writeHeading(in)
+	w := c.stdout
+
+	header := doc.Header
+	fmt.Fprintf(w, "Charset: %s\n", header.Charset)
+	if header.CodePage != 0 {
+		fmt.Fprintf(w, "Code page: %d\n", header.CodePage)
+	}
+
+	info := doc.InformationGroup
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"Title", info.Title},
+		{"Subject", info.Subject},
+		{"Author", info.Author},
+		{"Manager", info.Manager},
+		{"Company", info.Company},
+		{"Operator", info.Operator},
+		{"Category", info.Category},
+		{"Keywords", info.Keywords},
+		{"Comment", info.Comment},
+		{"Document comment", info.DocumentComment},
+		{"Base address", info.BaseAddress},
+		{"Created", formatTime(info.CreationTime)},
+		{"Revised", formatTime(info.RevisionTime)},
+		{"Printed", formatTime(info.LastPrintTime)},
+		{"Backed up", formatTime(info.BackupTime)},
+		{"Version", formatNumber(info.Version)},
+		{"Editing minutes", formatNumber(info.EditingMinutes)},
+		{"Pages", formatNumber(info.NumberOfPages)},
+		{"Words", formatNumber(info.NumberOfWords)},
+		{"Characters", formatNumber(info.NumberOfCharacters)},
+		{"Characters with spaces", formatNumber(info.NumberOfCharactersWithSpaces)},
+		{"Internal ID", formatNumber(info.InternalID)},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(w, "%s: %s\n", field.name, field.value)
+		}
+	}
+
+	if len(info.UserProperties) > 0 {
+		fmt.Fprintln(w, "User properties:")
+		for _, property := range info.UserProperties {
+			fmt.Fprintf(w, "  %s (%s): %v\n", property.Name, property.Type, property.Value)
+		}
+	}
+
+	if len(header.FontTable) > 0 {
+		fmt.Fprintln(w, "Fonts:")
+		for _, key := range sortedKeys(header.FontTable) {
+			font := header.FontTable[key]
+			fmt.Fprintf(w, "  %d\t%s\t%s\tcharset %d\n", key, font.Name, font.FontFamily, font.Charset)
+		}
+	}
+
+	if len(header.ColorTable) > 0 {
+		fmt.Fprintln(w, "Colors:")
+		for _, key := range sortedKeys(header.ColorTable) {
+			color := header.ColorTable[key]
+			if color.Theme != gortf.ColorThemeNone {
+				fmt.Fprintf(w, "  %d\t%s\t%s\n", key, color, color.Theme)
+			} else {
+				fmt.Fprintf(w, "  %d\t%s\n", key, color)
+			}
+		}
+	}
+
+	if len(header.Stylesheet) > 0 {
+		fmt.Fprintln(w, "Styles:")
+		names := []string{}
+		for name := range header.Stylesheet {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "  %s\n", name)
+		}
+	}
+
+	if len(doc.Pictures) > 0 {
+		fmt.Fprintf(w, "Pictures: %d\n", len(doc.Pictures))
+	}
+
+	if len(doc.Objects) > 0 {
+		fmt.Fprintf(w, "Objects: %d\n", len(doc.Objects))
+	}
+
+	if len(doc.Shapes) > 0 {
+		fmt.Fprintf(w, "Shapes: %d\n", len(doc.Shapes))
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func formatNumber(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return fmt.Sprint(n)
+}
+
+func sortedKeys[V any](table map[gortf.TableRef]V) []gortf.TableRef {
+	keys := []gortf.TableRef{}
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
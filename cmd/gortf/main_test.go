@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCommand(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+
+	return stdout.String(), stderr.String(), status
+}
+
+func TestConvertStdin(t *testing.T) {
+	stdout, stderr, status := runCommand(t, `{\rtf1\ansi Hello {\b world}\par}`, "text")
+	if status != exitOK || stderr != "" {
+		t.Fatalf("status %d: %s", status, stderr)
+	}
+	if stdout != "Hello world\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "Hello world\n", stdout)
+	}
+
+	stdout, _, _ = runCommand(t, `{\rtf1\ansi Hello {\b world}\par}`, "markdown", "-")
+	if stdout != "Hello **world**\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "Hello **world**\n", stdout)
+	}
+}
+
+func TestConvertDOCX(t *testing.T) {
+	output := t.TempDir()
+	source := filepath.Join(t.TempDir(), "letter.rtf")
+	os.WriteFile(source, []byte(`{\rtf1\ansi Dear {\b reader}\par}`), 0o644)
+
+	_, stderr, status := runCommand(t, "", "docx", "-o", output, source)
+	if status != exitOK {
+		t.Fatalf("status %d: %s", status, stderr)
+	}
+
+	data, err := os.ReadFile(filepath.Join(output, "letter.docx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		t.Errorf("expected a zip archive, got %q", data[:min(len(data), 16)])
+	}
+}
+
+func TestConvertODT(t *testing.T) {
+	output := t.TempDir()
+	source := filepath.Join(t.TempDir(), "letter.rtf")
+	os.WriteFile(source, []byte(`{\rtf1\ansi Dear {\b reader}\par}`), 0o644)
+
+	_, stderr, status := runCommand(t, "", "odt", "-o", output, source)
+	if status != exitOK {
+		t.Fatalf("status %d: %s", status, stderr)
+	}
+
+	data, err := os.ReadFile(filepath.Join(output, "letter.odt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("PK")) || !bytes.Contains(data, []byte("application/vnd.oasis.opendocument.text")) {
+		t.Errorf("expected an OpenDocument package, got %q", data[:min(len(data), 64)])
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	stdout, _, status := runCommand(t, `{\rtf1 unclosed`, "validate")
+	if status != exitWarnings {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", exitWarnings, status)
+	}
+	if stdout != "-: offset 0: group is not closed\n" {
+		t.Errorf("unexpected output: %q", stdout)
+	}
+
+	_, stderr, status := runCommand(t, `{\rtf1 unclosed`, "text")
+	if status != exitWarnings || !strings.Contains(stderr, "group is not closed") {
+		t.Errorf("status %d: %s", status, stderr)
+	}
+
+	_, _, status = runCommand(t, `{\rtf1 \bin10 abc}`, "text")
+	if status != exitError {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", exitError, status)
+	}
+
+	_, _, status = runCommand(t, "", "text", filepath.Join(t.TempDir(), "missing.rtf"))
+	if status != exitError {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", exitError, status)
+	}
+
+	_, _, status = runCommand(t, "", "unknown")
+	if status != exitError {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", exitError, status)
+	}
+}
+
+func TestBatchConversion(t *testing.T) {
+	source := t.TempDir()
+	output := t.TempDir()
+
+	os.MkdirAll(filepath.Join(source, "nested"), 0o755)
+	os.MkdirAll(filepath.Join(source, "c.rtfd"), 0o755)
+	os.WriteFile(filepath.Join(source, "a.rtf"), []byte(`{\rtf1 first}`), 0o644)
+	os.WriteFile(filepath.Join(source, "nested", "b.RTF"), []byte(`{\rtf1 second}`), 0o644)
+	os.WriteFile(filepath.Join(source, "c.rtfd", "TXT.rtf"), []byte(`{\rtf1 third}`), 0o644)
+	os.WriteFile(filepath.Join(source, "notes.txt"), []byte(`ignored`), 0o644)
+
+	_, stderr, status := runCommand(t, "", "text", "-o", output, source)
+	if status != exitOK {
+		t.Fatalf("status %d: %s", status, stderr)
+	}
+
+	for path, expected := range map[string]string{
+		"a.txt":                          "first",
+		filepath.Join("nested", "b.txt"): "second",
+		"c.txt":                          "third",
+	} {
+		content, err := os.ReadFile(filepath.Join(output, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, content)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(output, "notes.txt")); err == nil {
+		t.Errorf("non-RTF file converted")
+	}
+}
+
+func TestBatchConversionToStdout(t *testing.T) {
+	source := t.TempDir()
+	first, second := filepath.Join(source, "a.rtf"), filepath.Join(source, "b.rtf")
+	os.WriteFile(first, []byte(`{\rtf1 first}`), 0o644)
+	os.WriteFile(second, []byte(`{\rtf1 second\par}`), 0o644)
+
+	stdout, stderr, status := runCommand(t, "", "text", first, second)
+	if status != exitOK {
+		t.Fatalf("status %d: %s", status, stderr)
+	}
+	expected := "==> " + first + " <==\nfirst\n==> " + second + " <==\nsecond\n"
+	if stdout != expected {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, stdout)
+	}
+
+	stdout, stderr, status = runCommand(t, "", "docx", first, second)
+	if status != exitError || stdout != "" || !strings.Contains(stderr, "-o is required") {
+		t.Errorf("status %d: %q %s", status, stdout, stderr)
+	}
+}
+
+func TestImages(t *testing.T) {
+	output := t.TempDir()
+
+	stdout, stderr, status := runCommand(t, `{\rtf1{\pict\pngblip 89504e47}}`, "images", "-o", output)
+	if status != exitOK {
+		t.Fatalf("status %d: %s", status, stderr)
+	}
+
+	path := filepath.Join(output, "stdin-1.png")
+	if stdout != path+"\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", path+"\n", stdout)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, []byte{0x89, 0x50, 0x4e, 0x47}) {
+		t.Errorf("unexpected picture data: %x", content)
+	}
+}
+
+func TestInfoAndTokens(t *testing.T) {
+	stdout, _, status := runCommand(t, "", "info", filepath.Join("..", "..", "testfiles", "minimal.rtf"))
+	if status != exitOK {
+		t.Fatalf("status %d", status)
+	}
+	for _, line := range []string{"Charset: ANSI", "Author: word", "  0\tHelvetica\tSwiss\tcharset 0", "  0\tauto", "  1\t#ffffff"} {
+		if !strings.Contains(stdout, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, stdout)
+		}
+	}
+
+	stdout, _, _ = runCommand(t, `{\rtf1 hi}`, "tokens")
+	expected := "0\t{GroupStart}\n1\t{ControlWord \"rtf\" 1}\n7\t{Text \"hi\"}\n9\t{GroupEnd}\n"
+	if stdout != expected {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, stdout)
+	}
+}
@@ -0,0 +1,411 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Color is an entry of the color table.
+//
+// The first entry of a color table usually has no components at all: it is
+// the "auto" color, which readers replace with their default text or
+// background color, and is recorded with Auto set. Theme colors are resolved
+// to concrete RGB values, either from the components written alongside them
+// or from the document theme.
+type Color struct {
+	R int `json:"red"`
+	G int `json:"green"`
+	B int `json:"blue"`
+
+	Auto bool `json:"auto,omitempty"`
+
+	// Theme is the theme color the entry refers to, and Tint and Shade the
+	// \ctint and \cshade applied to it, 255 meaning unchanged. Tint and Shade
+	// are zero for entries that are not theme colors.
+	Theme ColorTheme `json:"theme,omitempty"`
+	Tint  int        `json:"tint,omitempty"`
+	Shade int        `json:"shade,omitempty"`
+
+	// Expanded holds the color space data of the matching entry of the
+	// {\*\expandedcolortbl} written by macOS, if there is one.
+	Expanded *ExpandedColor `json:"expanded,omitempty"`
+}
+
+// Hex returns the color in the #rrggbb notation.
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", clampColorComponent(c.R), clampColorComponent(c.G), clampColorComponent(c.B))
+}
+
+// CSS returns the color as a CSS color value. The auto color has no value of
+// its own and is rendered as "inherit"; colors with an alpha channel in the
+// expanded color table use the rgba() notation.
+func (c Color) CSS() string {
+	if c.Auto {
+		return "inherit"
+	}
+
+	if alpha, ok := c.Expanded.alpha(); ok && alpha < 1 {
+		return fmt.Sprintf("rgba(%d, %d, %d, %s)",
+			clampColorComponent(c.R), clampColorComponent(c.G), clampColorComponent(c.B),
+			strconv.FormatFloat(alpha, 'f', -1, 64))
+	}
+
+	return c.Hex()
+}
+
+func (c Color) String() string {
+	if c.Auto {
+		return "auto"
+	}
+
+	return c.Hex()
+}
+
+func clampColorComponent(value int) int {
+	return min(max(value, 0), 255)
+}
+
+// ColorTheme is a color of the document theme, as given by \cmaindarkone and
+// the like.
+type ColorTheme int
+
+const (
+	ColorThemeNone ColorTheme = iota
+	ColorThemeMainDarkOne
+	ColorThemeMainLightOne
+	ColorThemeMainDarkTwo
+	ColorThemeMainLightTwo
+	ColorThemeAccentOne
+	ColorThemeAccentTwo
+	ColorThemeAccentThree
+	ColorThemeAccentFour
+	ColorThemeAccentFive
+	ColorThemeAccentSix
+	ColorThemeHyperlink
+	ColorThemeFollowedHyperlink
+	ColorThemeBackgroundOne
+	ColorThemeTextOne
+	ColorThemeBackgroundTwo
+	ColorThemeTextTwo
+)
+
+func (c ColorTheme) String() string {
+	switch c {
+	case ColorThemeNone:
+		return "None"
+	case ColorThemeMainDarkOne:
+		return "MainDarkOne"
+	case ColorThemeMainLightOne:
+		return "MainLightOne"
+	case ColorThemeMainDarkTwo:
+		return "MainDarkTwo"
+	case ColorThemeMainLightTwo:
+		return "MainLightTwo"
+	case ColorThemeAccentOne:
+		return "AccentOne"
+	case ColorThemeAccentTwo:
+		return "AccentTwo"
+	case ColorThemeAccentThree:
+		return "AccentThree"
+	case ColorThemeAccentFour:
+		return "AccentFour"
+	case ColorThemeAccentFive:
+		return "AccentFive"
+	case ColorThemeAccentSix:
+		return "AccentSix"
+	case ColorThemeHyperlink:
+		return "Hyperlink"
+	case ColorThemeFollowedHyperlink:
+		return "FollowedHyperlink"
+	case ColorThemeBackgroundOne:
+		return "BackgroundOne"
+	case ColorThemeTextOne:
+		return "TextOne"
+	case ColorThemeBackgroundTwo:
+		return "BackgroundTwo"
+	case ColorThemeTextTwo:
+		return "TextTwo"
+	default:
+		return "Unknown"
+	}
+}
+
+func colorThemeFromToken(tkn token) ColorTheme {
+	if tkn.tokenType() == tokenTypeControlWord {
+		controlWord := tkn.(controlWordToken)
+
+		switch controlWord.name {
+		case `\cmaindarkone`:
+			return ColorThemeMainDarkOne
+		case `\cmainlightone`:
+			return ColorThemeMainLightOne
+		case `\cmaindarktwo`:
+			return ColorThemeMainDarkTwo
+		case `\cmainlighttwo`:
+			return ColorThemeMainLightTwo
+		case `\caccentone`:
+			return ColorThemeAccentOne
+		case `\caccenttwo`:
+			return ColorThemeAccentTwo
+		case `\caccentthree`:
+			return ColorThemeAccentThree
+		case `\caccentfour`:
+			return ColorThemeAccentFour
+		case `\caccentfive`:
+			return ColorThemeAccentFive
+		case `\caccentsix`:
+			return ColorThemeAccentSix
+		case `\chyperlink`:
+			return ColorThemeHyperlink
+		case `\cfollowedhyperlink`:
+			return ColorThemeFollowedHyperlink
+		case `\cbackgroundone`:
+			return ColorThemeBackgroundOne
+		case `\ctextone`:
+			return ColorThemeTextOne
+		case `\cbackgroundtwo`:
+			return ColorThemeBackgroundTwo
+		case `\ctexttwo`:
+			return ColorThemeTextTwo
+		}
+	}
+
+	return ColorThemeNone
+}
+
+// ColorSpace is the color space of an entry of the expanded color table.
+type ColorSpace int
+
+const (
+	ColorSpaceNone ColorSpace = iota
+	ColorSpaceGray
+	ColorSpaceGenericRGB
+	ColorSpaceSRGB
+)
+
+func (c ColorSpace) String() string {
+	switch c {
+	case ColorSpaceNone:
+		return "None"
+	case ColorSpaceGray:
+		return "Gray"
+	case ColorSpaceGenericRGB:
+		return "GenericRGB"
+	case ColorSpaceSRGB:
+		return "sRGB"
+	default:
+		return "Unknown"
+	}
+}
+
+func colorSpaceFromToken(tkn token) ColorSpace {
+	if tkn.tokenType() == tokenTypeControlWord {
+		controlWord := tkn.(controlWordToken)
+
+		switch controlWord.name {
+		case `\csgray`:
+			return ColorSpaceGray
+		case `\csgenericrgb`:
+			return ColorSpaceGenericRGB
+		case `\cssrgb`:
+			return ColorSpaceSRGB
+		}
+	}
+
+	return ColorSpaceNone
+}
+
+// ExpandedColor is an entry of the {\*\expandedcolortbl} group.
+type ExpandedColor struct {
+	Space ColorSpace `json:"space"`
+
+	// Components are the \c values of the entry scaled to the 0-1 range:
+	// one for gray and three for RGB color spaces, followed by an optional
+	// alpha component.
+	Components []float64 `json:"components"`
+
+	// Name is the name of a system color given by \cname, such as
+	// "textColor".
+	Name string `json:"name,omitempty"`
+}
+
+func (e *ExpandedColor) alpha() (float64, bool) {
+	if e == nil {
+		return 0, false
+	}
+
+	channels := 3
+	if e.Space == ColorSpaceGray {
+		channels = 1
+	}
+
+	if len(e.Components) <= channels {
+		return 0, false
+	}
+
+	return e.Components[channels], true
+}
+
+// colorScheme maps the colors of a document theme to their RGB values.
+type colorScheme map[ColorTheme]Color
+
+// defaultColorScheme is the color scheme of the default Office theme, used
+// when a document refers to theme colors without embedding its theme.
+var defaultColorScheme = colorScheme{
+	ColorThemeMainDarkOne:       {R: 0x00, G: 0x00, B: 0x00},
+	ColorThemeMainLightOne:      {R: 0xff, G: 0xff, B: 0xff},
+	ColorThemeMainDarkTwo:       {R: 0x44, G: 0x54, B: 0x6a},
+	ColorThemeMainLightTwo:      {R: 0xe7, G: 0xe6, B: 0xe6},
+	ColorThemeAccentOne:         {R: 0x44, G: 0x72, B: 0xc4},
+	ColorThemeAccentTwo:         {R: 0xed, G: 0x7d, B: 0x31},
+	ColorThemeAccentThree:       {R: 0xa5, G: 0xa5, B: 0xa5},
+	ColorThemeAccentFour:        {R: 0xff, G: 0xc0, B: 0x00},
+	ColorThemeAccentFive:        {R: 0x5b, G: 0x9b, B: 0xd5},
+	ColorThemeAccentSix:         {R: 0x70, G: 0xad, B: 0x47},
+	ColorThemeHyperlink:         {R: 0x05, G: 0x63, B: 0xc1},
+	ColorThemeFollowedHyperlink: {R: 0x95, G: 0x4f, B: 0x72},
+}
+
+// themeColorNames maps the elements of a DrawingML color scheme to theme
+// colors.
+var themeColorNames = map[string]ColorTheme{
+	"dk1": ColorThemeMainDarkOne, "lt1": ColorThemeMainLightOne,
+	"dk2": ColorThemeMainDarkTwo, "lt2": ColorThemeMainLightTwo,
+	"accent1": ColorThemeAccentOne, "accent2": ColorThemeAccentTwo,
+	"accent3": ColorThemeAccentThree, "accent4": ColorThemeAccentFour,
+	"accent5": ColorThemeAccentFive, "accent6": ColorThemeAccentSix,
+	"hlink": ColorThemeHyperlink, "folHlink": ColorThemeFollowedHyperlink,
+}
+
+// resolve returns the RGB value of a theme color, with the background and
+// text colors mapped to the main light and dark colors as Word does by
+// default.
+func (s colorScheme) resolve(theme ColorTheme) Color {
+	switch theme {
+	case ColorThemeBackgroundOne:
+		theme = ColorThemeMainLightOne
+	case ColorThemeTextOne:
+		theme = ColorThemeMainDarkOne
+	case ColorThemeBackgroundTwo:
+		theme = ColorThemeMainLightTwo
+	case ColorThemeTextTwo:
+		theme = ColorThemeMainDarkTwo
+	}
+
+	if color, ok := s[theme]; ok {
+		return color
+	}
+
+	return defaultColorScheme[theme]
+}
+
+type themeDocument struct {
+	ColorScheme struct {
+		Colors []themeSchemeColor `xml:",any"`
+	} `xml:"themeElements>clrScheme"`
+}
+
+type themeSchemeColor struct {
+	XMLName xml.Name
+	RGB     *struct {
+		Value string `xml:"val,attr"`
+	} `xml:"srgbClr"`
+	System *struct {
+		LastColor string `xml:"lastClr,attr"`
+	} `xml:"sysClr"`
+}
+
+// themePartName matches the name of the theme part of the package of the
+// {\*\themedata} group, which Word writes as theme/theme/theme1.xml after
+// theme/theme/themeManager.xml.
+var themePartName = regexp.MustCompile(`^theme\d+\.xml$`)
+
+// maxThemePartSize is the size up to which theme parts are read.
+const maxThemePartSize = 1 << 20
+
+// parseThemeData reads the color scheme of the theme embedded by Word in the
+// {\*\themedata} group, a hex encoded package holding the theme part. It
+// returns nil if the theme cannot be read.
+func parseThemeData(text string) colorScheme {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(text), ""))
+	if err != nil {
+		return nil
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+
+	for _, file := range archive.File {
+		if !themePartName.MatchString(path.Base(file.Name)) {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return nil
+		}
+
+		content, err := io.ReadAll(io.LimitReader(reader, maxThemePartSize))
+		reader.Close()
+		if err != nil {
+			return nil
+		}
+
+		theme := themeDocument{}
+		if err := xml.Unmarshal(content, &theme); err != nil {
+			return nil
+		}
+
+		scheme := colorScheme{}
+		for _, schemeColor := range theme.ColorScheme.Colors {
+			key, ok := themeColorNames[schemeColor.XMLName.Local]
+			if !ok {
+				continue
+			}
+
+			value := ""
+			switch {
+			case schemeColor.RGB != nil:
+				value = schemeColor.RGB.Value
+			case schemeColor.System != nil:
+				value = schemeColor.System.LastColor
+			}
+
+			rgb, err := hex.DecodeString(value)
+			if err != nil || len(rgb) != 3 {
+				continue
+			}
+
+			scheme[key] = Color{R: int(rgb[0]), G: int(rgb[1]), B: int(rgb[2])}
+		}
+
+		if len(scheme) > 0 {
+			return scheme
+		}
+	}
+
+	return nil
+}
+
+// applyTint lightens a color component towards white, a tint of 255 leaving
+// it unchanged.
+func applyTint(value int, tint int) int {
+	return int(math.Round(255 - float64(255-value)*float64(tint)/255))
+}
+
+// applyShade darkens a color component towards black, a shade of 255 leaving
+// it unchanged.
+func applyShade(value int, shade int) int {
+	return int(math.Round(float64(value) * float64(shade) / 255))
+}
@@ -0,0 +1,159 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestParseColorTableThemeColors(t *testing.T) {
+	content := `{\rtf1\ansi{\colortbl;\red255\green0\blue0;`
+	content += `\ctextone\ctint166\cshade255\red89\green89\blue89;`
+	content += `\caccentone\ctint153;`
+	content += `\caccenttwo\cshade191;`
+	content += `\chyperlink;`
+	content += `\caccentone\ctint0;\caccentone\cshade0;}}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ColorTable{
+		0: Color{Auto: true},
+		1: Color{R: 255, G: 0, B: 0},
+		2: Color{R: 89, G: 89, B: 89, Theme: ColorThemeTextOne, Tint: 166, Shade: 255},
+		3: Color{R: 143, G: 170, B: 220, Theme: ColorThemeAccentOne, Tint: 153, Shade: 255},
+		4: Color{R: 178, G: 94, B: 37, Theme: ColorThemeAccentTwo, Tint: 255, Shade: 191},
+		5: Color{R: 5, G: 99, B: 193, Theme: ColorThemeHyperlink, Tint: 255, Shade: 255},
+		6: Color{R: 255, G: 255, B: 255, Theme: ColorThemeAccentOne, Tint: 0, Shade: 255},
+		7: Color{R: 0, G: 0, B: 0, Theme: ColorThemeAccentOne, Tint: 255, Shade: 0},
+	}
+
+	if !reflect.DeepEqual(doc.Header.ColorTable, expected) {
+		t.Errorf("\n\nexpected: %+v\n\nactual\t: %+v", expected, doc.Header.ColorTable)
+	}
+
+	written, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc, err = parser.ParseContent(written); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Header.ColorTable, expected) {
+		t.Errorf("\n\nexpected: %+v\n\nactual\t: %+v", expected, doc.Header.ColorTable)
+	}
+}
+
+func TestParseColorTableThemeData(t *testing.T) {
+	theme := `<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" name="Custom">`
+	theme += `<a:themeElements><a:clrScheme name="Custom">`
+	theme += `<a:dk1><a:sysClr val="windowText" lastClr="101010"/></a:dk1>`
+	theme += `<a:lt1><a:sysClr val="window" lastClr="FFFFFF"/></a:lt1>`
+	theme += `<a:accent1><a:srgbClr val="FF0000"/></a:accent1>`
+	theme += `</a:clrScheme></a:themeElements></a:theme>`
+
+	// Word writes the theme manager part before the theme itself
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"/>`},
+		{"theme/theme/themeManager.xml", `<a:themeManager xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"/>`},
+		{"theme/theme/theme1.xml", theme},
+		{"theme/theme/_rels/themeManager.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"/>`},
+	} {
+		file, _ := writer.Create(part.name)
+		file.Write([]byte(part.content))
+	}
+	writer.Close()
+
+	content := `{\rtf1\ansi{\colortbl;\caccentone;\ctextone;\caccenttwo;}`
+	content += `{\*\themedata ` + hex.EncodeToString(archive.Bytes()) + `}}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ColorTable{
+		0: Color{Auto: true},
+		1: Color{R: 255, G: 0, B: 0, Theme: ColorThemeAccentOne, Tint: 255, Shade: 255},
+		2: Color{R: 16, G: 16, B: 16, Theme: ColorThemeTextOne, Tint: 255, Shade: 255},
+		3: Color{R: 237, G: 125, B: 49, Theme: ColorThemeAccentTwo, Tint: 255, Shade: 255},
+	}
+
+	if !reflect.DeepEqual(doc.Header.ColorTable, expected) {
+		t.Errorf("\n\nexpected: %+v\n\nactual\t: %+v", expected, doc.Header.ColorTable)
+	}
+}
+
+func TestParseExpandedColorTable(t *testing.T) {
+	content := `{\rtf1\ansi{\colortbl;\red255\green255\blue255;\red0\green0\blue0;\red255\green0\blue0;}` + "\n"
+	content += `{\*\expandedcolortbl;;\csgenericrgb\c0\c0\c0\c50000;\cssrgb\c100000\c0\c0\cname systemRedColor;}}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ColorTable{
+		0: Color{Auto: true},
+		1: Color{R: 255, G: 255, B: 255},
+		2: Color{Expanded: &ExpandedColor{Space: ColorSpaceGenericRGB, Components: []float64{0, 0, 0, 0.5}}},
+		3: Color{R: 255, Expanded: &ExpandedColor{Space: ColorSpaceSRGB, Components: []float64{1, 0, 0}, Name: "systemRedColor"}},
+	}
+
+	if !reflect.DeepEqual(doc.Header.ColorTable, expected) {
+		t.Errorf("\n\nexpected: %+v\n\nactual\t: %+v", expected, doc.Header.ColorTable)
+	}
+
+	minimal, err := parser.ParseFile("./testfiles/minimal.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = ColorTable{
+		0: Color{Auto: true},
+		1: Color{R: 255, G: 255, B: 255},
+	}
+
+	if !reflect.DeepEqual(minimal.Header.ColorTable, expected) {
+		t.Errorf("\n\nexpected: %+v\n\nactual\t: %+v", expected, minimal.Header.ColorTable)
+	}
+}
+
+func TestColorFormatting(t *testing.T) {
+	tests := []struct {
+		color Color
+		hex   string
+		css   string
+	}{
+		{Color{Auto: true}, "#000000", "inherit"},
+		{Color{R: 255, G: 128, B: 0}, "#ff8000", "#ff8000"},
+		{Color{R: 300, G: -1, B: 16}, "#ff0010", "#ff0010"},
+		{
+			Color{R: 0, G: 0, B: 255, Expanded: &ExpandedColor{Space: ColorSpaceSRGB, Components: []float64{0, 0, 1, 0.25}}},
+			"#0000ff", "rgba(0, 0, 255, 0.25)",
+		},
+		{
+			Color{R: 128, G: 128, B: 128, Expanded: &ExpandedColor{Space: ColorSpaceGray, Components: []float64{0.5, 1}}},
+			"#808080", "#808080",
+		},
+	}
+
+	for _, test := range tests {
+		if test.color.Hex() != test.hex {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", test.hex, test.color.Hex())
+		}
+		if test.color.CSS() != test.css {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", test.css, test.color.CSS())
+		}
+	}
+}
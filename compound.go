@@ -0,0 +1,240 @@
+package gortf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+)
+
+// compoundSignature starts the compound files of OLE2, which store the
+// native data of most embedded objects.
+var compoundSignature = []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}
+
+const (
+	compoundEndOfChain = 0xfffffffe
+	compoundNoStream   = 0xffffffff
+)
+
+var errCompoundFile = errors.New("gortf: malformed compound file")
+
+// isCompoundFile reports whether data is an OLE2 compound file.
+func isCompoundFile(data []byte) bool {
+	return bytes.HasPrefix(data, compoundSignature)
+}
+
+// compoundFile reads the streams of an OLE2 compound file.
+type compoundFile struct {
+	data       []byte
+	sectorSize int
+	fat        []uint32
+	miniFAT    []uint32
+	miniStream []byte
+	cutoff     int
+}
+
+// readCompoundFile returns the streams of a compound file by path, storages
+// and streams being separated by slashes.
+func readCompoundFile(data []byte) (map[string][]byte, error) {
+	if !isCompoundFile(data) || len(data) < 512 {
+		return nil, errCompoundFile
+	}
+
+	shift := binary.LittleEndian.Uint16(data[0x1e:])
+	if shift < 7 || shift > 16 {
+		return nil, errCompoundFile
+	}
+
+	c := &compoundFile{
+		data:       data,
+		sectorSize: 1 << shift,
+		cutoff:     int(binary.LittleEndian.Uint32(data[0x38:])),
+	}
+
+	// the sectors of the allocation table are listed in the header, then in
+	// a chain of sectors of their own, which cannot be longer than the file
+	fatSectors := []uint32{}
+	for i := 0; i < 109; i++ {
+		fatSectors = append(fatSectors, binary.LittleEndian.Uint32(data[0x4c+4*i:]))
+	}
+	next := binary.LittleEndian.Uint32(data[0x44:])
+	count := binary.LittleEndian.Uint32(data[0x48:])
+	if int64(count) > int64(len(data)/c.sectorSize) {
+		return nil, errCompoundFile
+	}
+	difat := map[uint32]bool{}
+	for ; count > 0 && next < compoundEndOfChain; count-- {
+		sector, ok := c.sector(next)
+		if !ok || difat[next] {
+			return nil, errCompoundFile
+		}
+		difat[next] = true
+		for i := 0; i+4 < len(sector); i += 4 {
+			fatSectors = append(fatSectors, binary.LittleEndian.Uint32(sector[i:]))
+		}
+		next = binary.LittleEndian.Uint32(sector[len(sector)-4:])
+	}
+
+	for _, id := range fatSectors[:min(len(fatSectors), int(binary.LittleEndian.Uint32(data[0x2c:])))] {
+		sector, ok := c.sector(id)
+		if !ok {
+			return nil, errCompoundFile
+		}
+		c.fat = append(c.fat, readUint32s(sector)...)
+	}
+
+	directory, err := c.chain(binary.LittleEndian.Uint32(data[0x30:]), -1)
+	if err != nil {
+		return nil, err
+	}
+	entries := []compoundEntry{}
+	for i := 0; i+128 <= len(directory); i += 128 {
+		entries = append(entries, readCompoundEntry(directory[i:i+128]))
+	}
+	if len(entries) == 0 || entries[0].kind != 5 {
+		return nil, errCompoundFile
+	}
+
+	if miniFAT, err := c.chain(binary.LittleEndian.Uint32(data[0x3c:]), -1); err == nil {
+		c.miniFAT = readUint32s(miniFAT)
+	}
+	if c.miniStream, err = c.chain(entries[0].start, entries[0].size); err != nil {
+		return nil, err
+	}
+
+	streams := map[string][]byte{}
+	visited := map[uint32]bool{}
+
+	var walk func(id uint32, path string) error
+	walk = func(id uint32, path string) error {
+		if id == compoundNoStream {
+			return nil
+		}
+		if int(id) >= len(entries) || visited[id] {
+			return errCompoundFile
+		}
+		visited[id] = true
+
+		entry := entries[id]
+		if err := walk(entry.left, path); err != nil {
+			return err
+		}
+		if err := walk(entry.right, path); err != nil {
+			return err
+		}
+
+		switch entry.kind {
+		case 1:
+			return walk(entry.child, path+entry.name+"/")
+		case 2:
+			stream, err := c.stream(entry)
+			if err != nil {
+				return err
+			}
+			streams[path+entry.name] = stream
+		}
+		return nil
+	}
+
+	if err := walk(entries[0].child, ""); err != nil {
+		return nil, err
+	}
+
+	return streams, nil
+}
+
+// compoundEntry is an entry of the directory of a compound file: a storage
+// (kind 1), a stream (kind 2) or the root (kind 5).
+type compoundEntry struct {
+	name               string
+	kind               byte
+	left, right, child uint32
+	start              uint32
+	size               int
+}
+
+func readCompoundEntry(data []byte) compoundEntry {
+	length := min(int(binary.LittleEndian.Uint16(data[0x40:])), 64)
+	name := []uint16{}
+	for i := 0; i+1 < length; i += 2 {
+		if character := binary.LittleEndian.Uint16(data[i:]); character != 0 {
+			name = append(name, character)
+		}
+	}
+
+	return compoundEntry{
+		name:  string(utf16.Decode(name)),
+		kind:  data[0x42],
+		left:  binary.LittleEndian.Uint32(data[0x44:]),
+		right: binary.LittleEndian.Uint32(data[0x48:]),
+		child: binary.LittleEndian.Uint32(data[0x4c:]),
+		start: binary.LittleEndian.Uint32(data[0x74:]),
+		size:  int(binary.LittleEndian.Uint32(data[0x78:])),
+	}
+}
+
+func (c *compoundFile) sector(id uint32) ([]byte, bool) {
+	offset := (int(id) + 1) * c.sectorSize
+	if id >= compoundEndOfChain-5 || offset+c.sectorSize > len(c.data) {
+		return nil, false
+	}
+
+	return c.data[offset : offset+c.sectorSize], true
+}
+
+// chain returns the content of the sectors chained from start, cut to size
+// unless it is negative.
+func (c *compoundFile) chain(start uint32, size int) ([]byte, error) {
+	content := []byte{}
+
+	for id, count := start, 0; id != compoundEndOfChain; count++ {
+		sector, ok := c.sector(id)
+		if !ok || count > len(c.fat) || int(id) >= len(c.fat) {
+			return nil, errCompoundFile
+		}
+		content = append(content, sector...)
+		id = c.fat[id]
+	}
+
+	if size >= 0 {
+		if size > len(content) {
+			return nil, errCompoundFile
+		}
+		content = content[:size]
+	}
+
+	return content, nil
+}
+
+// stream returns the content of a stream, which small streams keep in the
+// mini stream in sectors of 64 bytes.
+func (c *compoundFile) stream(entry compoundEntry) ([]byte, error) {
+	if entry.size >= c.cutoff {
+		return c.chain(entry.start, entry.size)
+	}
+
+	content := []byte{}
+	for id, count := entry.start, 0; id != compoundEndOfChain && len(content) < entry.size; count++ {
+		offset := int(id) * 64
+		if count > len(c.miniFAT) || int(id) >= len(c.miniFAT) || offset+64 > len(c.miniStream) {
+			return nil, errCompoundFile
+		}
+		content = append(content, c.miniStream[offset:offset+64]...)
+		id = c.miniFAT[id]
+	}
+
+	if entry.size > len(content) {
+		return nil, errCompoundFile
+	}
+
+	return content[:entry.size], nil
+}
+
+func readUint32s(data []byte) []uint32 {
+	values := make([]uint32, len(data)/4)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+
+	return values
+}
@@ -7,28 +7,43 @@ import (
 )
 
 type RtfInformationGroup struct {
-	Title           string
-	Subject         string
-	Author          string
-	Manager         string
-	Company         string
-	Operator        string
-	Category        string
-	Keywords        string
-	Comment         string
-	Version         int
-	DocumentComment string
-	BaseAddress     string
-	CreationTime    *time.Time
-	RevisionTime    *time.Time
-	LastPrintTime   *time.Time
-	BackupTime      *time.Time
+	Title           string     `json:"title,omitempty"`
+	Subject         string     `json:"subject,omitempty"`
+	Author          string     `json:"author,omitempty"`
+	Manager         string     `json:"manager,omitempty"`
+	Company         string     `json:"company,omitempty"`
+	Operator        string     `json:"operator,omitempty"`
+	Category        string     `json:"category,omitempty"`
+	Keywords        string     `json:"keywords,omitempty"`
+	Comment         string     `json:"comment,omitempty"`
+	Version         int        `json:"version,omitempty"`
+	DocumentComment string     `json:"documentComment,omitempty"`
+	BaseAddress     string     `json:"baseAddress,omitempty"`
+	CreationTime    *time.Time `json:"creationTime,omitempty"`
+	RevisionTime    *time.Time `json:"revisionTime,omitempty"`
+	LastPrintTime   *time.Time `json:"lastPrintTime,omitempty"`
+	BackupTime      *time.Time `json:"backupTime,omitempty"`
+
+	EditingMinutes               int `json:"editingMinutes,omitempty"`
+	NumberOfPages                int `json:"numberOfPages,omitempty"`
+	NumberOfWords                int `json:"numberOfWords,omitempty"`
+	NumberOfCharacters           int `json:"numberOfCharacters,omitempty"`
+	NumberOfCharactersWithSpaces int `json:"numberOfCharactersWithSpaces,omitempty"`
+	InternalID                   int `json:"internalId,omitempty"`
+
+	UserProperties []UserProperty `json:"userProperties,omitempty"`
 }
 
 type RtfDocument struct {
 	Header           RtfHeader
 	InformationGroup RtfInformationGroup
 	Body             []StyleBlock
+	Pictures         []Picture
+	Footnotes        []Footnote
+	HeadersFooters   []HeaderFooter
+	Annotations      []Annotation
+	Objects          []Object
+	Shapes           []Shape
 }
 
 func (r RtfDocument) String() string {
@@ -36,7 +51,17 @@ func (r RtfDocument) String() string {
 	return string(b)
 }
 
+// pushToBody appends a block to the body, merging text with the previous
+// block when both have the same formatting.
 func (r *RtfDocument) pushToBody(sb StyleBlock) {
+	if len(r.Body) > 0 && sb.Kind == BlockKindText {
+		last := &r.Body[len(r.Body)-1]
+		if last.Kind == BlockKindText && last.Painter == sb.Painter {
+			last.Text += sb.Text
+			return
+		}
+	}
+
 	r.Body = append(r.Body, sb)
 }
 
@@ -52,10 +77,37 @@ func (r *RtfDocument) popFromBody() StyleBlock {
 	return element
 }
 
+// flatBody returns the body as shown by formats that cannot hold objects
+// and shapes, such as plain text, HTML and Markdown: objects are replaced
+// with their results, and shapes with their pictures and the text of their
+// text boxes.
+func (r *RtfDocument) flatBody() []StyleBlock {
+	body := make([]StyleBlock, 0, len(r.Body))
+	for _, block := range r.Body {
+		switch block.Kind {
+		case BlockKindObject:
+			body = append(body, r.objectResult(block.ObjectIndex)...)
+		case BlockKindShape:
+			body = append(body, r.shapeContent(block.ShapeIndex)...)
+		default:
+			body = append(body, block)
+		}
+	}
+
+	return body
+}
+
+// ToText returns the plain text of the body. Text deleted while revisions
+// were tracked is left out, objects are shown by their results and shapes by
+// the text of their text boxes.
 func (r *RtfDocument) ToText() (string, error) {
 	var sb strings.Builder
 
-	for _, b := range r.Body {
+	for _, b := range r.flatBody() {
+		if b.Painter.Deleted && b.Kind != BlockKindCell && b.Kind != BlockKindRow && b.Kind != BlockKindSection {
+			continue
+		}
+
 		_, err := sb.WriteString(b.Text)
 		if err != nil {
 			return "", err
@@ -65,6 +117,73 @@ func (r *RtfDocument) ToText() (string, error) {
 	return sb.String(), nil
 }
 
+// TextOptions changes how documents are rendered as plain text.
+type TextOptions struct {
+	// CharacterWidth is the width of a character in twips, as if the text
+	// were shown in a monospaced font. When set, tabs are expanded to the
+	// spaces or leaders that reach the tab stops of their paragraph, so that
+	// text aligned with tabs stays aligned. 120 is the width of Courier at
+	// 10 points.
+	CharacterWidth int
+}
+
+// ToTextWithOptions returns the plain text of the body, as changed by
+// options.
+func (r *RtfDocument) ToTextWithOptions(options TextOptions) (string, error) {
+	if options.CharacterWidth <= 0 {
+		return r.ToText()
+	}
+
+	expander := tabExpander{characterWidth: options.CharacterWidth, defaultTab: r.Header.TabWidth()}
+
+	var sb strings.Builder
+	var paragraph strings.Builder
+
+	for _, b := range r.flatBody() {
+		if b.Painter.Deleted && b.Kind != BlockKindCell && b.Kind != BlockKindRow && b.Kind != BlockKindSection {
+			continue
+		}
+
+		// the text of a paragraph is expanded once its mark gives its
+		// format
+		switch {
+		case b.Paragraph != nil:
+			sb.WriteString(expander.expand(paragraph.String(), *b.Paragraph))
+			sb.WriteString(b.Text)
+			paragraph.Reset()
+		case b.Kind == BlockKindRow || b.Kind == BlockKindSection:
+			sb.WriteString(expander.expand(paragraph.String(), ParagraphFormat{}))
+			sb.WriteString(b.Text)
+			paragraph.Reset()
+		default:
+			paragraph.WriteString(b.Text)
+		}
+	}
+	sb.WriteString(expander.expand(paragraph.String(), ParagraphFormat{}))
+
+	return sb.String(), nil
+}
+
 func (r *RtfDocument) ToHTML() (string, error) {
 	return RTFToHTML(r)
 }
+
+func (r *RtfDocument) ToHTMLWithOptions(options HTMLOptions) (string, error) {
+	return RTFToHTMLWithOptions(r, options)
+}
+
+func (r *RtfDocument) ToMarkdown() (string, error) {
+	return RTFToMarkdown(r)
+}
+
+func (r *RtfDocument) ToRTF() (string, error) {
+	return DocumentToRTF(r)
+}
+
+func (r *RtfDocument) ToDOCX() ([]byte, error) {
+	return DocumentToDOCX(r)
+}
+
+func (r *RtfDocument) ToODT() ([]byte, error) {
+	return DocumentToODT(r)
+}
@@ -0,0 +1,1514 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespaces of the parts of a Word document.
+const (
+	docxNamespaceMain          = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	docxNamespaceRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	docxNamespaceDrawing       = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+	docxNamespaceGraphic       = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	docxNamespacePicture       = "http://schemas.openxmlformats.org/drawingml/2006/picture"
+	docxNamespaceVML           = "urn:schemas-microsoft-com:vml"
+	docxNamespaceWordVML       = "urn:schemas-microsoft-com:office:word"
+)
+
+// Types of the relationships between the parts of a Word document, and
+// content types of the parts.
+const (
+	docxRelationshipDocument     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
+	docxRelationshipCore         = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	docxRelationshipExtended     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"
+	docxRelationshipCustom       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+	docxRelationshipStyles       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"
+	docxRelationshipSettings     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings"
+	docxRelationshipFontTable    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/fontTable"
+	docxRelationshipNumbering    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering"
+	docxRelationshipFootnotes    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footnotes"
+	docxRelationshipEndnotes     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/endnotes"
+	docxRelationshipHeader       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/header"
+	docxRelationshipFooter       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer"
+	docxRelationshipImage        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	docxRelationshipHyperlink    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+	docxRelationshipTheme        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme"
+	docxContentTypePrefix        = "application/vnd.openxmlformats-officedocument.wordprocessingml."
+	docxContentTypeDocument      = docxContentTypePrefix + "document.main+xml"
+	docxContentTypeCore          = "application/vnd.openxmlformats-package.core-properties+xml"
+	docxContentTypeExtended      = "application/vnd.openxmlformats-officedocument.extended-properties+xml"
+	docxContentTypeCustom        = "application/vnd.openxmlformats-officedocument.custom-properties+xml"
+	docxContentTypeRelationships = "application/vnd.openxmlformats-package.relationships+xml"
+)
+
+// Page size and margins in twips that RTF assumes when a section gives none.
+const (
+	defaultPageWidth    = 12240
+	defaultPageHeight   = 15840
+	defaultMarginLeft   = 1800
+	defaultMarginRight  = 1800
+	defaultMarginTop    = 1440
+	defaultMarginBottom = 1440
+)
+
+// docxNamespaces declares the namespaces used by the parts holding content.
+var docxNamespaces = []string{
+	"xmlns:w", docxNamespaceMain,
+	"xmlns:r", docxNamespaceRelationships,
+	"xmlns:wp", docxNamespaceDrawing,
+	"xmlns:a", docxNamespaceGraphic,
+	"xmlns:pic", docxNamespacePicture,
+	"xmlns:v", docxNamespaceVML,
+	"xmlns:w10", docxNamespaceWordVML,
+}
+
+// DocumentToDOCX serializes a document as a Word document in the Office Open
+// XML format.
+func DocumentToDOCX(r *RtfDocument) ([]byte, error) {
+	var b bytes.Buffer
+	if err := WriteDOCX(&b, r); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// WriteDOCX serializes a document as a Word document to w. The fonts, styles
+// and lists of the header become the font table, styles and numbering of the
+// package, and the information group its document properties. Pictures in
+// formats Word cannot display, such as Mac PICT, are left out.
+func WriteDOCX(w io.Writer, r *RtfDocument) error {
+	d := &docxWriter{
+		document: r,
+		pkg:      &docxPart{},
+		styles:   map[int]Style{},
+		media:    map[int]string{},
+	}
+	for _, style := range r.Header.Stylesheet {
+		d.styles[style.Number] = style
+	}
+
+	d.writeProperties()
+	d.writeDocument()
+
+	archive := zip.NewWriter(w)
+	for _, file := range d.files() {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		data, err := file.data()
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// docxWriter builds the parts of a Word document.
+type docxWriter struct {
+	document *RtfDocument
+
+	// pkg holds the relationships of the package itself, and parts the
+	// parts written so far
+	pkg   *docxPart
+	parts []*docxPart
+
+	// styles of the stylesheet by number
+	styles map[int]Style
+
+	// names of the media parts of the pictures by index in the document,
+	// relative to the word directory
+	media map[int]string
+
+	// number of drawings written, from which their identifiers are taken
+	drawings int
+}
+
+// docxPart is a part of the package: either XML written with xml, or the
+// data of a media file.
+type docxPart struct {
+	name        string
+	contentType string
+	xml         *xmlWriter
+	content     []byte
+
+	relationships []docxRelationship
+}
+
+type docxRelationship struct {
+	id       string
+	kind     string
+	target   string
+	external bool
+}
+
+func (p *docxPart) data() ([]byte, error) {
+	if p.xml != nil {
+		return p.xml.bytes()
+	}
+
+	return p.content, nil
+}
+
+// relationship returns the identifier of the relationship of the part with
+// target, adding it if needed. Targets are relative to the directory of the
+// part.
+func (p *docxPart) relationship(kind string, target string, external bool) string {
+	for _, relationship := range p.relationships {
+		if relationship.kind == kind && relationship.target == target {
+			return relationship.id
+		}
+	}
+
+	id := "rId" + strconv.Itoa(len(p.relationships)+1)
+	p.relationships = append(p.relationships, docxRelationship{
+		id:       id,
+		kind:     kind,
+		target:   target,
+		external: external,
+	})
+
+	return id
+}
+
+func (d *docxWriter) newPart(name string, contentType string) *docxPart {
+	part := &docxPart{name: name, contentType: contentType, xml: newXMLWriter()}
+	d.parts = append(d.parts, part)
+
+	return part
+}
+
+// files returns the files of the package: the content types, the parts and
+// the relationships of those that have any.
+func (d *docxWriter) files() []*docxPart {
+	types := &docxPart{name: "[Content_Types].xml", xml: newXMLWriter()}
+	files := []*docxPart{types}
+
+	defaults := map[string]string{
+		"rels": docxContentTypeRelationships,
+		"xml":  "application/xml",
+	}
+	overrides := []*docxPart{}
+
+	for _, part := range append([]*docxPart{d.pkg}, d.parts...) {
+		if part != d.pkg {
+			files = append(files, part)
+			if part.xml != nil {
+				overrides = append(overrides, part)
+			} else {
+				defaults[strings.TrimPrefix(path.Ext(part.name), ".")] = part.contentType
+			}
+		}
+
+		if len(part.relationships) == 0 {
+			continue
+		}
+
+		directory, name := path.Split(part.name)
+		relationships := &docxPart{name: directory + "_rels/" + name + ".rels", xml: newXMLWriter()}
+		relationships.writeRelationships(part.relationships)
+		files = append(files, relationships)
+	}
+
+	x := types.xml
+	x.start("Types", "xmlns", "http://schemas.openxmlformats.org/package/2006/content-types")
+	extensions := []string{}
+	for extension := range defaults {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	for _, extension := range extensions {
+		x.empty("Default", "Extension", extension, "ContentType", defaults[extension])
+	}
+	for _, part := range overrides {
+		x.empty("Override", "PartName", "/"+part.name, "ContentType", part.contentType)
+	}
+	x.end()
+
+	return files
+}
+
+func (p *docxPart) writeRelationships(relationships []docxRelationship) {
+	x := p.xml
+	x.start("Relationships", "xmlns", "http://schemas.openxmlformats.org/package/2006/relationships")
+	for _, relationship := range relationships {
+		attributes := []string{"Id", relationship.id, "Type", relationship.kind, "Target", relationship.target}
+		if relationship.external {
+			attributes = append(attributes, "TargetMode", "External")
+		}
+		x.empty("Relationship", attributes...)
+	}
+	x.end()
+}
+
+// writeProperties writes the core, extended and custom properties from the
+// information group.
+func (d *docxWriter) writeProperties() {
+	info := d.document.InformationGroup
+
+	core := d.newPart("docProps/core.xml", docxContentTypeCore)
+	d.pkg.relationship(docxRelationshipCore, core.name, false)
+
+	x := core.xml
+	x.start("cp:coreProperties",
+		"xmlns:cp", "http://schemas.openxmlformats.org/package/2006/metadata/core-properties",
+		"xmlns:dc", "http://purl.org/dc/elements/1.1/",
+		"xmlns:dcterms", "http://purl.org/dc/terms/",
+		"xmlns:dcmitype", "http://purl.org/dc/dcmitype/",
+		"xmlns:xsi", "http://www.w3.org/2001/XMLSchema-instance")
+	for _, property := range []struct {
+		name  string
+		value string
+	}{
+		{"dc:title", info.Title},
+		{"dc:subject", info.Subject},
+		{"dc:creator", info.Author},
+		{"cp:keywords", info.Keywords},
+		{"dc:description", info.Comment},
+		{"cp:lastModifiedBy", info.Operator},
+		{"cp:category", info.Category},
+	} {
+		if property.value != "" {
+			x.textElement(property.name, property.value)
+		}
+	}
+	if info.Version != 0 {
+		x.textElement("cp:revision", strconv.Itoa(info.Version))
+	}
+	if info.CreationTime != nil {
+		x.textElement("dcterms:created", docxTime(*info.CreationTime), "xsi:type", "dcterms:W3CDTF")
+	}
+	if info.RevisionTime != nil {
+		x.textElement("dcterms:modified", docxTime(*info.RevisionTime), "xsi:type", "dcterms:W3CDTF")
+	}
+	if info.LastPrintTime != nil {
+		x.textElement("cp:lastPrinted", docxTime(*info.LastPrintTime))
+	}
+	x.end()
+
+	extended := d.newPart("docProps/app.xml", docxContentTypeExtended)
+	d.pkg.relationship(docxRelationshipExtended, extended.name, false)
+
+	x = extended.xml
+	x.start("Properties", "xmlns", "http://schemas.openxmlformats.org/officeDocument/2006/extended-properties")
+	for _, property := range []struct {
+		name  string
+		value string
+	}{
+		{"Manager", info.Manager},
+		{"Company", info.Company},
+		{"HyperlinkBase", info.BaseAddress},
+	} {
+		if property.value != "" {
+			x.textElement(property.name, property.value)
+		}
+	}
+	for _, property := range []struct {
+		name  string
+		value int
+	}{
+		{"Pages", info.NumberOfPages},
+		{"Words", info.NumberOfWords},
+		{"Characters", info.NumberOfCharacters},
+		{"CharactersWithSpaces", info.NumberOfCharactersWithSpaces},
+		{"TotalTime", info.EditingMinutes},
+	} {
+		if property.value != 0 {
+			x.textElement(property.name, strconv.Itoa(property.value))
+		}
+	}
+	x.end()
+
+	if len(info.UserProperties) == 0 {
+		return
+	}
+
+	custom := d.newPart("docProps/custom.xml", docxContentTypeCustom)
+	d.pkg.relationship(docxRelationshipCustom, custom.name, false)
+
+	x = custom.xml
+	x.start("Properties",
+		"xmlns", "http://schemas.openxmlformats.org/officeDocument/2006/custom-properties",
+		"xmlns:vt", "http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes")
+	for i, property := range info.UserProperties {
+		// identifiers start at 2 and share the format identifier Word uses
+		x.start("property", "fmtid", "{D5CDD505-2E9C-101B-9397-08002B2CF9AE}", "pid", strconv.Itoa(i+2), "name", property.Name)
+		switch value := property.Value.(type) {
+		case int:
+			x.textElement("vt:i4", strconv.Itoa(value))
+		case float64:
+			x.textElement("vt:r8", strconv.FormatFloat(value, 'f', -1, 64))
+		case bool:
+			x.textElement("vt:bool", strconv.FormatBool(value))
+		case time.Time:
+			x.textElement("vt:filetime", docxTime(value))
+		default:
+			x.textElement("vt:lpwstr", fmt.Sprint(value))
+		}
+		x.end()
+	}
+	x.end()
+}
+
+func docxTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// writeDocument writes the main document part and the parts it refers to.
+func (d *docxWriter) writeDocument() {
+	part := d.newPart("word/document.xml", docxContentTypeDocument)
+	d.pkg.relationship(docxRelationshipDocument, part.name, false)
+
+	d.writeStyles(part)
+	d.writeFontTable(part)
+	d.writeNumbering(part)
+	d.writeNotes(part, false)
+	d.writeNotes(part, true)
+
+	sections := d.document.Sections()
+	references, evenAndOdd := d.writeHeadersFooters(part, len(sections))
+	d.writeSettings(part, evenAndOdd)
+
+	x := part.xml
+	x.start("w:document", docxNamespaces...)
+	x.start("w:body")
+	for i, section := range sections {
+		properties := d.sectionProperties(section.Format, references[i])
+		if i < len(sections)-1 {
+			d.writeElements(part, section.Elements, &properties)
+			continue
+		}
+
+		d.writeElements(part, section.Elements, nil)
+		x.element(properties)
+	}
+	x.end()
+	x.end()
+}
+
+// writeElements writes the paragraphs and tables of a section. The
+// properties of a section other than the last are carried by its last
+// paragraph, which is added if the section does not end with one.
+func (d *docxWriter) writeElements(part *docxPart, elements []BodyElement, section *xmlElement) {
+	for i, element := range elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			if i == len(elements)-1 {
+				d.writeParagraph(part, *e, section, "")
+				section = nil
+			} else {
+				d.writeParagraph(part, *e, nil, "")
+			}
+		case *Table:
+			d.writeTable(part, e)
+		}
+	}
+
+	if section != nil {
+		d.writeParagraph(part, Paragraph{}, section, "")
+	}
+}
+
+// writeSubdocument writes the content of a note, header, footer or text box,
+// which holds at least one paragraph. Notes start with the reference mark given
+// by noteReference.
+func (d *docxWriter) writeSubdocument(part *docxPart, body []StyleBlock, noteReference string) {
+	elements := []BodyElement{}
+	for _, section := range sectionsOf(body) {
+		elements = append(elements, section.Elements...)
+	}
+	if len(elements) == 0 {
+		elements = append(elements, &Paragraph{})
+	}
+
+	for i, element := range elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			if i == 0 {
+				d.writeParagraph(part, *e, nil, noteReference)
+			} else {
+				d.writeParagraph(part, *e, nil, "")
+			}
+		case *Table:
+			d.writeTable(part, e)
+		}
+	}
+}
+
+// writeNotes writes the part holding the footnotes or the endnotes of the
+// document, if it has any. Notes are identified by their index in the
+// document plus one, 0 and -1 being the separators.
+func (d *docxWriter) writeNotes(document *docxPart, endnotes bool) {
+	name, kind, contentType := "footnote", docxRelationshipFootnotes, docxContentTypePrefix+"footnotes+xml"
+	if endnotes {
+		name, kind, contentType = "endnote", docxRelationshipEndnotes, docxContentTypePrefix+"endnotes+xml"
+	}
+
+	found := false
+	for _, note := range d.document.Footnotes {
+		found = found || note.Endnote == endnotes
+	}
+	if !found {
+		return
+	}
+
+	part := d.newPart("word/"+name+"s.xml", contentType)
+	document.relationship(kind, name+"s.xml", false)
+
+	x := part.xml
+	x.start("w:"+name+"s", docxNamespaces...)
+	for _, separator := range []struct {
+		id   string
+		kind string
+	}{
+		{"-1", "separator"},
+		{"0", "continuationSeparator"},
+	} {
+		x.start("w:"+name, "w:type", separator.kind, "w:id", separator.id)
+		x.element(xmlElement{name: "w:p", children: []xmlElement{
+			{name: "w:r", children: []xmlElement{{name: "w:" + separator.kind}}},
+		}})
+		x.end()
+	}
+	for i, note := range d.document.Footnotes {
+		if note.Endnote != endnotes {
+			continue
+		}
+
+		x.start("w:"+name, "w:id", strconv.Itoa(i+1))
+		d.writeSubdocument(part, note.Body, "w:"+name+"Ref")
+		x.end()
+	}
+	x.end()
+}
+
+// docxHeaderFooterTypes are the types of the references to headers and
+// footers of each kind. Headers of right pages are those of every page.
+var docxHeaderFooterTypes = map[HeaderFooterKind]string{
+	HeaderFooterKindHeader: "default", HeaderFooterKindHeaderLeft: "even",
+	HeaderFooterKindHeaderRight: "default", HeaderFooterKindHeaderFirst: "first",
+	HeaderFooterKindFooter: "default", HeaderFooterKindFooterLeft: "even",
+	HeaderFooterKindFooterRight: "default", HeaderFooterKindFooterFirst: "first",
+}
+
+// writeHeadersFooters writes a part for each header and footer and returns
+// the references to them by section, and whether left pages have headers of
+// their own. Headers of sections past the last belong to the last.
+func (d *docxWriter) writeHeadersFooters(document *docxPart, sections int) (map[int][]xmlElement, bool) {
+	references := map[int][]xmlElement{}
+	evenAndOdd := false
+	counts := map[string]int{}
+
+	for _, headerFooter := range d.document.HeadersFooters {
+		referenceType, ok := docxHeaderFooterTypes[headerFooter.Kind]
+		if !ok {
+			continue
+		}
+
+		name, root, kind := "header", "w:hdr", docxRelationshipHeader
+		if headerFooter.Kind.IsFooter() {
+			name, root, kind = "footer", "w:ftr", docxRelationshipFooter
+		}
+
+		section := min(max(headerFooter.Section, 0), sections-1)
+		duplicate := false
+		for _, reference := range references[section] {
+			duplicate = duplicate || (reference.name == "w:"+name+"Reference" && reference.attributes[1] == referenceType)
+		}
+		if duplicate {
+			continue
+		}
+
+		counts[name]++
+		file := name + strconv.Itoa(counts[name]) + ".xml"
+		part := d.newPart("word/"+file, docxContentTypePrefix+name+"+xml")
+		id := document.relationship(kind, file, false)
+
+		part.xml.start(root, docxNamespaces...)
+		d.writeSubdocument(part, headerFooter.Body, "")
+		part.xml.end()
+
+		references[section] = append(references[section], newXMLElement("w:"+name+"Reference", "w:type", referenceType, "r:id", id))
+		evenAndOdd = evenAndOdd || referenceType == "even"
+	}
+
+	return references, evenAndOdd
+}
+
+func (d *docxWriter) writeSettings(document *docxPart, evenAndOddHeaders bool) {
+	part := d.newPart("word/settings.xml", docxContentTypePrefix+"settings+xml")
+	document.relationship(docxRelationshipSettings, "settings.xml", false)
+
+	x := part.xml
+	x.start("w:settings", "xmlns:w", docxNamespaceMain)
+	if evenAndOddHeaders {
+		x.empty("w:evenAndOddHeaders")
+	}
+	x.end()
+}
+
+// sectionProperties returns the <w:sectPr> element of a section.
+func (d *docxWriter) sectionProperties(format SectionFormat, references []xmlElement) xmlElement {
+	properties := newXMLElement("w:sectPr")
+	properties.children = append(properties.children, references...)
+
+	switch format.Break {
+	case SectionBreakNone:
+		properties.add("w:type", "w:val", "continuous")
+	case SectionBreakColumn:
+		properties.add("w:type", "w:val", "nextColumn")
+	case SectionBreakEven:
+		properties.add("w:type", "w:val", "evenPage")
+	case SectionBreakOdd:
+		properties.add("w:type", "w:val", "oddPage")
+	}
+
+	width, height := format.PageWidth, format.PageHeight
+	if width == 0 && height == 0 && format.Landscape {
+		width, height = defaultPageHeight, defaultPageWidth
+	}
+	if width == 0 {
+		width = defaultPageWidth
+	}
+	if height == 0 {
+		height = defaultPageHeight
+	}
+	pageSize := []string{"w:w", strconv.Itoa(width), "w:h", strconv.Itoa(height)}
+	if format.Landscape {
+		pageSize = append(pageSize, "w:orient", "landscape")
+	}
+	properties.add("w:pgSz", pageSize...)
+
+	margins := []string{}
+	for _, margin := range []struct {
+		name         string
+		value        int
+		defaultValue int
+	}{
+		{"w:top", format.MarginTop, defaultMarginTop},
+		{"w:right", format.MarginRight, defaultMarginRight},
+		{"w:bottom", format.MarginBottom, defaultMarginBottom},
+		{"w:left", format.MarginLeft, defaultMarginLeft},
+	} {
+		if margin.value == 0 {
+			margin.value = margin.defaultValue
+		}
+		margins = append(margins, margin.name, strconv.Itoa(margin.value))
+	}
+	properties.add("w:pgMar", append(margins, "w:header", "720", "w:footer", "720", "w:gutter", "0")...)
+
+	if format.Columns > 1 {
+		properties.add("w:cols", "w:num", strconv.Itoa(format.Columns), "w:space", "720")
+	}
+
+	for _, reference := range references {
+		if reference.attributes[1] == "first" {
+			properties.add("w:titlePg")
+			break
+		}
+	}
+
+	return properties
+}
+
+// writeParagraph writes a paragraph, with the properties of the section it
+// ends if it is the last of one and the reference mark of the note it
+// starts if it is the first of one.
+func (d *docxWriter) writeParagraph(part *docxPart, paragraph Paragraph, section *xmlElement, noteReference string) {
+	x := part.xml
+	style := d.style(paragraph.Format.Style)
+
+	properties := newXMLElement("w:pPr")
+	properties.children = d.paragraphProperties(paragraph.Format, style.Paragraph)
+	if paragraph.Mark != nil {
+		properties.container("w:rPr", d.runProperties(*paragraph.Mark, style.Painter))
+	}
+	if section != nil {
+		properties.children = append(properties.children, *section)
+	}
+
+	x.start("w:p")
+	x.container(properties)
+
+	if noteReference != "" {
+		x.element(xmlElement{name: "w:r", children: []xmlElement{
+			{name: "w:rPr", children: []xmlElement{newXMLElement("w:vertAlign", "w:val", "superscript")}},
+			{name: noteReference},
+		}})
+	}
+
+	// hyperlinks, or else other fields, in progress
+	link, field := "", ""
+	for _, run := range paragraph.Runs {
+		if run.Painter.Field != "" && run.Painter.Link != "" {
+			run.Painter.Field = ""
+		}
+		if run.Painter.Link != link || run.Painter.Field != field {
+			if link != "" || field != "" {
+				x.end()
+			}
+			link, field = run.Painter.Link, run.Painter.Field
+
+			switch {
+			case strings.HasPrefix(link, "#"):
+				x.start("w:hyperlink", "w:anchor", link[1:])
+			case link != "":
+				x.start("w:hyperlink", "r:id", part.relationship(docxRelationshipHyperlink, link, true))
+			case field != "":
+				x.start("w:fldSimple", "w:instr", " "+field+" ")
+			}
+		}
+
+		d.writeRun(part, run, style.Painter)
+	}
+	if link != "" || field != "" {
+		x.end()
+	}
+
+	x.end()
+}
+
+// style returns the style of the given number, the Normal style standing for
+// those the stylesheet lacks as it does in Word.
+func (d *docxWriter) style(number int) Style {
+	if style, ok := d.styles[number]; ok {
+		return style
+	}
+
+	return d.styles[0]
+}
+
+func docxStyleID(number int) string {
+	if number == 0 {
+		return "Normal"
+	}
+
+	return "Style" + strconv.Itoa(number)
+}
+
+// paragraphProperties returns the children of the <w:pPr> element of a
+// paragraph of the given format, leaving out those its style already gives.
+func (d *docxWriter) paragraphProperties(format ParagraphFormat, style ParagraphFormat) []xmlElement {
+	properties := newXMLElement("")
+
+	if _, ok := d.styles[format.Style]; ok && format.Style != 0 {
+		properties.add("w:pStyle", "w:val", docxStyleID(format.Style))
+	}
+
+	properties.toggle("w:keepNext", format.KeepWithNext, style.KeepWithNext)
+	properties.toggle("w:keepLines", format.KeepTogether, style.KeepTogether)
+	properties.toggle("w:pageBreakBefore", format.PageBreakBefore, style.PageBreakBefore)
+
+	if _, ok := d.document.Header.Lists[format.List]; ok && format.List != 0 {
+		properties.children = append(properties.children, xmlElement{name: "w:numPr", children: []xmlElement{
+			newXMLElement("w:ilvl", "w:val", strconv.Itoa(min(max(format.ListLevel, 0), 8))),
+			newXMLElement("w:numId", "w:val", strconv.Itoa(int(format.List))),
+		}})
+	} else if style.List != 0 {
+		properties.children = append(properties.children, xmlElement{name: "w:numPr", children: []xmlElement{
+			newXMLElement("w:numId", "w:val", "0"),
+		}})
+	}
+
+	if format.SpaceBefore != style.SpaceBefore || format.SpaceAfter != style.SpaceAfter ||
+		format.LineSpacing != style.LineSpacing || format.LineSpacingMultiple != style.LineSpacingMultiple {
+		spacing := []string{"w:before", strconv.Itoa(format.SpaceBefore), "w:after", strconv.Itoa(format.SpaceAfter)}
+		switch {
+		case format.LineSpacing == 0:
+			spacing = append(spacing, "w:line", "240", "w:lineRule", "auto")
+		case format.LineSpacingMultiple:
+			spacing = append(spacing, "w:line", strconv.Itoa(format.LineSpacing), "w:lineRule", "auto")
+		case format.LineSpacing > 0:
+			spacing = append(spacing, "w:line", strconv.Itoa(format.LineSpacing), "w:lineRule", "atLeast")
+		default:
+			spacing = append(spacing, "w:line", strconv.Itoa(-format.LineSpacing), "w:lineRule", "exact")
+		}
+		properties.add("w:spacing", spacing...)
+	}
+
+	if format.LeftIndent != style.LeftIndent || format.RightIndent != style.RightIndent ||
+		format.FirstLineIndent != style.FirstLineIndent {
+		properties.add("w:ind", docxIndent(format.LeftIndent, format.RightIndent, format.FirstLineIndent)...)
+	}
+
+	if format.Alignment != style.Alignment {
+		properties.add("w:jc", "w:val", docxAlignment(format.Alignment))
+	}
+
+	return properties.children
+}
+
+// docxIndent returns the attributes of a <w:ind> element, negative first
+// line indents being hanging indents.
+func docxIndent(left int, right int, firstLine int) []string {
+	indent := []string{"w:left", strconv.Itoa(left), "w:right", strconv.Itoa(right)}
+	if firstLine < 0 {
+		return append(indent, "w:hanging", strconv.Itoa(-firstLine))
+	}
+
+	return append(indent, "w:firstLine", strconv.Itoa(firstLine))
+}
+
+func docxAlignment(alignment Alignment) string {
+	switch alignment {
+	case AlignmentCenter:
+		return "center"
+	case AlignmentRight:
+		return "right"
+	case AlignmentJustify:
+		return "both"
+	case AlignmentDistribute:
+		return "distribute"
+	default:
+		return "left"
+	}
+}
+
+// runProperties returns the children of the <w:rPr> element of a run with
+// the formatting of painter, leaving out what the paragraph style already
+// gives and turning off what it gives that painter does not.
+func (d *docxWriter) runProperties(painter Painter, style Painter) []xmlElement {
+	properties := newXMLElement("")
+
+	if painter.FontRef != style.FontRef {
+		if font, ok := d.document.Header.FontTable[painter.FontRef]; ok && font.Name != "" {
+			properties.add("w:rFonts", "w:ascii", font.Name, "w:hAnsi", font.Name, "w:eastAsia", font.Name, "w:cs", font.Name)
+		}
+	}
+
+	properties.toggle("w:b", painter.Bold, style.Bold)
+	properties.toggle("w:i", painter.Italic, style.Italic)
+	properties.toggle("w:smallCaps", painter.SmallCaps, style.SmallCaps)
+	properties.toggle("w:strike", painter.Strikethrough, style.Strikethrough)
+	properties.toggle("w:vanish", painter.Hidden, style.Hidden)
+
+	if painter.ForegroundColor != style.ForegroundColor {
+		properties.add("w:color", "w:val", d.color(painter.ForegroundColor))
+	}
+
+	if size, styleSize := docxFontSize(painter), docxFontSize(style); size != styleSize {
+		properties.add("w:sz", "w:val", strconv.Itoa(size))
+		properties.add("w:szCs", "w:val", strconv.Itoa(size))
+	}
+
+	if painter.Underline != style.Underline {
+		properties.add("w:u", "w:val", map[bool]string{true: "single", false: "none"}[painter.Underline])
+	}
+
+	if background, styleBackground := docxBackground(painter), docxBackground(style); background != styleBackground {
+		properties.add("w:shd", "w:val", "clear", "w:color", "auto", "w:fill", d.color(background))
+	}
+
+	if painter.Superscript != style.Superscript || painter.Subscript != style.Subscript {
+		switch {
+		case painter.Superscript:
+			properties.add("w:vertAlign", "w:val", "superscript")
+		case painter.Subscript:
+			properties.add("w:vertAlign", "w:val", "subscript")
+		default:
+			properties.add("w:vertAlign", "w:val", "baseline")
+		}
+	}
+
+	return properties.children
+}
+
+// docxFontSize returns the font size of painter in half-points, 24 being the
+// size of text without one.
+func docxFontSize(painter Painter) int {
+	if painter.FontSize <= 0 {
+		return 24
+	}
+
+	return painter.FontSize
+}
+
+// docxBackground returns the color table entry of the shading of text, for
+// which Word has no separate highlight of an arbitrary color.
+func docxBackground(painter Painter) TableRef {
+	if painter.Highlight != 0 {
+		return painter.Highlight
+	}
+
+	return painter.BackgroundColor
+}
+
+// color returns the value of a color table entry as written in attributes,
+// "auto" for the auto color and missing entries.
+func (d *docxWriter) color(ref TableRef) string {
+	color, ok := d.document.Header.ColorTable[ref]
+	if !ok || ref == 0 || color.Auto {
+		return "auto"
+	}
+
+	return strings.ToUpper(strings.TrimPrefix(color.Hex(), "#"))
+}
+
+// writeRun writes a run of a paragraph, turning tabs and line feeds of text
+// into their own elements.
+func (d *docxWriter) writeRun(part *docxPart, run StyleBlock, style Painter) {
+	if run.Kind == BlockKindObject {
+		for _, result := range d.document.objectResult(run.ObjectIndex) {
+			d.writeRun(part, result, style)
+		}
+		return
+	}
+
+	if run.Kind == BlockKindShape {
+		d.writeShape(part, run.ShapeIndex)
+		return
+	}
+
+	painter := run.Painter
+	content := []xmlElement{}
+
+	switch run.Kind {
+	case BlockKindText:
+		for i, line := range strings.Split(run.Text, "\n") {
+			if i > 0 {
+				content = append(content, newXMLElement("w:br"))
+			}
+			for j, text := range strings.Split(line, "\t") {
+				if j > 0 {
+					content = append(content, newXMLElement("w:tab"))
+				}
+				if text != "" {
+					content = append(content, xmlElement{name: "w:t", attributes: []string{"xml:space", "preserve"}, text: text})
+				}
+			}
+		}
+	case BlockKindLine:
+		content = append(content, newXMLElement("w:br"))
+	case BlockKindPage:
+		content = append(content, newXMLElement("w:br", "w:type", "page"))
+	case BlockKindPicture:
+		if drawing, ok := d.drawing(part, run.PictureIndex); ok {
+			content = append(content, drawing)
+		}
+	case BlockKindFootnote:
+		if run.FootnoteIndex >= 0 && run.FootnoteIndex < len(d.document.Footnotes) {
+			name := "w:footnoteReference"
+			if d.document.Footnotes[run.FootnoteIndex].Endnote {
+				name = "w:endnoteReference"
+			}
+			content = append(content, newXMLElement(name, "w:id", strconv.Itoa(run.FootnoteIndex+1)))
+			painter.Superscript, painter.Subscript = true, false
+		}
+	}
+
+	if len(content) == 0 {
+		return
+	}
+
+	element := newXMLElement("w:r")
+	element.container("w:rPr", d.runProperties(painter, style))
+	element.children = append(element.children, content...)
+	part.xml.element(element)
+}
+
+// drawing returns the <w:drawing> element of a picture, adding the media
+// part holding it on first use. It fails for pictures that cannot be
+// stored in a Word document.
+func (d *docxWriter) drawing(part *docxPart, index int) (xmlElement, bool) {
+	name, ok := d.mediaName(index)
+	if !ok {
+		return xmlElement{}, false
+	}
+	picture := d.document.Pictures[index]
+
+	d.drawings++
+	id := strconv.Itoa(d.drawings)
+	// English Metric Units, 635 per twip
+	width, height := picture.size()
+	cx, cy := strconv.Itoa(width*635), strconv.Itoa(height*635)
+
+	return xmlElement{name: "w:drawing", children: []xmlElement{
+		{name: "wp:inline", attributes: []string{"distT", "0", "distB", "0", "distL", "0", "distR", "0"}, children: []xmlElement{
+			newXMLElement("wp:extent", "cx", cx, "cy", cy),
+			newXMLElement("wp:docPr", "id", id, "name", "Picture "+id),
+			{name: "a:graphic", children: []xmlElement{
+				{name: "a:graphicData", attributes: []string{"uri", docxNamespacePicture}, children: []xmlElement{
+					{name: "pic:pic", children: []xmlElement{
+						{name: "pic:nvPicPr", children: []xmlElement{
+							newXMLElement("pic:cNvPr", "id", id, "name", path.Base(name)),
+							newXMLElement("pic:cNvPicPr"),
+						}},
+						{name: "pic:blipFill", children: []xmlElement{
+							newXMLElement("a:blip", "r:embed", part.relationship(docxRelationshipImage, name, false)),
+							{name: "a:stretch", children: []xmlElement{newXMLElement("a:fillRect")}},
+						}},
+						{name: "pic:spPr", children: []xmlElement{
+							{name: "a:xfrm", children: []xmlElement{
+								newXMLElement("a:off", "x", "0", "y", "0"),
+								newXMLElement("a:ext", "cx", cx, "cy", cy),
+							}},
+							{name: "a:prstGeom", attributes: []string{"prst", "rect"}, children: []xmlElement{newXMLElement("a:avLst")}},
+						}},
+					}},
+				}},
+			}},
+		}},
+	}}, true
+}
+
+// mediaName returns the name of the media part holding a picture, adding it
+// on first use. It fails for pictures that cannot be stored in a Word
+// document.
+func (d *docxWriter) mediaName(index int) (string, bool) {
+	if index < 0 || index >= len(d.document.Pictures) {
+		return "", false
+	}
+
+	name, ok := d.media[index]
+	if !ok {
+		data, extension, mimeType, ok := d.document.Pictures[index].fileData()
+		if !ok {
+			return "", false
+		}
+
+		name = "media/image" + strconv.Itoa(len(d.media)+1) + "." + extension
+		d.media[index] = name
+		d.parts = append(d.parts, &docxPart{name: "word/" + name, contentType: mimeType, content: data})
+	}
+
+	return name, true
+}
+
+// docxShapeAnchors are the values of the mso-position-horizontal-relative
+// and mso-position-vertical-relative properties of VML shapes.
+var docxShapeAnchors = map[ShapeAnchor]string{
+	ShapeAnchorMargin: "margin", ShapeAnchorPage: "page", ShapeAnchorColumn: "text",
+	ShapeAnchorParagraph: "text", ShapeAnchorCharacter: "char", ShapeAnchorLine: "line",
+}
+
+var docxShapeWraps = map[ShapeWrap]string{
+	ShapeWrapAround: "square", ShapeWrapTopBottom: "topAndBottom", ShapeWrapNone: "none",
+	ShapeWrapTight: "tight", ShapeWrapThrough: "through",
+}
+
+// writeShape writes a picture or a text box as a VML shape, at its position.
+// Other shapes are left out.
+func (d *docxWriter) writeShape(part *docxPart, index int) {
+	if index < 0 || index >= len(d.document.Shapes) {
+		return
+	}
+	shape := d.document.Shapes[index]
+
+	picture, hasPicture := d.mediaName(shape.PictureIndex)
+	if !hasPicture && !shape.IsTextBox() {
+		return
+	}
+
+	points := func(twips int) string {
+		return strconv.FormatFloat(float64(twips)/20, 'f', -1, 64) + "pt"
+	}
+	zIndex := shape.ZOrder + 1
+	if shape.BehindText {
+		zIndex = -zIndex
+	}
+	style := strings.Join([]string{
+		"position:absolute",
+		"margin-left:" + points(shape.Left),
+		"margin-top:" + points(shape.Top),
+		"width:" + points(shape.Width()),
+		"height:" + points(shape.Height()),
+		"z-index:" + strconv.Itoa(zIndex),
+		"mso-position-horizontal-relative:" + docxShapeAnchors[shape.HorizontalAnchor],
+		"mso-position-vertical-relative:" + docxShapeAnchors[shape.VerticalAnchor],
+	}, ";")
+
+	d.drawings++
+	attributes := []string{"id", "Shape" + strconv.Itoa(d.drawings), "style", style}
+	if hasPicture {
+		attributes = append(attributes, "stroked", "f")
+	}
+
+	x := part.xml
+	x.start("w:r")
+	x.start("w:pict")
+	x.start("v:shape", attributes...)
+	if hasPicture {
+		x.empty("v:imagedata", "r:id", part.relationship(docxRelationshipImage, picture, false))
+	} else {
+		x.start("v:textbox")
+		x.start("w:txbxContent")
+		d.writeSubdocument(part, shape.Body, "")
+		x.end()
+		x.end()
+	}
+	x.empty("w10:wrap", "type", docxShapeWraps[shape.Wrap])
+	x.end()
+	x.end()
+	x.end()
+}
+
+// writeTable writes a table. Its grid is made of the cell boundaries of
+// all of its rows, and horizontally merged cells become a single cell
+// spanning the grid columns of the cells merged, whose content is dropped.
+func (d *docxWriter) writeTable(part *docxPart, table *Table) {
+	if len(table.Rows) == 0 {
+		return
+	}
+
+	x := part.xml
+	first := table.Rows[0].Format
+	grid := docxTableGrid(table)
+
+	properties := newXMLElement("w:tblPr")
+	properties.add("w:tblW", "w:w", "0", "w:type", "auto")
+	if first.Alignment != AlignmentLeft {
+		properties.add("w:jc", "w:val", docxAlignment(first.Alignment))
+	}
+	if first.LeftIndent != 0 {
+		properties.add("w:tblInd", "w:w", strconv.Itoa(first.LeftIndent), "w:type", "dxa")
+	}
+	if len(grid) > 1 {
+		properties.add("w:tblLayout", "w:type", "fixed")
+	}
+	if first.Gap != 0 {
+		gap := strconv.Itoa(first.Gap)
+		properties.container("w:tblCellMar", []xmlElement{
+			newXMLElement("w:left", "w:w", gap, "w:type", "dxa"),
+			newXMLElement("w:right", "w:w", gap, "w:type", "dxa"),
+		})
+	}
+
+	x.start("w:tbl")
+	x.element(properties)
+
+	x.start("w:tblGrid")
+	if len(grid) > 1 {
+		for i := 1; i < len(grid); i++ {
+			x.empty("w:gridCol", "w:w", strconv.Itoa(grid[i]-grid[i-1]))
+		}
+	} else {
+		columns := 1
+		for _, row := range table.Rows {
+			columns = max(columns, len(row.Cells))
+		}
+		for i := 0; i < columns; i++ {
+			x.empty("w:gridCol")
+		}
+	}
+	x.end()
+
+	for _, row := range table.Rows {
+		d.writeRow(part, row, grid)
+	}
+
+	x.end()
+}
+
+// docxTableGrid returns the sorted positions of the cell boundaries of a
+// table, including the left edge of its rows.
+func docxTableGrid(table *Table) []int {
+	positions := map[int]bool{}
+	for _, row := range table.Rows {
+		if len(row.Format.Cells) == 0 {
+			continue
+		}
+
+		positions[row.Format.LeftIndent] = true
+		for _, cell := range row.Format.Cells {
+			positions[cell.Right] = true
+		}
+	}
+
+	grid := []int{}
+	for position := range positions {
+		grid = append(grid, position)
+	}
+	sort.Ints(grid)
+
+	return grid
+}
+
+func (d *docxWriter) writeRow(part *docxPart, row TableRow, grid []int) {
+	x := part.xml
+	x.start("w:tr")
+
+	if row.Format.Header {
+		x.element(xmlElement{name: "w:trPr", children: []xmlElement{newXMLElement("w:tblHeader")}})
+	}
+
+	cells := row.Format.Cells
+	left := row.Format.LeftIndent
+	count := max(len(row.Cells), len(cells), 1)
+
+	for i := 0; i < count; {
+		// the cell and those merged with it
+		next := i + 1
+		for next < len(cells) && cells[next].HorizontalMerge == CellMergePrevious {
+			next++
+		}
+
+		properties := newXMLElement("w:tcPr")
+		if i < len(cells) {
+			cell := cells[i]
+			right := cells[next-1].Right
+
+			if right > left {
+				properties.add("w:tcW", "w:w", strconv.Itoa(right-left), "w:type", "dxa")
+			}
+
+			span := next - i
+			if len(grid) > 1 {
+				span = 0
+				for _, position := range grid {
+					if position > left && position <= right {
+						span++
+					}
+				}
+			}
+			if span > 1 {
+				properties.add("w:gridSpan", "w:val", strconv.Itoa(span))
+			}
+
+			switch cell.VerticalMerge {
+			case CellMergeFirst:
+				properties.add("w:vMerge", "w:val", "restart")
+			case CellMergePrevious:
+				properties.add("w:vMerge")
+			}
+
+			if cell.Background != 0 {
+				properties.add("w:shd", "w:val", "clear", "w:color", "auto", "w:fill", d.color(cell.Background))
+			}
+
+			left = right
+		}
+
+		x.start("w:tc")
+		x.container(properties)
+		paragraphs := []Paragraph{{}}
+		if i < len(row.Cells) && len(row.Cells[i].Paragraphs) > 0 {
+			paragraphs = row.Cells[i].Paragraphs
+		}
+		for _, paragraph := range paragraphs {
+			d.writeParagraph(part, paragraph, nil, "")
+		}
+		x.end()
+
+		i = next
+	}
+
+	x.end()
+}
+
+// writeStyles writes the styles part: the document defaults, taken from the
+// first font, and the paragraph styles of the stylesheet, Normal being the
+// style numbered 0.
+func (d *docxWriter) writeStyles(document *docxPart) {
+	part := d.newPart("word/styles.xml", docxContentTypePrefix+"styles+xml")
+	document.relationship(docxRelationshipStyles, "styles.xml", false)
+
+	x := part.xml
+	x.start("w:styles", "xmlns:w", docxNamespaceMain)
+
+	defaults := newXMLElement("w:rPr")
+	if font, ok := d.document.Header.FontTable[0]; ok && font.Name != "" {
+		defaults.add("w:rFonts", "w:ascii", font.Name, "w:hAnsi", font.Name, "w:eastAsia", font.Name, "w:cs", font.Name)
+	}
+	defaults.add("w:sz", "w:val", "24")
+	defaults.add("w:szCs", "w:val", "24")
+	x.element(xmlElement{name: "w:docDefaults", children: []xmlElement{
+		{name: "w:rPrDefault", children: []xmlElement{defaults}},
+	}})
+
+	numbers := []int{}
+	for number := range d.styles {
+		numbers = append(numbers, number)
+	}
+	if _, ok := d.styles[0]; !ok {
+		numbers = append(numbers, 0)
+	}
+	sort.Ints(numbers)
+
+	for _, number := range numbers {
+		style := d.styles[number]
+		attributes := []string{"w:type", "paragraph"}
+		if number == 0 {
+			attributes = append(attributes, "w:default", "1")
+		}
+
+		name := style.Name
+		if name == "" {
+			name = map[bool]string{true: "Normal", false: "Style " + strconv.Itoa(number)}[number == 0]
+		}
+
+		paragraph := style.Paragraph
+		paragraph.Style = 0
+
+		element := newXMLElement("w:style", append(attributes, "w:styleId", docxStyleID(number))...)
+		element.add("w:name", "w:val", name)
+		element.container("w:pPr", d.paragraphProperties(paragraph, ParagraphFormat{}))
+		element.container("w:rPr", d.runProperties(style.Painter, Painter{}))
+		x.element(element)
+	}
+
+	x.end()
+}
+
+var docxFontFamilies = map[FontFamily]string{
+	FontFamilyRoman: "roman", FontFamilySwiss: "swiss", FontFamilyModern: "modern",
+	FontFamilyScript: "script", FontFamilyDecor: "decorative",
+}
+
+func (d *docxWriter) writeFontTable(document *docxPart) {
+	part := d.newPart("word/fontTable.xml", docxContentTypePrefix+"fontTable+xml")
+	document.relationship(docxRelationshipFontTable, "fontTable.xml", false)
+
+	x := part.xml
+	x.start("w:fonts", "xmlns:w", docxNamespaceMain)
+
+	written := map[string]bool{}
+	for _, key := range sortedTableRefs(d.document.Header.FontTable) {
+		font := d.document.Header.FontTable[key]
+		if font.Name == "" || written[font.Name] {
+			continue
+		}
+		written[font.Name] = true
+
+		element := newXMLElement("w:font", "w:name", font.Name)
+		if font.AlternateName != "" {
+			element.add("w:altName", "w:val", font.AlternateName)
+		}
+		if len(font.Panose) == 10 {
+			element.add("w:panose1", "w:val", strings.ToUpper(hex.EncodeToString(font.Panose)))
+		}
+		element.add("w:charset", "w:val", fmt.Sprintf("%02X", int(font.Charset)&0xff))
+
+		family, ok := docxFontFamilies[font.FontFamily]
+		if !ok {
+			family = "auto"
+		}
+		element.add("w:family", "w:val", family)
+
+		switch font.Pitch {
+		case FontPitchFixed:
+			element.add("w:pitch", "w:val", "fixed")
+		case FontPitchVariable:
+			element.add("w:pitch", "w:val", "variable")
+		default:
+			element.add("w:pitch", "w:val", "default")
+		}
+
+		x.element(element)
+	}
+
+	x.end()
+}
+
+var docxListFormats = map[ListFormat]string{
+	ListFormatDecimal: "decimal", ListFormatUpperRoman: "upperRoman", ListFormatLowerRoman: "lowerRoman",
+	ListFormatUpperLetter: "upperLetter", ListFormatLowerLetter: "lowerLetter", ListFormatOrdinal: "ordinal",
+	ListFormatCardinalText: "cardinalText", ListFormatOrdinalText: "ordinalText",
+	ListFormatDecimalZero: "decimalZero", ListFormatBullet: "bullet", ListFormatNone: "none",
+}
+
+// writeNumbering writes the numbering part if the document has lists. Each
+// list becomes an abstract numbering and each entry of the list table a
+// numbering of the same number, which paragraphs refer to.
+func (d *docxWriter) writeNumbering(document *docxPart) {
+	lists := d.document.Header.Lists
+	references := sortedTableRefs(lists)
+	if len(references) > 0 && references[0] == 0 {
+		references = references[1:]
+	}
+	if len(references) == 0 {
+		return
+	}
+
+	part := d.newPart("word/numbering.xml", docxContentTypePrefix+"numbering+xml")
+	document.relationship(docxRelationshipNumbering, "numbering.xml", false)
+
+	x := part.xml
+	x.start("w:numbering", "xmlns:w", docxNamespaceMain)
+
+	abstract := map[int]int{}
+	for _, reference := range references {
+		list := lists[reference]
+		if _, ok := abstract[list.ID]; ok {
+			continue
+		}
+		abstract[list.ID] = len(abstract)
+
+		x.start("w:abstractNum", "w:abstractNumId", strconv.Itoa(abstract[list.ID]))
+		for i, level := range list.Levels[:min(len(list.Levels), 9)] {
+			format, ok := docxListFormats[level.Format]
+			if !ok {
+				format = "decimal"
+			}
+
+			element := newXMLElement("w:lvl", "w:ilvl", strconv.Itoa(i))
+			element.add("w:start", "w:val", strconv.Itoa(level.Start))
+			element.add("w:numFmt", "w:val", format)
+			element.add("w:lvlText", "w:val", level.Text)
+			element.add("w:lvlJc", "w:val", "left")
+			if level.LeftIndent != 0 || level.FirstLineIndent != 0 {
+				element.children = append(element.children, xmlElement{name: "w:pPr", children: []xmlElement{
+					newXMLElement("w:ind", docxIndent(level.LeftIndent, 0, level.FirstLineIndent)...),
+				}})
+			}
+			x.element(element)
+		}
+		x.end()
+	}
+
+	for _, reference := range references {
+		x.start("w:num", "w:numId", strconv.Itoa(int(reference)))
+		x.empty("w:abstractNumId", "w:val", strconv.Itoa(abstract[lists[reference].ID]))
+		x.end()
+	}
+
+	x.end()
+}
+
+// xmlElement is an element written at once by xmlWriter. Names are written
+// as given, with their prefix, as are the names of the attributes, which
+// come in name and value pairs.
+type xmlElement struct {
+	name       string
+	attributes []string
+	children   []xmlElement
+	text       string
+}
+
+func newXMLElement(name string, attributes ...string) xmlElement {
+	return xmlElement{name: name, attributes: attributes}
+}
+
+// add appends a child element without content.
+func (e *xmlElement) add(name string, attributes ...string) {
+	e.children = append(e.children, newXMLElement(name, attributes...))
+}
+
+// container appends a child holding children, unless there are none.
+func (e *xmlElement) container(name string, children []xmlElement) {
+	if len(children) > 0 {
+		e.children = append(e.children, xmlElement{name: name, children: children})
+	}
+}
+
+// toggle appends an on/off property that differs from the one inherited.
+func (e *xmlElement) toggle(name string, value bool, inherited bool) {
+	switch {
+	case value && !inherited:
+		e.add(name)
+	case !value && inherited:
+		e.add(name, "w:val", "0")
+	}
+}
+
+// xmlWriter writes an XML document, keeping the first error that occurs.
+type xmlWriter struct {
+	buffer  bytes.Buffer
+	encoder *xml.Encoder
+	open    []string
+	err     error
+}
+
+func newXMLWriter() *xmlWriter {
+	x := newXMLFragmentWriter()
+	x.buffer.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+
+	return x
+}
+
+// newXMLFragmentWriter returns a writer of XML without a declaration, to be
+// included in a document with raw.
+func newXMLFragmentWriter() *xmlWriter {
+	x := &xmlWriter{}
+	x.encoder = xml.NewEncoder(&x.buffer)
+
+	return x
+}
+
+func (x *xmlWriter) token(t xml.Token) {
+	if x.err == nil {
+		x.err = x.encoder.EncodeToken(t)
+	}
+}
+
+func (x *xmlWriter) start(name string, attributes ...string) {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	for i := 0; i+1 < len(attributes); i += 2 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attributes[i]}, Value: attributes[i+1]})
+	}
+
+	x.token(start)
+	x.open = append(x.open, name)
+}
+
+// end closes the element opened last.
+func (x *xmlWriter) end() {
+	name := x.open[len(x.open)-1]
+	x.open = x.open[:len(x.open)-1]
+	x.token(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+// empty writes an element without content.
+func (x *xmlWriter) empty(name string, attributes ...string) {
+	x.start(name, attributes...)
+	x.end()
+}
+
+// textElement writes an element holding text.
+func (x *xmlWriter) textElement(name string, text string, attributes ...string) {
+	x.start(name, attributes...)
+	x.token(xml.CharData(text))
+	x.end()
+}
+
+func (x *xmlWriter) element(e xmlElement) {
+	x.start(e.name, e.attributes...)
+	if e.text != "" {
+		x.token(xml.CharData(e.text))
+	}
+	for _, child := range e.children {
+		x.element(child)
+	}
+	x.end()
+}
+
+// container writes an element holding children, unless there are none.
+func (x *xmlWriter) container(e xmlElement) {
+	if len(e.children) > 0 {
+		x.element(e)
+	}
+}
+
+// raw writes XML written by another writer.
+func (x *xmlWriter) raw(fragment *xmlWriter) {
+	data, err := fragment.bytes()
+	if x.err == nil {
+		x.err = err
+	}
+	if x.err == nil {
+		x.err = x.encoder.Flush()
+	}
+	x.buffer.Write(data)
+}
+
+func (x *xmlWriter) bytes() ([]byte, error) {
+	if x.err == nil {
+		x.err = x.encoder.Flush()
+	}
+
+	return x.buffer.Bytes(), x.err
+}
@@ -0,0 +1,166 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDocumentToDOCX(t *testing.T) {
+	content := `{\rtf1\ansi\ansicpg1252{\fonttbl{\f0\froman\fprq2\fcharset0 Times New Roman;}{\f1\fswiss\fcharset0 Arial;}}` +
+		`{\colortbl;\red255\green0\blue0;\red0\green0\blue255;}` +
+		`{\stylesheet{\s0 Normal;}{\s1\sb240\b\fs32 heading 1;}}` +
+		`{\*\listtable{\list{\listlevel\levelnfc0\levelstartat1{\leveltext\'02\'00.;}\fi-360\li720}\listid7}}` +
+		`{\*\listoverridetable{\listoverride\listid7\listoverridecount0\ls1}}` +
+		`{\info{\title Tom & Jerry}{\author Ann}{\creatim\yr2020\mo1\dy2\hr3\min4}}` +
+		`\sectd\lndscpsxn{\header\pard Head\par}{\footerf\pard First\par}` +
+		`\pard\s1\sb240\b\fs32 Title\par` +
+		`\pard\plain\ls1 One\par` +
+		`\pard Some {\b bold} {\f1\cf1 red} {\field{\*\fldinst HYPERLINK "https://example.com/?a=1&b=2"}{\fldrslt link}}` +
+		`{\footnote\pard Note\par}\tab x\line y\par` +
+		`\trowd\trgaph108\trleft-108\clmgf\cellx2000\clmrg\cellx4000\clcbpat2\cellx6000` +
+		`\pard\intbl a\cell\cell b\cell\row` +
+		`\pard{\pict\pngblip\picw10\pich10 89504e470d0a1a0a}\par` +
+		`\sect\sectd\pard Last\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := doc.ToDOCX()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[file.Name] = string(content)
+	}
+
+	names := []string{}
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	expectedNames := []string{
+		"[Content_Types].xml",
+		"_rels/.rels",
+		"docProps/app.xml",
+		"docProps/core.xml",
+		"word/_rels/document.xml.rels",
+		"word/document.xml",
+		"word/fontTable.xml",
+		"word/footer1.xml",
+		"word/footnotes.xml",
+		"word/header1.xml",
+		"word/media/image1.png",
+		"word/numbering.xml",
+		"word/settings.xml",
+		"word/styles.xml",
+	}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedNames, names)
+	}
+
+	for name, content := range parts {
+		if !strings.HasSuffix(name, ".xml") && !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+
+		decoder := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s is not well-formed: %v", name, err)
+				break
+			}
+		}
+	}
+
+	for name, expected := range map[string][]string{
+		"[Content_Types].xml": {
+			`<Default Extension="png" ContentType="image/png">`,
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml">`,
+		},
+		"_rels/.rels": {
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"`,
+		},
+		"word/_rels/document.xml.rels": {
+			`Target="https://example.com/?a=1&amp;b=2" TargetMode="External"`,
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image1.png"`,
+		},
+		"word/document.xml": {
+			`<w:pPr><w:pStyle w:val="Style1"></w:pStyle></w:pPr><w:r><w:t xml:space="preserve">Title</w:t></w:r>`,
+			`<w:numPr><w:ilvl w:val="0"></w:ilvl><w:numId w:val="1"></w:numId></w:numPr>`,
+			`<w:r><w:rPr><w:b></w:b></w:rPr><w:t xml:space="preserve">bold</w:t></w:r>`,
+			`<w:rPr><w:rFonts w:ascii="Arial" w:hAnsi="Arial" w:eastAsia="Arial" w:cs="Arial"></w:rFonts><w:color w:val="FF0000"></w:color></w:rPr>`,
+			`<w:hyperlink r:id="rId8"><w:r><w:t xml:space="preserve">link</w:t></w:r></w:hyperlink>`,
+			`<w:rPr><w:vertAlign w:val="superscript"></w:vertAlign></w:rPr><w:footnoteReference w:id="1"></w:footnoteReference>`,
+			`<w:r><w:tab></w:tab><w:t xml:space="preserve">x</w:t></w:r><w:r><w:br></w:br></w:r>`,
+			`<w:gridCol w:w="2108"></w:gridCol><w:gridCol w:w="2000"></w:gridCol><w:gridCol w:w="2000"></w:gridCol>`,
+			`<w:tcPr><w:tcW w:w="4108" w:type="dxa"></w:tcW><w:gridSpan w:val="2"></w:gridSpan></w:tcPr>`,
+			`<w:shd w:val="clear" w:color="auto" w:fill="0000FF"></w:shd>`,
+			`<wp:extent cx="95250" cy="95250"></wp:extent>`,
+			`<a:blip r:embed="rId9"></a:blip>`,
+			`<w:headerReference w:type="default" r:id="rId5"></w:headerReference><w:footerReference w:type="first" r:id="rId6"></w:footerReference>` +
+				`<w:pgSz w:w="15840" w:h="12240" w:orient="landscape"></w:pgSz>`,
+			`<w:titlePg></w:titlePg></w:sectPr></w:pPr>`,
+			`<w:sectPr><w:pgSz w:w="12240" w:h="15840"></w:pgSz>`,
+		},
+		"word/styles.xml": {
+			`<w:rPrDefault><w:rPr><w:rFonts w:ascii="Times New Roman"`,
+			`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"></w:name></w:style>`,
+			`<w:style w:type="paragraph" w:styleId="Style1"><w:name w:val="heading 1"></w:name>` +
+				`<w:pPr><w:spacing w:before="240" w:after="0" w:line="240" w:lineRule="auto"></w:spacing></w:pPr>` +
+				`<w:rPr><w:b></w:b><w:sz w:val="32"></w:sz><w:szCs w:val="32"></w:szCs></w:rPr></w:style>`,
+		},
+		"word/numbering.xml": {
+			`<w:lvl w:ilvl="0"><w:start w:val="1"></w:start><w:numFmt w:val="decimal"></w:numFmt><w:lvlText w:val="%1."></w:lvlText>`,
+			`<w:ind w:left="720" w:right="0" w:hanging="360"></w:ind>`,
+			`<w:num w:numId="1"><w:abstractNumId w:val="0"></w:abstractNumId></w:num>`,
+		},
+		"word/footnotes.xml": {
+			`<w:footnote w:type="separator" w:id="-1">`,
+			`<w:footnote w:id="1"><w:p><w:r><w:rPr><w:vertAlign w:val="superscript"></w:vertAlign></w:rPr><w:footnoteRef></w:footnoteRef></w:r>` +
+				`<w:r><w:t xml:space="preserve">Note</w:t></w:r></w:p></w:footnote>`,
+		},
+		"word/header1.xml": {
+			`<w:p><w:r><w:t xml:space="preserve">Head</w:t></w:r></w:p></w:hdr>`,
+		},
+		"word/fontTable.xml": {
+			`<w:font w:name="Arial"><w:charset w:val="00"></w:charset><w:family w:val="swiss"></w:family><w:pitch w:val="default"></w:pitch></w:font>`,
+		},
+		"docProps/core.xml": {
+			`<dc:title>Tom &amp; Jerry</dc:title><dc:creator>Ann</dc:creator>`,
+			`<dcterms:created xsi:type="dcterms:W3CDTF">2020-01-02T03:04:00Z</dcterms:created>`,
+		},
+	} {
+		for _, e := range expected {
+			if !strings.Contains(parts[name], e) {
+				t.Errorf("\n\nexpected in %s: %v\n\nactual\t: %v", name, e, parts[name])
+			}
+		}
+	}
+}
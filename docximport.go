@@ -0,0 +1,1450 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDOCXFile reads a Word document file. See ParseDOCX.
+func ParseDOCXFile(filePath string) (RtfDocument, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return RtfDocument{}, err
+	}
+
+	return ParseDOCX(data)
+}
+
+// ParseDOCX reads a Word document in the Office Open XML format into the
+// document model, from which it can be written as RTF or converted like a
+// parsed RTF document.
+//
+// The effective formatting of the paragraphs and runs, including what they
+// inherit from their styles, is set on every paragraph and run as RTF
+// documents have it. Paragraph styles become the stylesheet, numbering
+// definitions the list table, and the document properties the information
+// group. Deleted text is left out, as are images in formats RTF cannot
+// hold, and the paragraphs of nested tables are added to the cell holding
+// them. As for parsed RTF documents, the format of the last section is not
+// kept, though its headers and footers are.
+func ParseDOCX(data []byte) (RtfDocument, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return RtfDocument{}, err
+	}
+
+	d := &docxReader{
+		files:      map[string]*zip.File{},
+		styles:     map[string]*docxStyle{},
+		fonts:      map[string]Font{},
+		notes:      map[bool]map[string]*docxNode{},
+		notesParts: map[bool]string{},
+	}
+	for _, file := range archive.File {
+		d.files[strings.TrimPrefix(file.Name, "/")] = file
+	}
+
+	return d.read()
+}
+
+// docxNode is an element of a part of a Word document, read without
+// interpreting it.
+type docxNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []docxNode `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// child returns the first child with the given local name, nil if there is
+// none or n is nil.
+func (n *docxNode) child(name string) *docxNode {
+	if n == nil {
+		return nil
+	}
+
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			return &n.Children[i]
+		}
+	}
+
+	return nil
+}
+
+// descendant returns the first element with the given local name within n,
+// in document order.
+func (n *docxNode) descendant(name string) *docxNode {
+	if n == nil {
+		return nil
+	}
+
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			return &n.Children[i]
+		}
+		if found := n.Children[i].descendant(name); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// attr returns the value of the first attribute with the given local name.
+func (n *docxNode) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+func (n *docxNode) value() string {
+	return n.attr("val")
+}
+
+// intAttr returns the value of an integer attribute. Decimals are
+// truncated.
+func (n *docxNode) intAttr(name string) (int, bool) {
+	value := n.attr(name)
+	if value == "" {
+		return 0, false
+	}
+
+	if integer, err := strconv.Atoi(value); err == nil {
+		return integer, true
+	}
+	if float, err := strconv.ParseFloat(value, 64); err == nil {
+		return int(float), true
+	}
+
+	return 0, false
+}
+
+// toggle returns the value of an on/off property, which is on unless its
+// value says otherwise.
+func (n *docxNode) toggle() bool {
+	switch n.value() {
+	case "0", "false", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// docxTarget is the target of a relationship, resolved to the name of a
+// part unless it is external.
+type docxTarget struct {
+	kind     string
+	target   string
+	external bool
+}
+
+// docxStyle is a style of the styles part.
+type docxStyle struct {
+	node   *docxNode
+	number int
+}
+
+// docxField is a complex field in progress, made of the runs between its
+// begin and end characters.
+type docxField struct {
+	instruction strings.Builder
+	separated   bool
+	link        string
+	field       string
+}
+
+// docxReader builds a document from the parts of a Word document.
+type docxReader struct {
+	files map[string]*zip.File
+	doc   RtfDocument
+
+	// relationships of the part being read, and of the main document
+	relationships map[string]docxTarget
+	main          map[string]docxTarget
+
+	// styles by identifier, and the default paragraph style
+	styles       map[string]*docxStyle
+	defaultStyle string
+
+	// formatting of the document defaults
+	defaultPainter   Painter
+	defaultParagraph ParagraphFormat
+
+	// fonts of the font table part by name, and the theme fonts by the
+	// names the runs refer to them by, such as "minorHAnsi"
+	fonts      map[string]Font
+	themeFonts map[string]string
+
+	// footnotes and endnotes by identifier, and the parts holding them, the
+	// keys telling endnotes
+	notes      map[bool]map[string]*docxNode
+	notesParts map[bool]string
+
+	fields []*docxField
+}
+
+func (d *docxReader) read() (RtfDocument, error) {
+	documentPart := "word/document.xml"
+	pkg := d.readRelationships("")
+	if target, ok := findRelationship(pkg, docxRelationshipDocument); ok {
+		documentPart = target
+	}
+
+	document, err := d.readXML(documentPart)
+	if err != nil {
+		return RtfDocument{}, err
+	}
+	if document == nil {
+		return RtfDocument{}, errors.New("docx: the package has no main document")
+	}
+
+	d.main = d.readRelationships(documentPart)
+	d.relationships = d.main
+
+	d.doc.Header = RtfHeader{
+		Charset:    CharacterSetAnsi,
+		FontTable:  FontTable{},
+		ColorTable: ColorTable{0: {Auto: true}},
+	}
+
+	d.readFontTable()
+	d.readTheme()
+	d.readStyles()
+	d.readNumbering()
+	d.readNotes(docxRelationshipFootnotes, false)
+	d.readNotes(docxRelationshipEndnotes, true)
+	d.readProperties(pkg)
+
+	d.doc.SetSections(d.readBody(document.child("body")))
+
+	return d.doc, nil
+}
+
+// readXML reads a part of the package, returning nil if there is no such
+// part.
+func (d *docxReader) readXML(name string) (*docxNode, error) {
+	file, ok := d.files[name]
+	if !ok {
+		return nil, nil
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	node := &docxNode{}
+	if err := xml.NewDecoder(reader).Decode(node); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// readOptionalXML reads a part that can be done without, treating a part
+// that cannot be read as missing.
+func (d *docxReader) readOptionalXML(name string) *docxNode {
+	node, err := d.readXML(name)
+	if err != nil {
+		return nil
+	}
+
+	return node
+}
+
+// readRelationships reads the relationships of a part, or of the package
+// itself for the empty name.
+func (d *docxReader) readRelationships(part string) map[string]docxTarget {
+	relationships := map[string]docxTarget{}
+
+	directory, name := path.Split(part)
+	node := d.readOptionalXML(directory + "_rels/" + name + ".rels")
+	if node == nil {
+		return relationships
+	}
+
+	for _, relationship := range node.Children {
+		target := docxTarget{
+			kind:     relationship.attr("Type"),
+			target:   relationship.attr("Target"),
+			external: relationship.attr("TargetMode") == "External",
+		}
+
+		if !target.external {
+			if strings.HasPrefix(target.target, "/") {
+				target.target = strings.TrimPrefix(target.target, "/")
+			} else {
+				target.target = path.Join(directory, target.target)
+			}
+		}
+
+		relationships[relationship.attr("Id")] = target
+	}
+
+	return relationships
+}
+
+// findRelationship returns the target of the first relationship of a kind,
+// in the order of the identifiers.
+func findRelationship(relationships map[string]docxTarget, kind string) (string, bool) {
+	ids := []string{}
+	for id, relationship := range relationships {
+		if relationship.kind == kind {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", false
+	}
+	sort.Strings(ids)
+
+	return relationships[ids[0]].target, true
+}
+
+var docxFontFamiliesByName = map[string]FontFamily{
+	"roman": FontFamilyRoman, "swiss": FontFamilySwiss, "modern": FontFamilyModern,
+	"script": FontFamilyScript, "decorative": FontFamilyDecor,
+}
+
+// readFontTable reads the descriptions of the fonts, which are added to the
+// font table as runs use them.
+func (d *docxReader) readFontTable() {
+	target, ok := findRelationship(d.main, docxRelationshipFontTable)
+	if !ok {
+		return
+	}
+
+	table := d.readOptionalXML(target)
+	if table == nil {
+		return
+	}
+
+	for _, node := range table.Children {
+		name := node.attr("name")
+		if node.XMLName.Local != "font" || name == "" {
+			continue
+		}
+
+		font := Font{Name: name, AlternateName: node.child("altName").value()}
+		if charset, err := strconv.ParseUint(node.child("charset").value(), 16, 8); err == nil {
+			font.Charset = FontCharset(charset)
+		}
+		font.FontFamily = docxFontFamiliesByName[node.child("family").value()]
+		switch node.child("pitch").value() {
+		case "fixed":
+			font.Pitch = FontPitchFixed
+		case "variable":
+			font.Pitch = FontPitchVariable
+		}
+		if panose, err := hexDecode(node.child("panose1").value()); err == nil && len(panose) == 10 {
+			font.Panose = panose
+		}
+
+		d.fonts[name] = font
+	}
+}
+
+func hexDecode(text string) ([]byte, error) {
+	data := make([]byte, len(text)/2)
+	for i := range data {
+		value, err := strconv.ParseUint(text[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = byte(value)
+	}
+
+	return data, nil
+}
+
+// readTheme reads the Latin fonts of the theme, which runs can refer to
+// instead of naming a font.
+func (d *docxReader) readTheme() {
+	d.themeFonts = map[string]string{}
+
+	target, ok := findRelationship(d.main, docxRelationshipTheme)
+	if !ok {
+		return
+	}
+
+	theme := d.readOptionalXML(target)
+	scheme := theme.descendant("fontScheme")
+	for _, font := range []struct {
+		element string
+		prefix  string
+	}{
+		{"majorFont", "major"},
+		{"minorFont", "minor"},
+	} {
+		name := scheme.child(font.element).child("latin").attr("typeface")
+		if name == "" {
+			continue
+		}
+		for _, suffix := range []string{"Ascii", "HAnsi", "EastAsia", "Bidi"} {
+			d.themeFonts[font.prefix+suffix] = name
+		}
+	}
+}
+
+// fontRef returns the font table entry of a font, adding it if needed.
+func (d *docxReader) fontRef(name string) TableRef {
+	font, ok := d.fonts[name]
+	if !ok {
+		font = Font{Name: name}
+	}
+
+	return d.doc.Header.FontTable.ref(font)
+}
+
+// fontName returns the name of the font of a <w:rFonts> element.
+func (d *docxReader) fontName(fonts *docxNode) string {
+	for _, attribute := range []string{"ascii", "hAnsi", "eastAsia", "cs"} {
+		if name := fonts.attr(attribute); name != "" {
+			return name
+		}
+		if name := d.themeFonts[fonts.attr(attribute+"Theme")]; name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// colorRef returns the color table entry of a color given in hexadecimal,
+// adding it if needed. The auto color and invalid colors are entry 0.
+func (d *docxReader) colorRef(value string) TableRef {
+	components, err := hexDecode(value)
+	if err != nil || len(components) != 3 {
+		return 0
+	}
+
+	return d.doc.Header.ColorTable.ref(Color{R: int(components[0]), G: int(components[1]), B: int(components[2])})
+}
+
+// docxHighlightColors are the colors of the highlight names.
+var docxHighlightColors = map[string]string{
+	"black": "000000", "blue": "0000FF", "cyan": "00FFFF", "green": "00FF00",
+	"magenta": "FF00FF", "red": "FF0000", "yellow": "FFFF00", "white": "FFFFFF",
+	"darkBlue": "000080", "darkCyan": "008080", "darkGreen": "008000", "darkMagenta": "800080",
+	"darkRed": "800000", "darkYellow": "808000", "darkGray": "808080", "lightGray": "C0C0C0",
+}
+
+// readStyles reads the document defaults and the styles. The default font,
+// or Times New Roman without one, is the first of the font table. Paragraph
+// styles make up the stylesheet, the default one being numbered 0.
+func (d *docxReader) readStyles() {
+	var styles *docxNode
+	if target, ok := findRelationship(d.main, docxRelationshipStyles); ok {
+		styles = d.readOptionalXML(target)
+	}
+
+	defaults := styles.child("docDefaults")
+	defaultRun := defaults.child("rPrDefault").child("rPr")
+
+	name := d.fontName(defaultRun.child("rFonts"))
+	if name == "" {
+		name = "Times New Roman"
+	}
+	d.fontRef(name)
+
+	d.applyRunProperties(&d.defaultPainter, defaultRun)
+	d.applyParagraphProperties(&d.defaultParagraph, defaults.child("pPrDefault").child("pPr"))
+
+	if styles == nil {
+		return
+	}
+
+	paragraphStyles := []string{}
+	for i := range styles.Children {
+		node := &styles.Children[i]
+		id := node.attr("styleId")
+		if node.XMLName.Local != "style" || id == "" {
+			continue
+		}
+
+		d.styles[id] = &docxStyle{node: node}
+		if node.attr("type") != "paragraph" {
+			continue
+		}
+
+		paragraphStyles = append(paragraphStyles, id)
+		if d.defaultStyle == "" && (node.attr("default") == "1" || node.attr("default") == "true") {
+			d.defaultStyle = id
+		}
+	}
+
+	d.doc.Header.Stylesheet = Stylesheet{}
+	number := 1
+	for _, id := range paragraphStyles {
+		style := d.styles[id]
+		if id != d.defaultStyle {
+			style.number = number
+			number++
+		}
+
+		name := style.node.child("name").value()
+		if name == "" {
+			name = id
+		}
+
+		painter, paragraph := d.paragraphStyle(id)
+		d.doc.Header.Stylesheet[name] = Style{
+			Name:      name,
+			Number:    style.number,
+			Painter:   painter,
+			Paragraph: paragraph,
+		}
+	}
+}
+
+// paragraphStyle returns the formatting of a paragraph style, the default
+// style standing for missing ones.
+func (d *docxReader) paragraphStyle(id string) (Painter, ParagraphFormat) {
+	style, ok := d.styles[id]
+	if !ok || style.node.attr("type") != "paragraph" {
+		id = d.defaultStyle
+		style = d.styles[id]
+	}
+
+	painter, paragraph := d.defaultPainter, d.defaultParagraph
+	d.applyStyle(&painter, &paragraph, id, 0)
+	if style != nil {
+		paragraph.Style = style.number
+	}
+
+	return painter, paragraph
+}
+
+// applyStyle applies the formatting of a style to a painter and, unless it
+// is nil, a paragraph format, after that of the style it is based on.
+func (d *docxReader) applyStyle(painter *Painter, paragraph *ParagraphFormat, id string, depth int) {
+	style, ok := d.styles[id]
+	// the depth guards against styles based on each other
+	if !ok || depth > 16 {
+		return
+	}
+
+	if basedOn := style.node.child("basedOn").value(); basedOn != "" {
+		d.applyStyle(painter, paragraph, basedOn, depth+1)
+	}
+
+	d.applyRunProperties(painter, style.node.child("rPr"))
+	if paragraph != nil {
+		d.applyParagraphProperties(paragraph, style.node.child("pPr"))
+	}
+}
+
+// applyRunProperties updates a painter with the properties of a <w:rPr>
+// element.
+func (d *docxReader) applyRunProperties(painter *Painter, properties *docxNode) {
+	if properties == nil {
+		return
+	}
+
+	for i := range properties.Children {
+		property := &properties.Children[i]
+
+		switch property.XMLName.Local {
+		case "rFonts":
+			if name := d.fontName(property); name != "" {
+				painter.FontRef = d.fontRef(name)
+			}
+		case "b":
+			painter.Bold = property.toggle()
+		case "i":
+			painter.Italic = property.toggle()
+		case "u":
+			painter.Underline = property.value() != "none"
+		case "strike", "dstrike":
+			painter.Strikethrough = property.toggle()
+		case "smallCaps":
+			painter.SmallCaps = property.toggle()
+		case "vanish":
+			painter.Hidden = property.toggle()
+		case "vertAlign":
+			painter.Superscript = property.value() == "superscript"
+			painter.Subscript = property.value() == "subscript"
+		case "color":
+			painter.ForegroundColor = d.colorRef(property.value())
+		case "sz":
+			if size, ok := property.intAttr("val"); ok {
+				painter.FontSize = size
+			}
+		case "highlight":
+			painter.Highlight = d.colorRef(docxHighlightColors[property.value()])
+		case "shd":
+			painter.BackgroundColor = d.colorRef(property.attr("fill"))
+		}
+	}
+}
+
+// applyParagraphProperties updates a paragraph format with the properties
+// of a <w:pPr> element, except for its style.
+func (d *docxReader) applyParagraphProperties(paragraph *ParagraphFormat, properties *docxNode) {
+	if properties == nil {
+		return
+	}
+
+	for i := range properties.Children {
+		property := &properties.Children[i]
+
+		switch property.XMLName.Local {
+		case "keepNext":
+			paragraph.KeepWithNext = property.toggle()
+		case "keepLines":
+			paragraph.KeepTogether = property.toggle()
+		case "pageBreakBefore":
+			paragraph.PageBreakBefore = property.toggle()
+		case "numPr":
+			if id := property.child("numId").value(); id != "" {
+				number, _ := strconv.Atoi(id)
+				paragraph.List = TableRef(max(number, 0))
+			}
+			if level, ok := property.child("ilvl").intAttr("val"); ok {
+				paragraph.ListLevel = level
+			}
+		case "spacing":
+			if before, ok := property.intAttr("before"); ok {
+				paragraph.SpaceBefore = before
+			}
+			if after, ok := property.intAttr("after"); ok {
+				paragraph.SpaceAfter = after
+			}
+			if line, ok := property.intAttr("line"); ok {
+				switch property.attr("lineRule") {
+				case "exact":
+					paragraph.LineSpacing, paragraph.LineSpacingMultiple = -line, false
+				case "atLeast":
+					paragraph.LineSpacing, paragraph.LineSpacingMultiple = line, false
+				case "", "auto":
+					// single spacing is the default of RTF paragraphs
+					if line == 240 {
+						line = 0
+					}
+					paragraph.LineSpacing, paragraph.LineSpacingMultiple = line, line != 0
+				}
+			}
+		case "ind":
+			for _, name := range []string{"left", "start"} {
+				if left, ok := property.intAttr(name); ok {
+					paragraph.LeftIndent = left
+				}
+			}
+			for _, name := range []string{"right", "end"} {
+				if right, ok := property.intAttr(name); ok {
+					paragraph.RightIndent = right
+				}
+			}
+			if firstLine, ok := property.intAttr("firstLine"); ok {
+				paragraph.FirstLineIndent = firstLine
+			}
+			if hanging, ok := property.intAttr("hanging"); ok {
+				paragraph.FirstLineIndent = -hanging
+			}
+		case "jc":
+			paragraph.Alignment = docxAlignmentFromValue(property.value())
+		}
+	}
+}
+
+func docxAlignmentFromValue(value string) Alignment {
+	switch value {
+	case "center":
+		return AlignmentCenter
+	case "right", "end":
+		return AlignmentRight
+	case "both":
+		return AlignmentJustify
+	case "distribute":
+		return AlignmentDistribute
+	default:
+		return AlignmentLeft
+	}
+}
+
+// readNumbering reads the numbering definitions into the list table, each
+// numbering being the entry of the same number.
+func (d *docxReader) readNumbering() {
+	target, ok := findRelationship(d.main, docxRelationshipNumbering)
+	if !ok {
+		return
+	}
+
+	numbering := d.readOptionalXML(target)
+	if numbering == nil {
+		return
+	}
+
+	lists := map[string]List{}
+	for _, node := range numbering.Children {
+		if node.XMLName.Local != "abstractNum" {
+			continue
+		}
+
+		id, _ := node.intAttr("abstractNumId")
+		list := List{ID: id + 1, Levels: []ListLevel{}}
+		for i := range node.Children {
+			level := &node.Children[i]
+			if level.XMLName.Local == "lvl" {
+				list.Levels = append(list.Levels, d.readListLevel(level))
+			}
+		}
+
+		lists[node.attr("abstractNumId")] = list
+	}
+
+	table := ListTable{}
+	for _, node := range numbering.Children {
+		if node.XMLName.Local != "num" {
+			continue
+		}
+
+		number, ok := node.intAttr("numId")
+		list, found := lists[node.child("abstractNumId").value()]
+		if ok && found && number > 0 {
+			table[TableRef(number)] = list
+		}
+	}
+
+	d.doc.Header.Lists = table
+}
+
+func (d *docxReader) readListLevel(node *docxNode) ListLevel {
+	level := ListLevel{Start: 1, Text: node.child("lvlText").value()}
+
+	if start, ok := node.child("start").intAttr("val"); ok {
+		level.Start = start
+	}
+
+	format := node.child("numFmt").value()
+	for listFormat, name := range docxListFormats {
+		if name == format {
+			level.Format = listFormat
+		}
+	}
+
+	paragraph := ParagraphFormat{}
+	d.applyParagraphProperties(&paragraph, node.child("pPr"))
+	level.LeftIndent, level.FirstLineIndent = paragraph.LeftIndent, paragraph.FirstLineIndent
+
+	return level
+}
+
+// readNotes indexes the footnotes or endnotes, which are added to the
+// document as they are referred to.
+func (d *docxReader) readNotes(kind string, endnotes bool) {
+	d.notes[endnotes] = map[string]*docxNode{}
+
+	target, ok := findRelationship(d.main, kind)
+	if !ok {
+		return
+	}
+
+	notes := d.readOptionalXML(target)
+	if notes == nil {
+		return
+	}
+
+	for i := range notes.Children {
+		note := &notes.Children[i]
+		if note.attr("type") == "" || note.attr("type") == "normal" {
+			d.notes[endnotes][note.attr("id")] = note
+		}
+	}
+	d.notesParts[endnotes] = target
+}
+
+// readNote adds a footnote or endnote to the document, returning its index.
+func (d *docxReader) readNote(id string, endnote bool) (int, bool) {
+	note, ok := d.notes[endnote][id]
+	if !ok {
+		return 0, false
+	}
+
+	body := d.readSubdocument(d.notesParts[endnote], note.Children)
+	d.doc.Footnotes = append(d.doc.Footnotes, Footnote{Endnote: endnote, Body: body})
+
+	return len(d.doc.Footnotes) - 1, true
+}
+
+// readSubdocument reads the content of a note, header or footer from the
+// given part.
+func (d *docxReader) readSubdocument(part string, nodes []docxNode) []StyleBlock {
+	relationships := d.relationships
+	d.relationships = d.readRelationships(part)
+	fields := d.fields
+	d.fields = nil
+
+	elements := []BodyElement{}
+	for _, node := range docxBlocks(nodes) {
+		switch node.XMLName.Local {
+		case "p":
+			paragraph := d.readParagraph(node, false)
+			elements = append(elements, &paragraph)
+		case "tbl":
+			elements = append(elements, d.readTable(node))
+		}
+	}
+
+	d.relationships = relationships
+	d.fields = fields
+
+	return bodyFromSections([]Section{{Elements: elements}})
+}
+
+// docxBlocks returns the paragraphs, tables and section properties of
+// block-level content, looking into the elements that wrap them.
+func docxBlocks(nodes []docxNode) []*docxNode {
+	blocks := []*docxNode{}
+
+	for i := range nodes {
+		node := &nodes[i]
+		switch node.XMLName.Local {
+		case "p", "tbl", "sectPr":
+			blocks = append(blocks, node)
+		case "sdt", "sdtContent", "customXml", "ins":
+			blocks = append(blocks, docxBlocks(node.Children)...)
+		}
+	}
+
+	return blocks
+}
+
+// readBody reads the body of the main document. Sections other than the
+// last end with the paragraph carrying their properties.
+func (d *docxReader) readBody(body *docxNode) []Section {
+	sections := []Section{}
+	current := Section{}
+
+	if body == nil {
+		return []Section{current}
+	}
+
+	for _, node := range docxBlocks(body.Children) {
+		switch node.XMLName.Local {
+		case "p":
+			paragraph := d.readParagraph(node, false)
+			current.Elements = append(current.Elements, &paragraph)
+
+			if properties := node.child("pPr").child("sectPr"); properties != nil {
+				current.Format = d.readSection(properties, len(sections))
+				current.Mark = paragraph.Mark
+				sections = append(sections, current)
+				current = Section{}
+			}
+		case "tbl":
+			current.Elements = append(current.Elements, d.readTable(node))
+		case "sectPr":
+			current.Format = d.readSection(node, len(sections))
+		}
+	}
+
+	return append(sections, current)
+}
+
+// docxHeaderFooterKinds are the kinds of headers and footers by reference
+// type.
+var docxHeaderFooterKinds = map[string][2]HeaderFooterKind{
+	"default": {HeaderFooterKindHeader, HeaderFooterKindFooter},
+	"even":    {HeaderFooterKindHeaderLeft, HeaderFooterKindFooterLeft},
+	"first":   {HeaderFooterKindHeaderFirst, HeaderFooterKindFooterFirst},
+}
+
+// readSection reads the properties of a section and its headers and
+// footers.
+func (d *docxReader) readSection(properties *docxNode, index int) SectionFormat {
+	format := SectionFormat{}
+
+	switch properties.child("type").value() {
+	case "continuous":
+		format.Break = SectionBreakNone
+	case "nextColumn":
+		format.Break = SectionBreakColumn
+	case "evenPage":
+		format.Break = SectionBreakEven
+	case "oddPage":
+		format.Break = SectionBreakOdd
+	}
+
+	size := properties.child("pgSz")
+	format.PageWidth, _ = size.intAttr("w")
+	format.PageHeight, _ = size.intAttr("h")
+	format.Landscape = size.attr("orient") == "landscape"
+
+	margins := properties.child("pgMar")
+	format.MarginLeft, _ = margins.intAttr("left")
+	format.MarginRight, _ = margins.intAttr("right")
+	format.MarginTop, _ = margins.intAttr("top")
+	format.MarginBottom, _ = margins.intAttr("bottom")
+
+	if columns, ok := properties.child("cols").intAttr("num"); ok && columns > 1 {
+		format.Columns = columns
+	}
+
+	for _, reference := range properties.Children {
+		footer := reference.XMLName.Local == "footerReference"
+		if !footer && reference.XMLName.Local != "headerReference" {
+			continue
+		}
+
+		kinds, ok := docxHeaderFooterKinds[reference.attr("type")]
+		target, found := d.relationships[reference.attr("id")]
+		if !ok || !found {
+			continue
+		}
+
+		part := d.readOptionalXML(target.target)
+		if part == nil {
+			continue
+		}
+
+		kind := kinds[0]
+		if footer {
+			kind = kinds[1]
+		}
+		d.doc.HeadersFooters = append(d.doc.HeadersFooters, HeaderFooter{
+			Kind:    kind,
+			Section: index,
+			Body:    d.readSubdocument(target.target, part.Children),
+		})
+	}
+
+	return format
+}
+
+// readParagraph reads a paragraph with the formatting of its style and its
+// own.
+func (d *docxReader) readParagraph(node *docxNode, inTable bool) Paragraph {
+	properties := node.child("pPr")
+
+	painter, format := d.paragraphStyle(properties.child("pStyle").value())
+	d.applyParagraphProperties(&format, properties)
+	format.InTable = inTable
+
+	mark := painter
+	d.applyRunProperties(&mark, properties.child("rPr"))
+
+	paragraph := Paragraph{Format: format, Mark: &mark}
+	d.readRuns(&paragraph, node.Children, painter, "")
+
+	return paragraph
+}
+
+// readRuns reads the runs of a paragraph, and those of the elements that
+// wrap them such as hyperlinks.
+func (d *docxReader) readRuns(paragraph *Paragraph, nodes []docxNode, painter Painter, link string) {
+	for i := range nodes {
+		node := &nodes[i]
+
+		switch node.XMLName.Local {
+		case "r":
+			d.readRun(paragraph, node, painter, link)
+		case "hyperlink":
+			target := ""
+			if relationship, ok := d.relationships[node.attr("id")]; ok {
+				target = relationship.target
+			}
+			if anchor := node.attr("anchor"); anchor != "" {
+				target += "#" + anchor
+			}
+			d.readRuns(paragraph, node.Children, painter, target)
+		case "fldSimple":
+			target, ok := hyperlinkTarget(node.attr("instr"))
+			if !ok {
+				target = link
+				painter := painter
+				painter.Field = strings.TrimSpace(node.attr("instr"))
+				d.readRuns(paragraph, node.Children, painter, target)
+				continue
+			}
+			d.readRuns(paragraph, node.Children, painter, target)
+		case "ins", "smartTag", "customXml", "sdt", "sdtContent", "dir", "bdo":
+			d.readRuns(paragraph, node.Children, painter, link)
+		}
+	}
+}
+
+// readRun reads the content of a run. Complex fields are followed as their
+// characters come: the instruction of a field is not part of the content,
+// the result of a hyperlink field is linked to its target, and that of other
+// fields is given their instruction.
+func (d *docxReader) readRun(paragraph *Paragraph, node *docxNode, painter Painter, link string) {
+	properties := node.child("rPr")
+	if style := properties.child("rStyle").value(); style != "" {
+		d.applyStyle(&painter, nil, style, 0)
+	}
+	d.applyRunProperties(&painter, properties)
+
+	for i := range node.Children {
+		child := &node.Children[i]
+
+		switch child.XMLName.Local {
+		case "fldChar":
+			d.readFieldCharacter(child.attr("fldCharType"))
+			continue
+		case "instrText":
+			if len(d.fields) > 0 && !d.fields[len(d.fields)-1].separated {
+				d.fields[len(d.fields)-1].instruction.WriteString(child.Text)
+			}
+			continue
+		}
+
+		if d.inFieldInstruction() {
+			continue
+		}
+
+		painter.Link = link
+		if painter.Link == "" {
+			painter.Link = d.fieldLink()
+		}
+		if painter.Field == "" && painter.Link == "" {
+			painter.Field = d.fieldInstruction()
+		}
+		run := StyleBlock{Painter: painter}
+
+		switch child.XMLName.Local {
+		case "t":
+			run.Text = child.Text
+		case "tab", "ptab":
+			run.Text = "\t"
+		case "noBreakHyphen":
+			run.Text = "‑"
+		case "softHyphen":
+			run.Text = "­"
+		case "sym":
+			character, err := strconv.ParseUint(child.attr("char"), 16, 32)
+			if err != nil {
+				continue
+			}
+			if font := child.attr("font"); font != "" {
+				run.Painter.FontRef = d.fontRef(font)
+			}
+			run.Text = string(rune(character))
+		case "br", "cr":
+			run.Kind, run.Text = BlockKindLine, blockKindText(BlockKindLine)
+			if child.attr("type") == "page" {
+				run.Kind, run.Text = BlockKindPage, blockKindText(BlockKindPage)
+			}
+		case "drawing", "pict", "object":
+			index, ok := d.readPicture(child)
+			if !ok {
+				continue
+			}
+			run.Kind, run.PictureIndex = BlockKindPicture, index
+		case "footnoteReference", "endnoteReference":
+			index, ok := d.readNote(child.attr("id"), child.XMLName.Local == "endnoteReference")
+			if !ok {
+				continue
+			}
+			run.Kind, run.FootnoteIndex = BlockKindFootnote, index
+		case "footnoteRef", "endnoteRef":
+			run.Kind = BlockKindNoteNumber
+		default:
+			continue
+		}
+
+		if run.Kind == BlockKindText && run.Text == "" {
+			continue
+		}
+		paragraph.Runs = appendRun(paragraph.Runs, run)
+	}
+}
+
+// appendRun appends a run to those of a paragraph, merging text with the
+// previous run when both have the same formatting.
+func appendRun(runs []StyleBlock, run StyleBlock) []StyleBlock {
+	if len(runs) > 0 && run.Kind == BlockKindText {
+		last := &runs[len(runs)-1]
+		if last.Kind == BlockKindText && last.Painter == run.Painter {
+			last.Text += run.Text
+			return runs
+		}
+	}
+
+	return append(runs, run)
+}
+
+func (d *docxReader) readFieldCharacter(kind string) {
+	switch kind {
+	case "begin":
+		d.fields = append(d.fields, &docxField{})
+	case "separate":
+		if len(d.fields) > 0 {
+			field := d.fields[len(d.fields)-1]
+			field.separated = true
+			field.link, _ = hyperlinkTarget(field.instruction.String())
+			if field.link == "" {
+				field.field = strings.TrimSpace(field.instruction.String())
+			}
+		}
+	case "end":
+		if len(d.fields) > 0 {
+			d.fields = d.fields[:len(d.fields)-1]
+		}
+	}
+}
+
+// inFieldInstruction reports whether the content read is part of the
+// instruction of a field rather than its result.
+func (d *docxReader) inFieldInstruction() bool {
+	for _, field := range d.fields {
+		if !field.separated {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldLink returns the target of the innermost hyperlink field in
+// progress.
+func (d *docxReader) fieldLink() string {
+	for i := len(d.fields) - 1; i >= 0; i-- {
+		if d.fields[i].link != "" {
+			return d.fields[i].link
+		}
+	}
+
+	return ""
+}
+
+// fieldInstruction returns the instruction of the innermost field in
+// progress other than a hyperlink.
+func (d *docxReader) fieldInstruction() string {
+	for i := len(d.fields) - 1; i >= 0; i-- {
+		if d.fields[i].field != "" {
+			return d.fields[i].field
+		}
+	}
+
+	return ""
+}
+
+// readPicture adds the image of a DrawingML or VML picture to the document,
+// returning its index.
+func (d *docxReader) readPicture(node *docxNode) (int, bool) {
+	id := node.descendant("blip").attr("embed")
+	width, height := 0, 0
+
+	if extent := node.descendant("extent"); extent != nil {
+		// English Metric Units, 635 per twip
+		cx, _ := extent.intAttr("cx")
+		cy, _ := extent.intAttr("cy")
+		width, height = cx/635, cy/635
+	}
+
+	if id == "" {
+		id = node.descendant("imagedata").attr("id")
+		for _, shape := range []string{"shape", "rect"} {
+			if style := node.descendant(shape).attr("style"); style != "" {
+				width, height = vmlSize(style)
+				break
+			}
+		}
+	}
+
+	target, ok := d.relationships[id]
+	if !ok || target.external {
+		return 0, false
+	}
+
+	file, ok := d.files[target.target]
+	if !ok {
+		return 0, false
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return 0, false
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return 0, false
+	}
+
+	picture, ok := pictureFromFile(data, strings.TrimPrefix(path.Ext(target.target), "."), width, height)
+	if !ok {
+		return 0, false
+	}
+
+	d.doc.Pictures = append(d.doc.Pictures, picture)
+
+	return len(d.doc.Pictures) - 1, true
+}
+
+// vmlSize returns the size in twips given by the style of a VML shape, such
+// as "width:120pt;height:80pt".
+func vmlSize(style string) (width int, height int) {
+	for _, declaration := range strings.Split(style, ";") {
+		name, value, _ := strings.Cut(declaration, ":")
+		value = strings.TrimSpace(value)
+
+		twips := 0
+		for unit, factor := range map[string]float64{"pt": 20, "in": 1440, "cm": 1440 / 2.54, "mm": 144 / 2.54, "px": 15} {
+			if number, err := strconv.ParseFloat(strings.TrimSuffix(value, unit), 64); err == nil && strings.HasSuffix(value, unit) {
+				twips = int(number * factor)
+			}
+		}
+
+		switch strings.TrimSpace(name) {
+		case "width":
+			width = twips
+		case "height":
+			height = twips
+		}
+	}
+
+	return width, height
+}
+
+// readTable reads a table, whose cell boundaries are taken from its grid.
+// The paragraphs of nested tables are added to the cell holding them.
+func (d *docxReader) readTable(node *docxNode) *Table {
+	properties := node.child("tblPr")
+
+	format := RowFormat{Alignment: docxAlignmentFromValue(properties.child("jc").value())}
+	format.LeftIndent, _ = properties.child("tblInd").intAttr("w")
+	for _, name := range []string{"left", "start"} {
+		if gap, ok := properties.child("tblCellMar").child(name).intAttr("w"); ok {
+			format.Gap = gap
+		}
+	}
+
+	grid := []int{}
+	for _, column := range node.child("tblGrid").Children {
+		width, _ := column.intAttr("w")
+		grid = append(grid, width)
+	}
+
+	table := &Table{}
+	for i := range node.Children {
+		if node.Children[i].XMLName.Local == "tr" {
+			table.Rows = append(table.Rows, d.readRow(&node.Children[i], format, grid))
+		}
+	}
+
+	return table
+}
+
+func (d *docxReader) readRow(node *docxNode, format RowFormat, grid []int) TableRow {
+	properties := node.child("trPr")
+	format.Header = properties.child("tblHeader") != nil && properties.child("tblHeader").toggle()
+
+	mark := d.defaultPainter
+	row := TableRow{Format: format, Mark: &mark}
+
+	column, _ := properties.child("gridBefore").intAttr("val")
+	position := format.LeftIndent
+	for _, width := range grid[:min(column, len(grid))] {
+		position += width
+	}
+
+	for _, cellNode := range docxBlocksOf(node.Children, "tc") {
+		cellProperties := cellNode.child("tcPr")
+
+		span := 1
+		if value, ok := cellProperties.child("gridSpan").intAttr("val"); ok && value > 1 {
+			span = value
+		}
+
+		width := 0
+		if column+span <= len(grid) {
+			for _, columnWidth := range grid[column : column+span] {
+				width += columnWidth
+			}
+		} else {
+			width, _ = cellProperties.child("tcW").intAttr("w")
+		}
+		column += span
+		position += width
+
+		cell := CellFormat{Right: position}
+		if merge := cellProperties.child("vMerge"); merge != nil {
+			cell.VerticalMerge = CellMergePrevious
+			if merge.value() == "restart" {
+				cell.VerticalMerge = CellMergeFirst
+			}
+		}
+		if merge := cellProperties.child("hMerge"); merge != nil {
+			cell.HorizontalMerge = CellMergePrevious
+			if merge.value() == "restart" {
+				cell.HorizontalMerge = CellMergeFirst
+			}
+		}
+		cell.Background = d.colorRef(cellProperties.child("shd").attr("fill"))
+
+		row.Format.Cells = append(row.Format.Cells, cell)
+		row.Cells = append(row.Cells, TableCell{Paragraphs: d.readCell(cellNode)})
+	}
+
+	return row
+}
+
+// docxBlocksOf returns the elements with the given local name, looking into
+// the elements that wrap them.
+func docxBlocksOf(nodes []docxNode, name string) []*docxNode {
+	found := []*docxNode{}
+
+	for i := range nodes {
+		node := &nodes[i]
+		switch node.XMLName.Local {
+		case name:
+			found = append(found, node)
+		case "sdt", "sdtContent", "customXml", "ins":
+			found = append(found, docxBlocksOf(node.Children, name)...)
+		}
+	}
+
+	return found
+}
+
+// readCell returns the paragraphs of a cell, which has at least one.
+func (d *docxReader) readCell(node *docxNode) []Paragraph {
+	paragraphs := []Paragraph{}
+
+	for _, block := range docxBlocks(node.Children) {
+		switch block.XMLName.Local {
+		case "p":
+			paragraphs = append(paragraphs, d.readParagraph(block, true))
+		case "tbl":
+			for _, row := range d.readTable(block).Rows {
+				for _, cell := range row.Cells {
+					paragraphs = append(paragraphs, cell.Paragraphs...)
+				}
+			}
+		}
+	}
+
+	if len(paragraphs) == 0 {
+		painter, format := d.paragraphStyle("")
+		format.InTable = true
+		paragraphs = append(paragraphs, Paragraph{Format: format, Mark: &painter})
+	}
+
+	return paragraphs
+}
+
+// readProperties reads the core, extended and custom properties into the
+// information group.
+func (d *docxReader) readProperties(pkg map[string]docxTarget) {
+	info := &d.doc.InformationGroup
+
+	part := func(kind string, name string) *docxNode {
+		if target, ok := findRelationship(pkg, kind); ok {
+			name = target
+		}
+		return d.readOptionalXML(name)
+	}
+
+	if core := part(docxRelationshipCore, "docProps/core.xml"); core != nil {
+		for _, property := range core.Children {
+			text := strings.TrimSpace(property.Text)
+
+			switch property.XMLName.Local {
+			case "title":
+				info.Title = text
+			case "subject":
+				info.Subject = text
+			case "creator":
+				info.Author = text
+			case "keywords":
+				info.Keywords = text
+			case "description":
+				info.Comment = text
+			case "lastModifiedBy":
+				info.Operator = text
+			case "category":
+				info.Category = text
+			case "revision":
+				info.Version, _ = strconv.Atoi(text)
+			case "created":
+				info.CreationTime = docxParseTime(text)
+			case "modified":
+				info.RevisionTime = docxParseTime(text)
+			case "lastPrinted":
+				info.LastPrintTime = docxParseTime(text)
+			}
+		}
+	}
+
+	if extended := part(docxRelationshipExtended, "docProps/app.xml"); extended != nil {
+		for _, property := range extended.Children {
+			text := strings.TrimSpace(property.Text)
+			number, _ := strconv.Atoi(text)
+
+			switch property.XMLName.Local {
+			case "Manager":
+				info.Manager = text
+			case "Company":
+				info.Company = text
+			case "HyperlinkBase":
+				info.BaseAddress = text
+			case "Pages":
+				info.NumberOfPages = number
+			case "Words":
+				info.NumberOfWords = number
+			case "Characters":
+				info.NumberOfCharacters = number
+			case "CharactersWithSpaces":
+				info.NumberOfCharactersWithSpaces = number
+			case "TotalTime":
+				info.EditingMinutes = number
+			}
+		}
+	}
+
+	if custom := part(docxRelationshipCustom, "docProps/custom.xml"); custom != nil {
+		for _, property := range custom.Children {
+			if property.XMLName.Local != "property" || len(property.Children) == 0 {
+				continue
+			}
+
+			value := property.Children[0]
+			propertyType := UserPropertyTypeText
+			switch value.XMLName.Local {
+			case "i1", "i2", "i4", "i8", "int", "ui1", "ui2", "ui4", "ui8", "uint":
+				propertyType = UserPropertyTypeInteger
+			case "r4", "r8", "decimal":
+				propertyType = UserPropertyTypeReal
+			case "bool":
+				propertyType = UserPropertyTypeBoolean
+			case "filetime", "date":
+				propertyType = UserPropertyTypeDate
+			}
+
+			info.UserProperties = append(info.UserProperties, UserProperty{
+				Name:  property.attr("name"),
+				Type:  propertyType,
+				Value: userPropertyValue(propertyType, value.Text),
+			})
+		}
+	}
+}
+
+func docxParseTime(text string) *time.Time {
+	t, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,291 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// docxPackage zips the given parts into a Word document.
+func docxPackage(t *testing.T, parts map[string]string) []byte {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for name, content := range parts {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestParseDOCXRoundTrip(t *testing.T) {
+	content := `{\rtf1\ansi{\fonttbl{\f0\froman\fcharset0 Times New Roman;}{\f1\fswiss\fcharset0 Arial;}}` +
+		`{\colortbl;\red255\green0\blue0;}` +
+		`{\stylesheet{\s0 Normal;}{\s1\sb240\b\fs32 heading 1;}}` +
+		`{\*\listtable{\list{\listlevel\levelnfc23\levelstartat1{\leveltext\'01\u8226 ?;}\fi-360\li720}\listid7}}` +
+		`{\*\listoverridetable{\listoverride\listid7\listoverridecount0\ls1}}` +
+		`{\info{\title Report}{\author Ann}}` +
+		`\sectd{\header\pard Head\par}` +
+		`\pard\s1\sb240\b\fs32 Title\par` +
+		`\pard\plain\ls1 One\par` +
+		`\pard Some {\b bold} {\f1\cf1 red} {\field{\*\fldinst HYPERLINK "https://example.com"}{\fldrslt link}}` +
+		`{\footnote\pard Note\par}\line y\par` +
+		`\trowd\trgaph108\cellx2000\clcbpat1\cellx4000\pard\intbl a\cell b\cell\row` +
+		`\pard{\pict\pngblip\picw10\pich10\picwgoal300\pichgoal150 89504e470d0a1a0a}\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := doc.ToDOCX()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseDOCX(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := Painter{FontSize: 24}
+	heading := Painter{FontSize: 32, Bold: true}
+	cell := ParagraphFormat{InTable: true}
+
+	expected := []Section{{
+		Elements: []BodyElement{
+			&Paragraph{
+				Format: ParagraphFormat{Style: 1, SpaceBefore: 240},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "Title", Painter: heading}},
+				Mark:   &heading,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{List: 1},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "One", Painter: body}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Runs: []StyleBlock{
+					{Kind: BlockKindText, Text: "Some ", Painter: body},
+					{Kind: BlockKindText, Text: "bold", Painter: Painter{FontSize: 24, Bold: true}},
+					{Kind: BlockKindText, Text: " ", Painter: body},
+					{Kind: BlockKindText, Text: "red", Painter: Painter{FontRef: 1, FontSize: 24, ForegroundColor: 1}},
+					{Kind: BlockKindText, Text: " ", Painter: body},
+					{Kind: BlockKindText, Text: "link", Painter: Painter{FontSize: 24, Link: "https://example.com"}},
+					{Kind: BlockKindFootnote, Painter: Painter{FontSize: 24, Superscript: true}},
+					{Kind: BlockKindLine, Text: blockKindText(BlockKindLine), Painter: body},
+					{Kind: BlockKindText, Text: "y", Painter: body},
+				},
+				Mark: &body,
+			},
+			&Table{Rows: []TableRow{{
+				Format: RowFormat{Gap: 108, Cells: []CellFormat{{Right: 2000}, {Right: 4000, Background: 1}}},
+				Cells: []TableCell{
+					{Paragraphs: []Paragraph{{Format: cell, Runs: []StyleBlock{{Kind: BlockKindText, Text: "a", Painter: body}}, Mark: &body}}},
+					{Paragraphs: []Paragraph{{Format: cell, Runs: []StyleBlock{{Kind: BlockKindText, Text: "b", Painter: body}}, Mark: &body}}},
+				},
+				Mark: &body,
+			}}},
+			&Paragraph{
+				Runs: []StyleBlock{{Kind: BlockKindPicture, Painter: body}},
+				Mark: &body,
+			},
+		},
+	}}
+
+	actual := parsed.Sections()
+	for i := range actual {
+		actual[i].Format = SectionFormat{}
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	styles := parsed.Header.Stylesheet
+	if style := styles["heading 1"]; style.Number != 1 || !style.Painter.Bold || style.Paragraph.SpaceBefore != 240 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "bold heading 1 numbered 1", style)
+	}
+
+	expectedLists := ListTable{1: {ID: 1, Levels: []ListLevel{
+		{Format: ListFormatBullet, Start: 1, Text: "•", LeftIndent: 720, FirstLineIndent: -360},
+	}}}
+	if !reflect.DeepEqual(expectedLists, parsed.Header.Lists) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedLists, parsed.Header.Lists)
+	}
+
+	if parsed.InformationGroup.Title != "Report" || parsed.InformationGroup.Author != "Ann" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Report by Ann", parsed.InformationGroup)
+	}
+
+	if len(parsed.Footnotes) != 1 || len(parsed.HeadersFooters) != 1 ||
+		parsed.HeadersFooters[0].Kind != HeaderFooterKindHeader {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a footnote and a header", parsed)
+	}
+
+	if len(parsed.Pictures) != 1 || parsed.Pictures[0].Format != PictureFormatPNG ||
+		parsed.Pictures[0].GoalWidth != 300 || parsed.Pictures[0].GoalHeight != 150 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a 300 by 150 twips PNG", parsed.Pictures)
+	}
+}
+
+func TestParseDOCX(t *testing.T) {
+	const namespaces = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+	parts := map[string]string{
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + docxRelationshipDocument + `" Target="word/main.xml"/>` +
+			`<Relationship Id="rId2" Type="` + docxRelationshipCustom + `" Target="docProps/custom.xml"/>` +
+			`</Relationships>`,
+		"word/_rels/main.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + docxRelationshipStyles + `" Target="styles.xml"/>` +
+			`<Relationship Id="rId2" Type="` + docxRelationshipTheme + `" Target="theme/theme1.xml"/>` +
+			`<Relationship Id="rId3" Type="` + docxRelationshipHyperlink + `" Target="https://example.com" TargetMode="External"/>` +
+			`</Relationships>`,
+		"word/theme/theme1.xml": `<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><a:themeElements>` +
+			`<a:fontScheme><a:majorFont><a:latin typeface="Cambria"/></a:majorFont>` +
+			`<a:minorFont><a:latin typeface="Calibri"/></a:minorFont></a:fontScheme>` +
+			`</a:themeElements></a:theme>`,
+		"word/styles.xml": `<w:styles ` + namespaces + `>` +
+			`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:asciiTheme="minorHAnsi"/><w:sz w:val="22"/></w:rPr></w:rPrDefault></w:docDefaults>` +
+			`<w:style w:type="paragraph" w:styleId="Base"><w:name w:val="Base"/><w:pPr><w:jc w:val="center"/></w:pPr></w:style>` +
+			`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>` +
+			`<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Base"/>` +
+			`<w:rPr><w:i/></w:rPr></w:style>` +
+			`<w:style w:type="character" w:styleId="Strong"><w:rPr><w:b/><w:color w:val="FF0000"/></w:rPr></w:style>` +
+			`</w:styles>`,
+		"word/main.xml": `<w:document ` + namespaces + `><w:body>` +
+			`<w:p><w:pPr><w:pStyle w:val="Quote"/></w:pPr>` +
+			`<w:r><w:t xml:space="preserve">A </w:t></w:r>` +
+			`<w:r><w:rPr><w:rStyle w:val="Strong"/><w:i w:val="0"/></w:rPr><w:t>b</w:t></w:r>` +
+			`<w:del><w:r><w:delText>gone</w:delText></w:r></w:del>` +
+			`<w:ins><w:r><w:t xml:space="preserve"> c</w:t></w:r></w:ins>` +
+			`</w:p>` +
+			`<w:p><w:pPr><w:sectPr><w:pgSz w:w="16838" w:h="11906" w:orient="landscape"/><w:cols w:num="2"/></w:sectPr></w:pPr>` +
+			`<w:r><w:fldChar w:fldCharType="begin"/></w:r>` +
+			`<w:r><w:instrText xml:space="preserve"> HYPERLINK "https://example.org" </w:instrText></w:r>` +
+			`<w:r><w:fldChar w:fldCharType="separate"/></w:r>` +
+			`<w:r><w:t>site</w:t></w:r>` +
+			`<w:r><w:fldChar w:fldCharType="end"/></w:r>` +
+			`<w:hyperlink r:id="rId3"><w:r><w:t>ex</w:t></w:r></w:hyperlink>` +
+			`<w:hyperlink w:anchor="top"><w:r><w:t>up</w:t></w:r></w:hyperlink>` +
+			`</w:p>` +
+			`<w:tbl><w:tblGrid><w:gridCol w:w="1000"/><w:gridCol w:w="2000"/></w:tblGrid>` +
+			`<w:tr><w:tc><w:tcPr><w:vMerge w:val="restart"/></w:tcPr><w:p><w:r><w:t>x</w:t></w:r></w:p></w:tc>` +
+			`<w:tc><w:p/></w:tc></w:tr>` +
+			`<w:tr><w:tc><w:tcPr><w:gridSpan w:val="2"/><w:vMerge/></w:tcPr></w:tc></w:tr>` +
+			`</w:tbl>` +
+			`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/></w:sectPr>` +
+			`</w:body></w:document>`,
+		"docProps/custom.xml": `<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties" ` +
+			`xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">` +
+			`<property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="2" name="Pages"><vt:i4>3</vt:i4></property>` +
+			`<property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="3" name="Final"><vt:bool>true</vt:bool></property>` +
+			`</Properties>`,
+	}
+
+	doc, err := ParseDOCX(docxPackage(t, parts))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedFonts := FontTable{0: {Name: "Calibri"}}
+	if !reflect.DeepEqual(expectedFonts, doc.Header.FontTable) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedFonts, doc.Header.FontTable)
+	}
+
+	expectedStyles := Stylesheet{
+		"Normal": {Name: "Normal", Number: 0, Painter: Painter{FontSize: 22}},
+		"Base": {Name: "Base", Number: 1, Painter: Painter{FontSize: 22},
+			Paragraph: ParagraphFormat{Style: 1, Alignment: AlignmentCenter}},
+		"Quote": {Name: "Quote", Number: 2, Painter: Painter{FontSize: 22, Italic: true},
+			Paragraph: ParagraphFormat{Style: 2, Alignment: AlignmentCenter}},
+	}
+	if !reflect.DeepEqual(expectedStyles, doc.Header.Stylesheet) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedStyles, doc.Header.Stylesheet)
+	}
+
+	quote := Painter{FontSize: 22, Italic: true}
+	normal := Painter{FontSize: 22}
+	cell := ParagraphFormat{InTable: true}
+
+	expected := []Section{
+		{
+			Format: SectionFormat{PageWidth: 16838, PageHeight: 11906, Landscape: true, Columns: 2},
+			Elements: []BodyElement{
+				&Paragraph{
+					Format: ParagraphFormat{Style: 2, Alignment: AlignmentCenter},
+					Runs: []StyleBlock{
+						{Kind: BlockKindText, Text: "A ", Painter: quote},
+						{Kind: BlockKindText, Text: "b", Painter: Painter{FontSize: 22, Bold: true, ForegroundColor: 1}},
+						{Kind: BlockKindText, Text: " c", Painter: quote},
+					},
+					Mark: &quote,
+				},
+				&Paragraph{
+					Runs: []StyleBlock{
+						{Kind: BlockKindText, Text: "site", Painter: Painter{FontSize: 22, Link: "https://example.org"}},
+						{Kind: BlockKindText, Text: "ex", Painter: Painter{FontSize: 22, Link: "https://example.com"}},
+						{Kind: BlockKindText, Text: "up", Painter: Painter{FontSize: 22, Link: "#top"}},
+					},
+					Mark: &normal,
+				},
+			},
+			Mark: &normal,
+		},
+		{
+			Elements: []BodyElement{
+				&Table{Rows: []TableRow{
+					{
+						Format: RowFormat{Cells: []CellFormat{{Right: 1000, VerticalMerge: CellMergeFirst}, {Right: 3000}}},
+						Cells: []TableCell{
+							{Paragraphs: []Paragraph{{Format: cell, Runs: []StyleBlock{{Kind: BlockKindText, Text: "x", Painter: normal}}, Mark: &normal}}},
+							{Paragraphs: []Paragraph{{Format: cell, Mark: &normal}}},
+						},
+						Mark: &normal,
+					},
+					{
+						Format: RowFormat{Cells: []CellFormat{{Right: 3000, VerticalMerge: CellMergePrevious}}},
+						Cells: []TableCell{
+							{Paragraphs: []Paragraph{{Format: cell, Mark: &normal}}},
+						},
+						Mark: &normal,
+					},
+				}},
+			},
+		},
+	}
+
+	actual := doc.Sections()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	expectedProperties := []UserProperty{
+		{Name: "Pages", Type: UserPropertyTypeInteger, Value: 3},
+		{Name: "Final", Type: UserPropertyTypeBoolean, Value: true},
+	}
+	if !reflect.DeepEqual(expectedProperties, doc.InformationGroup.UserProperties) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedProperties, doc.InformationGroup.UserProperties)
+	}
+}
+
+func TestParseDOCXInvalid(t *testing.T) {
+	if _, err := ParseDOCX([]byte("not a zip file")); err == nil {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "an error", err)
+	}
+
+	if _, err := ParseDOCX(docxPackage(t, map[string]string{"word/styles.xml": "<w:styles/>"})); err == nil {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "an error", err)
+	}
+}
@@ -0,0 +1,104 @@
+package gortf
+
+import (
+	"strings"
+)
+
+// parseField reads a {\field} group: the instruction in {\*\fldinst} and the
+// result in {\fldrslt}, which is part of the body. The result of a hyperlink
+// field is linked to the target of the field, and that of other fields is
+// given their instruction.
+func (r *RtfParser) parseField(doc *RtfDocument, g *Group) {
+	r.pushPainter(*r.lastPainter())
+
+	if instruction := g.Find("fldinst"); instruction != nil {
+		text := r.textFromGroup(instruction, r.codePage)
+		if target, ok := hyperlinkTarget(text); ok {
+			r.lastPainter().Link = target
+		} else if text = strings.TrimSpace(text); text != "" {
+			r.lastPainter().Field = text
+		}
+	}
+
+	r.parseBody(doc, g)
+	r.popPainter()
+}
+
+// hyperlinkTarget returns the target of a HYPERLINK field instruction, such
+// as `HYPERLINK "https://example.com" \o "tip"`. A location within the
+// document given with \l is appended as a fragment.
+func hyperlinkTarget(instruction string) (string, bool) {
+	arguments := splitFieldInstruction(instruction)
+	if len(arguments) == 0 || !strings.EqualFold(arguments[0], "HYPERLINK") {
+		return "", false
+	}
+
+	address, location := "", ""
+	for i := 1; i < len(arguments); i++ {
+		switch arguments[i] {
+		case `\l`:
+			if i+1 < len(arguments) {
+				location = arguments[i+1]
+				i++
+			}
+		case `\o`, `\t`:
+			// switches followed by an argument that is not part of the target
+			i++
+		case `\m`, `\n`, `\h`:
+		default:
+			if address == "" {
+				address = arguments[i]
+			}
+		}
+	}
+
+	if location != "" {
+		address += "#" + location
+	}
+
+	return address, address != ""
+}
+
+// splitFieldInstruction splits a field instruction into its arguments. Quoted
+// arguments may contain spaces, and \\ and \" stand for a backslash and a
+// quote within them.
+func splitFieldInstruction(instruction string) []string {
+	arguments := []string{}
+	var current strings.Builder
+	inArgument, quoted := false, false
+
+	end := func() {
+		if inArgument {
+			arguments = append(arguments, current.String())
+		}
+		current.Reset()
+		inArgument = false
+	}
+
+	for i := 0; i < len(instruction); i++ {
+		c := instruction[i]
+
+		switch {
+		case quoted && c == '\\' && i+1 < len(instruction) && (instruction[i+1] == '\\' || instruction[i+1] == '"'):
+			current.WriteByte(instruction[i+1])
+			i++
+		case c == '"':
+			if quoted {
+				quoted = false
+				end()
+			} else {
+				end()
+				quoted = true
+				inArgument = true
+			}
+		case !quoted && (c == ' ' || c == '\t' || c == '\r' || c == '\n'):
+			end()
+		default:
+			current.WriteByte(c)
+			inArgument = true
+		}
+	}
+	end()
+
+	return arguments
+}
@@ -0,0 +1,101 @@
+package gortf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHyperlinkTarget(t *testing.T) {
+	for _, test := range []struct {
+		instruction string
+		target      string
+		ok          bool
+	}{
+		{`HYPERLINK "https://example.com"`, "https://example.com", true},
+		{` hyperlink  https://example.com \o "A tip" `, "https://example.com", true},
+		{`HYPERLINK "file.rtf" \l "part 2"`, "file.rtf#part 2", true},
+		{`HYPERLINK \l "top"`, "#top", true},
+		{`HYPERLINK "say \"hi\".html" \h`, `say "hi".html`, true},
+		{`PAGE \* MERGEFORMAT`, "", false},
+		{`HYPERLINK`, "", false},
+	} {
+		target, ok := hyperlinkTarget(test.instruction)
+		if target != test.target || ok != test.ok {
+			t.Errorf("\n\nexpected: %v %v\n\nactual\t: %v %v", test.target, test.ok, target, ok)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	content := `{\rtf1\ansi Go to {\field{\*\fldinst{HYPERLINK "https://example.com"}}{\fldrslt{\ul site}}} or {\field{\*\fldinst PAGE}{\fldrslt 3}}.}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []StyleBlock{
+		{Text: "Go to "},
+		{Painter: Painter{Underline: true, Link: "https://example.com"}, Text: "site"},
+		{Text: " or "},
+		{Painter: Painter{Field: "PAGE"}, Text: "3"},
+		{Text: "."},
+	}
+
+	if !reflect.DeepEqual(doc.Body, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Body)
+	}
+}
+
+func TestWriteFields(t *testing.T) {
+	content := `{\rtf1\ansi{\fonttbl{\f0 Times;}}\pard Dear {\field{\*\fldinst MERGEFIELD Name}{\fldrslt \u171?Name\u187?}},` +
+		` see {\field{\*\fldinst HYPERLINK "https://example.com"}{\fldrslt site}}\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rtf, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser = NewRtfParser()
+	parsed, err := parser.ParseContent(rtf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(doc.Body, parsed.Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Body, parsed.Body)
+	}
+}
+
+func TestHTMLLinkSchemes(t *testing.T) {
+	content := `{\rtf1\ansi\pard ` +
+		`{\field{\*\fldinst HYPERLINK "javascript:alert(1)"}{\fldrslt a}} ` +
+		`{\field{\*\fldinst HYPERLINK " JavaScript:alert(1)"}{\fldrslt b}} ` +
+		`{\field{\*\fldinst HYPERLINK "data:text/html,x"}{\fldrslt c}} ` +
+		`{\field{\*\fldinst HYPERLINK "https://example.com"}{\fldrslt d}} ` +
+		`{\field{\*\fldinst HYPERLINK "mailto:ann@example.com"}{\fldrslt e}} ` +
+		`{\field{\*\fldinst HYPERLINK \\l "top"}{\fldrslt f}}\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := doc.ToHTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `a b c <a href="https://example.com">d</a> <a href="mailto:ann@example.com">e</a> <a href="#top">f</a>` + "\n"
+	if html != expected {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, html)
+	}
+}
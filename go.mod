@@ -1,3 +1,5 @@
 module github.com/axispx/gortf
 
 go 1.21.1
+
+require golang.org/x/text v0.22.0
@@ -2,12 +2,259 @@ package gortf
 
 import (
 	"encoding/json"
+	"strconv"
 )
 
 type Font struct {
-	Name       string
-	Charset    CharacterSet
-	FontFamily FontFamily
+	Name       string      `json:"name"`
+	Charset    FontCharset `json:"charset"`
+	CodePage   int         `json:"codePage,omitempty"`
+	FontFamily FontFamily  `json:"family"`
+	Pitch      FontPitch   `json:"pitch,omitempty"`
+
+	// AlternateName is the font to use when Name is not available (\falt).
+	AlternateName string `json:"alternateName,omitempty"`
+	// NonTaggedName is the font name without its script suffix (\fname).
+	NonTaggedName string `json:"nonTaggedName,omitempty"`
+	// Panose is the 10-byte PANOSE classification of the font.
+	Panose []byte    `json:"panose,omitempty"`
+	Bias   int       `json:"bias,omitempty"`
+	Theme  FontTheme `json:"theme,omitempty"`
+}
+
+// FontCharset is the character set of a font, given by \fcharset.
+type FontCharset int
+
+const (
+	FontCharsetAnsi             FontCharset = 0
+	FontCharsetDefault          FontCharset = 1
+	FontCharsetSymbol           FontCharset = 2
+	FontCharsetMac              FontCharset = 77
+	FontCharsetMacShiftJis      FontCharset = 78
+	FontCharsetMacHangul        FontCharset = 79
+	FontCharsetMacGb2312        FontCharset = 80
+	FontCharsetMacBig5          FontCharset = 81
+	FontCharsetMacHebrew        FontCharset = 83
+	FontCharsetMacArabic        FontCharset = 84
+	FontCharsetMacGreek         FontCharset = 85
+	FontCharsetMacTurkish       FontCharset = 86
+	FontCharsetMacThai          FontCharset = 87
+	FontCharsetMacEasternEurope FontCharset = 88
+	FontCharsetMacRussian       FontCharset = 89
+	FontCharsetShiftJis         FontCharset = 128
+	FontCharsetHangul           FontCharset = 129
+	FontCharsetJohab            FontCharset = 130
+	FontCharsetGb2312           FontCharset = 134
+	FontCharsetBig5             FontCharset = 136
+	FontCharsetGreek            FontCharset = 161
+	FontCharsetTurkish          FontCharset = 162
+	FontCharsetVietnamese       FontCharset = 163
+	FontCharsetHebrew           FontCharset = 177
+	FontCharsetArabic           FontCharset = 178
+	FontCharsetBaltic           FontCharset = 186
+	FontCharsetRussian          FontCharset = 204
+	FontCharsetThai             FontCharset = 222
+	FontCharsetEasternEurope    FontCharset = 238
+	FontCharsetPc437            FontCharset = 254
+	FontCharsetOem              FontCharset = 255
+)
+
+// fontCharsetCodePages maps font character sets to the code page their text
+// is encoded with.
+var fontCharsetCodePages = map[FontCharset]int{
+	FontCharsetAnsi:             1252,
+	FontCharsetSymbol:           codePageSymbol,
+	FontCharsetMac:              10000,
+	FontCharsetMacShiftJis:      10001,
+	FontCharsetMacHangul:        10003,
+	FontCharsetMacGb2312:        10008,
+	FontCharsetMacBig5:          10002,
+	FontCharsetMacHebrew:        10005,
+	FontCharsetMacArabic:        10004,
+	FontCharsetMacGreek:         10006,
+	FontCharsetMacTurkish:       10081,
+	FontCharsetMacThai:          10021,
+	FontCharsetMacEasternEurope: 10029,
+	FontCharsetMacRussian:       10007,
+	FontCharsetShiftJis:         932,
+	FontCharsetHangul:           949,
+	FontCharsetJohab:            1361,
+	FontCharsetGb2312:           936,
+	FontCharsetBig5:             950,
+	FontCharsetGreek:            1253,
+	FontCharsetTurkish:          1254,
+	FontCharsetVietnamese:       1258,
+	FontCharsetHebrew:           1255,
+	FontCharsetArabic:           1256,
+	FontCharsetBaltic:           1257,
+	FontCharsetRussian:          1251,
+	FontCharsetThai:             874,
+	FontCharsetEasternEurope:    1250,
+	FontCharsetPc437:            437,
+	FontCharsetOem:              850,
+}
+
+// CodePage returns the code page text in the character set is encoded with,
+// or 0 for the default character set, which uses the document code page.
+func (f FontCharset) CodePage() int {
+	return fontCharsetCodePages[f]
+}
+
+// String returns the name of the character set, or its number for character
+// sets without a name.
+func (f FontCharset) String() string {
+	switch f {
+	case FontCharsetAnsi:
+		return "Ansi"
+	case FontCharsetDefault:
+		return "Default"
+	case FontCharsetSymbol:
+		return "Symbol"
+	case FontCharsetMac:
+		return "Mac"
+	case FontCharsetMacShiftJis:
+		return "MacShiftJis"
+	case FontCharsetMacHangul:
+		return "MacHangul"
+	case FontCharsetMacGb2312:
+		return "MacGb2312"
+	case FontCharsetMacBig5:
+		return "MacBig5"
+	case FontCharsetMacHebrew:
+		return "MacHebrew"
+	case FontCharsetMacArabic:
+		return "MacArabic"
+	case FontCharsetMacGreek:
+		return "MacGreek"
+	case FontCharsetMacTurkish:
+		return "MacTurkish"
+	case FontCharsetMacThai:
+		return "MacThai"
+	case FontCharsetMacEasternEurope:
+		return "MacEasternEurope"
+	case FontCharsetMacRussian:
+		return "MacRussian"
+	case FontCharsetShiftJis:
+		return "ShiftJis"
+	case FontCharsetHangul:
+		return "Hangul"
+	case FontCharsetJohab:
+		return "Johab"
+	case FontCharsetGb2312:
+		return "Gb2312"
+	case FontCharsetBig5:
+		return "Big5"
+	case FontCharsetGreek:
+		return "Greek"
+	case FontCharsetTurkish:
+		return "Turkish"
+	case FontCharsetVietnamese:
+		return "Vietnamese"
+	case FontCharsetHebrew:
+		return "Hebrew"
+	case FontCharsetArabic:
+		return "Arabic"
+	case FontCharsetBaltic:
+		return "Baltic"
+	case FontCharsetRussian:
+		return "Russian"
+	case FontCharsetThai:
+		return "Thai"
+	case FontCharsetEasternEurope:
+		return "EasternEurope"
+	case FontCharsetPc437:
+		return "Pc437"
+	case FontCharsetOem:
+		return "Oem"
+	default:
+		return strconv.Itoa(int(f))
+	}
+}
+
+// FontPitch is the pitch of a font, given by \fprq.
+type FontPitch int
+
+const (
+	FontPitchDefault FontPitch = iota
+	FontPitchFixed
+	FontPitchVariable
+)
+
+func (f FontPitch) String() string {
+	switch f {
+	case FontPitchFixed:
+		return "Fixed"
+	case FontPitchVariable:
+		return "Variable"
+	default:
+		return "Default"
+	}
+}
+
+// FontTheme identifies the theme font a font table entry stands for, such as
+// \flomajor for the major Latin font.
+type FontTheme int
+
+const (
+	FontThemeNone FontTheme = iota
+	FontThemeMajorLatin
+	FontThemeMajorHighAnsi
+	FontThemeMajorEastAsian
+	FontThemeMajorBidi
+	FontThemeMinorLatin
+	FontThemeMinorHighAnsi
+	FontThemeMinorEastAsian
+	FontThemeMinorBidi
+)
+
+func (f FontTheme) String() string {
+	switch f {
+	case FontThemeMajorLatin:
+		return "MajorLatin"
+	case FontThemeMajorHighAnsi:
+		return "MajorHighAnsi"
+	case FontThemeMajorEastAsian:
+		return "MajorEastAsian"
+	case FontThemeMajorBidi:
+		return "MajorBidi"
+	case FontThemeMinorLatin:
+		return "MinorLatin"
+	case FontThemeMinorHighAnsi:
+		return "MinorHighAnsi"
+	case FontThemeMinorEastAsian:
+		return "MinorEastAsian"
+	case FontThemeMinorBidi:
+		return "MinorBidi"
+	default:
+		return "None"
+	}
+}
+
+func fontThemeFromToken(tkn token) FontTheme {
+	if tkn.tokenType() == tokenTypeControlWord {
+		controlWord := tkn.(controlWordToken)
+
+		switch controlWord.name {
+		case `\flomajor`:
+			return FontThemeMajorLatin
+		case `\fhimajor`:
+			return FontThemeMajorHighAnsi
+		case `\fdbmajor`:
+			return FontThemeMajorEastAsian
+		case `\fbimajor`:
+			return FontThemeMajorBidi
+		case `\flominor`:
+			return FontThemeMinorLatin
+		case `\fhiminor`:
+			return FontThemeMinorHighAnsi
+		case `\fdbminor`:
+			return FontThemeMinorEastAsian
+		case `\fbiminor`:
+			return FontThemeMinorBidi
+		}
+	}
+
+	return FontThemeNone
 }
 
 type CharacterSet int
@@ -119,19 +366,16 @@ func fontFamilyFromToken(tkn token) FontFamily {
 	return FontFamilyNil
 }
 
-type Color struct {
-	R int
-	G int
-	B int
-}
-
-func (c Color) valid() bool {
-	return c.R >= 0 && c.G >= 0 && c.B >= 0
-}
-
+// Style is a paragraph style of the stylesheet, referred to by the \s
+// control word of paragraphs.
 type Style struct {
-	Name   string
-	Number int
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+
+	// Painter and Paragraph are the character and paragraph formatting the
+	// style applies.
+	Painter   Painter         `json:"format"`
+	Paragraph ParagraphFormat `json:"paragraph"`
 }
 
 type TableRef uint16
@@ -140,11 +384,114 @@ type FontTable map[TableRef]Font
 type ColorTable map[TableRef]Color
 type Stylesheet map[string]Style
 
+// headingSizes are the font sizes of the heading styles in half-points.
+var headingSizes = []int{32, 28, 26, 24, 22, 20}
+
+// heading returns the "heading 1" to "heading 6" style of a level, adding it
+// if needed.
+func (s Stylesheet) heading(level int) Style {
+	name := "heading " + strconv.Itoa(level)
+	if style, ok := s[name]; ok {
+		return style
+	}
+
+	style := Style{
+		Name:      name,
+		Number:    level,
+		Painter:   Painter{Bold: true, FontSize: headingSizes[level-1]},
+		Paragraph: ParagraphFormat{Style: level, SpaceBefore: 240, SpaceAfter: 60, KeepWithNext: true},
+	}
+	s[name] = style
+
+	return style
+}
+
+// ref returns the entry of the font of the same name as font, adding font
+// after the last entry if there is none.
+func (t FontTable) ref(font Font) TableRef {
+	for _, key := range sortedTableRefs(t) {
+		if t[key].Name == font.Name {
+			return key
+		}
+	}
+
+	return addTableEntry(t, font)
+}
+
+// ref returns the entry of color, adding it after the last entry if there is
+// none. Entry 0 is the auto color, which is added first to empty tables.
+func (t ColorTable) ref(color Color) TableRef {
+	if _, ok := t[0]; !ok {
+		t[0] = Color{Auto: true}
+	}
+
+	for _, key := range sortedTableRefs(t) {
+		if key != 0 && t[key] == color {
+			return key
+		}
+	}
+
+	return addTableEntry(t, color)
+}
+
+// addTableEntry adds value after the last entry of table, returning its key.
+func addTableEntry[V any](table map[TableRef]V, value V) TableRef {
+	key := TableRef(0)
+	for existing := range table {
+		key = max(key, existing+1)
+	}
+	table[key] = value
+
+	return key
+}
+
 type RtfHeader struct {
 	Charset    CharacterSet
+	CodePage   int
 	FontTable  FontTable
 	ColorTable ColorTable
 	Stylesheet Stylesheet
+	Lists      ListTable
+
+	// RevisionAuthors are the names of the authors of tracked revisions,
+	// from the {\*\revtbl} group, which revisions refer to by index.
+	RevisionAuthors []string
+
+	// DefaultTab is the distance between the default tab stops in twips, 0
+	// meaning 720 (\deftab). See TabWidth.
+	DefaultTab int
+
+	// Cocoa is set for documents written by the Cocoa text system of Apple
+	// platforms.
+	Cocoa *CocoaMetadata
+}
+
+// TabWidth returns the distance between the default tab stops in twips.
+func (r RtfHeader) TabWidth() int {
+	if r.DefaultTab > 0 {
+		return r.DefaultTab
+	}
+
+	return defaultTabWidth
+}
+
+// codePage returns the code page used to decode \'hh escapes, falling back to
+// the one implied by the character set when \ansicpg is absent.
+func (r RtfHeader) codePage() int {
+	if r.CodePage != 0 {
+		return r.CodePage
+	}
+
+	switch r.Charset {
+	case CharacterSetMac:
+		return codePageMacRoman
+	case CharacterSetPc:
+		return 437
+	case CharacterSetPca:
+		return 850
+	default:
+		return codePageWindowsLatin1
+	}
 }
 
 func (r RtfHeader) String() string {
@@ -1,11 +1,85 @@
 package gortf
 
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// HTMLOptions changes how documents are rendered as HTML.
+type HTMLOptions struct {
+	// Annotations renders the comments of reviewers as side notes: the text
+	// they are about is marked, their references link to them, and the
+	// comments follow the body in an aside element.
+	Annotations bool
+
+	// Revisions renders tracked revisions as markup: inserted text in ins
+	// elements and deleted text in del elements, which give the author and
+	// time of the change. Otherwise deleted text is left out.
+	Revisions bool
+}
+
 func RTFToHTML(r *RtfDocument) (string, error) {
-	body := r.Body
+	return RTFToHTMLWithOptions(r, HTMLOptions{})
+}
+
+// RTFToHTMLWithOptions renders the body of a document as HTML, as changed by
+// options.
+func RTFToHTMLWithOptions(r *RtfDocument, options HTMLOptions) (string, error) {
+	body := r.flatBody()
+
+	// number of annotated ranges the text is in
+	annotated := 0
 
 	var htmlBody string
 	for _, styleBlock := range body {
+		if options.Annotations {
+			switch styleBlock.Kind {
+			case BlockKindAnnotationStart:
+				annotated++
+			case BlockKindAnnotationEnd:
+				annotated = max(annotated-1, 0)
+			case BlockKindAnnotation:
+				htmlBody += annotationReference(r, styleBlock.AnnotationIndex)
+			}
+		}
+
+		if styleBlock.Painter.Deleted && !options.Revisions && styleBlock.Kind == BlockKindText {
+			continue
+		}
+
+		if styleBlock.Kind == BlockKindBookmarkStart && styleBlock.Bookmark != "" {
+			htmlBody += fmt.Sprintf(`<a id="%s"></a>`, html.EscapeString(styleBlock.Bookmark))
+		}
+
+		if styleBlock.Kind == BlockKindPicture {
+			htmlBody += pictureHTML(r, styleBlock.PictureIndex)
+			continue
+		}
+
 		closingTagStack := []string{}
+		if href := htmlLinkTarget(styleBlock.Painter); href != "" && styleBlock.Kind == BlockKindText {
+			htmlBody += fmt.Sprintf(`<a href="%s">`, html.EscapeString(href))
+			closingTagStack = append(closingTagStack, "</a>")
+		}
+
+		if options.Revisions && styleBlock.Kind == BlockKindText {
+			if styleBlock.Painter.Deleted {
+				htmlBody += revisionTag("del", r.Header.RevisionAuthor(styleBlock.Painter.DeletedBy), styleBlock.Painter.DeletedAt)
+				closingTagStack = append(closingTagStack, "</del>")
+			}
+			if styleBlock.Painter.Inserted {
+				htmlBody += revisionTag("ins", r.Header.RevisionAuthor(styleBlock.Painter.InsertedBy), styleBlock.Painter.InsertedAt)
+				closingTagStack = append(closingTagStack, "</ins>")
+			}
+		}
+
+		if annotated > 0 && styleBlock.Kind == BlockKindText {
+			htmlBody += `<mark class="annotated">`
+			closingTagStack = append(closingTagStack, "</mark>")
+		}
+
 		if styleBlock.Painter.Bold {
 			htmlBody += "<bold>"
 			closingTagStack = append(closingTagStack, "</bold>")
@@ -21,12 +95,117 @@ func RTFToHTML(r *RtfDocument) (string, error) {
 			closingTagStack = append(closingTagStack, "</u>")
 		}
 
-		htmlBody += styleBlock.Text
+		htmlBody += html.EscapeString(styleBlock.Text)
 
 		for i := len(closingTagStack) - 1; i >= 0; i-- {
 			htmlBody += closingTagStack[i]
 		}
 	}
 
+	if options.Annotations && len(r.Annotations) > 0 {
+		htmlBody += annotationsHTML(r.Annotations)
+	}
+
 	return htmlBody, nil
 }
+
+// htmlLinkTarget returns the address text with the given formatting links
+// to: the target of its hyperlink, or the bookmark a REF or PAGEREF field
+// refers to, whose start bears its name as id. Hyperlinks to addresses that
+// are not safe to follow are left out.
+func htmlLinkTarget(painter Painter) string {
+	if painter.Link != "" {
+		if !isSafeLink(painter.Link) {
+			return ""
+		}
+		return painter.Link
+	}
+
+	if name, ok := referencedBookmark(painter); ok {
+		return "#" + name
+	}
+
+	return ""
+}
+
+// pictureHTML returns the img element of a picture, which embeds its data.
+// Pictures of formats browsers cannot read are left out.
+func pictureHTML(r *RtfDocument, index int) string {
+	if index < 0 || index >= len(r.Pictures) {
+		return ""
+	}
+
+	picture := r.Pictures[index]
+	src, ok := picture.dataURI()
+	if !ok {
+		return ""
+	}
+
+	// pixels at 96 dots per inch
+	width, height := picture.size()
+	return fmt.Sprintf(`<img src="%s" width="%d" height="%d" alt="%s">`, src, width/15, height/15, html.EscapeString(picture.Name))
+}
+
+// revisionTag returns the start tag of an ins or del element.
+func revisionTag(name string, author string, at RevisionTime) string {
+	tag := "<" + name
+	if author != "" {
+		tag += fmt.Sprintf(` title="%s"`, html.EscapeString(author))
+	}
+	if at != 0 {
+		tag += fmt.Sprintf(` datetime="%s"`, at.Time().Format("2006-01-02T15:04"))
+	}
+
+	return tag + ">"
+}
+
+// annotationReference returns the link from the body to a side note.
+func annotationReference(r *RtfDocument, index int) string {
+	if index < 0 || index >= len(r.Annotations) {
+		return ""
+	}
+
+	label := fmt.Sprintf("[%s%d]", r.Annotations[index].Initials, index+1)
+	return fmt.Sprintf(`<sup class="annotation-ref"><a href="#annotation-%d">%s</a></sup>`, index+1, html.EscapeString(label))
+}
+
+// annotationsHTML returns the side notes of the comments of a document.
+func annotationsHTML(annotations []Annotation) string {
+	var sb strings.Builder
+
+	sb.WriteString(`<aside class="annotations">`)
+	for i, annotation := range annotations {
+		fmt.Fprintf(&sb, `<p class="annotation" id="annotation-%d">`, i+1)
+		if annotation.Author != "" {
+			fmt.Fprintf(&sb, `<span class="author">%s</span> `, html.EscapeString(annotation.Author))
+		}
+		if annotation.Date != nil {
+			fmt.Fprintf(&sb, `<time datetime="%s">%s</time> `, annotation.Date.Format("2006-01-02T15:04"), annotation.Date.Format("2006-01-02 15:04"))
+		}
+		sb.WriteString(strings.ReplaceAll(html.EscapeString(annotation.Text()), "\n", "<br>"))
+		sb.WriteString("</p>")
+	}
+	sb.WriteString("</aside>")
+
+	return sb.String()
+}
+
+// safeLinkSchemes are the schemes of the addresses hyperlinks are kept to
+// when exporting documents, whose links may come from anywhere.
+var safeLinkSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// isSafeLink reports whether a hyperlink target is a bookmark of the
+// document or an address of a safe scheme, and not a script such as
+// "javascript:alert(1)".
+func isSafeLink(target string) bool {
+	if strings.HasPrefix(target, "#") {
+		return true
+	}
+
+	address, err := url.Parse(strings.TrimSpace(target))
+	if err != nil {
+		return false
+	}
+
+	return safeLinkSchemes[strings.ToLower(address.Scheme)]
+}
@@ -0,0 +1,1224 @@
+package gortf
+
+import (
+	"encoding/base64"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HTMLToRTF converts an HTML document or fragment to RTF. See ParseHTML.
+func HTMLToRTF(content string) (string, error) {
+	doc, err := ParseHTML(content)
+	if err != nil {
+		return "", err
+	}
+
+	return DocumentToRTF(&doc)
+}
+
+// ParseHTML reads an HTML document or fragment into the document model, as
+// produced by rich text editors.
+//
+// Paragraphs, headings, lists, tables, line breaks and the usual inline
+// elements are read, as are the color, font-family, font-size, font-weight,
+// font-style, text-decoration, vertical-align and text-align properties of
+// style attributes. Headings use the "heading 1" to "heading 6" styles of
+// the stylesheet, each list has an entry of the list table, and fonts and
+// colors are added to their tables as they are used. Only images embedded
+// in data URLs are kept. Like browsers, the reader accepts any input, and
+// elements that are not closed are closed where the elements holding them
+// end.
+func ParseHTML(content string) (RtfDocument, error) {
+	return readHTML(tokenizeHTML(content)), nil
+}
+
+// readHTML builds a document from HTML tokens.
+func readHTML(tokens []htmlToken) RtfDocument {
+	h := &htmlReader{
+		stack: []htmlElement{{painter: Painter{FontSize: 24}}},
+	}
+	h.doc.Header = RtfHeader{
+		Charset:    CharacterSetAnsi,
+		FontTable:  FontTable{0: {Name: "Times New Roman", FontFamily: FontFamilyRoman}},
+		ColorTable: ColorTable{0: {Auto: true}},
+		Stylesheet: Stylesheet{"Normal": {Name: "Normal", Number: 0, Painter: Painter{FontSize: 24}}},
+		Lists:      ListTable{},
+	}
+
+	for _, tkn := range tokens {
+		switch tkn.kind {
+		case htmlTokenText:
+			h.text(tkn.text)
+		case htmlTokenStart:
+			h.start(tkn)
+		case htmlTokenEnd:
+			h.end(tkn.name)
+		}
+	}
+
+	for len(h.stack) > 1 {
+		h.pop()
+	}
+	h.endParagraph()
+
+	h.doc.SetSections([]Section{{Elements: h.elements}})
+
+	return h.doc
+}
+
+type htmlTokenKind int
+
+const (
+	htmlTokenText htmlTokenKind = iota
+	htmlTokenStart
+	htmlTokenEnd
+)
+
+// htmlToken is a piece of text, with its character references replaced, or
+// a start or end tag.
+type htmlToken struct {
+	kind        htmlTokenKind
+	name        string
+	attributes  map[string]string
+	text        string
+	selfClosing bool
+}
+
+// htmlRawTextElements are the elements whose content is text up to their end
+// tag, rather than markup.
+var htmlRawTextElements = map[string]bool{
+	"script": true, "style": true, "title": true, "textarea": true,
+}
+
+// tokenizeHTML splits HTML into tokens. Names of elements and attributes are
+// lowercased, and comments, doctypes and processing instructions dropped.
+func tokenizeHTML(content string) []htmlToken {
+	tokens := []htmlToken{}
+
+	for i := 0; i < len(content); {
+		if content[i] != '<' {
+			end := strings.IndexByte(content[i:], '<')
+			if end < 0 {
+				end = len(content) - i
+			}
+			tokens = append(tokens, htmlToken{kind: htmlTokenText, text: html.UnescapeString(content[i : i+end])})
+			i += end
+			continue
+		}
+
+		rest := content[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return tokens
+			}
+			i += 4 + end + 3
+
+		case strings.HasPrefix(rest, "<!"), strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+
+		case strings.HasPrefix(rest, "</") && len(rest) > 2 && isAlpha(rest[2]):
+			name, length := htmlTagName(rest[2:])
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			tokens = append(tokens, htmlToken{kind: htmlTokenEnd, name: name})
+			i += max(end+1, 2+length)
+
+		case len(rest) > 1 && isAlpha(rest[1]):
+			tkn, length := htmlStartTag(rest)
+			tokens = append(tokens, tkn)
+			i += length
+
+			if htmlRawTextElements[tkn.name] && !tkn.selfClosing {
+				end := strings.Index(strings.ToLower(content[i:]), "</"+tkn.name)
+				if end < 0 {
+					end = len(content) - i
+				}
+				text := content[i : i+end]
+				if tkn.name == "title" || tkn.name == "textarea" {
+					text = html.UnescapeString(text)
+				}
+				tokens = append(tokens, htmlToken{kind: htmlTokenText, text: text})
+				i += end
+			}
+
+		default:
+			tokens = append(tokens, htmlToken{kind: htmlTokenText, text: "<"})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// htmlTagName reads the name of a tag, returning it lowercased and its
+// length.
+func htmlTagName(text string) (string, int) {
+	length := 0
+	for length < len(text) && !strings.ContainsRune(" \t\n\r\f/>", rune(text[length])) {
+		length++
+	}
+
+	return strings.ToLower(text[:length]), length
+}
+
+// htmlStartTag reads a start tag and its attributes, returning it and its
+// length.
+func htmlStartTag(text string) (htmlToken, int) {
+	name, length := htmlTagName(text[1:])
+	tkn := htmlToken{kind: htmlTokenStart, name: name, attributes: map[string]string{}}
+
+	i := 1 + length
+	for i < len(text) {
+		switch c := text[i]; {
+		case c == '>':
+			return tkn, i + 1
+		case c == '/' && i+1 < len(text) && text[i+1] == '>':
+			tkn.selfClosing = true
+			return tkn, i + 2
+		case strings.ContainsRune(" \t\n\r\f/", rune(c)):
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(text) && !strings.ContainsRune(" \t\n\r\f/>=", rune(text[i])) {
+			i++
+		}
+		attribute := strings.ToLower(text[start:i])
+
+		for i < len(text) && strings.ContainsRune(" \t\n\r\f", rune(text[i])) {
+			i++
+		}
+		if i >= len(text) || text[i] != '=' {
+			tkn.attributes[attribute] = ""
+			continue
+		}
+		i++
+		for i < len(text) && strings.ContainsRune(" \t\n\r\f", rune(text[i])) {
+			i++
+		}
+
+		value := ""
+		if i < len(text) && (text[i] == '"' || text[i] == '\'') {
+			end := strings.IndexByte(text[i+1:], text[i])
+			if end < 0 {
+				end = len(text) - i - 1
+			}
+			value = text[i+1 : i+1+end]
+			i += end + 2
+		} else {
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\n\r\f>", rune(text[i])) {
+				i++
+			}
+			value = text[start:i]
+		}
+
+		if _, ok := tkn.attributes[attribute]; !ok {
+			tkn.attributes[attribute] = html.UnescapeString(value)
+		}
+	}
+
+	return tkn, len(text)
+}
+
+// htmlVoidElements are the elements that have no content nor end tag.
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// htmlBlockElements are the elements that start and end paragraphs.
+var htmlBlockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true, "caption": true,
+	"center": true, "dd": true, "details": true, "dialog": true, "div": true, "dl": true, "dt": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hgroup": true, "html": true, "legend": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "summary": true, "table": true, "tbody": true, "td": true,
+	"tfoot": true, "th": true, "thead": true, "tr": true, "ul": true,
+}
+
+// htmlSkippedElements are the elements whose content is not displayed.
+var htmlSkippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true, "noscript": true, "select": true,
+	"textarea": true, "object": true, "iframe": true, "svg": true, "math": true,
+}
+
+// htmlImpliedEnds gives for elements that close an open element of the same
+// kinds, such as a list item closing the previous one, the elements at which
+// the search for it stops.
+var htmlImpliedEnds = map[string]struct {
+	closes []string
+	stops  []string
+}{
+	"li":    {[]string{"li"}, []string{"ul", "ol", "table"}},
+	"dt":    {[]string{"dt", "dd"}, []string{"dl", "table"}},
+	"dd":    {[]string{"dt", "dd"}, []string{"dl", "table"}},
+	"tr":    {[]string{"tr", "td", "th"}, []string{"table"}},
+	"td":    {[]string{"td", "th"}, []string{"tr", "table"}},
+	"th":    {[]string{"td", "th"}, []string{"tr", "table"}},
+	"thead": {[]string{"thead", "tbody", "tfoot"}, []string{"table"}},
+	"tbody": {[]string{"thead", "tbody", "tfoot"}, []string{"table"}},
+	"tfoot": {[]string{"thead", "tbody", "tfoot"}, []string{"table"}},
+}
+
+// htmlElement is an open element, with the formatting it gives its content.
+type htmlElement struct {
+	name    string
+	painter Painter
+	format  ParagraphFormat
+
+	skip         bool
+	preformatted bool
+
+	// list table entry and level of the lists, plus one, and whether the
+	// first paragraph of a list item has been numbered
+	list      TableRef
+	listLevel int
+	numbered  bool
+
+	// table is set on tables other than nested ones
+	table bool
+}
+
+// htmlCell is a cell of a table being read, spanning columns and rows.
+type htmlCell struct {
+	columns    int
+	rows       int
+	width      int
+	background TableRef
+	paragraphs []Paragraph
+}
+
+type htmlRow struct {
+	header bool
+	cells  []*htmlCell
+}
+
+// htmlReader builds a document from HTML tokens.
+type htmlReader struct {
+	doc      RtfDocument
+	stack    []htmlElement
+	elements []BodyElement
+
+	// paragraph in progress, nil between paragraphs
+	paragraph *Paragraph
+
+	// rows of the table in progress, and its cell in progress
+	rows []*htmlRow
+	cell *htmlCell
+}
+
+func (h *htmlReader) top() *htmlElement {
+	return &h.stack[len(h.stack)-1]
+}
+
+// skipping reports whether content is inside an element that is not
+// displayed.
+func (h *htmlReader) skipping() bool {
+	return h.top().skip
+}
+
+func (h *htmlReader) start(tkn htmlToken) {
+	name := tkn.name
+
+	if name == "meta" {
+		h.meta(tkn.attributes)
+		return
+	}
+	if h.skipping() {
+		if !htmlVoidElements[name] && !tkn.selfClosing {
+			h.push(htmlElement{name: name, skip: true})
+		}
+		return
+	}
+
+	if implied, ok := htmlImpliedEnds[name]; ok {
+		h.closeImplied(implied.closes, implied.stops)
+	}
+	if htmlBlockElements[name] {
+		h.closeImplied([]string{"p"}, []string{"table", "td", "th", "li", "blockquote", "div"})
+		h.endParagraph()
+	}
+
+	switch name {
+	case "br":
+		h.addRun(StyleBlock{Kind: BlockKindLine, Text: blockKindText(BlockKindLine), Painter: h.top().painter})
+		return
+	case "img":
+		h.image(tkn.attributes)
+		return
+	case "hr":
+		h.endParagraph()
+		return
+	}
+	if htmlVoidElements[name] {
+		return
+	}
+
+	element := *h.top()
+	element.name = name
+	element.numbered = false
+	element.table = false
+
+	h.applyElement(&element, tkn.attributes)
+	h.applyStyle(&element, parseCSSDeclarations(tkn.attributes["style"]))
+	h.push(element)
+
+	if tkn.selfClosing {
+		h.end(name)
+	}
+}
+
+// applyElement applies the formatting an element gives its content.
+func (h *htmlReader) applyElement(element *htmlElement, attributes map[string]string) {
+	painter := &element.painter
+	format := &element.format
+
+	switch name := element.name; name {
+	case "b", "strong", "th":
+		painter.Bold = true
+	case "i", "em", "cite", "var", "dfn", "address":
+		painter.Italic = true
+	case "u", "ins":
+		painter.Underline = true
+	case "s", "strike", "del":
+		painter.Strikethrough = true
+	case "sup":
+		painter.Superscript, painter.Subscript = true, false
+	case "sub":
+		painter.Subscript, painter.Superscript = true, false
+	case "code", "kbd", "samp", "tt":
+		painter.FontRef = h.fontRef("monospace")
+	case "pre":
+		painter.FontRef = h.fontRef("monospace")
+		element.preformatted = true
+	case "small":
+		painter.FontSize = max(painter.FontSize*5/6, 2)
+	case "big":
+		painter.FontSize = painter.FontSize * 6 / 5
+	case "mark":
+		painter.Highlight = h.doc.Header.ColorTable.ref(Color{R: 255, G: 255})
+	case "a":
+		if href := strings.TrimSpace(attributes["href"]); href != "" {
+			painter.Link = href
+		}
+	case "font":
+		if face := attributes["face"]; face != "" {
+			painter.FontRef = h.fontRef(face)
+		}
+		if color, ok := parseCSSColor(attributes["color"]); ok {
+			painter.ForegroundColor = h.doc.Header.ColorTable.ref(color)
+		}
+		if size, err := strconv.Atoi(strings.TrimSpace(attributes["size"])); err == nil {
+			sizes := []int{15, 20, 24, 27, 36, 48, 72}
+			painter.FontSize = sizes[min(max(size, 1), 7)-1]
+		}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(name[1] - '0')
+		style := h.doc.Header.Stylesheet.heading(level)
+		painter.Bold = style.Painter.Bold
+		painter.FontSize = style.Painter.FontSize
+		list, listLevel := format.List, format.ListLevel
+		*format = style.Paragraph
+		format.Alignment = element.format.Alignment
+		format.List, format.ListLevel = list, listLevel
+	case "blockquote", "dd":
+		format.LeftIndent += 720
+	case "center":
+		format.Alignment = AlignmentCenter
+	case "ul", "ol":
+		h.list(element, attributes)
+	case "li":
+		if element.listLevel > 0 {
+			format.List, format.ListLevel = element.list, element.listLevel-1
+			format.LeftIndent, format.FirstLineIndent = 720*element.listLevel, -360
+		}
+	case "table":
+		element.table = !h.inTable()
+		if element.table {
+			h.rows = []*htmlRow{}
+		}
+		format.Alignment = AlignmentLeft
+	case "tr":
+		if h.tableDepth() == 1 {
+			h.rows = append(h.rows, &htmlRow{header: h.inElement("thead")})
+		}
+	}
+
+	if name := element.name; (name == "td" || name == "th") && h.tableDepth() == 1 {
+		h.startCell(attributes)
+	}
+
+	if htmlSkippedElements[element.name] || element.name == "title" {
+		element.skip = true
+	}
+
+	if align := strings.ToLower(attributes["align"]); align != "" && htmlBlockElements[element.name] {
+		format.Alignment = cssAlignment(align, format.Alignment)
+	}
+}
+
+// list sets up a list element: outermost lists add an entry to the list
+// table, and nested lists set the format of their level of it.
+func (h *htmlReader) list(element *htmlElement, attributes map[string]string) {
+	if element.listLevel == 0 {
+		element.list = h.doc.Header.Lists.add(false)
+	}
+
+	element.listLevel = min(element.listLevel+1, 9)
+	if element.name == "ul" {
+		return
+	}
+
+	level := &h.doc.Header.Lists[element.list].Levels[element.listLevel-1]
+	level.Format = ListFormatDecimal
+	level.Text = "%" + strconv.Itoa(element.listLevel) + "."
+	switch attributes["type"] {
+	case "a":
+		level.Format = ListFormatLowerLetter
+	case "A":
+		level.Format = ListFormatUpperLetter
+	case "i":
+		level.Format = ListFormatLowerRoman
+	case "I":
+		level.Format = ListFormatUpperRoman
+	}
+	if start, err := strconv.Atoi(strings.TrimSpace(attributes["start"])); err == nil {
+		level.Start = start
+	}
+}
+
+// applyStyle applies the declarations of a style attribute.
+func (h *htmlReader) applyStyle(element *htmlElement, declarations map[string]string) {
+	painter := &element.painter
+
+	for property, value := range declarations {
+		lower := strings.ToLower(value)
+
+		switch property {
+		case "color":
+			if color, ok := parseCSSColor(value); ok {
+				painter.ForegroundColor = h.doc.Header.ColorTable.ref(color)
+			}
+		case "background-color", "background":
+			if color, ok := parseCSSColor(value); ok && element.name != "td" && element.name != "th" {
+				painter.BackgroundColor = h.doc.Header.ColorTable.ref(color)
+			}
+		case "font-family":
+			if family := strings.TrimSpace(strings.Split(value, ",")[0]); family != "" {
+				painter.FontRef = h.fontRef(family)
+			}
+		case "font-size":
+			if size, ok := parseCSSFontSize(lower, painter.FontSize); ok {
+				painter.FontSize = size
+			}
+		case "font-weight":
+			weight, err := strconv.Atoi(lower)
+			painter.Bold = lower == "bold" || lower == "bolder" || (err == nil && weight >= 600)
+		case "font-style":
+			painter.Italic = lower == "italic" || lower == "oblique"
+		case "font-variant":
+			painter.SmallCaps = lower == "small-caps"
+		case "text-decoration", "text-decoration-line":
+			painter.Underline = strings.Contains(lower, "underline")
+			painter.Strikethrough = strings.Contains(lower, "line-through")
+		case "vertical-align":
+			painter.Superscript = lower == "super"
+			painter.Subscript = lower == "sub"
+		case "display", "visibility":
+			painter.Hidden = lower == "none" || lower == "hidden"
+		case "text-align":
+			element.format.Alignment = cssAlignment(lower, element.format.Alignment)
+		}
+	}
+}
+
+func cssAlignment(value string, current Alignment) Alignment {
+	switch value {
+	case "left", "start":
+		return AlignmentLeft
+	case "center", "middle":
+		return AlignmentCenter
+	case "right", "end":
+		return AlignmentRight
+	case "justify":
+		return AlignmentJustify
+	default:
+		return current
+	}
+}
+
+// htmlGenericFonts are the fonts used for the generic CSS font families.
+var htmlGenericFonts = map[string]Font{
+	"serif":      {Name: "Times New Roman", FontFamily: FontFamilyRoman},
+	"sans-serif": {Name: "Arial", FontFamily: FontFamilySwiss},
+	"monospace":  {Name: "Courier New", FontFamily: FontFamilyModern, Pitch: FontPitchFixed},
+	"cursive":    {Name: "Comic Sans MS", FontFamily: FontFamilyScript},
+	"fantasy":    {Name: "Impact", FontFamily: FontFamilyDecor},
+}
+
+// fontRef returns the font table entry of a CSS font family, adding it if
+// needed.
+func (h *htmlReader) fontRef(family string) TableRef {
+	return h.doc.Header.FontTable.ref(familyFont(family))
+}
+
+// familyFont returns the font of a CSS font family. The fonts of generic
+// families are given their family.
+func familyFont(family string) Font {
+	family = strings.Trim(strings.TrimSpace(family), `"'`)
+
+	if font, ok := htmlGenericFonts[strings.ToLower(family)]; ok {
+		return font
+	}
+	for _, generic := range htmlGenericFonts {
+		if strings.EqualFold(generic.Name, family) {
+			return generic
+		}
+	}
+
+	return Font{Name: family}
+}
+
+func (h *htmlReader) meta(attributes map[string]string) {
+	info := &h.doc.InformationGroup
+	content := strings.TrimSpace(attributes["content"])
+
+	switch strings.ToLower(attributes["name"]) {
+	case "author":
+		info.Author = content
+	case "description":
+		info.Comment = content
+	case "keywords":
+		info.Keywords = content
+	case "subject":
+		info.Subject = content
+	}
+}
+
+func (h *htmlReader) push(element htmlElement) {
+	h.stack = append(h.stack, element)
+}
+
+// pop closes the innermost open element.
+func (h *htmlReader) pop() {
+	element := h.stack[len(h.stack)-1]
+	if htmlBlockElements[element.name] && !element.skip {
+		h.endParagraph()
+	}
+
+	h.stack = h.stack[:len(h.stack)-1]
+	if element.skip {
+		return
+	}
+
+	switch element.name {
+	case "td", "th":
+		if h.tableDepth() == 0 || (h.tableDepth() == 1 && !h.inElement("td") && !h.inElement("th")) {
+			h.cell = nil
+		}
+	case "table":
+		if element.table {
+			h.endTable()
+		}
+	}
+}
+
+// end closes the innermost open element of the given name and those inside
+// it. End tags of elements that are not open are ignored.
+func (h *htmlReader) end(name string) {
+	if name == "br" {
+		h.start(htmlToken{kind: htmlTokenStart, name: name})
+		return
+	}
+
+	for i := len(h.stack) - 1; i > 0; i-- {
+		if h.stack[i].name == name {
+			for len(h.stack) > i {
+				h.pop()
+			}
+			return
+		}
+	}
+}
+
+// closeImplied closes an open element of one of the given names, unless one
+// of the stopping elements is found first.
+func (h *htmlReader) closeImplied(names []string, stops []string) {
+	for i := len(h.stack) - 1; i > 0; i-- {
+		name := h.stack[i].name
+		if containsString(stops, name) {
+			return
+		}
+		if containsString(names, name) {
+			for len(h.stack) > i {
+				h.pop()
+			}
+			return
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inElement reports whether an element of the given name is open.
+func (h *htmlReader) inElement(name string) bool {
+	for i := range h.stack {
+		if h.stack[i].name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tableDepth returns the number of open tables.
+func (h *htmlReader) tableDepth() int {
+	depth := 0
+	for i := range h.stack {
+		if h.stack[i].name == "table" {
+			depth++
+		}
+	}
+
+	return depth
+}
+
+func (h *htmlReader) inTable() bool {
+	return h.tableDepth() > 0
+}
+
+// text adds text, whose white space is collapsed outside preformatted
+// elements.
+func (h *htmlReader) text(text string) {
+	if h.skipping() {
+		if h.top().name == "title" {
+			h.doc.InformationGroup.Title += strings.Join(strings.Fields(text), " ")
+		}
+		return
+	}
+
+	painter := h.top().painter
+
+	if h.top().preformatted {
+		// a newline right after the start tag is not part of the content
+		if h.paragraph == nil {
+			text = strings.TrimPrefix(strings.TrimPrefix(text, "\r"), "\n")
+		}
+		for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+			if i > 0 {
+				h.addRun(StyleBlock{Kind: BlockKindLine, Text: blockKindText(BlockKindLine), Painter: painter})
+			}
+			if line != "" {
+				h.addRun(StyleBlock{Kind: BlockKindText, Text: line, Painter: painter})
+			}
+		}
+		return
+	}
+
+	collapsed := strings.Join(strings.Fields(text), " ")
+	if strings.TrimLeft(text, " \t\n\r\f") != text {
+		collapsed = " " + collapsed
+	}
+	if len(collapsed) > 1 && strings.TrimRight(text, " \t\n\r\f") != text {
+		collapsed += " "
+	}
+
+	if strings.HasPrefix(collapsed, " ") && h.atLineStart() {
+		collapsed = collapsed[1:]
+	}
+	if collapsed == "" {
+		return
+	}
+
+	h.addRun(StyleBlock{Kind: BlockKindText, Text: collapsed, Painter: painter})
+}
+
+// atLineStart reports whether the paragraph in progress, if any, is empty or
+// ends with a space or a line break, after which spaces are dropped.
+func (h *htmlReader) atLineStart() bool {
+	if h.paragraph == nil || len(h.paragraph.Runs) == 0 {
+		return true
+	}
+
+	last := h.paragraph.Runs[len(h.paragraph.Runs)-1]
+	return last.Kind == BlockKindLine || (last.Kind == BlockKindText && strings.HasSuffix(last.Text, " "))
+}
+
+// addRun adds a run to the paragraph in progress, starting one if needed.
+func (h *htmlReader) addRun(run StyleBlock) {
+	if h.paragraph == nil {
+		h.startParagraph()
+	}
+
+	h.paragraph.Runs = appendRun(h.paragraph.Runs, run)
+}
+
+// startParagraph starts a paragraph with the format given by the open
+// elements. Only the first paragraph of a list item is numbered.
+func (h *htmlReader) startParagraph() {
+	format := h.top().format
+
+	for i := len(h.stack) - 1; i > 0; i-- {
+		if h.stack[i].name != "li" {
+			continue
+		}
+		if h.stack[i].numbered {
+			format.List, format.FirstLineIndent = 0, 0
+		}
+		h.stack[i].numbered = true
+		break
+	}
+
+	if h.inTable() {
+		format.InTable = true
+		if h.cell == nil {
+			h.startCell(map[string]string{})
+		}
+	}
+
+	h.paragraph = &Paragraph{Format: format}
+}
+
+// endParagraph ends the paragraph in progress, dropping its trailing spaces.
+func (h *htmlReader) endParagraph() {
+	paragraph := h.paragraph
+	if paragraph == nil {
+		return
+	}
+	h.paragraph = nil
+
+	for len(paragraph.Runs) > 0 {
+		last := &paragraph.Runs[len(paragraph.Runs)-1]
+		if last.Kind != BlockKindText {
+			break
+		}
+		last.Text = strings.TrimRight(last.Text, " ")
+		if last.Text != "" {
+			break
+		}
+		paragraph.Runs = paragraph.Runs[:len(paragraph.Runs)-1]
+	}
+
+	mark := h.top().painter
+	mark.Link = ""
+	paragraph.Mark = &mark
+
+	if paragraph.Format.InTable && h.cell != nil {
+		h.cell.paragraphs = append(h.cell.paragraphs, *paragraph)
+		return
+	}
+
+	paragraph.Format.InTable = false
+	h.elements = append(h.elements, paragraph)
+}
+
+// image adds the picture of an image embedded in a data URL, sized as given
+// by its width and height.
+func (h *htmlReader) image(attributes map[string]string) {
+	src := strings.TrimSpace(attributes["src"])
+	if !strings.HasPrefix(strings.ToLower(src), "data:") {
+		return
+	}
+
+	header, payload, ok := strings.Cut(src[len("data:"):], ",")
+	if !ok || !strings.HasSuffix(strings.ToLower(header), ";base64") {
+		return
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(payload), ""))
+	if err != nil {
+		return
+	}
+
+	mimeType := strings.ToLower(strings.TrimSuffix(strings.ToLower(header), ";base64"))
+	extension := strings.TrimPrefix(strings.TrimPrefix(mimeType, "image/"), "x-")
+	picture, ok := pictureFromFile(data, extension, 0, 0)
+	if !ok {
+		return
+	}
+
+	declarations := parseCSSDeclarations(attributes["style"])
+	width, hasWidth := parseCSSLength(attributes["width"])
+	if value, ok := parseCSSLength(declarations["width"]); ok {
+		width, hasWidth = value, true
+	}
+	height, hasHeight := parseCSSLength(attributes["height"])
+	if value, ok := parseCSSLength(declarations["height"]); ok {
+		height, hasHeight = value, true
+	}
+
+	switch {
+	case hasWidth && hasHeight:
+		picture.GoalWidth, picture.GoalHeight = width, height
+	case hasWidth && picture.GoalWidth > 0:
+		picture.GoalWidth, picture.GoalHeight = width, picture.GoalHeight*width/picture.GoalWidth
+	case hasHeight && picture.GoalHeight > 0:
+		picture.GoalWidth, picture.GoalHeight = picture.GoalWidth*height/picture.GoalHeight, height
+	}
+
+	h.doc.Pictures = append(h.doc.Pictures, picture)
+	h.addRun(StyleBlock{Kind: BlockKindPicture, PictureIndex: len(h.doc.Pictures) - 1, Painter: h.top().painter})
+}
+
+// startCell starts a cell of the table in progress, in a new row if there is
+// none.
+func (h *htmlReader) startCell(attributes map[string]string) {
+	if len(h.rows) == 0 {
+		h.rows = append(h.rows, &htmlRow{})
+	}
+	row := h.rows[len(h.rows)-1]
+
+	cell := &htmlCell{columns: 1, rows: 1}
+	if columns, err := strconv.Atoi(strings.TrimSpace(attributes["colspan"])); err == nil && columns > 1 {
+		cell.columns = min(columns, 63)
+	}
+	if rows, err := strconv.Atoi(strings.TrimSpace(attributes["rowspan"])); err == nil && rows > 1 {
+		cell.rows = rows
+	}
+
+	declarations := parseCSSDeclarations(attributes["style"])
+	if width, ok := parseCSSLength(attributes["width"]); ok {
+		cell.width = width
+	}
+	if width, ok := parseCSSLength(declarations["width"]); ok {
+		cell.width = width
+	}
+
+	background := attributes["bgcolor"]
+	for _, property := range []string{"background", "background-color"} {
+		if value, ok := declarations[property]; ok {
+			background = value
+		}
+	}
+	if color, ok := parseCSSColor(background); ok {
+		cell.background = h.doc.Header.ColorTable.ref(color)
+	}
+
+	row.cells = append(row.cells, cell)
+	h.cell = cell
+}
+
+// htmlTableWidth is the width in twips of tables, 6.5 inches, shared by the
+// columns whose width is not given.
+const htmlTableWidth = 9360
+
+// endTable adds the table in progress to the document. Cells are laid out
+// on a grid as browsers do, cells spanning several rows being merged with
+// those of the rows below.
+func (h *htmlReader) endTable() {
+	rows := h.rows
+	h.rows, h.cell = nil, nil
+
+	// the cell starting at each position of the grid, and the cell covering
+	// the positions it spans
+	type slot struct {
+		cell  *htmlCell
+		first bool
+		row   int
+	}
+	grid := [][]slot{}
+	columns := 0
+
+	for r, row := range rows {
+		for len(grid) <= r {
+			grid = append(grid, []slot{})
+		}
+
+		column := 0
+		for _, cell := range row.cells {
+			for column < len(grid[r]) && grid[r][column].cell != nil {
+				column++
+			}
+
+			for dr := 0; dr < min(cell.rows, len(rows)-r); dr++ {
+				for len(grid) <= r+dr {
+					grid = append(grid, []slot{})
+				}
+				for dc := 0; dc < cell.columns; dc++ {
+					for len(grid[r+dr]) <= column+dc {
+						grid[r+dr] = append(grid[r+dr], slot{})
+					}
+					grid[r+dr][column+dc] = slot{cell: cell, first: dr == 0 && dc == 0, row: r}
+				}
+			}
+
+			column += cell.columns
+			columns = max(columns, column)
+		}
+	}
+
+	if columns == 0 {
+		return
+	}
+
+	widths := make([]int, columns)
+	given := 0
+	for _, row := range grid {
+		for c, s := range row {
+			if s.first && s.cell.columns == 1 && s.cell.width > 0 && widths[c] == 0 {
+				widths[c] = s.cell.width
+				given += s.cell.width
+			}
+		}
+	}
+	missing := 0
+	for _, width := range widths {
+		if width == 0 {
+			missing++
+		}
+	}
+	for c := range widths {
+		if widths[c] == 0 {
+			widths[c] = max((htmlTableWidth-given)/missing, 360)
+		}
+	}
+
+	table := &Table{}
+	for r, row := range grid {
+		format := RowFormat{Gap: 108, LeftIndent: -108, Header: r < len(rows) && rows[r].header}
+		cells := []TableCell{}
+		right := format.LeftIndent
+
+		for c := 0; c < columns; c++ {
+			right += widths[c]
+			cell := CellFormat{Right: right}
+
+			var s slot
+			if c < len(row) {
+				s = row[c]
+			}
+			if s.cell == nil {
+				format.Cells = append(format.Cells, cell)
+				cells = append(cells, TableCell{Paragraphs: h.cellParagraphs(nil)})
+				continue
+			}
+
+			// the position of the cell within those it spans
+			startColumn := c
+			for startColumn > 0 && startColumn-1 < len(row) && row[startColumn-1].cell == s.cell {
+				startColumn--
+			}
+			if s.cell.columns > 1 {
+				cell.HorizontalMerge = CellMergePrevious
+				if c == startColumn {
+					cell.HorizontalMerge = CellMergeFirst
+				}
+			}
+			if s.cell.rows > 1 {
+				cell.VerticalMerge = CellMergePrevious
+				if s.row == r {
+					cell.VerticalMerge = CellMergeFirst
+				}
+			}
+			cell.Background = s.cell.background
+
+			paragraphs := []Paragraph(nil)
+			if s.first {
+				paragraphs = s.cell.paragraphs
+			}
+			format.Cells = append(format.Cells, cell)
+			cells = append(cells, TableCell{Paragraphs: h.cellParagraphs(paragraphs)})
+		}
+
+		mark := h.top().painter
+		mark.Link = ""
+		table.Rows = append(table.Rows, TableRow{Format: format, Cells: cells, Mark: &mark})
+	}
+
+	h.elements = append(h.elements, table)
+}
+
+// cellParagraphs returns the paragraphs of a cell, adding an empty one to
+// cells that have none.
+func (h *htmlReader) cellParagraphs(paragraphs []Paragraph) []Paragraph {
+	if len(paragraphs) > 0 {
+		return paragraphs
+	}
+
+	mark := h.top().painter
+	mark.Link = ""
+
+	return []Paragraph{{Format: ParagraphFormat{InTable: true}, Mark: &mark}}
+}
+
+// parseCSSDeclarations reads the declarations of a style attribute into a
+// map of the lowercased property names to their values.
+func parseCSSDeclarations(style string) map[string]string {
+	declarations := map[string]string{}
+
+	for _, declaration := range strings.Split(style, ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		declarations[strings.ToLower(strings.TrimSpace(property))] = value
+	}
+
+	return declarations
+}
+
+// cssNamedColors are the colors of the most common CSS color names.
+var cssNamedColors = map[string]Color{
+	"black": {}, "white": {R: 255, G: 255, B: 255}, "red": {R: 255}, "lime": {G: 255}, "blue": {B: 255},
+	"yellow": {R: 255, G: 255}, "cyan": {G: 255, B: 255}, "aqua": {G: 255, B: 255},
+	"magenta": {R: 255, B: 255}, "fuchsia": {R: 255, B: 255}, "green": {G: 128},
+	"maroon": {R: 128}, "navy": {B: 128}, "olive": {R: 128, G: 128}, "purple": {R: 128, B: 128},
+	"teal": {G: 128, B: 128}, "gray": {R: 128, G: 128, B: 128}, "grey": {R: 128, G: 128, B: 128},
+	"silver": {R: 192, G: 192, B: 192}, "orange": {R: 255, G: 165},
+}
+
+// parseCSSColor reads a color written in hexadecimal, with rgb() or by name.
+func parseCSSColor(value string) (Color, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if color, ok := cssNamedColors[value]; ok {
+		return color, true
+	}
+
+	if strings.HasPrefix(value, "#") {
+		digits := value[1:]
+		if len(digits) == 3 || len(digits) == 4 {
+			digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+		}
+		if len(digits) == 8 {
+			digits = digits[:6]
+		}
+		components, err := hexDecode(digits)
+		if err != nil || len(components) != 3 {
+			return Color{}, false
+		}
+		return Color{R: int(components[0]), G: int(components[1]), B: int(components[2])}, true
+	}
+
+	for _, function := range []string{"rgb(", "rgba("} {
+		if !strings.HasPrefix(value, function) || !strings.HasSuffix(value, ")") {
+			continue
+		}
+
+		arguments := strings.FieldsFunc(value[len(function):len(value)-1], func(c rune) bool {
+			return c == ',' || c == ' ' || c == '/'
+		})
+		if len(arguments) < 3 {
+			return Color{}, false
+		}
+
+		components := [3]int{}
+		for i := range components {
+			argument := arguments[i]
+			scale := 1.0
+			if strings.HasSuffix(argument, "%") {
+				argument, scale = strings.TrimSuffix(argument, "%"), 2.55
+			}
+			number, err := strconv.ParseFloat(argument, 64)
+			if err != nil {
+				return Color{}, false
+			}
+			components[i] = clampColorComponent(int(math.Round(number * scale)))
+		}
+		return Color{R: components[0], G: components[1], B: components[2]}, true
+	}
+
+	return Color{}, false
+}
+
+// cssFontSizes are the font sizes of the CSS keywords in half-points.
+var cssFontSizes = map[string]int{
+	"xx-small": 14, "x-small": 15, "small": 20, "medium": 24, "large": 27,
+	"x-large": 36, "xx-large": 48, "xxx-large": 72,
+}
+
+// maxCSSFontSize is the largest font size in half-points, that of 1638
+// points which word processors accept.
+const maxCSSFontSize = 3276
+
+// parseCSSFontSize reads a font size in half-points, relative sizes being
+// relative to the current one. Sizes are kept within those word processors
+// accept.
+func parseCSSFontSize(value string, current int) (int, bool) {
+	if size, ok := cssFontSizes[value]; ok {
+		return size, true
+	}
+
+	switch value {
+	case "smaller":
+		return max(current*5/6, 2), true
+	case "larger":
+		return min(current*6/5, maxCSSFontSize), true
+	}
+
+	for _, unit := range []struct {
+		suffix string
+		scale  float64
+	}{
+		{"pt", 2},
+		{"px", 1.5},
+		{"rem", 24},
+		{"em", float64(current)},
+		{"%", float64(current) / 100},
+	} {
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), 64)
+		if err != nil || number <= 0 {
+			return 0, false
+		}
+		return int(math.Max(math.Min(math.Round(number*unit.scale), maxCSSFontSize), 1)), true
+	}
+
+	return 0, false
+}
+
+// parseCSSLength reads a length in twips, given in pixels, which plain
+// numbers are, or in points, inches or centimeters.
+func parseCSSLength(value string) (int, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	for _, unit := range []struct {
+		suffix string
+		scale  float64
+	}{
+		{"px", 15},
+		{"pt", 20},
+		{"in", 1440},
+		{"cm", 1440 / 2.54},
+		{"mm", 144 / 2.54},
+		{"", 15},
+	} {
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(strings.TrimSuffix(value, unit.suffix), 64)
+		if err != nil || number <= 0 {
+			return 0, false
+		}
+		return int(math.Round(number * unit.scale)), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,271 @@
+package gortf
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	content := `<!DOCTYPE html><html><head><title>Tom &amp; Jerry</title>` +
+		`<meta name="author" content="Ann"><style>p { color: red }</style></head><body>` +
+		`<h1 style="text-align: center">Title</h1>` +
+		"<p>Some  <b>bold</b>\n<span style=\"color: #f00; font-family: 'Arial', sans-serif; font-size: 14pt\">red</span><br>" +
+		`<a href="https://example.com/?a=1&amp;b=2">link</a> H<sub>2</sub>O <!-- comment -->` +
+		`<p>Call <code>f()</code>` +
+		`<ul><li>One<li>Two<ol type="a" start="3"><li>Sub</ol></ul>` +
+		`<blockquote>Quoted</blockquote>` +
+		"<pre>\nx  y\nz</pre>" +
+		`</body></html>`
+
+	doc, err := ParseHTML(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := Painter{FontSize: 24}
+	heading := Painter{Bold: true, FontSize: 32}
+	red := Painter{FontRef: 1, FontSize: 28, ForegroundColor: 1}
+	code := Painter{FontRef: 2, FontSize: 24}
+
+	expected := []Section{{
+		Elements: []BodyElement{
+			&Paragraph{
+				Format: ParagraphFormat{Style: 1, Alignment: AlignmentCenter, SpaceBefore: 240, SpaceAfter: 60, KeepWithNext: true},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "Title", Painter: heading}},
+				Mark:   &heading,
+			},
+			&Paragraph{
+				Runs: []StyleBlock{
+					{Kind: BlockKindText, Text: "Some ", Painter: body},
+					{Kind: BlockKindText, Text: "bold", Painter: Painter{Bold: true, FontSize: 24}},
+					{Kind: BlockKindText, Text: " ", Painter: body},
+					{Kind: BlockKindText, Text: "red", Painter: red},
+					{Kind: BlockKindLine, Text: "\n", Painter: body},
+					{Kind: BlockKindText, Text: "link", Painter: Painter{FontSize: 24, Link: "https://example.com/?a=1&b=2"}},
+					{Kind: BlockKindText, Text: " H", Painter: body},
+					{Kind: BlockKindText, Text: "2", Painter: Painter{FontSize: 24, Subscript: true}},
+					{Kind: BlockKindText, Text: "O", Painter: body},
+				},
+				Mark: &body,
+			},
+			&Paragraph{
+				Runs: []StyleBlock{
+					{Kind: BlockKindText, Text: "Call ", Painter: body},
+					{Kind: BlockKindText, Text: "f()", Painter: code},
+				},
+				Mark: &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{List: 1, LeftIndent: 720, FirstLineIndent: -360},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "One", Painter: body}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{List: 1, LeftIndent: 720, FirstLineIndent: -360},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "Two", Painter: body}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{List: 1, ListLevel: 1, LeftIndent: 1440, FirstLineIndent: -360},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "Sub", Painter: body}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{LeftIndent: 720},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "Quoted", Painter: body}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Runs: []StyleBlock{
+					{Kind: BlockKindText, Text: "x  y", Painter: code},
+					{Kind: BlockKindLine, Text: "\n", Painter: code},
+					{Kind: BlockKindText, Text: "z", Painter: code},
+				},
+				Mark: &code,
+			},
+		},
+	}}
+
+	actual := doc.Sections()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	expectedFonts := FontTable{
+		0: {Name: "Times New Roman", FontFamily: FontFamilyRoman},
+		1: {Name: "Arial", FontFamily: FontFamilySwiss},
+		2: {Name: "Courier New", FontFamily: FontFamilyModern, Pitch: FontPitchFixed},
+	}
+	if !reflect.DeepEqual(expectedFonts, doc.Header.FontTable) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedFonts, doc.Header.FontTable)
+	}
+
+	expectedColors := ColorTable{0: {Auto: true}, 1: {R: 255}}
+	if !reflect.DeepEqual(expectedColors, doc.Header.ColorTable) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedColors, doc.Header.ColorTable)
+	}
+
+	if style, ok := doc.Header.Stylesheet["heading 1"]; !ok || style.Number != 1 || len(doc.Header.Stylesheet) != 2 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Normal and heading 1 styles", doc.Header.Stylesheet)
+	}
+
+	levels := doc.Header.Lists[1].Levels
+	if len(levels) != 9 || levels[0].Format != ListFormatBullet || levels[0].Text != "•" ||
+		levels[1].Format != ListFormatLowerLetter || levels[1].Start != 3 || levels[1].Text != "%2." {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a bulleted list with a lettered second level", levels)
+	}
+
+	if doc.InformationGroup.Title != "Tom & Jerry" || doc.InformationGroup.Author != "Ann" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Tom & Jerry by Ann", doc.InformationGroup)
+	}
+}
+
+func TestParseHTMLTable(t *testing.T) {
+	content := `<table><thead><tr><th colspan="2" bgcolor="#0000ff">H</th></tr></thead>` +
+		`<tr><td rowspan=2 style="width: 100pt">a<td>b</tr><tr><td>c<p>d</td></tr></table>`
+
+	doc, err := ParseHTML(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := Painter{FontSize: 24}
+	bold := Painter{Bold: true, FontSize: 24}
+	cell := ParagraphFormat{InTable: true}
+	text := func(text string, painter Painter) []Paragraph {
+		return []Paragraph{{Format: cell, Runs: []StyleBlock{{Kind: BlockKindText, Text: text, Painter: painter}}, Mark: &painter}}
+	}
+	empty := []Paragraph{{Format: cell, Mark: &body}}
+
+	expected := []Section{{
+		Elements: []BodyElement{&Table{Rows: []TableRow{
+			{
+				Format: RowFormat{Gap: 108, LeftIndent: -108, Header: true, Cells: []CellFormat{
+					{Right: 1892, HorizontalMerge: CellMergeFirst, Background: 1},
+					{Right: 9252, HorizontalMerge: CellMergePrevious, Background: 1},
+				}},
+				Cells: []TableCell{{Paragraphs: text("H", bold)}, {Paragraphs: empty}},
+				Mark:  &body,
+			},
+			{
+				Format: RowFormat{Gap: 108, LeftIndent: -108, Cells: []CellFormat{
+					{Right: 1892, VerticalMerge: CellMergeFirst},
+					{Right: 9252},
+				}},
+				Cells: []TableCell{{Paragraphs: text("a", body)}, {Paragraphs: text("b", body)}},
+				Mark:  &body,
+			},
+			{
+				Format: RowFormat{Gap: 108, LeftIndent: -108, Cells: []CellFormat{
+					{Right: 1892, VerticalMerge: CellMergePrevious},
+					{Right: 9252},
+				}},
+				Cells: []TableCell{{Paragraphs: empty}, {Paragraphs: append(text("c", body), text("d", body)...)}},
+				Mark:  &body,
+			},
+		}}},
+	}}
+
+	actual := doc.Sections()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+}
+
+func TestParseHTMLImage(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), 0, 0, 0, 20, 0, 0, 0, 10)
+	source := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+
+	tests := map[string][2]int{
+		`<img src="` + source + `">`:                             {300, 150},
+		`<img src="` + source + `" width="40">`:                  {600, 300},
+		`<img src="` + source + `" style="height: 30px">`:        {900, 450},
+		`<img src="` + source + `" width="10" height="10">`:      {150, 150},
+		`<img src="https://example.com/a.png"><img src="data:">`: {},
+	}
+
+	for content, size := range tests {
+		doc, err := ParseHTML(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if size == [2]int{} {
+			if len(doc.Pictures) != 0 {
+				t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no picture", doc.Pictures)
+			}
+			continue
+		}
+
+		if len(doc.Pictures) != 1 || doc.Pictures[0].Format != PictureFormatPNG ||
+			doc.Pictures[0].GoalWidth != size[0] || doc.Pictures[0].GoalHeight != size[1] {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", size, doc.Pictures)
+		}
+	}
+}
+
+func TestTokenizeHTML(t *testing.T) {
+	content := `a &lt;b&gt;<P Class=x id='y' hidden data-v="1 &amp; 2"/><br>< c</p>` +
+		`<script>if (a < b) {}</script><!-- <p> --><?xml?>`
+
+	expected := []htmlToken{
+		{kind: htmlTokenText, text: "a <b>"},
+		{kind: htmlTokenStart, name: "p", attributes: map[string]string{"class": "x", "id": "y", "hidden": "", "data-v": "1 & 2"}, selfClosing: true},
+		{kind: htmlTokenStart, name: "br", attributes: map[string]string{}},
+		{kind: htmlTokenText, text: "<"},
+		{kind: htmlTokenText, text: " c"},
+		{kind: htmlTokenEnd, name: "p"},
+		{kind: htmlTokenStart, name: "script", attributes: map[string]string{}},
+		{kind: htmlTokenText, text: "if (a < b) {}"},
+		{kind: htmlTokenEnd, name: "script"},
+	}
+
+	actual := tokenizeHTML(content)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+}
+
+func TestParseCSS(t *testing.T) {
+	colors := map[string]Color{
+		"#f80":               {R: 255, G: 136},
+		"#0080FF":            {G: 128, B: 255},
+		"rgb(1, 2, 3)":       {R: 1, G: 2, B: 3},
+		"rgba(100%,0,0,0.5)": {R: 255},
+		"Navy":               {B: 128},
+	}
+	for value, expected := range colors {
+		if actual, ok := parseCSSColor(value); !ok || actual != expected {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+	if _, ok := parseCSSColor("#12"); ok {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "an invalid color", "#12")
+	}
+
+	sizes := map[string]int{"12pt": 24, "16px": 24, "1.5em": 36, "50%": 12, "2rem": 48, "large": 27, "smaller": 20,
+		"99999999999pt": maxCSSFontSize, "1e300px": maxCSSFontSize, "0.1pt": 1}
+	for value, expected := range sizes {
+		if actual, ok := parseCSSFontSize(value, 24); !ok || actual != expected {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+
+	if actual, ok := parseCSSFontSize("larger", maxCSSFontSize); !ok || actual != maxCSSFontSize {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", maxCSSFontSize, actual)
+	}
+
+	lengths := map[string]int{"10": 150, "10px": 150, "1in": 1440, "2.54cm": 1440, "3pt": 60}
+	for value, expected := range lengths {
+		if actual, ok := parseCSSLength(value); !ok || actual != expected {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+
+	expected := map[string]string{"color": "red", "font-weight": "bold"}
+	actual := parseCSSDeclarations(" Color : red ; font-weight: bold !important;;bad")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+}
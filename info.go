@@ -0,0 +1,279 @@
+package gortf
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserPropertyType is the type of a user-defined document property, as given
+// by \proptype.
+type UserPropertyType int
+
+const (
+	UserPropertyTypeInteger UserPropertyType = 3
+	UserPropertyTypeReal    UserPropertyType = 5
+	UserPropertyTypeBoolean UserPropertyType = 11
+	UserPropertyTypeText    UserPropertyType = 30
+	UserPropertyTypeDate    UserPropertyType = 64
+)
+
+func (u UserPropertyType) String() string {
+	switch u {
+	case UserPropertyTypeInteger:
+		return "Integer"
+	case UserPropertyTypeReal:
+		return "Real"
+	case UserPropertyTypeBoolean:
+		return "Boolean"
+	case UserPropertyTypeText:
+		return "Text"
+	case UserPropertyTypeDate:
+		return "Date"
+	default:
+		return "Unknown"
+	}
+}
+
+// UserProperty is a custom document property from the {\*\userprops} group.
+type UserProperty struct {
+	Name string           `json:"name"`
+	Type UserPropertyType `json:"type"`
+
+	// Value is an int, float64, bool, time.Time or string depending on Type.
+	// Values that cannot be converted to their declared type are kept as the
+	// original string.
+	Value interface{} `json:"value"`
+
+	// Link is the name of the bookmark the property is linked to, if any.
+	Link string `json:"link,omitempty"`
+}
+
+// userPropertyDateLayouts are the formats accepted for date properties.
+var userPropertyDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"1/2/2006",
+}
+
+func (r *RtfParser) parseInformationGroup(root *Group) RtfInformationGroup {
+	informationGroup := RtfInformationGroup{}
+
+	info := root.Find("info")
+	if info == nil {
+		info = &Group{}
+	}
+
+	for _, child := range info.Children {
+		switch node := child.(type) {
+		case Token:
+			r.parseInformationGroupControlWord(&informationGroup, node)
+
+		case *Group:
+			if node.Destination == "" {
+				// Word writes statistics as {\version2}{\edmins5}
+				for _, grandchild := range node.Children {
+					if tkn, ok := grandchild.(Token); ok {
+						r.parseInformationGroupControlWord(&informationGroup, tkn)
+					}
+				}
+				continue
+			}
+
+			r.parseInformationGroupEntry(&informationGroup, node)
+		}
+	}
+
+	// the user properties are found inside the information group as well
+	// as right after it
+	for _, path := range []string{"info/userprops", "userprops"} {
+		for _, userProperties := range root.FindAll(path) {
+			informationGroup.UserProperties = append(informationGroup.UserProperties, r.parseUserProperties(userProperties)...)
+		}
+	}
+
+	return informationGroup
+}
+
+func (r *RtfParser) parseInformationGroupControlWord(informationGroup *RtfInformationGroup, tkn Token) {
+	if tkn.Kind != TokenKindControlWord {
+		return
+	}
+
+	controlWord := controlWordFromToken(tkn)
+
+	switch controlWord.controlWordType {
+	case controlWordTypeInfoVersion:
+		informationGroup.Version = controlWord.parameter
+	case controlWordTypeInfoEditingMinutes:
+		informationGroup.EditingMinutes = controlWord.parameter
+	case controlWordTypeInfoNumberOfPages:
+		informationGroup.NumberOfPages = controlWord.parameter
+	case controlWordTypeInfoNumberOfWords:
+		informationGroup.NumberOfWords = controlWord.parameter
+	case controlWordTypeInfoNumberOfCharacters:
+		informationGroup.NumberOfCharacters = controlWord.parameter
+	case controlWordTypeInfoNumberOfCharactersWithSpaces:
+		informationGroup.NumberOfCharactersWithSpaces = controlWord.parameter
+	case controlWordTypeInfoInternalID:
+		informationGroup.InternalID = controlWord.parameter
+	}
+}
+
+func (r *RtfParser) parseInformationGroupEntry(informationGroup *RtfInformationGroup, entry *Group) {
+	controlWordType := getControlWordTypeFromPrefix(`\` + entry.Destination)
+
+	switch controlWordType {
+	case controlWordTypeInfoCreationTime:
+		informationGroup.CreationTime = parseInformationTime(entry)
+	case controlWordTypeInfoRevisionTime:
+		informationGroup.RevisionTime = parseInformationTime(entry)
+	case controlWordTypeInfoPrintTime:
+		informationGroup.LastPrintTime = parseInformationTime(entry)
+	case controlWordTypeInfoBackupTime:
+		informationGroup.BackupTime = parseInformationTime(entry)
+	}
+
+	text := r.textFromGroup(entry, r.codePage)
+
+	switch controlWordType {
+	case controlWordTypeInfoTitle:
+		informationGroup.Title = text
+	case controlWordTypeInfoSubject:
+		informationGroup.Subject = text
+	case controlWordTypeInfoAuthor:
+		informationGroup.Author = text
+	case controlWordTypeInfoManager:
+		informationGroup.Manager = text
+	case controlWordTypeInfoCompany:
+		informationGroup.Company = text
+	case controlWordTypeInfoOperator:
+		informationGroup.Operator = text
+	case controlWordTypeInfoCategory:
+		informationGroup.Category = text
+	case controlWordTypeInfoKeywords:
+		informationGroup.Keywords = text
+	case controlWordTypeInfoComment:
+		informationGroup.Comment = text
+	case controlWordTypeInfoDoccom:
+		informationGroup.DocumentComment = text
+	case controlWordTypeInfoHlinkBase:
+		informationGroup.BaseAddress = text
+	}
+}
+
+// parseInformationTime reads a time group such as
+// {\creatim\yr2024\mo3\dy14\hr9\min30}. RTF times carry no time zone, so they
+// are returned in UTC.
+func parseInformationTime(entry *Group) *time.Time {
+	year, month, day, hour, minute, second := 0, 1, 1, 0, 0, 0
+	found := false
+
+	for _, child := range entry.Children {
+		tkn, ok := child.(Token)
+		if !ok || tkn.Kind != TokenKindControlWord {
+			continue
+		}
+
+		switch getControlWordTypeFromPrefix(`\` + tkn.Name) {
+		case controlWordTypeInfoYear:
+			year = tkn.Param
+			found = true
+		case controlWordTypeInfoMonth:
+			month = tkn.Param
+		case controlWordTypeInfoDay:
+			day = tkn.Param
+		case controlWordTypeInfoHour:
+			hour = tkn.Param
+		case controlWordTypeInfoMinute:
+			minute = tkn.Param
+		case controlWordTypeInfoSecond:
+			second = tkn.Param
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	t := time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC)
+	return &t
+}
+
+// parseUserProperties reads the properties of a {\*\userprops} group, each
+// made of a {\propname} group, a \proptype control word and a {\staticval}
+// or {\linkval} group.
+func (r *RtfParser) parseUserProperties(userProperties *Group) []UserProperty {
+	properties := []UserProperty{}
+	values := []string{}
+
+	for _, child := range userProperties.Children {
+		switch node := child.(type) {
+		case Token:
+			if node.Kind == TokenKindControlWord && node.Name == "proptype" && len(properties) > 0 {
+				properties[len(properties)-1].Type = UserPropertyType(node.Param)
+			}
+
+		case *Group:
+			text := r.textFromGroup(node, r.codePage)
+
+			switch getControlWordTypeFromPrefix(`\` + node.Destination) {
+			case controlWordTypeUserPropertyName:
+				properties = append(properties, UserProperty{Name: text, Type: UserPropertyTypeText})
+				values = append(values, "")
+			case controlWordTypeUserPropertyStaticValue:
+				if len(values) > 0 {
+					values[len(values)-1] = text
+				}
+			case controlWordTypeUserPropertyLinkValue:
+				if len(properties) > 0 {
+					properties[len(properties)-1].Link = text
+				}
+			}
+		}
+	}
+
+	for i := range properties {
+		properties[i].Value = userPropertyValue(properties[i].Type, values[i])
+	}
+
+	return properties
+}
+
+func userPropertyValue(propertyType UserPropertyType, text string) interface{} {
+	trimmed := strings.TrimSpace(text)
+
+	switch propertyType {
+	case UserPropertyTypeInteger:
+		if value, err := strconv.Atoi(trimmed); err == nil {
+			return value
+		}
+	case UserPropertyTypeReal:
+		if !strings.Contains(trimmed, ".") {
+			// decimal comma
+			trimmed = strings.Replace(trimmed, ",", ".", 1)
+		}
+		if value, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			return value
+		}
+	case UserPropertyTypeBoolean:
+		switch strings.ToLower(trimmed) {
+		case "1", "-1", "true", "yes":
+			return true
+		case "0", "false", "no":
+			return false
+		}
+	case UserPropertyTypeDate:
+		for _, layout := range userPropertyDateLayouts {
+			if value, err := time.Parse(layout, trimmed); err == nil {
+				return value
+			}
+		}
+	}
+
+	return text
+}
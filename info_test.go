@@ -0,0 +1,73 @@
+package gortf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInformationGroup(t *testing.T) {
+	content := `{\rtf1\ansi\ansicpg1252{\fonttbl\f0\fswiss Helvetica;}{\colortbl;\red0\green0\blue0;}`
+	content += `{\*\generator Riched20;}{\info{\title The {\b Bold} Caf\'e9 \{draft\}}{\author Jane Doe}{\operator jd}`
+	content += `{\creatim\yr2024\mo3\dy14\hr9\min30}{\revtim\yr2024\mo3\dy15\hr17\min5\sec12}{\printim\yr1601\mo1\dy1}`
+	content += `{\version3}\edmins42\nofpages2\nofwords120\nofchars700\nofcharsws812\id1234}`
+	content += `{\*\userprops{\propname Client}\proptype30{\staticval ACME}{\propname Pages}\proptype3{\staticval 12}`
+	content += `{\propname Ratio}\proptype5{\staticval 0,5}{\propname Approved}\proptype11{\staticval 1}`
+	content += `{\propname Due}\proptype64{\staticval 2024-04-01}{\propname Section}\proptype30{\linkval intro}}`
+	content += `\f0 Body}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creationTime := time.Date(2024, 3, 14, 9, 30, 0, 0, time.UTC)
+	revisionTime := time.Date(2024, 3, 15, 17, 5, 12, 0, time.UTC)
+	lastPrintTime := time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expected := RtfInformationGroup{
+		Title:                        "The Bold Café {draft}",
+		Author:                       "Jane Doe",
+		Operator:                     "jd",
+		Version:                      3,
+		CreationTime:                 &creationTime,
+		RevisionTime:                 &revisionTime,
+		LastPrintTime:                &lastPrintTime,
+		EditingMinutes:               42,
+		NumberOfPages:                2,
+		NumberOfWords:                120,
+		NumberOfCharacters:           700,
+		NumberOfCharactersWithSpaces: 812,
+		InternalID:                   1234,
+		UserProperties: []UserProperty{
+			{Name: "Client", Type: UserPropertyTypeText, Value: "ACME"},
+			{Name: "Pages", Type: UserPropertyTypeInteger, Value: 12},
+			{Name: "Ratio", Type: UserPropertyTypeReal, Value: 0.5},
+			{Name: "Approved", Type: UserPropertyTypeBoolean, Value: true},
+			{Name: "Due", Type: UserPropertyTypeDate, Value: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+			{Name: "Section", Type: UserPropertyTypeText, Value: "", Link: "intro"},
+		},
+	}
+
+	if !reflect.DeepEqual(doc.InformationGroup, expected) {
+		t.Errorf("\n\nexpected: %+v\n\nactual\t: %+v", expected, doc.InformationGroup)
+	}
+
+	text, _ := doc.ToText()
+	if text != "Body" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Body", text)
+	}
+}
+
+func TestParseInformationGroupMinimal(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseFile("./testfiles/minimal.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.InformationGroup.Author != "word" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "word", doc.InformationGroup.Author)
+	}
+}
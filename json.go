@@ -0,0 +1,824 @@
+package gortf
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// JSONVersion is the version of the JSON representation of documents
+// written by RtfDocument.MarshalJSON. It is increased whenever the shape
+// changes in a way older readers cannot handle.
+//
+// A document is an object with the following members:
+//
+//	version         the JSON version, currently 1
+//	header          the header: charset, codePage, and the fonts, colors and
+//	                styles tables as arrays of entries, each font and color
+//	                carrying its table number as id
+//	info            the information group; times are RFC 3339 strings
+//	images          the pictures of the document, with base64 data
+//	footnotes       the footnotes and endnotes, with their content
+//	headersFooters  the headers and footers, with their kind, the index of
+//	                the section defining them and their content
+//	annotations     the comments of reviewers, with their initials, author,
+//	                date and content
+//	objects         the OLE objects, with their type, class, name, size,
+//	                scaling, base64 data and result
+//	shapes          the drawing objects, with their position, anchors,
+//	                wrapping, properties, picture and content
+//	sections        the body as sections of elements
+//
+// The header also holds the list table as lists, each entry carrying the
+// number paragraphs refer to as id, and the names of the authors of
+// revisions as revisionAuthors. The content of footnotes, headers and
+// footers is an array of sections like the body.
+//
+// A section has a format, its elements and a mark. An element is either
+// {"type": "paragraph"} with a format, runs and a mark, or {"type": "table"}
+// with rows, each row holding a format, cells of paragraphs and a mark.
+// Marks are the character formatting of the paragraph, cell, row or section
+// mark and are missing for content left unterminated. Runs are blocks of
+// kind "Text", "Line", "Page", "Picture" or "Footnote"; pictures and
+// footnotes refer to their entry of images and footnotes by index with
+// "image" and "footnote". Blocks of kind "Annotation", "AnnotationStart" and
+// "AnnotationEnd" refer to their entry of annotations with "annotation",
+// those of kind "BookmarkStart" and "BookmarkEnd" name their bookmark with
+// "bookmark", and those of kind "Object" and "Shape" refer to their entry of
+// objects and shapes with "object" and "shape". The result of an object and
+// the content of a shape are arrays of sections like the body, and shapes
+// refer to their picture in images with "image".
+//
+// Enumerations are written by name, such as "Center" for an alignment.
+// Optional members equal to their zero value are left out. The tables of the
+// header are always written, as null when the document has none so that
+// missing tables and empty ones are told apart.
+const JSONVersion = 1
+
+type jsonDocument struct {
+	Version        int                 `json:"version"`
+	Header         RtfHeader           `json:"header"`
+	Info           RtfInformationGroup `json:"info"`
+	Images         []Picture           `json:"images,omitempty"`
+	Footnotes      []jsonFootnote      `json:"footnotes,omitempty"`
+	HeadersFooters []jsonHeaderFooter  `json:"headersFooters,omitempty"`
+	Annotations    []jsonAnnotation    `json:"annotations,omitempty"`
+	Objects        []jsonObject        `json:"objects,omitempty"`
+	Shapes         []jsonShape         `json:"shapes,omitempty"`
+	Sections       []Section           `json:"sections"`
+}
+
+type jsonFootnote struct {
+	Endnote bool      `json:"endnote,omitempty"`
+	Content []Section `json:"content"`
+}
+
+type jsonAnnotation struct {
+	Initials string     `json:"initials,omitempty"`
+	Author   string     `json:"author,omitempty"`
+	Date     *time.Time `json:"date,omitempty"`
+	Content  []Section  `json:"content"`
+}
+
+type jsonObject struct {
+	Type   ObjectType `json:"type"`
+	Class  string     `json:"class,omitempty"`
+	Name   string     `json:"name,omitempty"`
+	Width  int        `json:"width,omitempty"`
+	Height int        `json:"height,omitempty"`
+	ScaleX int        `json:"scaleX"`
+	ScaleY int        `json:"scaleY"`
+	Data   []byte     `json:"data,omitempty"`
+	Result []Section  `json:"result,omitempty"`
+}
+
+type jsonShape struct {
+	ID               int               `json:"id,omitempty"`
+	Left             int               `json:"left"`
+	Top              int               `json:"top"`
+	Right            int               `json:"right"`
+	Bottom           int               `json:"bottom"`
+	HorizontalAnchor ShapeAnchor       `json:"horizontalAnchor"`
+	VerticalAnchor   ShapeAnchor       `json:"verticalAnchor"`
+	Wrap             ShapeWrap         `json:"wrap"`
+	BehindText       bool              `json:"behindText,omitempty"`
+	ZOrder           int               `json:"zOrder,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+	Image            *int              `json:"image,omitempty"`
+	Content          []Section         `json:"content,omitempty"`
+}
+
+type jsonHeaderFooter struct {
+	Kind    HeaderFooterKind `json:"kind"`
+	Section int              `json:"section"`
+	Content []Section        `json:"content"`
+}
+
+// MarshalJSON encodes the document in the format described by JSONVersion.
+func (r RtfDocument) MarshalJSON() ([]byte, error) {
+	document := jsonDocument{
+		Version:  JSONVersion,
+		Header:   r.Header,
+		Info:     r.InformationGroup,
+		Images:   r.Pictures,
+		Sections: r.Sections(),
+	}
+
+	for _, footnote := range r.Footnotes {
+		document.Footnotes = append(document.Footnotes, jsonFootnote{
+			Endnote: footnote.Endnote,
+			Content: sectionsOf(footnote.Body),
+		})
+	}
+
+	for _, headerFooter := range r.HeadersFooters {
+		document.HeadersFooters = append(document.HeadersFooters, jsonHeaderFooter{
+			Kind:    headerFooter.Kind,
+			Section: headerFooter.Section,
+			Content: sectionsOf(headerFooter.Body),
+		})
+	}
+
+	for _, annotation := range r.Annotations {
+		document.Annotations = append(document.Annotations, jsonAnnotation{
+			Initials: annotation.Initials,
+			Author:   annotation.Author,
+			Date:     annotation.Date,
+			Content:  sectionsOf(annotation.Body),
+		})
+	}
+
+	for _, object := range r.Objects {
+		document.Objects = append(document.Objects, jsonObject{
+			Type:   object.Type,
+			Class:  object.Class,
+			Name:   object.Name,
+			Width:  object.Width,
+			Height: object.Height,
+			ScaleX: object.ScaleX,
+			ScaleY: object.ScaleY,
+			Data:   object.Data,
+			Result: sectionsOf(object.Result),
+		})
+	}
+
+	for _, shape := range r.Shapes {
+		jsonShape := jsonShape{
+			ID:               shape.ID,
+			Left:             shape.Left,
+			Top:              shape.Top,
+			Right:            shape.Right,
+			Bottom:           shape.Bottom,
+			HorizontalAnchor: shape.HorizontalAnchor,
+			VerticalAnchor:   shape.VerticalAnchor,
+			Wrap:             shape.Wrap,
+			BehindText:       shape.BehindText,
+			ZOrder:           shape.ZOrder,
+			Properties:       shape.Properties,
+			Content:          sectionsOf(shape.Body),
+		}
+		if shape.PictureIndex >= 0 {
+			index := shape.PictureIndex
+			jsonShape.Image = &index
+		}
+		document.Shapes = append(document.Shapes, jsonShape)
+	}
+
+	return json.Marshal(document)
+}
+
+// UnmarshalJSON decodes a document written by MarshalJSON.
+func (r *RtfDocument) UnmarshalJSON(data []byte) error {
+	var document jsonDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	if document.Version < 1 || document.Version > JSONVersion {
+		return fmt.Errorf("gortf: unsupported JSON version %d", document.Version)
+	}
+
+	*r = RtfDocument{
+		Header:           document.Header,
+		InformationGroup: document.Info,
+		Pictures:         document.Images,
+	}
+	r.SetSections(document.Sections)
+
+	for _, footnote := range document.Footnotes {
+		r.Footnotes = append(r.Footnotes, Footnote{
+			Endnote: footnote.Endnote,
+			Body:    subdocumentBody(footnote.Content),
+		})
+	}
+
+	for _, headerFooter := range document.HeadersFooters {
+		r.HeadersFooters = append(r.HeadersFooters, HeaderFooter{
+			Kind:    headerFooter.Kind,
+			Section: headerFooter.Section,
+			Body:    subdocumentBody(headerFooter.Content),
+		})
+	}
+
+	for _, annotation := range document.Annotations {
+		r.Annotations = append(r.Annotations, Annotation{
+			Initials: annotation.Initials,
+			Author:   annotation.Author,
+			Date:     annotation.Date,
+			Body:     subdocumentBody(annotation.Content),
+		})
+	}
+
+	for _, object := range document.Objects {
+		r.Objects = append(r.Objects, Object{
+			Type:   object.Type,
+			Class:  object.Class,
+			Name:   object.Name,
+			Width:  object.Width,
+			Height: object.Height,
+			ScaleX: object.ScaleX,
+			ScaleY: object.ScaleY,
+			Data:   object.Data,
+			Result: subdocumentBody(object.Result),
+		})
+	}
+
+	for _, shape := range document.Shapes {
+		properties := shape.Properties
+		if properties == nil {
+			properties = map[string]string{}
+		}
+		image := -1
+		if shape.Image != nil {
+			image = *shape.Image
+		}
+
+		r.Shapes = append(r.Shapes, Shape{
+			ID:               shape.ID,
+			Left:             shape.Left,
+			Top:              shape.Top,
+			Right:            shape.Right,
+			Bottom:           shape.Bottom,
+			HorizontalAnchor: shape.HorizontalAnchor,
+			VerticalAnchor:   shape.VerticalAnchor,
+			Wrap:             shape.Wrap,
+			BehindText:       shape.BehindText,
+			ZOrder:           shape.ZOrder,
+			Properties:       properties,
+			PictureIndex:     image,
+			Body:             subdocumentBody(shape.Content),
+		})
+	}
+
+	return nil
+}
+
+// subdocumentBody rebuilds the content of a footnote or header, which the
+// parser leaves nil when empty.
+func subdocumentBody(sections []Section) []StyleBlock {
+	body := bodyFromSections(sections)
+	if len(body) == 0 {
+		return nil
+	}
+
+	return body
+}
+
+type jsonHeader struct {
+	Charset  CharacterSet `json:"charset"`
+	CodePage int          `json:"codePage,omitempty"`
+	Fonts    []jsonFont   `json:"fonts"`
+	Colors   []jsonColor  `json:"colors"`
+	Styles   []Style      `json:"styles"`
+	Lists    []jsonList   `json:"lists"`
+
+	DefaultTab      int            `json:"defaultTab,omitempty"`
+	RevisionAuthors []string       `json:"revisionAuthors,omitempty"`
+	Cocoa           *CocoaMetadata `json:"cocoa,omitempty"`
+}
+
+type jsonList struct {
+	ID TableRef `json:"id"`
+	List
+}
+
+type jsonFont struct {
+	ID TableRef `json:"id"`
+	Font
+}
+
+type jsonColor struct {
+	ID TableRef `json:"id"`
+	Color
+}
+
+// MarshalJSON encodes the header with its tables as arrays ordered by table
+// number, or by name for the stylesheet. Missing tables are null.
+func (r RtfHeader) MarshalJSON() ([]byte, error) {
+	header := jsonHeader{Charset: r.Charset, CodePage: r.CodePage, DefaultTab: r.DefaultTab, RevisionAuthors: r.RevisionAuthors, Cocoa: r.Cocoa}
+
+	if r.FontTable != nil {
+		header.Fonts = []jsonFont{}
+		for _, key := range sortedTableRefs(r.FontTable) {
+			header.Fonts = append(header.Fonts, jsonFont{ID: key, Font: r.FontTable[key]})
+		}
+	}
+
+	if r.ColorTable != nil {
+		header.Colors = []jsonColor{}
+		for _, key := range sortedTableRefs(r.ColorTable) {
+			header.Colors = append(header.Colors, jsonColor{ID: key, Color: r.ColorTable[key]})
+		}
+	}
+
+	if r.Stylesheet != nil {
+		header.Styles = []Style{}
+		for _, style := range r.Stylesheet {
+			header.Styles = append(header.Styles, style)
+		}
+		sort.Slice(header.Styles, func(i, j int) bool {
+			return header.Styles[i].Name < header.Styles[j].Name
+		})
+	}
+
+	if r.Lists != nil {
+		header.Lists = []jsonList{}
+		for _, key := range sortedTableRefs(r.Lists) {
+			header.Lists = append(header.Lists, jsonList{ID: key, List: r.Lists[key]})
+		}
+	}
+
+	return json.Marshal(header)
+}
+
+// UnmarshalJSON decodes a header written by MarshalJSON.
+func (r *RtfHeader) UnmarshalJSON(data []byte) error {
+	var header jsonHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+
+	*r = RtfHeader{Charset: header.Charset, CodePage: header.CodePage, DefaultTab: header.DefaultTab, RevisionAuthors: header.RevisionAuthors, Cocoa: header.Cocoa}
+
+	if header.Fonts != nil {
+		r.FontTable = FontTable{}
+		for _, font := range header.Fonts {
+			r.FontTable[font.ID] = font.Font
+		}
+	}
+
+	if header.Colors != nil {
+		r.ColorTable = ColorTable{}
+		for _, color := range header.Colors {
+			r.ColorTable[color.ID] = color.Color
+		}
+	}
+
+	if header.Styles != nil {
+		r.Stylesheet = Stylesheet{}
+		for _, style := range header.Styles {
+			r.Stylesheet[style.Name] = style
+		}
+	}
+
+	if header.Lists != nil {
+		r.Lists = ListTable{}
+		for _, list := range header.Lists {
+			r.Lists[list.ID] = list.List
+		}
+	}
+
+	return nil
+}
+
+type jsonElement struct {
+	Type string `json:"type"`
+
+	// paragraph
+	Format *ParagraphFormat `json:"format,omitempty"`
+	Runs   []StyleBlock     `json:"runs,omitempty"`
+	Mark   *Painter         `json:"mark,omitempty"`
+
+	// table
+	Rows []TableRow `json:"rows,omitempty"`
+}
+
+type jsonSection struct {
+	Format   SectionFormat `json:"format"`
+	Elements []jsonElement `json:"elements"`
+	Mark     *Painter      `json:"mark,omitempty"`
+}
+
+// MarshalJSON encodes the section with each of its elements tagged with its
+// type.
+func (s Section) MarshalJSON() ([]byte, error) {
+	section := jsonSection{Format: s.Format, Elements: []jsonElement{}, Mark: s.Mark}
+
+	for _, element := range s.Elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			format := e.Format
+			section.Elements = append(section.Elements, jsonElement{
+				Type:   "paragraph",
+				Format: &format,
+				Runs:   e.Runs,
+				Mark:   e.Mark,
+			})
+		case *Table:
+			section.Elements = append(section.Elements, jsonElement{Type: "table", Rows: e.Rows})
+		}
+	}
+
+	return json.Marshal(section)
+}
+
+// UnmarshalJSON decodes a section written by MarshalJSON.
+func (s *Section) UnmarshalJSON(data []byte) error {
+	var section jsonSection
+	if err := json.Unmarshal(data, &section); err != nil {
+		return err
+	}
+
+	*s = Section{Format: section.Format, Mark: section.Mark}
+
+	for _, element := range section.Elements {
+		switch element.Type {
+		case "paragraph":
+			paragraph := &Paragraph{Runs: element.Runs, Mark: element.Mark}
+			if element.Format != nil {
+				paragraph.Format = *element.Format
+			}
+			s.Elements = append(s.Elements, paragraph)
+		case "table":
+			s.Elements = append(s.Elements, &Table{Rows: element.Rows})
+		default:
+			return fmt.Errorf("gortf: unknown element type %q", element.Type)
+		}
+	}
+
+	return nil
+}
+
+type jsonBlock struct {
+	Kind       BlockKind        `json:"kind"`
+	Text       string           `json:"text,omitempty"`
+	Format     Painter          `json:"format"`
+	Paragraph  *ParagraphFormat `json:"paragraph,omitempty"`
+	Row        *RowFormat       `json:"row,omitempty"`
+	Section    *SectionFormat   `json:"section,omitempty"`
+	Image      *int             `json:"image,omitempty"`
+	Footnote   *int             `json:"footnote,omitempty"`
+	Annotation *int             `json:"annotation,omitempty"`
+	Object     *int             `json:"object,omitempty"`
+	Shape      *int             `json:"shape,omitempty"`
+	Bookmark   string           `json:"bookmark,omitempty"`
+}
+
+// MarshalJSON encodes the block with its kind by name. The text of marks,
+// which follows from their kind, is left out, as are the picture and
+// footnote indexes of other blocks.
+func (s StyleBlock) MarshalJSON() ([]byte, error) {
+	block := jsonBlock{
+		Kind:      s.Kind,
+		Format:    s.Painter,
+		Paragraph: s.Paragraph,
+		Row:       s.Row,
+		Section:   s.Section,
+		Bookmark:  s.Bookmark,
+	}
+
+	if s.Kind == BlockKindText {
+		block.Text = s.Text
+	}
+
+	if s.Kind == BlockKindPicture {
+		index := s.PictureIndex
+		block.Image = &index
+	}
+
+	if s.Kind == BlockKindFootnote {
+		index := s.FootnoteIndex
+		block.Footnote = &index
+	}
+
+	if s.Kind == BlockKindAnnotation || isAnnotationAnchor(s) {
+		index := s.AnnotationIndex
+		block.Annotation = &index
+	}
+
+	if s.Kind == BlockKindObject {
+		index := s.ObjectIndex
+		block.Object = &index
+	}
+
+	if s.Kind == BlockKindShape {
+		index := s.ShapeIndex
+		block.Shape = &index
+	}
+
+	return json.Marshal(block)
+}
+
+// UnmarshalJSON decodes a block written by MarshalJSON.
+func (s *StyleBlock) UnmarshalJSON(data []byte) error {
+	var block jsonBlock
+	if err := json.Unmarshal(data, &block); err != nil {
+		return err
+	}
+
+	*s = StyleBlock{
+		Painter:   block.Format,
+		Text:      block.Text,
+		Kind:      block.Kind,
+		Paragraph: block.Paragraph,
+		Row:       block.Row,
+		Section:   block.Section,
+		Bookmark:  block.Bookmark,
+	}
+
+	if block.Kind != BlockKindText {
+		s.Text = blockKindText(block.Kind)
+	}
+
+	if block.Image != nil {
+		s.PictureIndex = *block.Image
+	}
+
+	if block.Footnote != nil {
+		s.FootnoteIndex = *block.Footnote
+	}
+
+	if block.Annotation != nil {
+		s.AnnotationIndex = *block.Annotation
+	}
+
+	if block.Object != nil {
+		s.ObjectIndex = *block.Object
+	}
+
+	if block.Shape != nil {
+		s.ShapeIndex = *block.Shape
+	}
+
+	return nil
+}
+
+// UnmarshalJSON decodes a user property, converting its value back to the
+// Go type matching its type.
+func (u *UserProperty) UnmarshalJSON(data []byte) error {
+	var property struct {
+		Name  string           `json:"name"`
+		Type  UserPropertyType `json:"type"`
+		Value json.RawMessage  `json:"value"`
+		Link  string           `json:"link"`
+	}
+	if err := json.Unmarshal(data, &property); err != nil {
+		return err
+	}
+
+	*u = UserProperty{Name: property.Name, Type: property.Type, Link: property.Link}
+	if len(property.Value) == 0 {
+		return nil
+	}
+
+	// values that could not be converted when parsed are kept as strings
+	var text string
+	if err := json.Unmarshal(property.Value, &text); err == nil && property.Type != UserPropertyTypeDate {
+		u.Value = text
+		return nil
+	}
+
+	var err error
+	switch property.Type {
+	case UserPropertyTypeInteger:
+		var value int
+		err = json.Unmarshal(property.Value, &value)
+		u.Value = value
+	case UserPropertyTypeReal:
+		var value float64
+		err = json.Unmarshal(property.Value, &value)
+		u.Value = value
+	case UserPropertyTypeBoolean:
+		var value bool
+		err = json.Unmarshal(property.Value, &value)
+		u.Value = value
+	case UserPropertyTypeDate:
+		var value time.Time
+		if json.Unmarshal(property.Value, &value) == nil {
+			u.Value = value
+		} else {
+			err = json.Unmarshal(property.Value, &text)
+			u.Value = text
+		}
+	default:
+		err = json.Unmarshal(property.Value, &u.Value)
+	}
+
+	return err
+}
+
+// enum is an enumeration type with names given by its String method.
+type enum interface {
+	~int
+	String() string
+}
+
+// maxEnumValue bounds the values searched for a name, the largest being the
+// font character sets.
+const maxEnumValue = 255
+
+// marshalEnum returns the name of an enumeration value, or its number if the
+// name does not lead back to the value.
+func marshalEnum[T enum](value T) ([]byte, error) {
+	name := value.String()
+	if parsed, err := unmarshalEnum[T]([]byte(name)); err == nil && parsed == value {
+		return []byte(name), nil
+	}
+
+	return []byte(strconv.Itoa(int(value))), nil
+}
+
+// unmarshalEnum returns the enumeration value with the given name or number.
+func unmarshalEnum[T enum](text []byte) (T, error) {
+	if number, err := strconv.Atoi(string(text)); err == nil {
+		return T(number), nil
+	}
+
+	for i := 0; i <= maxEnumValue; i++ {
+		if T(i).String() == string(text) {
+			return T(i), nil
+		}
+	}
+
+	var zero T
+	return zero, fmt.Errorf("gortf: unknown %T %q", zero, text)
+}
+
+// characterSetNames are the names of character sets in JSON, which are
+// written like those of font character sets rather than as String does.
+var characterSetNames = map[CharacterSet]string{
+	CharacterSetNone: "None",
+	CharacterSetAnsi: "Ansi",
+	CharacterSetMac:  "Mac",
+	CharacterSetPc:   "Pc",
+	CharacterSetPca:  "Pca",
+}
+
+func (c CharacterSet) MarshalText() ([]byte, error) {
+	if name, ok := characterSetNames[c]; ok {
+		return []byte(name), nil
+	}
+
+	return []byte(strconv.Itoa(int(c))), nil
+}
+
+func (c *CharacterSet) UnmarshalText(text []byte) error {
+	if number, err := strconv.Atoi(string(text)); err == nil {
+		*c = CharacterSet(number)
+		return nil
+	}
+
+	for charset, name := range characterSetNames {
+		if name == string(text) {
+			*c = charset
+			return nil
+		}
+	}
+
+	return fmt.Errorf("gortf: unknown %T %q", *c, text)
+}
+
+func (f FontCharset) MarshalText() ([]byte, error) { return marshalEnum(f) }
+
+func (f *FontCharset) UnmarshalText(text []byte) (err error) {
+	*f, err = unmarshalEnum[FontCharset](text)
+	return err
+}
+
+func (f FontFamily) MarshalText() ([]byte, error) { return marshalEnum(f) }
+
+func (f *FontFamily) UnmarshalText(text []byte) (err error) {
+	*f, err = unmarshalEnum[FontFamily](text)
+	return err
+}
+
+func (f FontPitch) MarshalText() ([]byte, error) { return marshalEnum(f) }
+
+func (f *FontPitch) UnmarshalText(text []byte) (err error) {
+	*f, err = unmarshalEnum[FontPitch](text)
+	return err
+}
+
+func (f FontTheme) MarshalText() ([]byte, error) { return marshalEnum(f) }
+
+func (f *FontTheme) UnmarshalText(text []byte) (err error) {
+	*f, err = unmarshalEnum[FontTheme](text)
+	return err
+}
+
+func (c ColorTheme) MarshalText() ([]byte, error) { return marshalEnum(c) }
+
+func (c *ColorTheme) UnmarshalText(text []byte) (err error) {
+	*c, err = unmarshalEnum[ColorTheme](text)
+	return err
+}
+
+func (c ColorSpace) MarshalText() ([]byte, error) { return marshalEnum(c) }
+
+func (c *ColorSpace) UnmarshalText(text []byte) (err error) {
+	*c, err = unmarshalEnum[ColorSpace](text)
+	return err
+}
+
+func (u UserPropertyType) MarshalText() ([]byte, error) { return marshalEnum(u) }
+
+func (u *UserPropertyType) UnmarshalText(text []byte) (err error) {
+	*u, err = unmarshalEnum[UserPropertyType](text)
+	return err
+}
+
+func (p PictureFormat) MarshalText() ([]byte, error) { return marshalEnum(p) }
+
+func (p *PictureFormat) UnmarshalText(text []byte) (err error) {
+	*p, err = unmarshalEnum[PictureFormat](text)
+	return err
+}
+
+func (o ObjectType) MarshalText() ([]byte, error) { return marshalEnum(o) }
+
+func (o *ObjectType) UnmarshalText(text []byte) (err error) {
+	*o, err = unmarshalEnum[ObjectType](text)
+	return err
+}
+
+func (s ShapeAnchor) MarshalText() ([]byte, error) { return marshalEnum(s) }
+
+func (s *ShapeAnchor) UnmarshalText(text []byte) (err error) {
+	*s, err = unmarshalEnum[ShapeAnchor](text)
+	return err
+}
+
+func (s ShapeWrap) MarshalText() ([]byte, error) { return marshalEnum(s) }
+
+func (s *ShapeWrap) UnmarshalText(text []byte) (err error) {
+	*s, err = unmarshalEnum[ShapeWrap](text)
+	return err
+}
+
+func (b BlockKind) MarshalText() ([]byte, error) { return marshalEnum(b) }
+
+func (b *BlockKind) UnmarshalText(text []byte) (err error) {
+	*b, err = unmarshalEnum[BlockKind](text)
+	return err
+}
+
+func (a Alignment) MarshalText() ([]byte, error) { return marshalEnum(a) }
+
+func (a *Alignment) UnmarshalText(text []byte) (err error) {
+	*a, err = unmarshalEnum[Alignment](text)
+	return err
+}
+
+func (t TabAlignment) MarshalText() ([]byte, error) { return marshalEnum(t) }
+
+func (t *TabAlignment) UnmarshalText(text []byte) (err error) {
+	*t, err = unmarshalEnum[TabAlignment](text)
+	return err
+}
+
+func (t TabLeader) MarshalText() ([]byte, error) { return marshalEnum(t) }
+
+func (t *TabLeader) UnmarshalText(text []byte) (err error) {
+	*t, err = unmarshalEnum[TabLeader](text)
+	return err
+}
+
+func (c CellMerge) MarshalText() ([]byte, error) { return marshalEnum(c) }
+
+func (c *CellMerge) UnmarshalText(text []byte) (err error) {
+	*c, err = unmarshalEnum[CellMerge](text)
+	return err
+}
+
+func (s SectionBreak) MarshalText() ([]byte, error) { return marshalEnum(s) }
+
+func (s *SectionBreak) UnmarshalText(text []byte) (err error) {
+	*s, err = unmarshalEnum[SectionBreak](text)
+	return err
+}
+
+func (l ListFormat) MarshalText() ([]byte, error) { return marshalEnum(l) }
+
+func (l *ListFormat) UnmarshalText(text []byte) (err error) {
+	*l, err = unmarshalEnum[ListFormat](text)
+	return err
+}
+
+func (h HeaderFooterKind) MarshalText() ([]byte, error) { return marshalEnum(h) }
+
+func (h *HeaderFooterKind) UnmarshalText(text []byte) (err error) {
+	*h, err = unmarshalEnum[HeaderFooterKind](text)
+	return err
+}
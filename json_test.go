@@ -0,0 +1,185 @@
+package gortf
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roundTripContent exercises most of the document model: font, color and
+// information tables, the stylesheet and list table, character and paragraph
+// formatting, a hyperlink, a picture, a footnote, a header, a table and
+// several sections.
+const roundTripContent = `{\rtf1\ansi\ansicpg1252` +
+	`{\fonttbl{\f0\fswiss\fcharset0 Helvetica;}{\f1\froman\fprq2 Times;}}` +
+	`{\colortbl;\red255\green0\blue0;\caccentone\ctint153;}` +
+	`{\stylesheet{\s0 Normal;}{\s1\qc\b\fs32 Heading;}}` +
+	`{\*\listtable{\list{\listlevel\levelnfc23{\leveltext\'01\u8226 ?;}\fi-360\li720}\listid4}}` +
+	`{\*\listoverridetable{\listoverride\listid4\listoverridecount0\ls1}}` +
+	`{\info{\title Report}{\creatim\yr2024\mo3\dy5\hr10\min30}` +
+	`{\*\userprops{\propname Pages}\proptype3{\staticval 12}{\propname Draft}\proptype11{\staticval 1}}}` +
+	`{\header\pard Page header\par}` +
+	`\pard\s1\qc\sb120\f1\fs32\b Title\b0\par` +
+	`\pard\ls1 See {\field{\*\fldinst HYPERLINK "https://example.com"}{\fldrslt the site}}{\super\chftn}{\footnote\pard{\super\chftn}Note\par}\par` +
+	`\pard Some \i italic\i0  and \cf1 red\cf0  text\line next{\pict\pngblip\picw2\pich2 89504e47}\par` +
+	`\trowd\trgaph108\clcbpat2\cellx2000\clmgf\cellx4000` +
+	`\pard\intbl A\cell\pard\intbl B\par\pard\intbl C\cell\row` +
+	`\sect\sectd\pard Last\par}`
+
+func parseRoundTripContent(t *testing.T) RtfDocument {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(roundTripContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	doc := parseRoundTripContent(t)
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual RtfDocument
+	if err := json.Unmarshal(data, &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(doc, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc, actual)
+	}
+
+	content, err := actual.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser := NewRtfParser()
+	reparsed, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(doc, reparsed) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc, reparsed)
+	}
+}
+
+func TestJSONShape(t *testing.T) {
+	doc := parseRoundTripContent(t)
+	actual := doc.String()
+
+	for _, expected := range []string{
+		`"version":1`,
+		`"charset":"Ansi"`,
+		`{"id":1,"name":"Times","charset":"Default","family":"Roman","pitch":"Variable"}`,
+		`{"id":0,"red":0,"green":0,"blue":0,"auto":true}`,
+		`"theme":"AccentOne"`,
+		`"creationTime":"2024-03-05T10:30:00Z"`,
+		`{"name":"Pages","type":"Integer","value":12}`,
+		`{"name":"Draft","type":"Boolean","value":true}`,
+		`"images":[{"format":"PNG","width":2,"height":2,`,
+		`{"name":"Heading","number":1,"format":{"fontSize":32,"bold":true},"paragraph":{"style":1,"alignment":"Center"}}`,
+		`"lists":[{"id":1,"listId":4,"levels":[{"format":"Bullet","start":1,"text":"•","leftIndent":720,"firstLineIndent":-360}]}]`,
+		`"headersFooters":[{"kind":"Header","section":0,"content":[`,
+		`"format":{"style":1,"alignment":"Center","spaceBefore":120}`,
+		`{"kind":"Text","text":"the site","format":{"font":1,"fontSize":32,"link":"https://example.com"}}`,
+		`{"kind":"Footnote","format":{"font":1,"fontSize":32},"footnote":0}`,
+		`{"kind":"Text","text":"italic","format":{"font":1,"fontSize":32,"italic":true}}`,
+		`{"kind":"Picture","format":{"font":1,"fontSize":32},"image":0}`,
+		`{"type":"table","rows":[{"format":{"cells":[{"right":2000,"background":2},{"right":4000,"horizontalMerge":"First"}],"gap":108}`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+}
+
+func TestJSONUnmarshalErrors(t *testing.T) {
+	for _, content := range []string{
+		`{"version":2,"sections":[]}`,
+		`{"sections":[]}`,
+		`{"version":1,"sections":[{"elements":[{"type":"list"}]}]}`,
+		`{"version":1,"sections":[{"format":{"break":"Sideways"}}]}`,
+	} {
+		var doc RtfDocument
+		if err := json.Unmarshal([]byte(content), &doc); err == nil {
+			t.Errorf("expected an error for %s", content)
+		}
+	}
+}
+
+func TestJSONEnumNumbers(t *testing.T) {
+	font := Font{Name: "Custom", Charset: FontCharset(100), FontFamily: FontFamilyModern}
+
+	data, err := json.Marshal(font)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"name":"Custom","charset":"100","family":"Modern"}`
+	if string(data) != expected {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, string(data))
+	}
+
+	var actual Font
+	if err := json.Unmarshal(data, &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(font, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", font, actual)
+	}
+}
+
+func TestJSONMissingTables(t *testing.T) {
+	header := RtfHeader{Charset: CharacterSetAnsi, FontTable: FontTable{}}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"charset":"Ansi","fonts":[],"colors":null,"styles":null,"lists":null}`
+	if string(data) != expected {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, string(data))
+	}
+
+	var actual RtfHeader
+	if err := json.Unmarshal(data, &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(header, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", header, actual)
+	}
+}
+
+func TestJSONCharacterSetNames(t *testing.T) {
+	for charset, name := range map[CharacterSet]string{CharacterSetAnsi: "Ansi", CharacterSetMac: "Mac", CharacterSetPca: "Pca", CharacterSet(9): "9"} {
+		data, err := json.Marshal(charset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != `"`+name+`"` {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %s", name, data)
+		}
+
+		var actual CharacterSet
+		if err := json.Unmarshal(data, &actual); err != nil || actual != charset {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v %v", charset, actual, err)
+		}
+	}
+
+	if CharacterSetAnsi.String() != "ANSI" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "ANSI", CharacterSetAnsi.String())
+	}
+	if err := json.Unmarshal([]byte(`"ANSI"`), new(CharacterSet)); err == nil {
+		t.Errorf("expected an error for %s", "ANSI")
+	}
+}
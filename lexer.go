@@ -0,0 +1,322 @@
+package gortf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// maxControlWordLength is the longest control word name allowed by the
+	// specification, not counting the leading backslash.
+	maxControlWordLength = 32
+
+	// maxControlWordParameterLength is the maximum number of digits in a
+	// control word parameter, not counting the sign.
+	maxControlWordParameterLength = 10
+)
+
+// TokenKind is the lexical category of a Token.
+type TokenKind int
+
+const (
+	TokenKindGroupStart TokenKind = iota
+	TokenKindGroupEnd
+	TokenKindControlWord
+	TokenKindControlSymbol
+	TokenKindText
+	TokenKindBinary
+)
+
+func (t TokenKind) String() string {
+	switch t {
+	case TokenKindGroupStart:
+		return "GroupStart"
+	case TokenKindGroupEnd:
+		return "GroupEnd"
+	case TokenKindControlWord:
+		return "ControlWord"
+	case TokenKindControlSymbol:
+		return "ControlSymbol"
+	case TokenKindText:
+		return "Text"
+	case TokenKindBinary:
+		return "Binary"
+	default:
+		return "Unknown"
+	}
+}
+
+// Token is a single lexical element of an RTF stream.
+//
+// For control words, Name is the word without its backslash and Param holds
+// the numeric parameter when HasParam is set. For control symbols, Name is
+// the character following the backslash; the \'hh escape is the control
+// symbol "'" with the decoded byte as its parameter. Text tokens hold the
+// source text verbatim, including carriage returns and line feeds, which RTF
+// readers ignore. Binary tokens are a \binN control word together with the
+// N bytes of data that follow it.
+//
+// Offset and End are the byte offsets of the token in the source, End being
+// exclusive.
+type Token struct {
+	Kind     TokenKind
+	Name     string
+	Param    int
+	HasParam bool
+	Text     string
+	Data     []byte
+	Offset   int
+	End      int
+
+	// source is the token as it was lexed, whose source text Writer
+	// re-emits verbatim as long as the token has not been modified
+	source tokenSource
+}
+
+// tokenSource is the content of a token when it was lexed, along with its
+// source text.
+type tokenSource struct {
+	raw      string
+	kind     TokenKind
+	name     string
+	param    int
+	hasParam bool
+	text     string
+	data     []byte
+}
+
+// unmodified reports whether a token still has the content it was lexed
+// with.
+func (t Token) unmodified() bool {
+	s := t.source
+	return t.Kind == s.kind &&
+		t.Name == s.name &&
+		t.Param == s.param &&
+		t.HasParam == s.hasParam &&
+		t.Text == s.text &&
+		bytes.Equal(t.Data, s.data)
+}
+
+func (t Token) String() string {
+	switch t.Kind {
+	case TokenKindControlWord, TokenKindControlSymbol:
+		if t.HasParam {
+			return fmt.Sprintf("{%s %q %d}", t.Kind, t.Name, t.Param)
+		}
+		return fmt.Sprintf("{%s %q}", t.Kind, t.Name)
+	case TokenKindText:
+		return fmt.Sprintf("{%s %q}", t.Kind, t.Text)
+	case TokenKindBinary:
+		return fmt.Sprintf("{%s %d bytes}", t.Kind, len(t.Data))
+	default:
+		return fmt.Sprintf("{%s}", t.Kind)
+	}
+}
+
+// equal reports whether two tokens have the same content, regardless of
+// their position in the source.
+func (t Token) equal(other Token) bool {
+	return t.Kind == other.Kind &&
+		t.Name == other.Name &&
+		t.Param == other.Param &&
+		t.HasParam == other.HasParam &&
+		t.Text == other.Text &&
+		bytes.Equal(t.Data, other.Data)
+}
+
+// Lexer splits an RTF stream into tokens following the lexical rules of the
+// RTF specification. It does not interpret the tokens in any way.
+type Lexer struct {
+	reader *bufio.Reader
+	offset int
+	raw    bytes.Buffer
+	err    error
+}
+
+func NewLexer(r io.Reader) *Lexer {
+	return &Lexer{
+		reader: bufio.NewReader(r),
+	}
+}
+
+// Next returns the next token in the stream. It returns io.EOF once the
+// stream is exhausted, and io.ErrUnexpectedEOF if the stream ends in the
+// middle of binary data.
+func (l *Lexer) Next() (Token, error) {
+	if l.err != nil {
+		return Token{}, l.err
+	}
+
+	start := l.offset
+	l.raw.Reset()
+
+	c, err := l.advance()
+	if err != nil {
+		return Token{}, err
+	}
+
+	var tkn Token
+	switch c {
+	case '{':
+		tkn = Token{Kind: TokenKindGroupStart}
+
+	case '}':
+		tkn = Token{Kind: TokenKindGroupEnd}
+
+	case '\\':
+		tkn, err = l.nextControl()
+		if err != nil {
+			return Token{}, err
+		}
+
+	default:
+		for {
+			pc, ok := l.peek()
+			if !ok || isDelimiter(pc) {
+				break
+			}
+			l.advance()
+		}
+
+		tkn = Token{Kind: TokenKindText, Text: l.raw.String()}
+	}
+
+	tkn.Offset = start
+	tkn.End = l.offset
+	tkn.source = tokenSource{
+		raw:      l.raw.String(),
+		kind:     tkn.Kind,
+		name:     tkn.Name,
+		param:    tkn.Param,
+		hasParam: tkn.HasParam,
+		text:     tkn.Text,
+		data:     tkn.Data,
+	}
+
+	return tkn, nil
+}
+
+// nextControl scans whatever follows a backslash: a control word, binary
+// data or a control symbol.
+func (l *Lexer) nextControl() (Token, error) {
+	pc, ok := l.peek()
+	if !ok {
+		// a lone backslash at the end of the stream
+		return Token{Kind: TokenKindText, Text: `\`}, nil
+	}
+
+	if isAlpha(pc) {
+		return l.nextControlWord()
+	}
+
+	l.advance()
+	tkn := Token{Kind: TokenKindControlSymbol, Name: string(pc)}
+
+	if pc == '\'' {
+		digits, err := l.reader.Peek(2)
+		if err == nil {
+			value, err := strconv.ParseUint(string(digits), 16, 8)
+			if err == nil {
+				l.advance()
+				l.advance()
+				tkn.Param = int(value)
+				tkn.HasParam = true
+			}
+		}
+	}
+
+	return tkn, nil
+}
+
+// nextControlWord scans a control word: up to 32 ASCII letters, an optional
+// signed parameter of up to 10 digits and a single delimiting space, which is
+// consumed. Any other delimiter is left in place for the next token.
+func (l *Lexer) nextControlWord() (Token, error) {
+	var name []byte
+	for len(name) < maxControlWordLength {
+		pc, ok := l.peek()
+		if !ok || !isAlpha(pc) {
+			break
+		}
+
+		l.advance()
+		name = append(name, pc)
+	}
+
+	tkn := Token{Kind: TokenKindControlWord, Name: string(name)}
+
+	var parameter []byte
+	if next, err := l.reader.Peek(2); err == nil && next[0] == '-' && isNumber(next[1]) {
+		l.advance()
+		parameter = append(parameter, '-')
+	}
+
+	digits := 0
+	for digits < maxControlWordParameterLength {
+		pc, ok := l.peek()
+		if !ok || !isNumber(pc) {
+			break
+		}
+
+		l.advance()
+		parameter = append(parameter, pc)
+		digits += 1
+	}
+
+	if len(parameter) > 0 {
+		p, err := strconv.Atoi(string(parameter))
+		if err == nil {
+			tkn.Param = p
+			tkn.HasParam = true
+		}
+	}
+
+	if pc, ok := l.peek(); ok && pc == ' ' {
+		l.advance()
+	}
+
+	if tkn.Name == "bin" && tkn.HasParam && tkn.Param > 0 {
+		// copied rather than preallocated, as the length comes from the
+		// untrusted source
+		var data bytes.Buffer
+		n, err := io.CopyN(&data, l.reader, int64(tkn.Param))
+		l.raw.Write(data.Bytes())
+		l.offset += int(n)
+		if err != nil {
+			return Token{}, io.ErrUnexpectedEOF
+		}
+
+		tkn.Kind = TokenKindBinary
+		tkn.Data = data.Bytes()
+	}
+
+	return tkn, nil
+}
+
+func (l *Lexer) peek() (byte, bool) {
+	b, err := l.reader.Peek(1)
+	if err != nil {
+		if err != io.EOF {
+			// reported by the next call to Next
+			l.err = err
+		}
+		return 0, false
+	}
+
+	return b[0], true
+}
+
+func (l *Lexer) advance() (byte, error) {
+	c, err := l.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	l.offset += 1
+	l.raw.WriteByte(c)
+
+	return c, nil
+}
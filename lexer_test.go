@@ -0,0 +1,112 @@
+package gortf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lexAll(t *testing.T, content string) []Token {
+	lexer := NewLexer(strings.NewReader(content))
+
+	tokens := []Token{}
+	for {
+		tkn, err := lexer.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = append(tokens, tkn)
+	}
+
+	return tokens
+}
+
+func TestLexer(t *testing.T) {
+	content := `{\b0 caf\'e9\~\bin2 {}\par}`
+
+	tokens := lexAll(t, content)
+
+	// the source is only used by the writer
+	for i := range tokens {
+		tokens[i].source = tokenSource{}
+	}
+
+	expected := []Token{
+		{Kind: TokenKindGroupStart, Offset: 0, End: 1},
+		{Kind: TokenKindControlWord, Name: "b", Param: 0, HasParam: true, Offset: 1, End: 5},
+		{Kind: TokenKindText, Text: "caf", Offset: 5, End: 8},
+		{Kind: TokenKindControlSymbol, Name: "'", Param: 0xe9, HasParam: true, Offset: 8, End: 12},
+		{Kind: TokenKindControlSymbol, Name: "~", Offset: 12, End: 14},
+		{Kind: TokenKindBinary, Name: "bin", Param: 2, HasParam: true, Data: []byte("{}"), Offset: 14, End: 22},
+		{Kind: TokenKindControlWord, Name: "par", Offset: 22, End: 26},
+		{Kind: TokenKindGroupEnd, Offset: 26, End: 27},
+	}
+
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, tokens)
+	}
+}
+
+func TestLexerTruncatedBinary(t *testing.T) {
+	lexer := NewLexer(strings.NewReader(`\bin10 abc`))
+
+	_, err := lexer.Next()
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	minimal, err := os.ReadFile("./testfiles/minimal.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []string{
+		string(minimal),
+		"{\\rtf1\\fs024 \\'E9\\'e9 \\bin3 a}b\\\r\n\\u-3913 ?\\" + "\n}",
+		`{\b0text\b word\b-x\b1-2 \abcdefghijklmnopqrstuvwxyzabcdefgh\fs1234567890123}`,
+	}
+
+	for _, source := range sources {
+		var buf bytes.Buffer
+		writer := NewWriter(&buf)
+
+		if err := writer.WriteTokens(lexAll(t, source)); err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != source {
+			t.Errorf("\n\nexpected: %q\n\nactual\t: %q", source, buf.String())
+		}
+	}
+}
+
+func TestWriterModifiedTokens(t *testing.T) {
+	tokens := lexAll(t, `{\b\fs24;}`)
+
+	// turn bold off, change the size and replace the text
+	tokens[1].Param = 0
+	tokens[1].HasParam = true
+	tokens[2].Param = 32
+	tokens[3].Text = "1 {x} é"
+
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+	if err := writer.WriteTokens(tokens); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{\b0\fs32 1 \{x\} \u233?}`
+
+	if buf.String() != expected {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, buf.String())
+	}
+}
@@ -0,0 +1,240 @@
+package gortf
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ListFormat is the numbering format of a list level, given by \levelnfc.
+type ListFormat int
+
+const (
+	ListFormatDecimal      ListFormat = 0
+	ListFormatUpperRoman   ListFormat = 1
+	ListFormatLowerRoman   ListFormat = 2
+	ListFormatUpperLetter  ListFormat = 3
+	ListFormatLowerLetter  ListFormat = 4
+	ListFormatOrdinal      ListFormat = 5
+	ListFormatCardinalText ListFormat = 6
+	ListFormatOrdinalText  ListFormat = 7
+	ListFormatDecimalZero  ListFormat = 22
+	ListFormatBullet       ListFormat = 23
+	ListFormatNone         ListFormat = 255
+)
+
+// String returns the name of the format, or its number for formats without a
+// name.
+func (l ListFormat) String() string {
+	switch l {
+	case ListFormatDecimal:
+		return "Decimal"
+	case ListFormatUpperRoman:
+		return "UpperRoman"
+	case ListFormatLowerRoman:
+		return "LowerRoman"
+	case ListFormatUpperLetter:
+		return "UpperLetter"
+	case ListFormatLowerLetter:
+		return "LowerLetter"
+	case ListFormatOrdinal:
+		return "Ordinal"
+	case ListFormatCardinalText:
+		return "CardinalText"
+	case ListFormatOrdinalText:
+		return "OrdinalText"
+	case ListFormatDecimalZero:
+		return "DecimalZero"
+	case ListFormatBullet:
+		return "Bullet"
+	case ListFormatNone:
+		return "None"
+	default:
+		return strconv.Itoa(int(l))
+	}
+}
+
+// ListLevel is a level of a list of the list table.
+type ListLevel struct {
+	Format ListFormat `json:"format"`
+	Start  int        `json:"start"`
+
+	// Text is the text of the number, in which %1 to %9 stand for the number
+	// of the first to ninth level, such as "%1.%2." or a bullet character.
+	Text string `json:"text"`
+
+	// LeftIndent and FirstLineIndent are the indents of the paragraphs of the
+	// level in twips.
+	LeftIndent      int `json:"leftIndent,omitempty"`
+	FirstLineIndent int `json:"firstLineIndent,omitempty"`
+}
+
+// List is a list definition of the {\*\listtable} group.
+type List struct {
+	ID     int         `json:"listId"`
+	Levels []ListLevel `json:"levels"`
+}
+
+// ListTable maps the list override numbers paragraphs refer to with \ls to
+// their list.
+type ListTable map[TableRef]List
+
+// listBullets are the bullets of the levels of bulleted lists, in turn.
+var listBullets = []string{"•", "o", "▪"}
+
+// listNumbers are the formats of the levels of numbered lists, in turn.
+var listNumbers = []ListFormat{ListFormatDecimal, ListFormatLowerLetter, ListFormatLowerRoman}
+
+// add adds a list of 9 levels, bulleted or numbered, after the last entry,
+// returning its entry. Each level is indented by half an inch more than the
+// previous one.
+func (t ListTable) add(numbered bool) TableRef {
+	ref := TableRef(len(t) + 1)
+	for _, ok := t[ref]; ok; _, ok = t[ref] {
+		ref++
+	}
+
+	list := List{ID: int(ref), Levels: []ListLevel{}}
+	for i := 0; i < 9; i++ {
+		level := ListLevel{
+			Format:          ListFormatBullet,
+			Start:           1,
+			Text:            listBullets[i%len(listBullets)],
+			LeftIndent:      720 * (i + 1),
+			FirstLineIndent: -360,
+		}
+		if numbered {
+			level.Format = listNumbers[i%len(listNumbers)]
+			level.Text = "%" + strconv.Itoa(i+1) + "."
+		}
+		list.Levels = append(list.Levels, level)
+	}
+	t[ref] = list
+
+	return ref
+}
+
+// parseListTable reads the list definitions of the {\*\listtable} group and
+// the overrides of the {\*\listoverridetable} group that refer to them.
+// Overrides of individual levels are not supported.
+func (r *RtfParser) parseListTable(listTable *Group, overrideTable *Group) ListTable {
+	lists := map[int]List{}
+	for _, group := range listTable.FindAll("list") {
+		list := List{Levels: []ListLevel{}}
+		if id, ok := group.ControlWord("listid"); ok {
+			list.ID = id.Param
+		}
+
+		for _, level := range group.Groups() {
+			if _, ok := level.ControlWord("listlevel"); ok {
+				list.Levels = append(list.Levels, r.parseListLevel(level))
+			}
+		}
+
+		lists[list.ID] = list
+	}
+
+	table := ListTable{}
+	if overrideTable == nil {
+		return table
+	}
+
+	for _, override := range overrideTable.FindAll("listoverride") {
+		id, hasID := override.ControlWord("listid")
+		ls, hasLs := override.ControlWord("ls")
+		if !hasID || !hasLs || ls.Param < 0 {
+			continue
+		}
+
+		if list, ok := lists[id.Param]; ok {
+			table[TableRef(ls.Param)] = list
+		}
+	}
+
+	return table
+}
+
+func (r *RtfParser) parseListLevel(g *Group) ListLevel {
+	level := ListLevel{Start: 1}
+
+	for _, tkn := range g.tokens() {
+		if tkn.Kind != TokenKindControlWord {
+			continue
+		}
+
+		controlWord := controlWordFromToken(tkn)
+		switch controlWord.controlWordType {
+		case controlWordTypeListLevelNfc, controlWordTypeListLevelNfcn:
+			level.Format = ListFormat(max(controlWord.parameter, 0))
+		case controlWordTypeListLevelStartAt:
+			level.Start = controlWord.parameter
+		case controlWordTypeLeftIndent:
+			level.LeftIndent = controlWord.parameter
+		case controlWordTypeFirstLineIndent:
+			level.FirstLineIndent = controlWord.parameter
+		}
+	}
+
+	if text := g.Find("leveltext"); text != nil {
+		level.Text = r.parseLevelText(text)
+	}
+
+	return level
+}
+
+// parseLevelText reads a {\leveltext} group, whose first character is the
+// length of the text and in which the characters 0 to 8 stand for the
+// numbers of the levels.
+func (r *RtfParser) parseLevelText(g *Group) string {
+	characters := []rune{}
+	skip := 0
+
+	for _, tkn := range g.tokens() {
+		switch tkn.Kind {
+		case TokenKindControlSymbol:
+			if tkn.Name != "'" || !tkn.HasParam {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			characters = append(characters, []rune(string(decodeCodePage(r.codePage, byte(tkn.Param))))...)
+
+		case TokenKindControlWord:
+			if tkn.Name == "u" && tkn.HasParam {
+				codePoint := tkn.Param
+				if codePoint < 0 {
+					codePoint += 65536
+				}
+				characters = append(characters, rune(codePoint))
+				skip = 1
+			}
+
+		case TokenKindText:
+			for _, c := range tkn.Text {
+				if skip > 0 {
+					skip--
+					continue
+				}
+				characters = append(characters, c)
+			}
+		}
+	}
+
+	if len(characters) == 0 {
+		return ""
+	}
+
+	length := min(int(characters[0]), len(characters)-1)
+
+	var text strings.Builder
+	for _, c := range characters[1 : 1+length] {
+		if c < 9 {
+			text.WriteString("%" + strconv.Itoa(int(c)+1))
+			continue
+		}
+		text.WriteRune(c)
+	}
+
+	return text.String()
+}
@@ -0,0 +1,39 @@
+package gortf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseListTable(t *testing.T) {
+	content := `{\rtf1\ansi{\*\listtable{\list\listtemplateid9\listhybrid` +
+		`{\listlevel\levelnfc0\levelnfcn0\leveljc0\levelfollow0\levelstartat3{\leveltext\'02\'00.;}{\levelnumbers\'01;}\fi-360\li720}` +
+		`{\listlevel\levelnfc4\levelstartat1{\leveltext\'04\'00.\'01);}{\levelnumbers\'01\'03;}\fi-360\li1440}` +
+		`{\listname ;}\listid12}` +
+		`{\list\listsimple{\listlevel\levelnfc23{\leveltext\'01\u8226 ?;}}\listid13}}` +
+		`{\*\listoverridetable{\listoverride\listid12\listoverridecount0\ls1}{\listoverride\listid13\listoverridecount0\ls2}{\listoverride\listid99\ls3}}` +
+		`\pard\ls1\ilvl1 Item\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ListTable{
+		1: {ID: 12, Levels: []ListLevel{
+			{Format: ListFormatDecimal, Start: 3, Text: "%1.", LeftIndent: 720, FirstLineIndent: -360},
+			{Format: ListFormatLowerLetter, Start: 1, Text: "%1.%2)", LeftIndent: 1440, FirstLineIndent: -360},
+		}},
+		2: {ID: 13, Levels: []ListLevel{{Format: ListFormatBullet, Start: 1, Text: "•"}}},
+	}
+
+	if !reflect.DeepEqual(doc.Header.Lists, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Header.Lists)
+	}
+
+	paragraph := doc.Paragraphs()[0].Format
+	if paragraph.List != 1 || paragraph.ListLevel != 1 {
+		t.Errorf("\n\nexpected: list 1 level 1\n\nactual\t: %v", paragraph)
+	}
+}
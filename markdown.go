@@ -0,0 +1,337 @@
+package gortf
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownRun is a piece of a paragraph with uniform formatting.
+type markdownRun struct {
+	painter Painter
+	text    string
+
+	// image is the Markdown of a picture, written as is
+	image string
+
+	// link is the target of the link the run belongs to, if any
+	link string
+}
+
+// RTFToMarkdown renders the body of a document as Markdown. Every line of the
+// body becomes a paragraph; paragraphs in the "heading 1" to "heading 6"
+// styles become headings, list paragraphs list items and tables pipe
+// tables. Bold and italic text use emphasis, and underlined text, which
+// Markdown cannot express, the <u> HTML element. Hyperlinks to http, https
+// and mailto addresses and to bookmarks become links. Text deleted while
+// revisions were tracked is left out. Pictures are embedded, objects are
+// shown by their results and shapes by their pictures and the text of their
+// text boxes.
+func RTFToMarkdown(r *RtfDocument) (string, error) {
+	rendered := []markdownPart{}
+
+	for _, section := range sectionsOf(r.flatBody()) {
+		for _, element := range section.Elements {
+			switch element := element.(type) {
+			case *Paragraph:
+				rendered = append(rendered, markdownParagraph(r, element)...)
+			case *Table:
+				if table := markdownTable(r, element); table != "" {
+					rendered = append(rendered, markdownPart{text: table})
+				}
+			}
+		}
+	}
+
+	if len(rendered) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	for i, block := range rendered {
+		if i > 0 {
+			// the items of a list are kept together
+			if block.listItem && rendered[i-1].listItem {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(block.text)
+	}
+	sb.WriteString("\n")
+
+	return sb.String(), nil
+}
+
+// markdownPart is a paragraph, list item, heading or table rendered as
+// Markdown.
+type markdownPart struct {
+	text     string
+	listItem bool
+}
+
+// markdownParagraph renders each line of a paragraph as a block, the first
+// one being a heading or list item as the format of the paragraph says.
+// Headings are written on a single line.
+func markdownParagraph(r *RtfDocument, paragraph *Paragraph) []markdownPart {
+	if level, style := markdownHeading(r, paragraph.Format); level > 0 {
+		// headings are bold or italic by their style already
+		runs := append([]StyleBlock{}, paragraph.Runs...)
+		for i := range runs {
+			runs[i].Painter.Bold = runs[i].Painter.Bold && !style.Painter.Bold
+			runs[i].Painter.Italic = runs[i].Painter.Italic && !style.Painter.Italic
+		}
+
+		text := strings.Join(markdownLines(r, runs), " ")
+		if text == "" {
+			return nil
+		}
+		return []markdownPart{{text: strings.Repeat("#", level) + " " + text}}
+	}
+
+	lines := markdownLines(r, paragraph.Runs)
+	blocks := []markdownPart{}
+	marker, indent, isListItem := markdownListItemMarker(r, paragraph.Format)
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		if isListItem && i == 0 {
+			blocks = append(blocks, markdownPart{text: indent + marker + line, listItem: true})
+			continue
+		}
+		blocks = append(blocks, markdownPart{text: line})
+	}
+
+	return blocks
+}
+
+// markdownLines renders the runs of a paragraph, returning a line of
+// Markdown for each of its lines.
+func markdownLines(r *RtfDocument, runs []StyleBlock) []string {
+	lines := [][]markdownRun{{}}
+
+	for _, styleBlock := range runs {
+		if styleBlock.Painter.Deleted && styleBlock.Kind == BlockKindText {
+			continue
+		}
+
+		if styleBlock.Kind == BlockKindPicture {
+			if image := markdownImage(r, styleBlock.PictureIndex); image != "" {
+				lines[len(lines)-1] = append(lines[len(lines)-1], markdownRun{image: image})
+			}
+			continue
+		}
+
+		// links are made to the same targets as in HTML
+		link := htmlLinkTarget(styleBlock.Painter)
+		for i, text := range strings.Split(styleBlock.Text, "\n") {
+			if i > 0 {
+				lines = append(lines, []markdownRun{})
+			}
+			if text == "" {
+				continue
+			}
+
+			line := lines[len(lines)-1]
+			if last := len(line) - 1; last >= 0 && line[last].image == "" && line[last].link == link && sameMarkdownStyle(line[last].painter, styleBlock.Painter) {
+				line[last].text += text
+				continue
+			}
+
+			lines[len(lines)-1] = append(line, markdownRun{
+				painter: styleBlock.Painter,
+				text:    text,
+				link:    link,
+			})
+		}
+	}
+
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		var sb strings.Builder
+		for j := 0; j < len(line); j++ {
+			if line[j].link == "" {
+				writeMarkdownRun(&sb, line[j])
+				continue
+			}
+
+			// the runs of a link are written within its brackets
+			sb.WriteString("[")
+			link := line[j].link
+			for ; j < len(line) && line[j].link == link; j++ {
+				writeMarkdownRun(&sb, line[j])
+			}
+			j--
+			sb.WriteString("](" + markdownLinkEscaper.Replace(link) + ")")
+		}
+		rendered[i] = strings.TrimSpace(sb.String())
+	}
+
+	return rendered
+}
+
+// markdownHeading returns the level and style of the heading style of a
+// paragraph, or 0 if it is not a heading.
+func markdownHeading(r *RtfDocument, format ParagraphFormat) (int, Style) {
+	for _, style := range r.Header.Stylesheet {
+		if style.Number != format.Style {
+			continue
+		}
+		if match := markdownHeadingStyle.FindStringSubmatch(strings.ToLower(style.Name)); match != nil {
+			return int(match[1][0] - '0'), style
+		}
+	}
+
+	return 0, Style{}
+}
+
+var markdownHeadingStyle = regexp.MustCompile(`^heading ([1-6])$`)
+
+// markdownListItemMarker returns the marker of a list paragraph, a bullet or a
+// number as its list level says, and the indent of its level.
+func markdownListItemMarker(r *RtfDocument, format ParagraphFormat) (string, string, bool) {
+	if format.List == 0 || format.InTable {
+		return "", "", false
+	}
+
+	indent := strings.Repeat("    ", min(max(format.ListLevel, 0), 8))
+	list, ok := r.Header.Lists[format.List]
+	if !ok || format.ListLevel < 0 || format.ListLevel >= len(list.Levels) || list.Levels[format.ListLevel].Format == ListFormatBullet {
+		return "- ", indent, true
+	}
+
+	return "1. ", indent, true
+}
+
+// markdownTable renders a table as a pipe table, the first row of which is
+// its header. The paragraphs of a cell are separated by line breaks.
+func markdownTable(r *RtfDocument, table *Table) string {
+	rows := [][]string{}
+	columns := 0
+	for _, row := range table.Rows {
+		cells := []string{}
+		for _, cell := range row.Cells {
+			paragraphs := []string{}
+			for _, paragraph := range cell.Paragraphs {
+				for _, line := range markdownLines(r, paragraph.Runs) {
+					if line != "" {
+						paragraphs = append(paragraphs, strings.ReplaceAll(line, "|", `\|`))
+					}
+				}
+			}
+			cells = append(cells, strings.Join(paragraphs, "<br>"))
+		}
+		rows = append(rows, cells)
+		columns = max(columns, len(cells))
+	}
+
+	if columns == 0 {
+		return ""
+	}
+
+	lines := []string{}
+	for i, cells := range rows {
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// markdownLinkEscaper escapes the characters that would end the address of
+// a link.
+var markdownLinkEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
+// markdownImage returns the image of a picture, whose data is embedded.
+// Pictures of formats other programs cannot read are left out.
+func markdownImage(r *RtfDocument, index int) string {
+	if index < 0 || index >= len(r.Pictures) {
+		return ""
+	}
+
+	src, ok := r.Pictures[index].dataURI()
+	if !ok {
+		return ""
+	}
+
+	return "![" + escapeMarkdown(r.Pictures[index].Name, false) + "](" + src + ")"
+}
+
+func sameMarkdownStyle(a Painter, b Painter) bool {
+	return a.Bold == b.Bold && a.Italic == b.Italic && a.Underline == b.Underline
+}
+
+// writeMarkdownRun writes the text of a run wrapped in its emphasis markers.
+// Surrounding whitespace is kept outside of the markers, which would not be
+// recognized otherwise.
+func writeMarkdownRun(sb *strings.Builder, run markdownRun) {
+	if run.image != "" {
+		sb.WriteString(run.image)
+		return
+	}
+
+	core := strings.TrimLeft(run.text, " \t")
+	sb.WriteString(run.text[:len(run.text)-len(core)])
+
+	trailing := core[len(strings.TrimRight(core, " \t")):]
+	core = core[:len(core)-len(trailing)]
+
+	if core != "" {
+		opening, closing := "", ""
+		if run.painter.Underline {
+			opening, closing = "<u>", "</u>"
+		}
+
+		switch {
+		case run.painter.Bold && run.painter.Italic:
+			opening, closing = opening+"***", "***"+closing
+		case run.painter.Bold:
+			opening, closing = opening+"**", "**"+closing
+		case run.painter.Italic:
+			opening, closing = opening+"*", "*"+closing
+		}
+
+		lineStart := opening == "" && strings.TrimSpace(sb.String()) == ""
+
+		sb.WriteString(opening)
+		sb.WriteString(escapeMarkdown(core, lineStart))
+		sb.WriteString(closing)
+	}
+
+	sb.WriteString(trailing)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`,
+	`[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`,
+)
+
+// escapeMarkdown escapes the characters of text that Markdown would
+// interpret, including those that only have a meaning at the start of a line.
+func escapeMarkdown(text string, lineStart bool) string {
+	escaped := markdownEscaper.Replace(text)
+
+	if !lineStart || escaped == "" {
+		return escaped
+	}
+
+	// list markers, headings and fences, quotes and emphasis being escaped
+	// wherever they are
+	if strings.ContainsRune("#-+~=", rune(escaped[0])) {
+		return `\` + escaped
+	}
+	if match := markdownOrderedListMarker.FindStringIndex(escaped); match != nil {
+		return escaped[:match[1]-1] + `\` + escaped[match[1]-1:]
+	}
+
+	return escaped
+}
+
+// markdownOrderedListMarker matches the number starting an ordered list item.
+var markdownOrderedListMarker = regexp.MustCompile(`^[0-9]{1,9}[.)]`)
@@ -0,0 +1,78 @@
+package gortf
+
+import (
+	"testing"
+)
+
+func TestRTFToMarkdown(t *testing.T) {
+	content := `{\rtf1\ansi Some {\b bold }and {\i italic} text\par`
+	content += `{\b\i both} and {\ul under}{\ul\b lined}\par\par`
+	content += `# not a heading, 2 * 3 [x]_y_\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Some **bold** and *italic* text\n\n"
+	expected += "***both*** and <u>under</u><u>**lined**</u>\n\n"
+	expected += `\# not a heading, 2 \* 3 \[x\]\_y\_` + "\n"
+
+	markdown, _ := doc.ToMarkdown()
+	if markdown != expected {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, markdown)
+	}
+}
+
+func TestRTFToMarkdownStructure(t *testing.T) {
+	b := NewBuilder()
+	b.Heading(1, "Report")
+	b.Paragraph().Text("See ").Link("https://example.com/a b", "the site").Text(", not ").Link("javascript:alert(1)", "this")
+	b.Heading(2, "Items")
+	b.BulletList().Item("First").Item("Second")
+	b.NumberedList().Item("One")
+	b.Table(2000, 2000).Row("Name", "Value").Row("a|b", "2")
+	built, err := b.Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := built.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "# Report\n\n"
+	expected += "See [the site](https://example.com/a%20b), not this\n\n"
+	expected += "## Items\n\n"
+	expected += "- First\n- Second\n1. One\n\n"
+	expected += "| Name | Value |\n| --- | --- |\n| a\\|b | 2 |\n"
+
+	markdown, _ := doc.ToMarkdown()
+	if markdown != expected {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, markdown)
+	}
+}
+
+func TestRTFToMarkdownLineStarts(t *testing.T) {
+	content := `{\rtf1\ansi 1. not a list\par 2) nor this\par > not a quote\par * not an item\par ~~~ not a fence\par 2024 was a year\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1\\. not a list\n\n2\\) nor this\n\n\\> not a quote\n\n\\* not an item\n\n\\~~~ not a fence\n\n2024 was a year\n"
+
+	markdown, _ := doc.ToMarkdown()
+	if markdown != expected {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, markdown)
+	}
+}
@@ -0,0 +1,1100 @@
+package gortf
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MarkdownToRTF converts Markdown to RTF. See ParseMarkdown.
+func MarkdownToRTF(content string) (string, error) {
+	doc, err := ParseMarkdown(content)
+	if err != nil {
+		return "", err
+	}
+
+	return DocumentToRTF(&doc)
+}
+
+// ParseMarkdown reads CommonMark, with the tables and strikethrough of GitHub
+// Flavored Markdown, into the document model.
+//
+// The document is read as the HTML it renders to would be by ParseHTML:
+// headings use the "heading 1" to "heading 6" styles of the stylesheet,
+// emphasis and strikethrough set the flags of the painter, code is set in
+// Courier New, links become hyperlinks, block quotes are indented, and each
+// list has an entry of the list table. HTML within the Markdown is read as
+// well.
+func ParseMarkdown(content string) (RtfDocument, error) {
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\r", "\n")
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = expandIndentTabs(line)
+	}
+
+	m := &markdownReader{references: map[string]markdownLink{}}
+	blocks := m.blocks(lines)
+
+	return readHTML(m.render(blocks, false)), nil
+}
+
+// expandIndentTabs replaces the tabs of the indentation of a line with
+// spaces, tab stops being 4 columns apart.
+func expandIndentTabs(line string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			b.WriteByte(' ')
+		case '\t':
+			b.WriteString(strings.Repeat(" ", 4-b.Len()%4))
+		default:
+			return b.String() + line[i:]
+		}
+	}
+
+	return b.String()
+}
+
+type markdownBlockKind int
+
+const (
+	markdownBlockParagraph markdownBlockKind = iota
+	markdownBlockHeading
+	markdownBlockRule
+	markdownBlockCode
+	markdownBlockQuote
+	markdownBlockList
+	markdownBlockItem
+	markdownBlockTable
+	markdownBlockHTML
+)
+
+// markdownBlock is a block of a Markdown document, holding either text or
+// other blocks.
+type markdownBlock struct {
+	kind     markdownBlockKind
+	text     string
+	level    int
+	children []markdownBlock
+
+	// lists
+	ordered bool
+	start   int
+	tight   bool
+
+	// tables, the first row being the header
+	alignments []string
+	rows       [][]string
+}
+
+// markdownLink is the destination and title of a link reference definition.
+type markdownLink struct {
+	destination string
+	title       string
+}
+
+type markdownReader struct {
+	references map[string]markdownLink
+}
+
+func lineIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// stripIndent removes up to width spaces from the start of a line.
+func stripIndent(line string, width int) string {
+	return line[min(lineIndent(line), width):]
+}
+
+var (
+	markdownATXHeading     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))??(?:[ \t]+#+)?[ \t]*$`)
+	markdownThematicBreak  = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	markdownFence          = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+	markdownSetext         = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	markdownListMarker     = regexp.MustCompile(`^ {0,3}(?:([-+*])|(\d{1,9})([.)]))(?:([ \t]+)|$)`)
+	markdownReference      = regexp.MustCompile(`^ {0,3}\[((?:[^\[\]\\]|\\.)+)\]:[ \t]*(<[^<>\n]*>|\S+)(?:[ \t]+("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|\((?:[^()\\]|\\.)*\)))?[ \t]*$`)
+	markdownTableDelimiter = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	markdownHTMLRaw        = regexp.MustCompile(`(?i)^ {0,3}<(script|pre|style|textarea)(?:[ \t>]|$)`)
+	markdownHTMLBlock      = regexp.MustCompile(`^ {0,3}</?([A-Za-z][A-Za-z0-9]*)(?:[ \t/>]|$)`)
+)
+
+// markdownHTMLStart returns the kind of the HTML block a line starts: raw text
+// elements, which end at their end tag, comments, or other block elements,
+// which end at a blank line.
+func markdownHTMLStart(line string) string {
+	if match := markdownHTMLRaw.FindStringSubmatch(line); match != nil {
+		return strings.ToLower(match[1])
+	}
+	if strings.HasPrefix(strings.TrimLeft(line, " "), "<!--") && lineIndent(line) < 4 {
+		return "--"
+	}
+	if match := markdownHTMLBlock.FindStringSubmatch(line); match != nil && htmlBlockElements[strings.ToLower(match[1])] {
+		return "block"
+	}
+
+	return ""
+}
+
+// markdownItem is the list marker of a line starting a list item.
+type markdownItem struct {
+	ordered bool
+	marker  string
+	start   int
+	offset  int
+	empty   bool
+}
+
+func markdownListItem(line string) (markdownItem, bool) {
+	match := markdownListMarker.FindStringSubmatchIndex(line)
+	if match == nil || markdownThematicBreak.MatchString(line) {
+		return markdownItem{}, false
+	}
+
+	item := markdownItem{offset: match[1]}
+	if match[2] >= 0 {
+		item.marker = line[match[2]:match[3]]
+	} else {
+		item.ordered = true
+		item.start, _ = strconv.Atoi(line[match[4]:match[5]])
+		item.marker = line[match[6]:match[7]]
+	}
+
+	item.empty = isBlankLine(line[match[1]:])
+	if match[8] < 0 || item.empty || match[9]-match[8] > 4 {
+		// content indented more than 4 spaces is indented code, after a
+		// single space
+		item.offset = match[1] - max(match[9]-match[8], 0) + 1
+	}
+
+	return item, true
+}
+
+// markdownInterrupts reports whether a line starts a block even within a
+// paragraph.
+func markdownInterrupts(line string) bool {
+	if lineIndent(line) >= 4 {
+		return false
+	}
+	if markdownATXHeading.MatchString(line) || markdownThematicBreak.MatchString(line) ||
+		markdownFence.MatchString(line) || strings.HasPrefix(strings.TrimLeft(line, " "), ">") {
+		return true
+	}
+	if kind := markdownHTMLStart(line); kind != "" {
+		return true
+	}
+	if item, ok := markdownListItem(line); ok && !item.empty && (!item.ordered || item.start == 1) {
+		return true
+	}
+
+	return false
+}
+
+// blocks reads lines into blocks.
+func (m *markdownReader) blocks(lines []string) []markdownBlock {
+	blocks := []markdownBlock{}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		if isBlankLine(line) {
+			i++
+			continue
+		}
+
+		if lineIndent(line) >= 4 {
+			code := []string{}
+			for ; i < len(lines) && (isBlankLine(lines[i]) || lineIndent(lines[i]) >= 4); i++ {
+				code = append(code, stripIndent(lines[i], 4))
+			}
+			for len(code) > 0 && isBlankLine(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, markdownBlock{kind: markdownBlockCode, text: strings.Join(code, "\n")})
+			continue
+		}
+
+		if match := markdownFence.FindStringSubmatch(line); match != nil && !(match[2][0] == '`' && strings.Contains(match[3], "`")) {
+			indent, fence := len(match[1]), match[2]
+			code := []string{}
+			for i++; i < len(lines); i++ {
+				closing := strings.TrimSpace(lines[i])
+				if lineIndent(lines[i]) < 4 && strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, stripIndent(lines[i], indent))
+			}
+			blocks = append(blocks, markdownBlock{kind: markdownBlockCode, text: strings.Join(code, "\n")})
+			continue
+		}
+
+		if match := markdownATXHeading.FindStringSubmatch(line); match != nil {
+			blocks = append(blocks, markdownBlock{kind: markdownBlockHeading, level: len(match[1]), text: match[2]})
+			i++
+			continue
+		}
+
+		if markdownThematicBreak.MatchString(line) {
+			blocks = append(blocks, markdownBlock{kind: markdownBlockRule})
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(strings.TrimLeft(line, " "), ">") {
+			quoted := []string{}
+			lazy := false
+			for ; i < len(lines); i++ {
+				line := lines[i]
+				if trimmed := strings.TrimLeft(line, " "); lineIndent(line) < 4 && strings.HasPrefix(trimmed, ">") {
+					line = strings.TrimPrefix(expandIndentTabs(trimmed[1:]), " ")
+					lazy = !isBlankLine(line) && lineIndent(line) < 4 && !markdownInterrupts(line)
+				} else if !lazy || isBlankLine(line) || markdownInterrupts(line) {
+					break
+				}
+				quoted = append(quoted, line)
+			}
+			blocks = append(blocks, markdownBlock{kind: markdownBlockQuote, children: m.blocks(quoted)})
+			continue
+		}
+
+		if item, ok := markdownListItem(line); ok {
+			list := markdownBlock{kind: markdownBlockList, ordered: item.ordered, start: item.start, tight: true}
+			i = m.list(lines, i, &list)
+			blocks = append(blocks, list)
+			continue
+		}
+
+		if kind := markdownHTMLStart(line); kind != "" {
+			raw := []string{}
+			for ; i < len(lines); i++ {
+				if kind == "block" && isBlankLine(lines[i]) {
+					break
+				}
+				raw = append(raw, lines[i])
+				if kind == "--" && strings.Contains(lines[i], "-->") ||
+					kind != "block" && kind != "--" && strings.Contains(strings.ToLower(lines[i]), "</"+kind+">") {
+					i++
+					break
+				}
+			}
+			blocks = append(blocks, markdownBlock{kind: markdownBlockHTML, text: strings.Join(raw, "\n")})
+			continue
+		}
+
+		if i+1 < len(lines) && strings.Contains(line, "|") && markdownTableDelimiter.MatchString(lines[i+1]) {
+			header := markdownTableRow(line)
+			delimiter := markdownTableRow(lines[i+1])
+			if len(header) == len(delimiter) {
+				table := markdownBlock{kind: markdownBlockTable, rows: [][]string{header}}
+				for _, cell := range delimiter {
+					cell = strings.TrimSpace(cell)
+					switch {
+					case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+						table.alignments = append(table.alignments, "center")
+					case strings.HasSuffix(cell, ":"):
+						table.alignments = append(table.alignments, "right")
+					case strings.HasPrefix(cell, ":"):
+						table.alignments = append(table.alignments, "left")
+					default:
+						table.alignments = append(table.alignments, "")
+					}
+				}
+				for i += 2; i < len(lines) && !isBlankLine(lines[i]) && !markdownInterrupts(lines[i]); i++ {
+					row := markdownTableRow(lines[i])
+					for len(row) < len(header) {
+						row = append(row, "")
+					}
+					table.rows = append(table.rows, row[:len(header)])
+				}
+				blocks = append(blocks, table)
+				continue
+			}
+		}
+
+		paragraph := []string{line}
+		heading := 0
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if isBlankLine(line) {
+				break
+			}
+			if match := markdownSetext.FindStringSubmatch(line); match != nil {
+				heading = 2
+				if match[1][0] == '=' {
+					heading = 1
+				}
+				i++
+				break
+			}
+			if markdownInterrupts(line) {
+				break
+			}
+			paragraph = append(paragraph, line)
+		}
+
+		paragraph = m.definitions(paragraph)
+		switch {
+		case len(paragraph) == 0:
+		case heading > 0:
+			blocks = append(blocks, markdownBlock{kind: markdownBlockHeading, level: heading, text: markdownInlineSource(paragraph)})
+		default:
+			blocks = append(blocks, markdownBlock{kind: markdownBlockParagraph, text: markdownInlineSource(paragraph)})
+		}
+	}
+
+	return blocks
+}
+
+// list reads the items of a list starting at line i, returning the line
+// after it.
+func (m *markdownReader) list(lines []string, i int, list *markdownBlock) int {
+	first, _ := markdownListItem(lines[i])
+
+	for i < len(lines) {
+		item, ok := markdownListItem(lines[i])
+		if !ok || item.ordered != first.ordered || item.marker != first.marker {
+			break
+		}
+
+		content := []string{lines[i][min(item.offset, len(lines[i])):]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			switch {
+			case isBlankLine(line):
+				// an item starts with at most one blank line
+				if len(content) == 1 && isBlankLine(content[0]) {
+					break
+				}
+				content = append(content, "")
+				continue
+			case lineIndent(line) >= item.offset:
+				content = append(content, line[item.offset:])
+				continue
+			case !isBlankLine(content[len(content)-1]) && !markdownInterrupts(line):
+				if _, ok := markdownListItem(line); !ok {
+					content = append(content, line)
+					continue
+				}
+			}
+			break
+		}
+
+		// blank lines between the blocks of items, or between items, make
+		// lists loose
+		trailing := 0
+		for len(content) > 0 && isBlankLine(content[len(content)-1]) {
+			content = content[:len(content)-1]
+			trailing++
+		}
+		children := m.blocks(content)
+		for j := 1; j < len(content) && len(children) > 1; j++ {
+			if isBlankLine(content[j]) {
+				list.tight = false
+			}
+		}
+		if next, ok := markdownListItem(lineAt(lines, i)); trailing > 0 && ok && next.ordered == first.ordered && next.marker == first.marker {
+			list.tight = false
+		}
+
+		list.children = append(list.children, markdownBlock{kind: markdownBlockItem, children: children})
+	}
+
+	return i
+}
+
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+
+	return ""
+}
+
+// definitions reads the link reference definitions starting a paragraph,
+// returning its remaining lines.
+func (m *markdownReader) definitions(lines []string) []string {
+	for len(lines) > 0 {
+		match := markdownReference.FindStringSubmatch(lines[0])
+		if match == nil {
+			break
+		}
+
+		label := markdownLabel(match[1])
+		if _, ok := m.references[label]; !ok && label != "" {
+			destination := strings.TrimSuffix(strings.TrimPrefix(match[2], "<"), ">")
+			title := ""
+			if len(match[3]) >= 2 {
+				title = match[3][1 : len(match[3])-1]
+			}
+			m.references[label] = markdownLink{destination: unescapeMarkdown(destination), title: unescapeMarkdown(title)}
+		}
+		lines = lines[1:]
+	}
+
+	return lines
+}
+
+func markdownLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// markdownInlineSource joins the lines of a paragraph, removing the indentation of
+// each and the trailing spaces of the last.
+func markdownInlineSource(lines []string) string {
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimLeft(line, " \t")
+	}
+
+	return strings.TrimRight(strings.Join(trimmed, "\n"), " \t")
+}
+
+// markdownTableRow splits a table row into its cells, at pipes that are not
+// escaped.
+func markdownTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	cells := []string{}
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func htmlStartToken(name string, attributes map[string]string) htmlToken {
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+
+	return htmlToken{kind: htmlTokenStart, name: name, attributes: attributes}
+}
+
+func htmlEndToken(name string) htmlToken {
+	return htmlToken{kind: htmlTokenEnd, name: name}
+}
+
+// render returns the HTML tokens of blocks. The paragraphs of tight lists
+// are not wrapped in paragraph elements.
+func (m *markdownReader) render(blocks []markdownBlock, tight bool) []htmlToken {
+	tokens := []htmlToken{}
+
+	for _, block := range blocks {
+		switch block.kind {
+		case markdownBlockParagraph:
+			if tight {
+				tokens = append(tokens, m.inlines(block.text)...)
+				continue
+			}
+			tokens = append(tokens, htmlStartToken("p", nil))
+			tokens = append(tokens, m.inlines(block.text)...)
+			tokens = append(tokens, htmlEndToken("p"))
+
+		case markdownBlockHeading:
+			name := "h" + strconv.Itoa(block.level)
+			tokens = append(tokens, htmlStartToken(name, nil))
+			tokens = append(tokens, m.inlines(block.text)...)
+			tokens = append(tokens, htmlEndToken(name))
+
+		case markdownBlockRule:
+			tokens = append(tokens, htmlStartToken("hr", nil))
+
+		case markdownBlockCode:
+			// the reader drops the newline starting preformatted text
+			tokens = append(tokens, htmlStartToken("pre", nil), htmlStartToken("code", nil),
+				htmlToken{kind: htmlTokenText, text: "\n" + block.text},
+				htmlEndToken("code"), htmlEndToken("pre"))
+
+		case markdownBlockQuote:
+			tokens = append(tokens, htmlStartToken("blockquote", nil))
+			tokens = append(tokens, m.render(block.children, false)...)
+			tokens = append(tokens, htmlEndToken("blockquote"))
+
+		case markdownBlockList:
+			name, attributes := "ul", map[string]string{}
+			if block.ordered {
+				name = "ol"
+				if block.start != 1 {
+					attributes["start"] = strconv.Itoa(block.start)
+				}
+			}
+			tokens = append(tokens, htmlStartToken(name, attributes))
+			for _, item := range block.children {
+				tokens = append(tokens, htmlStartToken("li", nil))
+				tokens = append(tokens, m.render(item.children, block.tight)...)
+				tokens = append(tokens, htmlEndToken("li"))
+			}
+			tokens = append(tokens, htmlEndToken(name))
+
+		case markdownBlockTable:
+			tokens = append(tokens, htmlStartToken("table", nil))
+			for r, row := range block.rows {
+				section, cell := "tbody", "td"
+				if r == 0 {
+					section, cell = "thead", "th"
+				}
+				if r <= 1 {
+					if r == 1 {
+						tokens = append(tokens, htmlEndToken("thead"))
+					}
+					tokens = append(tokens, htmlStartToken(section, nil))
+				}
+				tokens = append(tokens, htmlStartToken("tr", nil))
+				for c, text := range row {
+					attributes := map[string]string{}
+					if block.alignments[c] != "" {
+						attributes["align"] = block.alignments[c]
+					}
+					tokens = append(tokens, htmlStartToken(cell, attributes))
+					tokens = append(tokens, m.inlines(text)...)
+					tokens = append(tokens, htmlEndToken(cell))
+				}
+				tokens = append(tokens, htmlEndToken("tr"))
+			}
+			tokens = append(tokens, htmlEndToken("table"))
+
+		case markdownBlockHTML:
+			tokens = append(tokens, tokenizeHTML(block.text)...)
+		}
+	}
+
+	return tokens
+}
+
+// markdownInline is a piece of the inline content of a block: text, an HTML
+// token, a run of emphasis delimiters, or the opening bracket of a link or
+// image.
+type markdownInline struct {
+	text  string
+	token *htmlToken
+
+	// delimiter runs, with the number of delimiters left and the tags they
+	// open and close
+	delimiter byte
+	count     int
+	length    int
+	canOpen   bool
+	canClose  bool
+	inactive  bool
+	opens     []htmlToken
+	closes    []htmlToken
+
+	// brackets, with the position of the link text
+	bracket  bool
+	image    bool
+	position int
+}
+
+var (
+	markdownEntity       = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	markdownAutolink     = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`)
+	markdownEmailLink    = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*)>`)
+	markdownInlineHTML   = regexp.MustCompile(`^(?:<[A-Za-z][A-Za-z0-9-]*(?:\s+[A-Za-z_:][A-Za-z0-9_.:-]*(?:\s*=\s*(?:[^\s"'=<>` + "`" + `]+|'[^']*'|"[^"]*"))?)*\s*/?>|</[A-Za-z][A-Za-z0-9-]*\s*>|<!--(?:[^-]|-[^-])*-->)`)
+	markdownExtendedLink = regexp.MustCompile(`^(?:https?://|www\.)[^\s<]*`)
+)
+
+// inlines returns the HTML tokens of the inline content of a block.
+func (m *markdownReader) inlines(source string) []htmlToken {
+	nodes := []markdownInline{}
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, markdownInline{text: text.String()})
+			text.Reset()
+		}
+	}
+	add := func(node markdownInline) {
+		flush()
+		nodes = append(nodes, node)
+	}
+	addToken := func(tkn htmlToken) {
+		add(markdownInline{token: &tkn})
+	}
+
+	for i := 0; i < len(source); {
+		c := source[i]
+
+		switch {
+		case c == '\\' && i+1 < len(source) && source[i+1] == '\n':
+			addToken(htmlStartToken("br", nil))
+			i += 2
+
+		case c == '\\' && i+1 < len(source) && isASCIIPunctuation(source[i+1]):
+			text.WriteByte(source[i+1])
+			i += 2
+
+		case c == '`':
+			length := runLength(source, i)
+			end := i + length
+			for end < len(source) {
+				next := strings.IndexByte(source[end:], '`')
+				if next < 0 {
+					end = len(source)
+					break
+				}
+				end += next
+				if runLength(source, end) == length {
+					break
+				}
+				end += runLength(source, end)
+			}
+			if end >= len(source) {
+				text.WriteString(source[i : i+length])
+				i += length
+				continue
+			}
+
+			code := strings.ReplaceAll(source[i+length:end], "\n", " ")
+			if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			addToken(htmlStartToken("code", nil))
+			addToken(htmlToken{kind: htmlTokenText, text: code})
+			addToken(htmlEndToken("code"))
+			i = end + length
+
+		case c == '*' || c == '_' || c == '~':
+			length := runLength(source, i)
+			if c == '~' && length > 2 {
+				text.WriteString(source[i : i+length])
+				i += length
+				continue
+			}
+
+			before, _ := utf8.DecodeLastRuneInString(source[:i])
+			after, _ := utf8.DecodeRuneInString(source[i+length:])
+			if i == 0 {
+				before = ' '
+			}
+			if i+length == len(source) {
+				after = ' '
+			}
+			left := !unicode.IsSpace(after) && (!isPunctuation(after) || unicode.IsSpace(before) || isPunctuation(before))
+			right := !unicode.IsSpace(before) && (!isPunctuation(before) || unicode.IsSpace(after) || isPunctuation(after))
+
+			node := markdownInline{delimiter: c, count: length, length: length, canOpen: left, canClose: right}
+			if c == '_' {
+				node.canOpen = left && (!right || isPunctuation(before))
+				node.canClose = right && (!left || isPunctuation(after))
+			}
+			add(node)
+			i += length
+
+		case c == '!' && i+1 < len(source) && source[i+1] == '[':
+			add(markdownInline{bracket: true, image: true, position: i + 2})
+			i += 2
+
+		case c == '[':
+			add(markdownInline{bracket: true, position: i + 1})
+			i++
+
+		case c == ']':
+			flush()
+			consumed := m.closeBracket(&nodes, source, i)
+			if consumed == 0 {
+				text.WriteByte(']')
+				consumed = 1
+			}
+			i += consumed
+
+		case c == '<':
+			if match := markdownAutolink.FindStringSubmatch(source[i:]); match != nil {
+				addToken(htmlStartToken("a", map[string]string{"href": match[1]}))
+				addToken(htmlToken{kind: htmlTokenText, text: match[1]})
+				addToken(htmlEndToken("a"))
+				i += len(match[0])
+			} else if match := markdownEmailLink.FindStringSubmatch(source[i:]); match != nil {
+				addToken(htmlStartToken("a", map[string]string{"href": "mailto:" + match[1]}))
+				addToken(htmlToken{kind: htmlTokenText, text: match[1]})
+				addToken(htmlEndToken("a"))
+				i += len(match[0])
+			} else if match := markdownInlineHTML.FindString(source[i:]); match != "" {
+				for _, tkn := range tokenizeHTML(match) {
+					addToken(tkn)
+				}
+				i += len(match)
+			} else {
+				text.WriteByte(c)
+				i++
+			}
+
+		case c == '&':
+			if match := markdownEntity.FindString(source[i:]); match != "" {
+				text.WriteString(html.UnescapeString(match))
+				i += len(match)
+			} else {
+				text.WriteByte(c)
+				i++
+			}
+
+		case c == '\n':
+			preceding := text.String()
+			trimmed := strings.TrimRight(preceding, " ")
+			text.Reset()
+			text.WriteString(trimmed)
+			if len(preceding)-len(trimmed) >= 2 {
+				addToken(htmlStartToken("br", nil))
+			} else {
+				text.WriteByte('\n')
+			}
+			for i++; i < len(source) && source[i] == ' '; i++ {
+			}
+
+		case (c == 'h' || c == 'w') && (i == 0 || strings.ContainsRune(" \n*_~(", rune(source[i-1]))) &&
+			markdownExtendedLink.MatchString(source[i:]):
+			link := markdownTrimLink(markdownExtendedLink.FindString(source[i:]))
+			if link == "www." || strings.HasSuffix(link, "://") {
+				text.WriteByte(c)
+				i++
+				continue
+			}
+			href := link
+			if strings.HasPrefix(link, "www.") {
+				href = "http://" + link
+			}
+			addToken(htmlStartToken("a", map[string]string{"href": href}))
+			addToken(htmlToken{kind: htmlTokenText, text: link})
+			addToken(htmlEndToken("a"))
+			i += len(link)
+
+		default:
+			text.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+
+	markdownEmphasis(nodes, 0)
+
+	return markdownTokens(nodes)
+}
+
+// closeBracket handles a closing bracket at position i of source: if it ends
+// the text of a link or image, the nodes from its opening bracket are
+// replaced with it, and the length of the source read is returned.
+func (m *markdownReader) closeBracket(nodes *[]markdownInline, source string, i int) int {
+	opener := -1
+	for k := len(*nodes) - 1; k >= 0; k-- {
+		if (*nodes)[k].bracket {
+			opener = k
+			break
+		}
+	}
+	if opener < 0 {
+		return 0
+	}
+
+	bracket := &(*nodes)[opener]
+	if bracket.inactive {
+		*bracket = markdownInline{text: "["}
+		return 0
+	}
+
+	label := source[bracket.position:i]
+	link, length, ok := markdownLink{}, 1, false
+
+	rest := source[i+1:]
+	if strings.HasPrefix(rest, "(") {
+		var consumed int
+		if link, consumed, ok = parseInlineLink(rest); ok {
+			length += consumed
+		}
+	}
+	if !ok && strings.HasPrefix(rest, "[") {
+		if end := strings.IndexByte(rest, ']'); end >= 0 {
+			reference := rest[1:end]
+			if strings.TrimSpace(reference) == "" {
+				reference = label
+			}
+			if link, ok = m.references[markdownLabel(reference)]; ok {
+				length += end + 1
+			}
+		}
+	}
+	if !ok {
+		link, ok = m.references[markdownLabel(label)]
+	}
+
+	if !ok {
+		if bracket.image {
+			*bracket = markdownInline{text: "!["}
+		} else {
+			*bracket = markdownInline{text: "["}
+		}
+		return 0
+	}
+
+	markdownEmphasis(*nodes, opener+1)
+	for k := opener + 1; k < len(*nodes); k++ {
+		(*nodes)[k].inactive = true
+	}
+
+	if bracket.image {
+		alt := []string{}
+		for _, tkn := range markdownTokens((*nodes)[opener+1:]) {
+			if tkn.kind == htmlTokenText {
+				alt = append(alt, tkn.text)
+			}
+		}
+		attributes := map[string]string{"src": link.destination, "alt": strings.Join(alt, "")}
+		if link.title != "" {
+			attributes["title"] = link.title
+		}
+		tkn := htmlStartToken("img", attributes)
+		*nodes = append((*nodes)[:opener], markdownInline{token: &tkn})
+		return length
+	}
+
+	attributes := map[string]string{"href": link.destination}
+	if link.title != "" {
+		attributes["title"] = link.title
+	}
+	start, end := htmlStartToken("a", attributes), htmlEndToken("a")
+	(*nodes)[opener] = markdownInline{token: &start}
+	*nodes = append(*nodes, markdownInline{token: &end})
+
+	// links do not contain links
+	for k := 0; k < opener; k++ {
+		if (*nodes)[k].bracket && !(*nodes)[k].image {
+			(*nodes)[k].inactive = true
+		}
+	}
+
+	return length
+}
+
+// parseInlineLink reads the destination and title of an inline link, in
+// parentheses, returning them and the length read.
+func parseInlineLink(source string) (markdownLink, int, bool) {
+	i := 1
+	skipSpace := func() {
+		for i < len(source) && strings.ContainsRune(" \t\n", rune(source[i])) {
+			i++
+		}
+	}
+
+	skipSpace()
+	link := markdownLink{}
+	if i < len(source) && source[i] == '<' {
+		end := strings.IndexAny(source[i+1:], ">\n")
+		if end < 0 || source[i+1+end] != '>' {
+			return markdownLink{}, 0, false
+		}
+		link.destination = source[i+1 : i+1+end]
+		i += end + 2
+	} else {
+		start, depth := i, 0
+		for ; i < len(source); i++ {
+			c := source[i]
+			if c == '\\' && i+1 < len(source) && isASCIIPunctuation(source[i+1]) {
+				i++
+				continue
+			}
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			} else if c <= ' ' {
+				break
+			}
+		}
+		link.destination = source[start:i]
+	}
+
+	spaced := i
+	skipSpace()
+	if i > spaced && i < len(source) && strings.ContainsRune(`"'(`, rune(source[i])) {
+		closing := source[i]
+		if closing == '(' {
+			closing = ')'
+		}
+		end := i + 1
+		for ; end < len(source) && source[end] != closing; end++ {
+			if source[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(source) {
+			return markdownLink{}, 0, false
+		}
+		link.title = source[i+1 : end]
+		i = end + 1
+		skipSpace()
+	}
+
+	if i >= len(source) || source[i] != ')' {
+		return markdownLink{}, 0, false
+	}
+
+	link.destination = unescapeMarkdown(link.destination)
+	link.title = unescapeMarkdown(link.title)
+
+	return link, i + 1, true
+}
+
+// markdownEmphasis matches the delimiter runs from bottom on, as openers and
+// closers of emphasis, strong emphasis and strikethrough.
+func markdownEmphasis(nodes []markdownInline, bottom int) {
+	for c := bottom; c < len(nodes); c++ {
+		closer := &nodes[c]
+		if closer.delimiter == 0 || !closer.canClose || closer.inactive {
+			continue
+		}
+
+		for closer.count > 0 {
+			o := -1
+			for k := c - 1; k >= bottom; k-- {
+				opener := &nodes[k]
+				if opener.delimiter != closer.delimiter || !opener.canOpen || opener.inactive || opener.count == 0 {
+					continue
+				}
+				if closer.delimiter == '~' {
+					if opener.count != closer.count {
+						continue
+					}
+				} else if (opener.canClose || closer.canOpen) && (opener.length+closer.length)%3 == 0 &&
+					!(opener.length%3 == 0 && closer.length%3 == 0) {
+					continue
+				}
+				o = k
+				break
+			}
+			if o < 0 {
+				break
+			}
+
+			opener := &nodes[o]
+			use, name := 1, "em"
+			switch {
+			case closer.delimiter == '~':
+				use, name = closer.count, "del"
+			case opener.count >= 2 && closer.count >= 2:
+				use, name = 2, "strong"
+			}
+
+			opener.count -= use
+			closer.count -= use
+			opener.opens = append([]htmlToken{htmlStartToken(name, nil)}, opener.opens...)
+			closer.closes = append(closer.closes, htmlEndToken(name))
+
+			for k := o + 1; k < c; k++ {
+				if nodes[k].delimiter != 0 {
+					nodes[k].inactive = true
+				}
+			}
+		}
+	}
+}
+
+// markdownTokens returns the HTML tokens of inline nodes, joining text.
+func markdownTokens(nodes []markdownInline) []htmlToken {
+	tokens := []htmlToken{}
+	addText := func(text string) {
+		if text == "" {
+			return
+		}
+		if n := len(tokens); n > 0 && tokens[n-1].kind == htmlTokenText {
+			tokens[n-1].text += text
+			return
+		}
+		tokens = append(tokens, htmlToken{kind: htmlTokenText, text: text})
+	}
+
+	for _, node := range nodes {
+		switch {
+		case node.token != nil && node.token.kind == htmlTokenText:
+			addText(node.token.text)
+		case node.token != nil:
+			tokens = append(tokens, *node.token)
+		case node.delimiter != 0:
+			tokens = append(tokens, node.closes...)
+			addText(strings.Repeat(string(node.delimiter), node.count))
+			tokens = append(tokens, node.opens...)
+		case node.bracket && node.image:
+			addText("![")
+		case node.bracket:
+			addText("[")
+		default:
+			addText(node.text)
+		}
+	}
+
+	return tokens
+}
+
+// markdownTrimLink removes the trailing punctuation and unbalanced closing
+// parentheses of a link found in text.
+func markdownTrimLink(link string) string {
+	for len(link) > 0 {
+		last := link[len(link)-1]
+		switch {
+		case strings.IndexByte("?!.,:*_~'\"", last) >= 0:
+			link = link[:len(link)-1]
+		case last == ')' && strings.Count(link, ")") > strings.Count(link, "("):
+			link = link[:len(link)-1]
+		default:
+			return link
+		}
+	}
+
+	return link
+}
+
+// unescapeMarkdown replaces the backslash escapes and character references
+// of link destinations and titles.
+func unescapeMarkdown(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && isASCIIPunctuation(text[i+1]) {
+			i++
+		}
+		b.WriteByte(text[i])
+	}
+
+	return html.UnescapeString(b.String())
+}
+
+func runLength(text string, i int) int {
+	length := 0
+	for i+length < len(text) && text[i+length] == text[i] {
+		length++
+	}
+
+	return length
+}
+
+func isASCIIPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
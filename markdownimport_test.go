@@ -0,0 +1,165 @@
+package gortf
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// tokensHTML writes tokens back as HTML, with sorted attributes.
+func tokensHTML(tokens []htmlToken) string {
+	var b strings.Builder
+
+	for _, tkn := range tokens {
+		switch tkn.kind {
+		case htmlTokenText:
+			b.WriteString(tkn.text)
+		case htmlTokenStart:
+			b.WriteString("<" + tkn.name)
+			names := []string{}
+			for name := range tkn.attributes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				b.WriteString(" " + name + `="` + tkn.attributes[name] + `"`)
+			}
+			b.WriteString(">")
+		case htmlTokenEnd:
+			b.WriteString("</" + tkn.name + ">")
+		}
+	}
+
+	return b.String()
+}
+
+func TestMarkdownBlocks(t *testing.T) {
+	tests := map[string]string{
+		"# One #\n## Two\nSetext\n---":    "<h1>One</h1><h2>Two</h2><h2>Setext</h2>",
+		"a\nb\n\nc":                       "<p>a\nb</p><p>c</p>",
+		"***\n- - -":                      "<hr><hr>",
+		"    code\n\n    more\n":          "<pre><code>\ncode\n\nmore</code></pre>",
+		"```go\n  x\n```\ny":              "<pre><code>\n  x</code></pre><p>y</p>",
+		"> a\nlazy\n> > b":                "<blockquote><p>a\nlazy</p><blockquote><p>b</p></blockquote></blockquote>",
+		"- a\n- b\n  - c\n+ d":            "<ul><li>a</li><li>b<ul><li>c</li></ul></li></ul><ul><li>d</li></ul>",
+		"3. a\n\n4. b":                    `<ol start="3"><li><p>a</p></li><li><p>b</p></li></ol>`,
+		"1) a\n2. b":                      "<ol><li>a</li></ol><ol start=\"2\"><li>b</li></ol>",
+		"text\n2. no list":                "<p>text\n2. no list</p>",
+		"| a | b |\n|:-:|--:|\n| 1 |\n":   `<table><thead><tr><th align="center">a</th><th align="right">b</th></tr></thead><tbody><tr><td align="center">1</td><td align="right"></td></tr></table>`,
+		"<div>\n*raw*\n</div>\n\n*md*":    "<div>\n*raw*\n</div><p><em>md</em></p>",
+		"[x]: /url \"Title\"\n\nSee [x].": `<p>See <a href="/url" title="Title">x</a>.</p>`,
+		"a | b\n--|--\n`c|d` | e \\| f":   `<table><thead><tr><th>a</th><th>b</th></tr></thead><tbody><tr><td>` + "`c</td><td>d`</td></tr></table>",
+		"\tindented\n":                    "<pre><code>\nindented</code></pre>",
+		"- item\n\n  continued\n- next":   "<ul><li><p>item</p><p>continued</p></li><li><p>next</p></li></ul>",
+		"1. one\n   ```\n   code\n   ```": "<ol><li>one<pre><code>\ncode</code></pre></li></ol>",
+	}
+
+	for content, expected := range tests {
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			lines[i] = expandIndentTabs(line)
+		}
+
+		m := &markdownReader{references: map[string]markdownLink{}}
+		actual := tokensHTML(m.render(m.blocks(lines), false))
+		if actual != expected {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+}
+
+func TestMarkdownInlines(t *testing.T) {
+	tests := map[string]string{
+		"*a* _b_ **c** __d__":           "<em>a</em> <em>b</em> <strong>c</strong> <strong>d</strong>",
+		"***a*** ~~b~~ ~c~ ~~~d~~~":     "<em><strong>a</strong></em> <del>b</del> <del>c</del> ~~~d~~~",
+		"*a **b** c* snake_case_name":   "<em>a <strong>b</strong> c</em> snake_case_name",
+		"*foo**bar**baz* **foo*bar**":   "<em>foo<strong>bar</strong>baz</em> <strong>foo*bar</strong>",
+		"* not emphasis * a*":           "* not emphasis * a*",
+		"`a  *b*` `` c`d `` `open":      "<code>a  *b*</code> <code>c`d</code> `open",
+		"\\*lit\\* &amp; &copy; &bogus": "*lit* & © &bogus",
+		"soft\nbreak  \nhard\\\nhard":   "soft\nbreak<br>hard<br>hard",
+		"[a *b*](/u 'T') [c](<d e>)":    `<a href="/u" title="T">a <em>b</em></a> <a href="d e">c</a>`,
+		"[a [b](/in)](/out) [no link]":  `[a <a href="/in">b</a>](/out) [no link]`,
+		"*[a*](/u)":                     `*<a href="/u">a*</a>`,
+		"![alt *x*](data:image/png)":    `<img alt="alt x" src="data:image/png">`,
+		"<http://a.b/c> <me@x.org>":     `<a href="http://a.b/c">http://a.b/c</a> <a href="mailto:me@x.org">me@x.org</a>`,
+		"see www.x.org/a. or https://y.z/(a)).": `see <a href="http://www.x.org/a">www.x.org/a</a>. or ` +
+			`<a href="https://y.z/(a)">https://y.z/(a)</a>).`,
+		`a <span class="x">b</span> <3`: `a <span class="x">b</span> <3`,
+	}
+
+	for content, expected := range tests {
+		m := &markdownReader{references: map[string]markdownLink{}}
+		actual := tokensHTML(m.inlines(content))
+		if actual != expected {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	content := "# Reply\n\nHi *there*, see `docs` at [the site](https://example.com).\n\n" +
+		"- one\n- two\n\n> quoted **text**\n"
+
+	doc, err := ParseMarkdown(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := Painter{FontSize: 24}
+	heading := Painter{Bold: true, FontSize: 32}
+
+	expected := []Section{{
+		Elements: []BodyElement{
+			&Paragraph{
+				Format: ParagraphFormat{Style: 1, SpaceBefore: 240, SpaceAfter: 60, KeepWithNext: true},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "Reply", Painter: heading}},
+				Mark:   &heading,
+			},
+			&Paragraph{
+				Runs: []StyleBlock{
+					{Kind: BlockKindText, Text: "Hi ", Painter: body},
+					{Kind: BlockKindText, Text: "there", Painter: Painter{FontSize: 24, Italic: true}},
+					{Kind: BlockKindText, Text: ", see ", Painter: body},
+					{Kind: BlockKindText, Text: "docs", Painter: Painter{FontRef: 1, FontSize: 24}},
+					{Kind: BlockKindText, Text: " at ", Painter: body},
+					{Kind: BlockKindText, Text: "the site", Painter: Painter{FontSize: 24, Link: "https://example.com"}},
+					{Kind: BlockKindText, Text: ".", Painter: body},
+				},
+				Mark: &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{List: 1, LeftIndent: 720, FirstLineIndent: -360},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "one", Painter: body}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{List: 1, LeftIndent: 720, FirstLineIndent: -360},
+				Runs:   []StyleBlock{{Kind: BlockKindText, Text: "two", Painter: body}},
+				Mark:   &body,
+			},
+			&Paragraph{
+				Format: ParagraphFormat{LeftIndent: 720},
+				Runs: []StyleBlock{
+					{Kind: BlockKindText, Text: "quoted ", Painter: body},
+					{Kind: BlockKindText, Text: "text", Painter: Painter{FontSize: 24, Bold: true}},
+				},
+				Mark: &body,
+			},
+		},
+	}}
+
+	actual := doc.Sections()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	if font := doc.Header.FontTable[1]; font.Name != "Courier New" || font.Pitch != FontPitchFixed {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a monospace font", font)
+	}
+
+	if style, ok := doc.Header.Stylesheet["heading 1"]; !ok || !style.Painter.Bold {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a heading 1 style", doc.Header.Stylesheet)
+	}
+}
@@ -0,0 +1,322 @@
+package gortf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// ObjectType tells how an object is kept in the document, as given by the
+// control word following \object.
+type ObjectType int
+
+const (
+	// ObjectTypeEmbedded is an object whose data is in the document
+	// (\objemb).
+	ObjectTypeEmbedded ObjectType = iota
+	// ObjectTypeLink is a link to the file of the object (\objlink), and
+	// ObjectTypeAutoLink one updated automatically (\objautlink).
+	ObjectTypeLink
+	ObjectTypeAutoLink
+	// ObjectTypeSubscriber and ObjectTypePublisher are the Macintosh
+	// editions (\objsub, \objpub).
+	ObjectTypeSubscriber
+	ObjectTypePublisher
+	// ObjectTypeICEmbedded is an MS Word for Macintosh installable command
+	// (\objicemb).
+	ObjectTypeICEmbedded
+	// ObjectTypeHTML is an HTML control (\objhtml).
+	ObjectTypeHTML
+	// ObjectTypeOCX is an OLE control (\objocx).
+	ObjectTypeOCX
+)
+
+func (o ObjectType) String() string {
+	switch o {
+	case ObjectTypeEmbedded:
+		return "Embedded"
+	case ObjectTypeLink:
+		return "Link"
+	case ObjectTypeAutoLink:
+		return "AutoLink"
+	case ObjectTypeSubscriber:
+		return "Subscriber"
+	case ObjectTypePublisher:
+		return "Publisher"
+	case ObjectTypeICEmbedded:
+		return "ICEmbedded"
+	case ObjectTypeHTML:
+		return "HTML"
+	case ObjectTypeOCX:
+		return "OCX"
+	default:
+		return "Unknown"
+	}
+}
+
+var objectTypeWords = map[ObjectType]string{
+	ObjectTypeEmbedded: "objemb", ObjectTypeLink: "objlink", ObjectTypeAutoLink: "objautlink",
+	ObjectTypeSubscriber: "objsub", ObjectTypePublisher: "objpub", ObjectTypeICEmbedded: "objicemb",
+	ObjectTypeHTML: "objhtml", ObjectTypeOCX: "objocx",
+}
+
+func objectTypeFromToken(tkn controlWordToken) ObjectType {
+	for objectType, word := range objectTypeWords {
+		if tkn.name == `\`+word {
+			return objectType
+		}
+	}
+
+	return ObjectTypeEmbedded
+}
+
+// Object is an OLE object, such as a spreadsheet or an equation, from an
+// {\object} group. The body refers to it with a block of kind
+// BlockKindObject.
+type Object struct {
+	Type ObjectType
+
+	// Class is the name of the application the object belongs to, such as
+	// "Excel.Sheet.8" (\objclass), and Name that of the object (\objname).
+	Class string
+	Name  string
+
+	// Width and Height are the size of the object in twips, and ScaleX and
+	// ScaleY its horizontal and vertical scaling in percent.
+	Width  int
+	Height int
+	ScaleX int
+	ScaleY int
+
+	// Data is the object as an OLE1 stream (\objdata). See Native.
+	Data []byte
+
+	// Result is the rendering of the object kept for programs that cannot
+	// run its application, most often a picture (\result).
+	Result []StyleBlock
+}
+
+// ErrNoNativeData is returned for objects whose data holds no native data,
+// such as links.
+var ErrNoNativeData = errors.New("gortf: object has no native data")
+
+var errObjectData = errors.New("gortf: malformed object data")
+
+// Native returns the data the application of the object stores, which
+// follows the OLE1 header of Data. For objects of OLE2 applications it is a
+// compound file; see Streams.
+func (o Object) Native() ([]byte, error) {
+	data := o.Data
+
+	readUint32 := func() (uint32, bool) {
+		if len(data) < 4 {
+			return 0, false
+		}
+		value := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		return value, true
+	}
+
+	// version and format, 2 for embedded objects
+	if _, ok := readUint32(); !ok {
+		return nil, errObjectData
+	}
+	format, ok := readUint32()
+	if !ok {
+		return nil, errObjectData
+	}
+	if format != 2 {
+		return nil, ErrNoNativeData
+	}
+
+	// class, topic and item names
+	for i := 0; i < 3; i++ {
+		length, ok := readUint32()
+		if !ok || uint64(length) > uint64(len(data)) {
+			return nil, errObjectData
+		}
+		data = data[length:]
+	}
+
+	size, ok := readUint32()
+	if !ok || uint64(size) > uint64(len(data)) {
+		return nil, errObjectData
+	}
+
+	return data[:size], nil
+}
+
+// IsCompound reports whether the native data of the object is an OLE2
+// compound file.
+func (o Object) IsCompound() bool {
+	native, err := o.Native()
+	return err == nil && isCompoundFile(native)
+}
+
+// Streams returns the streams of the compound file holding the native data
+// of an OLE2 object, by path, storages and streams being separated by
+// slashes.
+func (o Object) Streams() (map[string][]byte, error) {
+	native, err := o.Native()
+	if err != nil {
+		return nil, err
+	}
+
+	return readCompoundFile(native)
+}
+
+// EmbeddedFile is a file packaged in the document as an object of class
+// Package.
+type EmbeddedFile struct {
+	// Name is the label of the file, normally its name, and Path the path
+	// it was packaged from.
+	Name string
+	Path string
+	Data []byte
+}
+
+// EmbeddedFile returns the file of an object of class Package, whose native
+// data holds it either directly or in the \x01Ole10Native stream of a
+// compound file. It fails for other objects.
+func (o Object) EmbeddedFile() (EmbeddedFile, bool) {
+	if !strings.HasPrefix(o.Class, "Package") {
+		return EmbeddedFile{}, false
+	}
+
+	native, err := o.Native()
+	if err != nil {
+		return EmbeddedFile{}, false
+	}
+
+	if isCompoundFile(native) {
+		streams, err := readCompoundFile(native)
+		if err != nil {
+			return EmbeddedFile{}, false
+		}
+		stream, ok := streams["\x01Ole10Native"]
+		if !ok || len(stream) < 4 {
+			return EmbeddedFile{}, false
+		}
+		native = stream[4:]
+	}
+
+	return parsePackage(native)
+}
+
+// parsePackage reads the native data of the Packager: a signature, the label
+// and path of the file, a type, 3 for embedded files, a temporary path and
+// the content of the file.
+func parsePackage(data []byte) (EmbeddedFile, bool) {
+	readString := func() (string, bool) {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			return "", false
+		}
+		value := string(data[:end])
+		data = data[end+1:]
+		return value, true
+	}
+
+	if len(data) < 2 || binary.LittleEndian.Uint16(data) != 2 {
+		return EmbeddedFile{}, false
+	}
+	data = data[2:]
+
+	file := EmbeddedFile{}
+	var ok bool
+	if file.Name, ok = readString(); !ok {
+		return EmbeddedFile{}, false
+	}
+	if file.Path, ok = readString(); !ok {
+		return EmbeddedFile{}, false
+	}
+
+	if len(data) < 8 || binary.LittleEndian.Uint16(data[2:]) != 3 {
+		return EmbeddedFile{}, false
+	}
+	data = data[4:]
+
+	length := binary.LittleEndian.Uint32(data)
+	if uint64(length) > uint64(len(data)-4) {
+		return EmbeddedFile{}, false
+	}
+	data = data[4+length:]
+
+	if len(data) < 4 {
+		return EmbeddedFile{}, false
+	}
+	size := binary.LittleEndian.Uint32(data)
+	if uint64(size) > uint64(len(data)-4) {
+		return EmbeddedFile{}, false
+	}
+	file.Data = data[4 : 4+size]
+
+	return file, true
+}
+
+// objectResult returns the runs of the result of an object, shown in its
+// place by formats that cannot hold the object itself. The marks of its
+// paragraphs are left out so that it stays within the paragraph of the
+// object.
+func (r *RtfDocument) objectResult(index int) []StyleBlock {
+	if index < 0 || index >= len(r.Objects) {
+		return nil
+	}
+
+	runs := []StyleBlock{}
+	for _, block := range r.Objects[index].Result {
+		switch block.Kind {
+		case BlockKindParagraph, BlockKindCell, BlockKindRow, BlockKindSection, BlockKindObject:
+		default:
+			runs = append(runs, block)
+		}
+	}
+
+	return runs
+}
+
+func (r *RtfParser) addObject(doc *RtfDocument, g *Group) {
+	object := Object{ScaleX: 100, ScaleY: 100}
+
+	for _, tkn := range g.tokens() {
+		if tkn.Kind != TokenKindControlWord {
+			continue
+		}
+
+		controlWord := controlWordFromToken(tkn)
+
+		switch controlWord.controlWordType {
+		case controlWordTypeObjectType:
+			object.Type = objectTypeFromToken(controlWord)
+		case controlWordTypeObjectWidth:
+			object.Width = controlWord.parameter
+		case controlWordTypeObjectHeight:
+			object.Height = controlWord.parameter
+		case controlWordTypeObjectScaleX:
+			object.ScaleX = controlWord.parameter
+		case controlWordTypeObjectScaleY:
+			object.ScaleY = controlWord.parameter
+		}
+	}
+
+	if class := g.Find("objclass"); class != nil {
+		object.Class = strings.TrimSpace(r.textFromGroup(class, r.codePage))
+	}
+	if name := g.Find("objname"); name != nil {
+		object.Name = strings.TrimSpace(r.textFromGroup(name, r.codePage))
+	}
+	if data := g.Find("objdata"); data != nil {
+		object.Data = r.groupData(data, "object")
+	}
+	if result := g.Find("result"); result != nil {
+		object.Result = r.parseSubdocument(doc, result)
+	}
+
+	doc.Objects = append(doc.Objects, object)
+	doc.pushToBody(StyleBlock{
+		Painter:     *r.lastPainter(),
+		Kind:        BlockKindObject,
+		ObjectIndex: len(doc.Objects) - 1,
+	})
+}
@@ -0,0 +1,272 @@
+package gortf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// ole1Stream returns the OLE1 stream of an embedded object.
+func ole1Stream(class string, native []byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, []uint32{0x0501, 2, uint32(len(class) + 1)})
+	b.WriteString(class + "\x00")
+	binary.Write(&b, binary.LittleEndian, []uint32{0, 0, uint32(len(native))})
+	b.Write(native)
+
+	return b.Bytes()
+}
+
+// packagedFile returns the native data of the Packager for a file.
+func packagedFile(name string, path string, content []byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, uint16(2))
+	b.WriteString(name + "\x00" + path + "\x00")
+	binary.Write(&b, binary.LittleEndian, []uint16{0, 3})
+	binary.Write(&b, binary.LittleEndian, uint32(len(path)+1))
+	b.WriteString(path + "\x00")
+	binary.Write(&b, binary.LittleEndian, uint32(len(content)))
+	b.Write(content)
+
+	return b.Bytes()
+}
+
+// compoundFileWith returns a compound file holding a single small stream,
+// which is kept in the mini stream.
+func compoundFileWith(name string, stream []byte) []byte {
+	const end, free = compoundEndOfChain, compoundNoStream
+	miniSectors := (len(stream) + 63) / 64
+	containerSectors := (miniSectors*64 + 511) / 512
+
+	// header, then the allocation table, directory, mini allocation table
+	// and mini stream container
+	file := make([]byte, 512*(4+containerSectors))
+	copy(file, compoundSignature)
+	binary.LittleEndian.PutUint16(file[0x18:], 0x3e)
+	binary.LittleEndian.PutUint16(file[0x1a:], 3)
+	binary.LittleEndian.PutUint16(file[0x1c:], 0xfffe)
+	binary.LittleEndian.PutUint16(file[0x1e:], 9)
+	binary.LittleEndian.PutUint16(file[0x20:], 6)
+	for offset, value := range map[int]uint32{0x2c: 1, 0x30: 1, 0x38: 4096, 0x3c: 2, 0x40: 1, 0x44: end, 0x48: 0} {
+		binary.LittleEndian.PutUint32(file[offset:], value)
+	}
+	for i := 0; i < 109; i++ {
+		binary.LittleEndian.PutUint32(file[0x4c+4*i:], free)
+	}
+	binary.LittleEndian.PutUint32(file[0x4c:], 0)
+
+	sector := func(id int) []byte { return file[512*(id+1) : 512*(id+2)] }
+
+	fat := []uint32{0xfffffffd, end, end}
+	for i := 0; i < containerSectors; i++ {
+		fat = append(fat, uint32(4+i))
+	}
+	fat[len(fat)-1] = end
+	for i := 0; i < 128; i++ {
+		value := uint32(free)
+		if i < len(fat) {
+			value = fat[i]
+		}
+		binary.LittleEndian.PutUint32(sector(0)[4*i:], value)
+	}
+
+	entry := func(index int, name string, kind byte, child uint32, start uint32, size int) {
+		data := sector(1)[128*index:]
+		units := utf16.Encode([]rune(name + "\x00"))
+		for i, unit := range units {
+			binary.LittleEndian.PutUint16(data[2*i:], unit)
+		}
+		binary.LittleEndian.PutUint16(data[0x40:], uint16(2*len(units)))
+		data[0x42] = kind
+		binary.LittleEndian.PutUint32(data[0x44:], free)
+		binary.LittleEndian.PutUint32(data[0x48:], free)
+		binary.LittleEndian.PutUint32(data[0x4c:], child)
+		binary.LittleEndian.PutUint32(data[0x74:], start)
+		binary.LittleEndian.PutUint32(data[0x78:], uint32(size))
+	}
+	entry(0, "Root Entry", 5, 1, 3, miniSectors*64)
+	entry(1, name, 2, free, 0, len(stream))
+	for i := 2; i < 4; i++ {
+		entry(i, "", 0, free, 0, 0)
+	}
+
+	for i := 0; i < 128; i++ {
+		value := uint32(free)
+		if i < miniSectors-1 {
+			value = uint32(i + 1)
+		} else if i == miniSectors-1 {
+			value = end
+		}
+		binary.LittleEndian.PutUint32(sector(2)[4*i:], value)
+	}
+
+	copy(file[512*4:], stream)
+
+	return file
+}
+
+func objectRTF(data []byte) string {
+	return `{\rtf1\ansi Before {\object\objemb\objw1200\objh800\objscalex50{\*\objclass Package}{\*\objname report}` +
+		`{\*\objdata ` + hex.EncodeToString(data) + `}{\result{\pict\pngblip\picw1\pich1 89504e47}}} after\par}`
+}
+
+func TestParseObjects(t *testing.T) {
+	data := ole1Stream("Package", packagedFile("report.txt", `C:\report.txt`, []byte("Quarterly report")))
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(objectRTF(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRuns := []StyleBlock{
+		{Kind: BlockKindText, Text: "Before "},
+		{Kind: BlockKindObject},
+		{Kind: BlockKindText, Text: " after"},
+	}
+	actualRuns := doc.Paragraphs()[0].Runs
+	if !reflect.DeepEqual(expectedRuns, actualRuns) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedRuns, actualRuns)
+	}
+
+	expected := []Object{{
+		Type:   ObjectTypeEmbedded,
+		Class:  "Package",
+		Name:   "report",
+		Width:  1200,
+		Height: 800,
+		ScaleX: 50,
+		ScaleY: 100,
+		Data:   data,
+		Result: []StyleBlock{{Kind: BlockKindPicture}},
+	}}
+	if !reflect.DeepEqual(expected, doc.Objects) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Objects)
+	}
+
+	expectedPictures := []Picture{{Format: PictureFormatPNG, Width: 1, Height: 1, ScaleX: 100, ScaleY: 100, Data: []byte{0x89, 0x50, 0x4e, 0x47}}}
+	if !reflect.DeepEqual(expectedPictures, doc.Pictures) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedPictures, doc.Pictures)
+	}
+
+	if text, _ := doc.ToText(); text != "Before  after\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "Before  after\n", text)
+	}
+}
+
+func TestObjectEmbeddedFile(t *testing.T) {
+	content := []byte("Quarterly report")
+	native := packagedFile("report.txt", `C:\report.txt`, content)
+	expected := EmbeddedFile{Name: "report.txt", Path: `C:\report.txt`, Data: content}
+
+	ole10Native := binary.LittleEndian.AppendUint32(nil, uint32(len(native)))
+	ole10Native = append(ole10Native, native...)
+
+	for _, object := range []Object{
+		{Class: "Package", Data: ole1Stream("Package", native)},
+		{Class: "Package", Data: ole1Stream("Package", compoundFileWith("\x01Ole10Native", ole10Native))},
+	} {
+		actual, ok := object.EmbeddedFile()
+		if !ok || !reflect.DeepEqual(expected, actual) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+		}
+	}
+
+	compound := Object{Class: "Excel.Sheet.8", Data: ole1Stream("Excel.Sheet.8", compoundFileWith("Workbook", content))}
+	if !compound.IsCompound() {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", true, compound.IsCompound())
+	}
+	streams, err := compound.Streams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expectedStreams := map[string][]byte{"Workbook": content}; !reflect.DeepEqual(expectedStreams, streams) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedStreams, streams)
+	}
+	if _, ok := compound.EmbeddedFile(); ok {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", false, ok)
+	}
+
+	link := Object{Type: ObjectTypeLink, Data: binary.LittleEndian.AppendUint32([]byte{1, 5, 0, 0}, 1)}
+	if _, err := link.Native(); err != ErrNoNativeData {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", ErrNoNativeData, err)
+	}
+	if _, err := (Object{Data: []byte{1, 5}}).Native(); err == nil {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", errObjectData, err)
+	}
+}
+
+func TestReadMalformedCompoundFile(t *testing.T) {
+	// the chain of sectors listing the allocation table is longer than the
+	// file, or comes back to its first sector
+	oversized := compoundFileWith("Workbook", []byte("content"))
+	binary.LittleEndian.PutUint32(oversized[0x44:], 3)
+	binary.LittleEndian.PutUint32(oversized[0x48:], 0xffffffff)
+
+	cyclic := compoundFileWith("Workbook", []byte("content"))
+	binary.LittleEndian.PutUint32(cyclic[0x44:], 3)
+	binary.LittleEndian.PutUint32(cyclic[0x48:], 2)
+	binary.LittleEndian.PutUint32(cyclic[512*5-4:], 3)
+
+	for _, data := range [][]byte{oversized, cyclic} {
+		if _, err := readCompoundFile(data); err != errCompoundFile {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", errCompoundFile, err)
+		}
+	}
+}
+
+func TestWriteObjects(t *testing.T) {
+	data := ole1Stream("Package", packagedFile("report.txt", `C:\report.txt`, []byte("Quarterly report")))
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(objectRTF(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser = NewRtfParser()
+	written, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Objects, written.Objects) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Objects, written.Objects)
+	}
+	if !reflect.DeepEqual(doc.Pictures, written.Pictures) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Pictures, written.Pictures)
+	}
+
+	doc.Sanitize(SanitizeOptions{StripObjects: true})
+	if len(doc.Objects) != 0 || len(doc.Paragraphs()[0].Runs) != 2 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no objects", doc.Paragraphs()[0].Runs)
+	}
+}
+
+func TestExportObjectResults(t *testing.T) {
+	content := `{\rtf1\ansi before {\object\objemb{\*\objclass Equation.3}{\*\objdata 0105}` +
+		`{\result\pard result {\b text}\par}} after\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text, _ := doc.ToText(); text != "before result text after\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "before result text after\n", text)
+	}
+	if text, _ := doc.ToHTML(); text != "before result <bold>text</bold> after\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "before result <bold>text</bold> after\n", text)
+	}
+	if text, _ := doc.ToMarkdown(); text != "before result **text** after\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "before result **text** after\n", text)
+	}
+}
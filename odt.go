@@ -0,0 +1,1483 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespaces of the parts of an OpenDocument text document.
+const (
+	odtNamespaceOffice   = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odtNamespaceStyle    = "urn:oasis:names:tc:opendocument:xmlns:style:1.0"
+	odtNamespaceText     = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odtNamespaceTable    = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odtNamespaceDrawing  = "urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+	odtNamespaceFormat   = "urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0"
+	odtNamespaceSVG      = "urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0"
+	odtNamespaceMeta     = "urn:oasis:names:tc:opendocument:xmlns:meta:1.0"
+	odtNamespaceManifest = "urn:oasis:names:tc:opendocument:xmlns:manifest:1.0"
+	odtNamespaceXLink    = "http://www.w3.org/1999/xlink"
+	odtNamespaceDC       = "http://purl.org/dc/elements/1.1/"
+	odtMimeType          = "application/vnd.oasis.opendocument.text"
+	odtVersion           = "1.3"
+)
+
+// odtNamespaces declares the namespaces used by the parts holding content.
+var odtNamespaces = []string{
+	"xmlns:office", odtNamespaceOffice,
+	"xmlns:style", odtNamespaceStyle,
+	"xmlns:text", odtNamespaceText,
+	"xmlns:table", odtNamespaceTable,
+	"xmlns:draw", odtNamespaceDrawing,
+	"xmlns:fo", odtNamespaceFormat,
+	"xmlns:svg", odtNamespaceSVG,
+	"xmlns:xlink", odtNamespaceXLink,
+	"office:version", odtVersion,
+}
+
+// DocumentToODT serializes a document as an OpenDocument text document.
+func DocumentToODT(r *RtfDocument) ([]byte, error) {
+	var b bytes.Buffer
+	if err := WriteODT(&b, r); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// WriteODT serializes a document as an OpenDocument text document to w. The
+// formatting of paragraphs and runs becomes automatic styles, the fonts of
+// the font table font face declarations, and the stylesheet and lists common
+// styles. The information group is written to the metadata. Each section
+// that starts on a new page has a master page of its own, holding its page
+// layout and headers; the columns of sections that do not start on a new
+// page are not kept. Pictures in formats that cannot be stored in the
+// package, such as Mac PICT, are left out.
+func WriteODT(w io.Writer, r *RtfDocument) error {
+	o := &odtWriter{
+		document: r,
+		styles:   map[int]Style{},
+		pictures: map[int]string{},
+		content:  newODTPart(""),
+		common:   newODTPart("M"),
+	}
+	for _, style := range r.Header.Stylesheet {
+		o.styles[style.Number] = style
+	}
+
+	content := o.writeContent()
+	styles := o.writeStyles()
+	meta := o.writeMeta()
+
+	archive := zip.NewWriter(w)
+
+	// the media type comes first and uncompressed, so that it can be read at
+	// a fixed offset
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write([]byte(odtMimeType)); err != nil {
+		return err
+	}
+
+	files := []odtFile{
+		{"content.xml", "text/xml", content},
+		{"styles.xml", "text/xml", styles},
+		{"meta.xml", "text/xml", meta},
+	}
+	files = append(files, o.files...)
+	files = append(files, odtFile{"META-INF/manifest.xml", "", odtManifest(files)})
+
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		data, err := file.data.bytes()
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// odtFile is a file of the package, XML written with data or a picture.
+type odtFile struct {
+	name      string
+	mediaType string
+	data      interface{ bytes() ([]byte, error) }
+}
+
+// odtData is the content of a file that is not XML.
+type odtData []byte
+
+func (d odtData) bytes() ([]byte, error) {
+	return d, nil
+}
+
+// odtPart is content.xml or styles.xml, whose content is written before the
+// automatic styles it refers to, which come first in the part.
+type odtPart struct {
+	x *xmlWriter
+
+	// automatic styles by their properties, in the order they were added,
+	// with the prefix of their names
+	automatic []xmlElement
+	names     map[string]string
+	counts    map[string]int
+	prefix    string
+}
+
+func newODTPart(prefix string) *odtPart {
+	return &odtPart{
+		x:      newXMLFragmentWriter(),
+		names:  map[string]string{},
+		counts: map[string]int{},
+		prefix: prefix,
+	}
+}
+
+// automaticStyle returns the name of the automatic style holding the
+// attributes and children of element, adding it if needed. Names are made
+// of prefix and a number.
+func (p *odtPart) automaticStyle(prefix string, element xmlElement) string {
+	key := fmt.Sprint(element)
+	if name, ok := p.names[key]; ok {
+		return name
+	}
+
+	p.counts[prefix]++
+	name := p.prefix + prefix + strconv.Itoa(p.counts[prefix])
+	p.names[key] = name
+
+	element.attributes = append([]string{"style:name", name}, element.attributes...)
+	p.automatic = append(p.automatic, element)
+
+	return name
+}
+
+// odtWriter builds the parts of an OpenDocument text document.
+type odtWriter struct {
+	document *RtfDocument
+
+	// styles of the stylesheet by number
+	styles map[int]Style
+
+	// content.xml, and styles.xml which holds headers and footers
+	content *odtPart
+	common  *odtPart
+
+	// names of the picture files by index in the document, and the files
+	// of the package other than its parts
+	pictures map[int]string
+	files    []odtFile
+
+	// number of tables, frames, footnotes and endnotes written, from which
+	// their names and numbers are taken
+	tables    int
+	frames    int
+	footnotes int
+	endnotes  int
+}
+
+// writeContent writes content.xml: the body, with a master page for each
+// section that starts on a new page.
+func (o *odtWriter) writeContent() *xmlWriter {
+	part := o.content
+	x := part.x
+
+	x.start("office:body")
+	x.start("office:text")
+	for i, section := range o.document.Sections() {
+		masterPage := ""
+		if i > 0 && section.Format.Break != SectionBreakNone {
+			masterPage = odtMasterPageName(i)
+		}
+		o.writeElements(part, section.Elements, masterPage)
+	}
+	x.end()
+	x.end()
+
+	return o.writePart("office:document-content", part, nil)
+}
+
+// writePart writes content.xml or styles.xml: the font face declarations,
+// the common styles if given, the automatic styles and the content.
+func (o *odtWriter) writePart(root string, part *odtPart, common *xmlWriter) *xmlWriter {
+	x := newXMLWriter()
+	x.start(root, odtNamespaces...)
+
+	x.start("office:font-face-decls")
+	written := map[string]bool{}
+	for _, key := range sortedTableRefs(o.document.Header.FontTable) {
+		font := o.document.Header.FontTable[key]
+		if font.Name == "" || written[font.Name] {
+			continue
+		}
+		written[font.Name] = true
+
+		attributes := []string{"style:name", font.Name, "svg:font-family", odtQuoteFont(font.Name)}
+		if family, ok := odtFontFamilies[font.FontFamily]; ok {
+			attributes = append(attributes, "style:font-family-generic", family)
+		}
+		switch font.Pitch {
+		case FontPitchFixed:
+			attributes = append(attributes, "style:font-pitch", "fixed")
+		case FontPitchVariable:
+			attributes = append(attributes, "style:font-pitch", "variable")
+		}
+		x.empty("style:font-face", attributes...)
+	}
+	x.end()
+
+	if common != nil {
+		x.raw(common)
+	}
+
+	x.start("office:automatic-styles")
+	for _, style := range part.automatic {
+		x.element(style)
+	}
+	x.end()
+
+	x.raw(part.x)
+	x.end()
+
+	return x
+}
+
+// odtQuoteFont quotes font names holding spaces, as svg:font-family takes a
+// CSS font family.
+func odtQuoteFont(name string) string {
+	if strings.ContainsAny(name, " ,") {
+		return "'" + strings.ReplaceAll(name, "'", "") + "'"
+	}
+
+	return name
+}
+
+var odtFontFamilies = map[FontFamily]string{
+	FontFamilyRoman: "roman", FontFamilySwiss: "swiss", FontFamilyModern: "modern",
+	FontFamilyScript: "script", FontFamilyDecor: "decorative", FontFamilyTech: "system",
+}
+
+func odtMasterPageName(section int) string {
+	if section == 0 {
+		return "Standard"
+	}
+
+	return "Section" + strconv.Itoa(section+1)
+}
+
+// odtStyleName returns the name of a style of the stylesheet, Standard being
+// the style numbered 0.
+func odtStyleName(number int) string {
+	if number == 0 {
+		return "Standard"
+	}
+
+	return "Style" + strconv.Itoa(number)
+}
+
+// odtLength returns a length in twips in points.
+func odtLength(twips int) string {
+	return strconv.FormatFloat(float64(twips)/20, 'f', -1, 64) + "pt"
+}
+
+// style returns the style of the given number, the Standard style standing
+// for those the stylesheet lacks.
+func (o *odtWriter) style(number int) Style {
+	if style, ok := o.styles[number]; ok {
+		return style
+	}
+
+	return o.styles[0]
+}
+
+// writeElements writes the paragraphs and tables of a section or a
+// subdocument, gathering the paragraphs of lists into list elements. The
+// first element starts the given master page, if any.
+func (o *odtWriter) writeElements(part *odtPart, elements []BodyElement, masterPage string) {
+	lists := odtLists{x: part.x}
+
+	for _, element := range elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			lists.enter(e.Format, o.document.Header.Lists)
+			o.writeParagraph(part, *e, masterPage)
+		case *Table:
+			lists.close()
+			o.writeTable(part, e, masterPage)
+		}
+		masterPage = ""
+	}
+
+	lists.close()
+}
+
+// writeParagraphs writes the paragraphs of a cell or a subdocument, which
+// holds at least one.
+func (o *odtWriter) writeParagraphs(part *odtPart, paragraphs []Paragraph) {
+	elements := []BodyElement{}
+	for i := range paragraphs {
+		elements = append(elements, &paragraphs[i])
+	}
+	if len(elements) == 0 {
+		elements = append(elements, &Paragraph{})
+	}
+
+	o.writeElements(part, elements, "")
+}
+
+// writeSubdocument writes the content of a note, header, footer or text box.
+func (o *odtWriter) writeSubdocument(part *odtPart, body []StyleBlock) {
+	elements := []BodyElement{}
+	for _, section := range sectionsOf(body) {
+		elements = append(elements, section.Elements...)
+	}
+	if len(elements) == 0 {
+		elements = append(elements, &Paragraph{})
+	}
+
+	o.writeElements(part, elements, "")
+}
+
+// odtLists writes the list elements around the paragraphs of lists. Levels
+// are nested lists within the item of the level above.
+type odtLists struct {
+	x *xmlWriter
+
+	// list table entry of the open list, and whether an item is open at each
+	// level of those opened
+	list  TableRef
+	items []bool
+}
+
+// enter opens the lists and the item holding a paragraph of the given
+// format, closing those it is not part of.
+func (l *odtLists) enter(format ParagraphFormat, lists ListTable) {
+	if _, ok := lists[format.List]; !ok || format.List == 0 {
+		l.close()
+		return
+	}
+	if format.List != l.list {
+		l.close()
+	}
+
+	level := min(max(format.ListLevel, 0), 9)
+	for len(l.items) > level+1 {
+		l.closeLevel()
+	}
+	if len(l.items) == level+1 && l.items[level] {
+		l.x.end()
+		l.items[level] = false
+	}
+
+	for len(l.items) < level+1 {
+		if len(l.items) == 0 {
+			l.list = format.List
+			l.x.start("text:list", "text:style-name", odtListStyleName(format.List))
+		} else {
+			if !l.items[len(l.items)-1] {
+				l.x.start("text:list-item")
+				l.items[len(l.items)-1] = true
+			}
+			l.x.start("text:list")
+		}
+		l.items = append(l.items, false)
+	}
+
+	l.x.start("text:list-item")
+	l.items[level] = true
+}
+
+// closeLevel closes the item and the list of the deepest level.
+func (l *odtLists) closeLevel() {
+	if l.items[len(l.items)-1] {
+		l.x.end()
+	}
+	l.x.end()
+	l.items = l.items[:len(l.items)-1]
+}
+
+func (l *odtLists) close() {
+	for len(l.items) > 0 {
+		l.closeLevel()
+	}
+	l.list = 0
+}
+
+func odtListStyleName(list TableRef) string {
+	return "L" + strconv.Itoa(int(list))
+}
+
+// writeParagraph writes a paragraph, starting a new one after each page
+// break it holds.
+func (o *odtWriter) writeParagraph(part *odtPart, paragraph Paragraph, masterPage string) {
+	x := part.x
+	style := o.style(paragraph.Format.Style)
+
+	x.start("text:p", "text:style-name", o.paragraphStyle(part, paragraph.Format, masterPage, false))
+
+	link := ""
+	for _, run := range paragraph.Runs {
+		if run.Painter.Link != link || run.Kind == BlockKindPage {
+			if link != "" {
+				x.end()
+			}
+			link = ""
+		}
+
+		if run.Kind == BlockKindPage {
+			x.end()
+			x.start("text:p", "text:style-name", o.paragraphStyle(part, paragraph.Format, "", true))
+			continue
+		}
+
+		if run.Painter.Link != link {
+			link = run.Painter.Link
+			x.start("text:a", "xlink:type", "simple", "xlink:href", link)
+		}
+
+		o.writeRun(part, run, style.Painter)
+	}
+	if link != "" {
+		x.end()
+	}
+
+	x.end()
+}
+
+// paragraphStyle returns the name of the style of a paragraph: an automatic
+// style based on its style of the stylesheet if it differs from it, starts
+// a master page or follows a page break.
+func (o *odtWriter) paragraphStyle(part *odtPart, format ParagraphFormat, masterPage string, pageBreak bool) string {
+	name := odtStyleName(0)
+	if _, ok := o.styles[format.Style]; ok {
+		name = odtStyleName(format.Style)
+	}
+
+	properties := odtParagraphProperties(format, o.style(format.Style).Paragraph)
+	if pageBreak && !format.PageBreakBefore {
+		properties = append(properties, "fo:break-before", "page")
+	}
+	if len(properties) == 0 && masterPage == "" {
+		return name
+	}
+
+	attributes := []string{"style:family", "paragraph", "style:parent-style-name", name}
+	if masterPage != "" {
+		attributes = append(attributes, "style:master-page-name", masterPage)
+	}
+
+	element := newXMLElement("style:style", attributes...)
+	if len(properties) > 0 {
+		element.add("style:paragraph-properties", properties...)
+	}
+
+	return part.automaticStyle("P", element)
+}
+
+// odtParagraphProperties returns the attributes of the paragraph properties
+// of the given format, leaving out those its style already gives.
+func odtParagraphProperties(format ParagraphFormat, style ParagraphFormat) []string {
+	properties := []string{}
+
+	if format.LeftIndent != style.LeftIndent {
+		properties = append(properties, "fo:margin-left", odtLength(format.LeftIndent))
+	}
+	if format.RightIndent != style.RightIndent {
+		properties = append(properties, "fo:margin-right", odtLength(format.RightIndent))
+	}
+	if format.FirstLineIndent != style.FirstLineIndent {
+		properties = append(properties, "fo:text-indent", odtLength(format.FirstLineIndent))
+	}
+	if format.SpaceBefore != style.SpaceBefore {
+		properties = append(properties, "fo:margin-top", odtLength(format.SpaceBefore))
+	}
+	if format.SpaceAfter != style.SpaceAfter {
+		properties = append(properties, "fo:margin-bottom", odtLength(format.SpaceAfter))
+	}
+
+	if format.LineSpacing != style.LineSpacing || format.LineSpacingMultiple != style.LineSpacingMultiple {
+		switch {
+		case format.LineSpacing == 0:
+			properties = append(properties, "fo:line-height", "100%")
+		case format.LineSpacingMultiple:
+			properties = append(properties, "fo:line-height", strconv.Itoa(format.LineSpacing*100/240)+"%")
+		case format.LineSpacing > 0:
+			properties = append(properties, "style:line-height-at-least", odtLength(format.LineSpacing))
+		default:
+			properties = append(properties, "fo:line-height", odtLength(-format.LineSpacing))
+		}
+	}
+
+	if format.Alignment != style.Alignment {
+		properties = append(properties, "fo:text-align", odtAlignment(format.Alignment))
+	}
+
+	for _, property := range []struct {
+		name      string
+		value     bool
+		inherited bool
+		on        string
+		off       string
+	}{
+		{"fo:keep-together", format.KeepTogether, style.KeepTogether, "always", "auto"},
+		{"fo:keep-with-next", format.KeepWithNext, style.KeepWithNext, "always", "auto"},
+		{"fo:break-before", format.PageBreakBefore, style.PageBreakBefore, "page", "auto"},
+	} {
+		if property.value != property.inherited {
+			properties = append(properties, property.name, map[bool]string{true: property.on, false: property.off}[property.value])
+		}
+	}
+
+	return properties
+}
+
+func odtAlignment(alignment Alignment) string {
+	switch alignment {
+	case AlignmentCenter:
+		return "center"
+	case AlignmentRight:
+		return "end"
+	case AlignmentJustify, AlignmentDistribute:
+		return "justify"
+	default:
+		return "start"
+	}
+}
+
+// textProperties returns the attributes of the text properties of painter,
+// leaving out what the paragraph style already gives and turning off what it
+// gives that painter does not.
+func (o *odtWriter) textProperties(painter Painter, style Painter) []string {
+	properties := []string{}
+
+	if painter.FontRef != style.FontRef {
+		if font, ok := o.document.Header.FontTable[painter.FontRef]; ok && font.Name != "" {
+			properties = append(properties, "style:font-name", font.Name)
+		}
+	}
+
+	if size, styleSize := docxFontSize(painter), docxFontSize(style); size != styleSize {
+		properties = append(properties, "fo:font-size", strconv.FormatFloat(float64(size)/2, 'f', -1, 64)+"pt")
+	}
+
+	for _, property := range []struct {
+		names     []string
+		value     bool
+		inherited bool
+		on        []string
+		off       []string
+	}{
+		{[]string{"fo:font-weight"}, painter.Bold, style.Bold, []string{"bold"}, []string{"normal"}},
+		{[]string{"fo:font-style"}, painter.Italic, style.Italic, []string{"italic"}, []string{"normal"}},
+		{
+			[]string{"style:text-underline-style", "style:text-underline-width", "style:text-underline-color"},
+			painter.Underline, style.Underline,
+			[]string{"solid", "auto", "font-color"}, []string{"none"},
+		},
+		{[]string{"style:text-line-through-style"}, painter.Strikethrough, style.Strikethrough, []string{"solid"}, []string{"none"}},
+		{[]string{"fo:font-variant"}, painter.SmallCaps, style.SmallCaps, []string{"small-caps"}, []string{"normal"}},
+		{[]string{"text:display"}, painter.Hidden, style.Hidden, []string{"none"}, []string{"true"}},
+	} {
+		if property.value == property.inherited {
+			continue
+		}
+
+		values := property.off
+		if property.value {
+			values = property.on
+		}
+		for i, value := range values {
+			properties = append(properties, property.names[i], value)
+		}
+	}
+
+	if painter.Superscript != style.Superscript || painter.Subscript != style.Subscript {
+		switch {
+		case painter.Superscript:
+			properties = append(properties, "style:text-position", "super 58%")
+		case painter.Subscript:
+			properties = append(properties, "style:text-position", "sub 58%")
+		default:
+			properties = append(properties, "style:text-position", "0% 100%")
+		}
+	}
+
+	if painter.ForegroundColor != style.ForegroundColor {
+		if color, ok := o.color(painter.ForegroundColor); ok {
+			properties = append(properties, "fo:color", color)
+		} else {
+			properties = append(properties, "style:use-window-font-color", "true")
+		}
+	}
+
+	if background, styleBackground := docxBackground(painter), docxBackground(style); background != styleBackground {
+		color, ok := o.color(background)
+		if !ok {
+			color = "transparent"
+		}
+		properties = append(properties, "fo:background-color", color)
+	}
+
+	return properties
+}
+
+// color returns a color table entry as a hexadecimal color, failing for the
+// auto color and missing entries.
+func (o *odtWriter) color(ref TableRef) (string, bool) {
+	color, ok := o.document.Header.ColorTable[ref]
+	if !ok || ref == 0 || color.Auto {
+		return "", false
+	}
+
+	return color.Hex(), true
+}
+
+// writeRun writes a run of a paragraph within a span of its formatting if it
+// differs from that of the paragraph style.
+func (o *odtWriter) writeRun(part *odtPart, run StyleBlock, style Painter) {
+	if run.Kind == BlockKindObject {
+		for _, result := range o.document.objectResult(run.ObjectIndex) {
+			o.writeRun(part, result, style)
+		}
+		return
+	}
+
+	if run.Kind == BlockKindShape {
+		o.writeShape(part, run.ShapeIndex)
+		return
+	}
+	// notes are numbered by their citation
+	if run.Kind == BlockKindNoteNumber {
+		return
+	}
+
+	x := part.x
+	painter := run.Painter
+
+	if run.Kind == BlockKindFootnote {
+		painter.Superscript, painter.Subscript = false, false
+	}
+
+	span := false
+	if properties := o.textProperties(painter, style); len(properties) > 0 {
+		element := newXMLElement("style:style", "style:family", "text")
+		element.add("style:text-properties", properties...)
+		x.start("text:span", "text:style-name", part.automaticStyle("T", element))
+		span = true
+	}
+
+	switch run.Kind {
+	case BlockKindText:
+		odtText(x, run.Text)
+	case BlockKindLine:
+		x.empty("text:line-break")
+	case BlockKindPicture:
+		o.writeFrame(part, run.PictureIndex)
+	case BlockKindFootnote:
+		o.writeNote(part, run.FootnoteIndex)
+	}
+
+	if span {
+		x.end()
+	}
+}
+
+// odtText writes text, in which tabs, line feeds and spaces following
+// another space have elements of their own.
+func odtText(x *xmlWriter, text string) {
+	var chunk strings.Builder
+	spaces := 0
+	previous := ' '
+
+	flush := func() {
+		if chunk.Len() > 0 {
+			x.token(xml.CharData(chunk.String()))
+			chunk.Reset()
+		}
+		if spaces > 0 {
+			if spaces > 1 {
+				x.empty("text:s", "text:c", strconv.Itoa(spaces))
+			} else {
+				x.empty("text:s")
+			}
+			spaces = 0
+		}
+	}
+
+	for i, c := range text {
+		switch {
+		case c == '\t':
+			flush()
+			x.empty("text:tab")
+		case c == '\n':
+			flush()
+			x.empty("text:line-break")
+		case c == ' ' && (previous == ' ' || i == 0):
+			if chunk.Len() > 0 {
+				x.token(xml.CharData(chunk.String()))
+				chunk.Reset()
+			}
+			spaces++
+		default:
+			if spaces > 0 {
+				flush()
+			}
+			chunk.WriteRune(c)
+		}
+		previous = c
+		if c == '\t' || c == '\n' {
+			previous = ' '
+		}
+	}
+
+	flush()
+}
+
+// writeFrame writes a frame holding a picture, adding the file holding it on
+// first use. Pictures that cannot be stored are left out.
+func (o *odtWriter) writeFrame(part *odtPart, index int) {
+	name, ok := o.pictureName(index)
+	if !ok {
+		return
+	}
+	picture := o.document.Pictures[index]
+
+	graphic := newXMLElement("style:style", "style:family", "graphic")
+	graphic.add("style:graphic-properties", "style:vertical-pos", "top", "style:vertical-rel", "baseline")
+
+	o.frames++
+	width, height := picture.size()
+
+	x := part.x
+	x.start("draw:frame",
+		"draw:style-name", part.automaticStyle("fr", graphic),
+		"draw:name", "Image"+strconv.Itoa(o.frames),
+		"text:anchor-type", "as-char",
+		"svg:width", odtLength(width),
+		"svg:height", odtLength(height),
+		"draw:z-index", "0")
+	x.empty("draw:image", "xlink:href", name, "xlink:type", "simple", "xlink:show", "embed", "xlink:actuate", "onLoad")
+	x.end()
+}
+
+// pictureName returns the name of the file holding a picture, adding it on
+// first use. It fails for pictures other programs cannot read.
+func (o *odtWriter) pictureName(index int) (string, bool) {
+	if index < 0 || index >= len(o.document.Pictures) {
+		return "", false
+	}
+
+	name, ok := o.pictures[index]
+	if !ok {
+		data, extension, mimeType, ok := o.document.Pictures[index].fileData()
+		if !ok {
+			return "", false
+		}
+
+		name = "Pictures/image" + strconv.Itoa(len(o.pictures)+1) + "." + extension
+		o.pictures[index] = name
+		o.files = append(o.files, odtFile{name, mimeType, odtData(data)})
+	}
+
+	return name, true
+}
+
+// odtShapeAnchors are the values of the style:horizontal-rel and
+// style:vertical-rel properties of frames.
+var odtShapeAnchors = map[ShapeAnchor]string{
+	ShapeAnchorMargin: "page-content", ShapeAnchorPage: "page", ShapeAnchorColumn: "paragraph",
+	ShapeAnchorParagraph: "paragraph", ShapeAnchorCharacter: "char", ShapeAnchorLine: "line",
+}
+
+var odtShapeWraps = map[ShapeWrap]string{
+	ShapeWrapAround: "parallel", ShapeWrapTopBottom: "none", ShapeWrapNone: "run-through",
+	ShapeWrapTight: "parallel", ShapeWrapThrough: "run-through",
+}
+
+// writeShape writes a picture or a text box as a frame, at its position.
+// Other shapes are left out.
+func (o *odtWriter) writeShape(part *odtPart, index int) {
+	if index < 0 || index >= len(o.document.Shapes) {
+		return
+	}
+	shape := o.document.Shapes[index]
+
+	picture, hasPicture := o.pictureName(shape.PictureIndex)
+	if !hasPicture && !shape.IsTextBox() {
+		return
+	}
+
+	runThrough := "foreground"
+	if shape.BehindText {
+		runThrough = "background"
+	}
+	graphic := newXMLElement("style:style", "style:family", "graphic")
+	graphic.add("style:graphic-properties",
+		"style:wrap", odtShapeWraps[shape.Wrap],
+		"style:run-through", runThrough,
+		"style:horizontal-pos", "from-left",
+		"style:horizontal-rel", odtShapeAnchors[shape.HorizontalAnchor],
+		"style:vertical-pos", "from-top",
+		"style:vertical-rel", odtShapeAnchors[shape.VerticalAnchor])
+
+	anchor := "paragraph"
+	if shape.HorizontalAnchor == ShapeAnchorCharacter || shape.VerticalAnchor == ShapeAnchorLine {
+		anchor = "char"
+	}
+
+	o.frames++
+	x := part.x
+	x.start("draw:frame",
+		"draw:style-name", part.automaticStyle("fr", graphic),
+		"draw:name", "Shape"+strconv.Itoa(o.frames),
+		"text:anchor-type", anchor,
+		"svg:x", odtLength(shape.Left),
+		"svg:y", odtLength(shape.Top),
+		"svg:width", odtLength(shape.Width()),
+		"svg:height", odtLength(shape.Height()),
+		"draw:z-index", strconv.Itoa(max(shape.ZOrder, 0)))
+	if hasPicture {
+		x.empty("draw:image", "xlink:href", picture, "xlink:type", "simple", "xlink:show", "embed", "xlink:actuate", "onLoad")
+	} else {
+		x.start("draw:text-box")
+		o.writeSubdocument(part, shape.Body)
+		x.end()
+	}
+	x.end()
+}
+
+// writeNote writes a footnote or an endnote where it is referred to. Notes
+// cannot be written in headers and footers, and are left out there.
+func (o *odtWriter) writeNote(part *odtPart, index int) {
+	if index < 0 || index >= len(o.document.Footnotes) || part != o.content {
+		return
+	}
+	note := o.document.Footnotes[index]
+
+	class, number := "footnote", 0
+	if note.Endnote {
+		o.endnotes++
+		class, number = "endnote", o.endnotes
+	} else {
+		o.footnotes++
+		number = o.footnotes
+	}
+
+	x := part.x
+	x.start("text:note", "text:id", "note"+strconv.Itoa(index+1), "text:note-class", class)
+	x.textElement("text:note-citation", strconv.Itoa(number))
+	x.start("text:note-body")
+	o.writeSubdocument(part, note.Body)
+	x.end()
+	x.end()
+}
+
+// writeTable writes a table, whose columns are made of the cell boundaries
+// of all of its rows. Merged cells span the columns and rows of the cells
+// merged with them, which are covered.
+func (o *odtWriter) writeTable(part *odtPart, table *Table, masterPage string) {
+	if len(table.Rows) == 0 {
+		return
+	}
+
+	x := part.x
+	first := table.Rows[0].Format
+	grid := docxTableGrid(table)
+
+	o.tables++
+	name := "Table" + strconv.Itoa(o.tables)
+
+	properties := []string{"table:align", "left"}
+	switch first.Alignment {
+	case AlignmentCenter:
+		properties = []string{"table:align", "center"}
+	case AlignmentRight:
+		properties = []string{"table:align", "right"}
+	}
+	if len(grid) > 1 {
+		properties = append(properties, "style:width", odtLength(grid[len(grid)-1]-grid[0]))
+	}
+	if first.LeftIndent != 0 && first.Alignment == AlignmentLeft {
+		properties = append(properties, "fo:margin-left", odtLength(first.LeftIndent))
+	}
+
+	attributes := []string{"style:family", "table"}
+	if masterPage != "" {
+		attributes = append(attributes, "style:master-page-name", masterPage)
+	}
+	style := newXMLElement("style:style", attributes...)
+	style.add("style:table-properties", properties...)
+
+	x.start("table:table", "table:name", name, "table:style-name", part.automaticStyle("Table", style))
+
+	rows := odtTableLayout(table, grid)
+	columns := 1
+	for _, row := range rows {
+		for _, cell := range row {
+			columns = max(columns, cell.column+cell.columns)
+		}
+	}
+
+	for i := 0; i < columns; i++ {
+		column := newXMLElement("style:style", "style:family", "table-column")
+		if len(grid) > 1 && i+1 < len(grid) {
+			column.add("style:table-column-properties", "style:column-width", odtLength(grid[i+1]-grid[i]))
+		}
+		x.empty("table:table-column", "table:style-name", part.automaticStyle("co", column))
+	}
+
+	header := false
+	for i, row := range rows {
+		if table.Rows[i].Format.Header && (i == 0 || header) {
+			if !header {
+				x.start("table:table-header-rows")
+				header = true
+			}
+		} else if header {
+			x.end()
+			header = false
+		}
+
+		o.writeRow(part, table.Rows[i], row, rows[i+1:], columns)
+	}
+	if header {
+		x.end()
+	}
+
+	x.end()
+}
+
+// odtCell is a cell of a table row placed on the columns of the table.
+type odtCell struct {
+	column     int
+	columns    int
+	format     CellFormat
+	paragraphs []Paragraph
+}
+
+// odtTableLayout places the cells of the rows of a table on the columns made
+// of the given cell boundaries. Horizontally merged cells become a single
+// cell spanning the columns of the cells merged, whose content is dropped.
+func odtTableLayout(table *Table, grid []int) [][]odtCell {
+	rows := [][]odtCell{}
+
+	for _, row := range table.Rows {
+		cells := row.Format.Cells
+		left := row.Format.LeftIndent
+		column := 0
+		for column+1 < len(grid) && grid[column+1] <= left {
+			column++
+		}
+
+		placed := []odtCell{}
+		count := max(len(row.Cells), len(cells), 1)
+		for i := 0; i < count; {
+			next := i + 1
+			for next < len(cells) && cells[next].HorizontalMerge == CellMergePrevious {
+				next++
+			}
+
+			cell := odtCell{column: column, columns: next - i}
+			if i < len(cells) {
+				cell.format = cells[i]
+				right := cells[next-1].Right
+				if len(grid) > 1 {
+					cell.columns = 0
+					for _, position := range grid {
+						if position > left && position <= right {
+							cell.columns++
+						}
+					}
+					cell.columns = max(cell.columns, 1)
+				}
+				left = right
+			}
+			if i < len(row.Cells) {
+				cell.paragraphs = row.Cells[i].Paragraphs
+			}
+
+			placed = append(placed, cell)
+			column += cell.columns
+			i = next
+		}
+
+		rows = append(rows, placed)
+	}
+
+	return rows
+}
+
+// writeRow writes a row of a table, given the cells placed on the columns of
+// the table of the row and those following it.
+func (o *odtWriter) writeRow(part *odtPart, row TableRow, cells []odtCell, following [][]odtCell, columns int) {
+	x := part.x
+	x.start("table:table-row")
+
+	column := 0
+	for _, cell := range cells {
+		for ; column < cell.column; column++ {
+			x.empty("table:covered-table-cell")
+		}
+
+		if cell.format.VerticalMerge == CellMergePrevious {
+			for i := 0; i < cell.columns; i++ {
+				x.empty("table:covered-table-cell")
+			}
+			column += cell.columns
+			continue
+		}
+
+		attributes := []string{}
+		if properties := o.cellProperties(cell.format, row.Format.Gap); len(properties) > 0 {
+			style := newXMLElement("style:style", "style:family", "table-cell")
+			style.add("style:table-cell-properties", properties...)
+			attributes = append(attributes, "table:style-name", part.automaticStyle("ce", style))
+		}
+		if cell.columns > 1 {
+			attributes = append(attributes, "table:number-columns-spanned", strconv.Itoa(cell.columns))
+		}
+		if cell.format.VerticalMerge == CellMergeFirst {
+			if spanned := odtRowsSpanned(cell.column, following); spanned > 1 {
+				attributes = append(attributes, "table:number-rows-spanned", strconv.Itoa(spanned))
+			}
+		}
+		attributes = append(attributes, "office:value-type", "string")
+
+		x.start("table:table-cell", attributes...)
+		o.writeParagraphs(part, cell.paragraphs)
+		x.end()
+
+		for i := 1; i < cell.columns; i++ {
+			x.empty("table:covered-table-cell")
+		}
+		column += cell.columns
+	}
+
+	for ; column < columns; column++ {
+		x.empty("table:table-cell")
+	}
+
+	x.end()
+}
+
+// odtRowsSpanned returns the number of rows a vertically merged cell starting
+// at column spans, counting the cells merged with it in the rows following.
+func odtRowsSpanned(column int, following [][]odtCell) int {
+	spanned := 1
+
+	for _, row := range following {
+		merged := false
+		for _, cell := range row {
+			merged = merged || (cell.column == column && cell.format.VerticalMerge == CellMergePrevious)
+		}
+		if !merged {
+			break
+		}
+		spanned++
+	}
+
+	return spanned
+}
+
+func (o *odtWriter) cellProperties(cell CellFormat, gap int) []string {
+	properties := []string{}
+
+	if color, ok := o.color(cell.Background); ok {
+		properties = append(properties, "fo:background-color", color)
+	}
+	if gap > 0 {
+		properties = append(properties, "fo:padding-left", odtLength(gap), "fo:padding-right", odtLength(gap))
+	}
+
+	return properties
+}
+
+// writeStyles writes styles.xml: the stylesheet, the lists, and the page
+// layout and master page of each section with its headers and footers.
+func (o *odtWriter) writeStyles() *xmlWriter {
+	common := newXMLFragmentWriter()
+	common.start("office:styles")
+	o.writeCommonStyles(common)
+	common.end()
+
+	part := o.common
+	part.x.start("office:master-styles")
+	o.writeMasterPages(part)
+	part.x.end()
+
+	return o.writePart("office:document-styles", part, common)
+}
+
+// writeCommonStyles writes the default paragraph style, taken from the first
+// font, the paragraph styles of the stylesheet and the list styles.
+func (o *odtWriter) writeCommonStyles(x *xmlWriter) {
+	defaults := newXMLElement("style:default-style", "style:family", "paragraph")
+	textProperties := []string{"fo:font-size", "12pt"}
+	if font, ok := o.document.Header.FontTable[0]; ok && font.Name != "" {
+		textProperties = append([]string{"style:font-name", font.Name}, textProperties...)
+	}
+	defaults.add("style:text-properties", textProperties...)
+	x.element(defaults)
+
+	numbers := []int{}
+	for number := range o.styles {
+		numbers = append(numbers, number)
+	}
+	if _, ok := o.styles[0]; !ok {
+		numbers = append(numbers, 0)
+	}
+	sort.Ints(numbers)
+
+	for _, number := range numbers {
+		style := o.styles[number]
+
+		name := style.Name
+		if name == "" {
+			name = map[bool]string{true: "Standard", false: "Style " + strconv.Itoa(number)}[number == 0]
+		}
+
+		paragraph := style.Paragraph
+		paragraph.Style = 0
+
+		element := newXMLElement("style:style",
+			"style:name", odtStyleName(number),
+			"style:display-name", name,
+			"style:family", "paragraph",
+			"style:class", "text")
+		if properties := odtParagraphProperties(paragraph, ParagraphFormat{}); len(properties) > 0 {
+			element.add("style:paragraph-properties", properties...)
+		}
+		if properties := o.textProperties(style.Painter, Painter{}); len(properties) > 0 {
+			element.add("style:text-properties", properties...)
+		}
+		x.element(element)
+	}
+
+	lists := o.document.Header.Lists
+	for _, reference := range sortedTableRefs(lists) {
+		if reference != 0 {
+			x.element(odtListStyle(reference, lists[reference]))
+		}
+	}
+}
+
+var odtNumberFormats = map[ListFormat]string{
+	ListFormatDecimal: "1", ListFormatUpperRoman: "I", ListFormatLowerRoman: "i",
+	ListFormatUpperLetter: "A", ListFormatLowerLetter: "a", ListFormatDecimalZero: "1",
+	ListFormatNone: "",
+}
+
+// odtListStyle returns the list style of a list. The text of numbered levels
+// is split into the prefix before the first level number and the suffix
+// after the last.
+func odtListStyle(reference TableRef, list List) xmlElement {
+	style := newXMLElement("text:list-style", "style:name", odtListStyleName(reference))
+
+	for i, level := range list.Levels[:min(len(list.Levels), 10)] {
+		alignment := []string{"text:label-followed-by", "listtab"}
+		if level.LeftIndent != 0 || level.FirstLineIndent != 0 {
+			alignment = append(alignment,
+				"text:list-tab-stop-position", odtLength(level.LeftIndent),
+				"fo:text-indent", odtLength(level.FirstLineIndent),
+				"fo:margin-left", odtLength(level.LeftIndent))
+		}
+		properties := xmlElement{
+			name:       "style:list-level-properties",
+			attributes: []string{"text:list-level-position-and-space-mode", "label-alignment"},
+			children:   []xmlElement{newXMLElement("style:list-level-label-alignment", alignment...)},
+		}
+
+		var element xmlElement
+		if level.Format == ListFormatBullet {
+			bullet := level.Text
+			if bullet == "" {
+				bullet = "•"
+			}
+			element = newXMLElement("text:list-level-style-bullet",
+				"text:level", strconv.Itoa(i+1),
+				"text:bullet-char", string([]rune(bullet)[:1]))
+		} else {
+			format, ok := odtNumberFormats[level.Format]
+			if !ok {
+				format = "1"
+			}
+			prefix, suffix, levels := odtLevelText(level.Text)
+			element = newXMLElement("text:list-level-style-number",
+				"text:level", strconv.Itoa(i+1),
+				"style:num-format", format,
+				"style:num-prefix", prefix,
+				"style:num-suffix", suffix,
+				"text:start-value", strconv.Itoa(max(level.Start, 1)),
+				"text:display-levels", strconv.Itoa(max(levels, 1)))
+		}
+		element.children = append(element.children, properties)
+		style.children = append(style.children, element)
+	}
+
+	return style
+}
+
+// odtLevelText splits the text of a numbered list level, such as "(%1.%2)",
+// into the text before the first level number and after the last, and
+// returns the number of levels it shows.
+func odtLevelText(text string) (prefix string, suffix string, levels int) {
+	first, last := -1, -1
+	for i := 0; i+1 < len(text); i++ {
+		if text[i] == '%' && text[i+1] >= '1' && text[i+1] <= '9' {
+			if first < 0 {
+				first = i
+			}
+			last = i + 2
+			levels++
+		}
+	}
+
+	if first < 0 {
+		return "", text, 0
+	}
+
+	return text[:first], text[last:], levels
+}
+
+// odtHeaderFooterElements are the elements of the master pages holding the
+// headers and footers of each kind, in the order they are written. Headers
+// of right pages are those of every page.
+var odtHeaderFooterElements = []struct {
+	name  string
+	kinds []HeaderFooterKind
+}{
+	{"style:header", []HeaderFooterKind{HeaderFooterKindHeader, HeaderFooterKindHeaderRight}},
+	{"style:header-left", []HeaderFooterKind{HeaderFooterKindHeaderLeft}},
+	{"style:header-first", []HeaderFooterKind{HeaderFooterKindHeaderFirst}},
+	{"style:footer", []HeaderFooterKind{HeaderFooterKindFooter, HeaderFooterKindFooterRight}},
+	{"style:footer-left", []HeaderFooterKind{HeaderFooterKindFooterLeft}},
+	{"style:footer-first", []HeaderFooterKind{HeaderFooterKindFooterFirst}},
+}
+
+// writeMasterPages writes a page layout and a master page for the first
+// section and each section starting on a new page. Headers of sections past
+// the last belong to the last.
+func (o *odtWriter) writeMasterPages(part *odtPart) {
+	x := part.x
+	sections := o.document.Sections()
+
+	for i, section := range sections {
+		if i > 0 && section.Format.Break == SectionBreakNone {
+			continue
+		}
+
+		layout := newXMLElement("style:page-layout")
+		layout.children = append(layout.children, odtPageLayoutProperties(section.Format))
+		layout.add("style:header-style")
+		layout.add("style:footer-style")
+
+		x.start("style:master-page",
+			"style:name", odtMasterPageName(i),
+			"style:page-layout-name", part.automaticStyle("pm", layout))
+
+		for _, element := range odtHeaderFooterElements {
+			for _, headerFooter := range o.document.HeadersFooters {
+				index := min(max(headerFooter.Section, 0), len(sections)-1)
+				if index != i || !containsHeaderFooterKind(element.kinds, headerFooter.Kind) {
+					continue
+				}
+
+				x.start(element.name)
+				o.writeSubdocument(part, headerFooter.Body)
+				x.end()
+				break
+			}
+		}
+
+		x.end()
+	}
+}
+
+func containsHeaderFooterKind(kinds []HeaderFooterKind, kind HeaderFooterKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// odtPageLayoutProperties returns the page layout properties of a section,
+// with the page size and margins RTF assumes when it gives none.
+func odtPageLayoutProperties(format SectionFormat) xmlElement {
+	width, height := format.PageWidth, format.PageHeight
+	if width == 0 && height == 0 && format.Landscape {
+		width, height = defaultPageHeight, defaultPageWidth
+	}
+	if width == 0 {
+		width = defaultPageWidth
+	}
+	if height == 0 {
+		height = defaultPageHeight
+	}
+
+	orientation := "portrait"
+	if format.Landscape {
+		orientation = "landscape"
+	}
+
+	properties := newXMLElement("style:page-layout-properties",
+		"fo:page-width", odtLength(width),
+		"fo:page-height", odtLength(height),
+		"style:print-orientation", orientation)
+	for _, margin := range []struct {
+		name         string
+		value        int
+		defaultValue int
+	}{
+		{"fo:margin-top", format.MarginTop, defaultMarginTop},
+		{"fo:margin-bottom", format.MarginBottom, defaultMarginBottom},
+		{"fo:margin-left", format.MarginLeft, defaultMarginLeft},
+		{"fo:margin-right", format.MarginRight, defaultMarginRight},
+	} {
+		if margin.value == 0 {
+			margin.value = margin.defaultValue
+		}
+		properties.attributes = append(properties.attributes, margin.name, odtLength(margin.value))
+	}
+
+	if format.Columns > 1 {
+		properties.add("style:columns", "fo:column-count", strconv.Itoa(format.Columns), "fo:column-gap", odtLength(720))
+	}
+
+	return properties
+}
+
+// writeMeta writes meta.xml from the information group. Properties without
+// an equivalent in OpenDocument, such as the manager and the company, are
+// written as user-defined properties.
+func (o *odtWriter) writeMeta() *xmlWriter {
+	info := o.document.InformationGroup
+
+	x := newXMLWriter()
+	x.start("office:document-meta",
+		"xmlns:office", odtNamespaceOffice,
+		"xmlns:meta", odtNamespaceMeta,
+		"xmlns:dc", odtNamespaceDC,
+		"office:version", odtVersion)
+	x.start("office:meta")
+
+	for _, property := range []struct {
+		name  string
+		value string
+	}{
+		{"dc:title", info.Title},
+		{"dc:subject", info.Subject},
+		{"dc:description", info.Comment},
+		{"meta:keyword", info.Keywords},
+		{"meta:initial-creator", info.Author},
+		{"dc:creator", info.Operator},
+	} {
+		if property.value != "" {
+			x.textElement(property.name, property.value)
+		}
+	}
+
+	for _, date := range []struct {
+		name  string
+		value *time.Time
+	}{
+		{"meta:creation-date", info.CreationTime},
+		{"dc:date", info.RevisionTime},
+		{"meta:print-date", info.LastPrintTime},
+	} {
+		if date.value != nil {
+			x.textElement(date.name, docxTime(*date.value))
+		}
+	}
+
+	if info.Version != 0 {
+		x.textElement("meta:editing-cycles", strconv.Itoa(info.Version))
+	}
+	if info.EditingMinutes != 0 {
+		x.textElement("meta:editing-duration", "PT"+strconv.Itoa(info.EditingMinutes)+"M")
+	}
+
+	statistics := []string{}
+	for _, statistic := range []struct {
+		name  string
+		value int
+	}{
+		{"meta:page-count", info.NumberOfPages},
+		{"meta:word-count", info.NumberOfWords},
+		{"meta:character-count", info.NumberOfCharacters},
+	} {
+		if statistic.value != 0 {
+			statistics = append(statistics, statistic.name, strconv.Itoa(statistic.value))
+		}
+	}
+	if len(statistics) > 0 {
+		x.empty("meta:document-statistic", statistics...)
+	}
+
+	properties := []UserProperty{}
+	for _, property := range []struct {
+		name  string
+		value string
+	}{
+		{"Category", info.Category},
+		{"Manager", info.Manager},
+		{"Company", info.Company},
+	} {
+		if property.value != "" {
+			properties = append(properties, UserProperty{Name: property.name, Type: UserPropertyTypeText, Value: property.value})
+		}
+	}
+
+	for _, property := range append(properties, info.UserProperties...) {
+		switch value := property.Value.(type) {
+		case int:
+			x.textElement("meta:user-defined", strconv.Itoa(value), "meta:name", property.Name, "meta:value-type", "float")
+		case float64:
+			x.textElement("meta:user-defined", strconv.FormatFloat(value, 'f', -1, 64), "meta:name", property.Name, "meta:value-type", "float")
+		case bool:
+			x.textElement("meta:user-defined", strconv.FormatBool(value), "meta:name", property.Name, "meta:value-type", "boolean")
+		case time.Time:
+			x.textElement("meta:user-defined", docxTime(value), "meta:name", property.Name, "meta:value-type", "date")
+		default:
+			x.textElement("meta:user-defined", fmt.Sprint(value), "meta:name", property.Name, "meta:value-type", "string")
+		}
+	}
+
+	x.end()
+	x.end()
+
+	return x
+}
+
+// odtManifest returns the manifest listing the files of the package.
+func odtManifest(files []odtFile) *xmlWriter {
+	x := newXMLWriter()
+	x.start("manifest:manifest", "xmlns:manifest", odtNamespaceManifest, "manifest:version", odtVersion)
+	x.empty("manifest:file-entry", "manifest:full-path", "/", "manifest:version", odtVersion, "manifest:media-type", odtMimeType)
+	for _, file := range files {
+		if file.mediaType != "" {
+			x.empty("manifest:file-entry", "manifest:full-path", file.name, "manifest:media-type", file.mediaType)
+		}
+	}
+	x.end()
+
+	return x
+}
@@ -0,0 +1,183 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocumentToODT(t *testing.T) {
+	content := `{\rtf1\ansi{\fonttbl{\f0\froman\fprq2\fcharset0 Times New Roman;}{\f1\fswiss\fcharset0 Arial;}}` +
+		`{\colortbl;\red255\green0\blue0;\red0\green0\blue255;}` +
+		`{\stylesheet{\s0 Normal;}{\s1\sb240\b\fs32 heading 1;}}` +
+		`{\*\listtable{\list{\listlevel\levelnfc0\levelstartat1{\leveltext\'02\'00.;}\fi-360\li720}` +
+		`{\listlevel\levelnfc4\levelstartat1{\leveltext\'03(\'01);}\fi-360\li1440}\listid7}}` +
+		`{\*\listoverridetable{\listoverride\listid7\listoverridecount0\ls1}}` +
+		`{\info{\title Tom & Jerry}{\author Ann}{\creatim\yr2020\mo1\dy2\hr3\min4}}` +
+		`\sectd\lndscpsxn{\header\pard Head\par}{\footerf\pard First\par}` +
+		`\pard\s1\sb240\b\fs32 Title\par` +
+		`\pard\plain\ls1 One\par\pard\ls1\ilvl1 Sub\par\pard\ls1 Two\par` +
+		`\pard Some  {\b bold} {\f1\cf1 red} {\field{\*\fldinst HYPERLINK "https://example.com/?a=1&b=2"}{\fldrslt link}}` +
+		`{\footnote\pard Note\par}\tab x\line y\page z\par` +
+		`\trowd\trgaph108\clvmgf\clcbpat2\cellx2000\clmgf\cellx4000\clmrg\cellx6000\pard\intbl a\cell b\cell\cell\row` +
+		`\trowd\trgaph108\clvmrg\cellx2000\cellx6000\pard\intbl\cell c\cell\row` +
+		`\pard{\pict\pngblip\picw10\pich10 89504e470d0a1a0a}\par` +
+		`\sect\sectd\pard Last\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := doc.ToODT()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, file.Name)
+		parts[file.Name] = string(content)
+	}
+
+	expectedNames := []string{
+		"mimetype",
+		"content.xml",
+		"styles.xml",
+		"meta.xml",
+		"Pictures/image1.png",
+		"META-INF/manifest.xml",
+	}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedNames, names)
+	}
+
+	if archive.File[0].Method != zip.Store || parts["mimetype"] != "application/vnd.oasis.opendocument.text" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "an uncompressed media type", parts["mimetype"])
+	}
+
+	for name, content := range parts {
+		if !strings.HasSuffix(name, ".xml") {
+			continue
+		}
+
+		decoder := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s is not well-formed: %v", name, err)
+				break
+			}
+		}
+	}
+
+	for name, expected := range map[string][]string{
+		"content.xml": {
+			`<style:font-face style:name="Arial" svg:font-family="Arial" style:font-family-generic="swiss"></style:font-face>`,
+			`<style:style style:name="T2" style:family="text"><style:text-properties style:font-name="Arial" fo:color="#ff0000">`,
+			`<text:p text:style-name="Style1">Title</text:p>`,
+			`<text:list text:style-name="L1"><text:list-item><text:p text:style-name="Standard">One</text:p>` +
+				`<text:list><text:list-item><text:p text:style-name="Standard">Sub</text:p></text:list-item></text:list></text:list-item>` +
+				`<text:list-item><text:p text:style-name="Standard">Two</text:p></text:list-item></text:list>`,
+			`Some <text:s></text:s><text:span text:style-name="T1">bold</text:span>`,
+			`<text:a xlink:type="simple" xlink:href="https://example.com/?a=1&amp;b=2">link</text:a>`,
+			`<text:note text:id="note1" text:note-class="footnote"><text:note-citation>1</text:note-citation>` +
+				`<text:note-body><text:p text:style-name="Standard">Note</text:p></text:note-body></text:note>`,
+			`<text:tab></text:tab>x<text:line-break></text:line-break>y</text:p><text:p text:style-name="P1">z</text:p>`,
+			`<style:paragraph-properties fo:break-before="page">`,
+			`<table:table-cell table:style-name="ce1" table:number-rows-spanned="2" office:value-type="string">`,
+			`<table:table-cell table:style-name="ce2" table:number-columns-spanned="2" office:value-type="string">` +
+				`<text:p text:style-name="Standard">b</text:p></table:table-cell><table:covered-table-cell></table:covered-table-cell>`,
+			`<table:table-row><table:covered-table-cell></table:covered-table-cell>`,
+			`<style:table-cell-properties fo:background-color="#0000ff" fo:padding-left="5.4pt" fo:padding-right="5.4pt">`,
+			`<draw:frame draw:style-name="fr1" draw:name="Image1" text:anchor-type="as-char" svg:width="7.5pt" svg:height="7.5pt" draw:z-index="0">` +
+				`<draw:image xlink:href="Pictures/image1.png"`,
+		},
+		"styles.xml": {
+			`<style:default-style style:family="paragraph"><style:text-properties style:font-name="Times New Roman" fo:font-size="12pt">`,
+			`<style:style style:name="Style1" style:display-name="heading 1" style:family="paragraph" style:class="text">` +
+				`<style:paragraph-properties fo:margin-top="12pt"></style:paragraph-properties>` +
+				`<style:text-properties fo:font-size="16pt" fo:font-weight="bold"></style:text-properties></style:style>`,
+			`<text:list-level-style-number text:level="2" style:num-format="a" style:num-prefix="(" style:num-suffix=")" text:start-value="1" text:display-levels="1">`,
+			`<style:page-layout-properties fo:page-width="792pt" fo:page-height="612pt" style:print-orientation="landscape"`,
+			`<style:master-page style:name="Standard" style:page-layout-name="Mpm1"><style:header><text:p text:style-name="Standard">Head</text:p></style:header>` +
+				`<style:footer-first><text:p text:style-name="Standard">First</text:p></style:footer-first></style:master-page>`,
+		},
+		"meta.xml": {
+			`<dc:title>Tom &amp; Jerry</dc:title><meta:initial-creator>Ann</meta:initial-creator>`,
+			`<meta:creation-date>2020-01-02T03:04:00Z</meta:creation-date>`,
+		},
+		"META-INF/manifest.xml": {
+			`<manifest:file-entry manifest:full-path="/" manifest:version="1.3" manifest:media-type="application/vnd.oasis.opendocument.text">`,
+			`<manifest:file-entry manifest:full-path="Pictures/image1.png" manifest:media-type="image/png">`,
+		},
+	} {
+		for _, e := range expected {
+			if !strings.Contains(parts[name], e) {
+				t.Errorf("\n\nexpected in %s: %v\n\nactual\t: %v", name, e, parts[name])
+			}
+		}
+	}
+}
+
+func TestODTText(t *testing.T) {
+	tests := map[string]string{
+		"plain":      "plain",
+		"two  words": "two <text:s></text:s>words",
+		" a\tb  \nc": "<text:s></text:s>a<text:tab></text:tab>b <text:s></text:s><text:line-break></text:line-break>c",
+		"   ":        `<text:s text:c="3"></text:s>`,
+	}
+
+	for text, expected := range tests {
+		x := newXMLFragmentWriter()
+		odtText(x, text)
+		data, err := x.bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != expected {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, string(data))
+		}
+	}
+}
+
+func TestODTLevelText(t *testing.T) {
+	tests := []struct {
+		text   string
+		prefix string
+		suffix string
+		levels int
+	}{
+		{"%1.", "", ".", 1},
+		{"(%1.%2)", "(", ")", 2},
+		{"•", "", "•", 0},
+	}
+
+	for _, test := range tests {
+		prefix, suffix, levels := odtLevelText(test.text)
+		if prefix != test.prefix || suffix != test.suffix || levels != test.levels {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", test, []interface{}{prefix, suffix, levels})
+		}
+	}
+}
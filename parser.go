@@ -1,18 +1,49 @@
 package gortf
 
 import (
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
+	"io/fs"
 	"os"
+	"strconv"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
+// Painter holds the character formatting properties of a piece of text.
+// Font sizes are in half-points and colors refer to the color table, 0
+// being the auto color.
 type Painter struct {
-	FontRef   TableRef
-	FontSize  int
-	Bold      bool
-	Italic    bool
-	Underline bool
+	FontRef         TableRef `json:"font,omitempty"`
+	FontSize        int      `json:"fontSize,omitempty"`
+	Bold            bool     `json:"bold,omitempty"`
+	Italic          bool     `json:"italic,omitempty"`
+	Underline       bool     `json:"underline,omitempty"`
+	Strikethrough   bool     `json:"strikethrough,omitempty"`
+	Superscript     bool     `json:"superscript,omitempty"`
+	Subscript       bool     `json:"subscript,omitempty"`
+	SmallCaps       bool     `json:"smallCaps,omitempty"`
+	Hidden          bool     `json:"hidden,omitempty"`
+	ForegroundColor TableRef `json:"color,omitempty"`
+	BackgroundColor TableRef `json:"backgroundColor,omitempty"`
+	Highlight       TableRef `json:"highlight,omitempty"`
+
+	// Link is the target of the hyperlink field the text belongs to, and
+	// Field the instruction of any other field, such as "MERGEFIELD Name",
+	// whose result the text is.
+	Link  string `json:"link,omitempty"`
+	Field string `json:"field,omitempty"`
+
+	// Inserted and Deleted mark text inserted or deleted while revisions
+	// were tracked, by the author of the given index of
+	// RtfHeader.RevisionAuthors at the given time.
+	Inserted   bool         `json:"inserted,omitempty"`
+	InsertedBy int          `json:"insertedBy,omitempty"`
+	InsertedAt RevisionTime `json:"insertedAt,omitempty"`
+	Deleted    bool         `json:"deleted,omitempty"`
+	DeletedBy  int          `json:"deletedBy,omitempty"`
+	DeletedAt  RevisionTime `json:"deletedAt,omitempty"`
 }
 
 func (p Painter) String() string {
@@ -20,9 +51,34 @@ func (p Painter) String() string {
 	return string(b)
 }
 
+// StyleBlock is an element of the body of a document: either a piece of text
+// with uniform formatting or one of the marks described by BlockKind.
 type StyleBlock struct {
 	Painter Painter
 	Text    string
+	Kind    BlockKind
+
+	// Paragraph is the format of the paragraph ended by a paragraph or cell
+	// mark, Row that of the row ended by a row mark and Section that of the
+	// section ended by a section mark.
+	Paragraph *ParagraphFormat
+	Row       *RowFormat
+	Section   *SectionFormat
+
+	// PictureIndex is the index in RtfDocument.Pictures of the picture of a
+	// picture block, FootnoteIndex that in RtfDocument.Footnotes of the note
+	// of a footnote block, AnnotationIndex that in RtfDocument.Annotations
+	// of the comment of an annotation block, ObjectIndex that in
+	// RtfDocument.Objects of the object of an object block, and ShapeIndex
+	// that in RtfDocument.Shapes of the shape of a shape block.
+	PictureIndex    int
+	FootnoteIndex   int
+	AnnotationIndex int
+	ObjectIndex     int
+	ShapeIndex      int
+
+	// Bookmark is the name of the bookmark a bookmark block starts or ends.
+	Bookmark string
 }
 
 func (s StyleBlock) String() string {
@@ -31,16 +87,52 @@ func (s StyleBlock) String() string {
 }
 
 type RtfParser struct {
-	tokens       []token
-	painterStack []*Painter
-	cursor       int
+	painterStack     []*Painter
+	paragraphStack   []*ParagraphFormat
+	unicodeSkipStack []int
+
+	// table row and section properties, which are not scoped by groups
+	row     RowFormat
+	cell    CellFormat
+	section SectionFormat
+
+	// code page used to decode \'hh escapes in the body, unless the
+	// current font has one of its own
+	codePage  int
+	fontTable FontTable
+
+	// number of fallback characters still to be skipped after a \u
+	pendingSkip int
+	// the first half of a UTF-16 surrogate pair, waiting for the \u of the
+	// second half
+	highSurrogate rune
+	// the lead byte of a character of a double-byte code page, waiting for
+	// its trail byte
+	leadByte byte
+
+	// offset of the token being parsed, and the code pages that could not
+	// be decoded, which are warned about once
+	offset             int
+	undecodedCodePages map[int]bool
+
+	// the author of the annotation to come, and the annotations whose
+	// anchors were read, by name
+	annotationInitials string
+	annotationAuthor   string
+	annotationAnchors  map[string]int
+
+	// the files of the RTFD bundle being parsed, which {\NeXTGraphic}
+	// groups refer to
+	attachments fs.FS
+
+	warnings []Warning
 }
 
 func NewRtfParser() RtfParser {
 	return RtfParser{
-		tokens:       []token{},
-		painterStack: []*Painter{},
-		cursor:       0,
+		painterStack:     []*Painter{},
+		paragraphStack:   []*ParagraphFormat{},
+		unicodeSkipStack: []int{},
 	}
 }
 
@@ -59,11 +151,14 @@ func (r *RtfParser) ParseFile(filePath string) (RtfDocument, error) {
 }
 
 func (r *RtfParser) ParseContent(content string) (RtfDocument, error) {
-	scanner := newScanner(content)
-	scanner.scanTokens()
-	r.tokens = scanner.tokens
+	r.warnings = nil
 
-	doc, err := r.parse()
+	root, err := r.ParseTree(content)
+	if err != nil {
+		return RtfDocument{}, err
+	}
+
+	doc, err := r.parse(root)
 	if err != nil {
 		return RtfDocument{}, err
 	}
@@ -71,155 +166,683 @@ func (r *RtfParser) ParseContent(content string) (RtfDocument, error) {
 	return doc, nil
 }
 
-func (r *RtfParser) parse() (RtfDocument, error) {
+// Warnings returns the problems found in the document parsed last, which
+// did not prevent it from being parsed.
+func (r *RtfParser) Warnings() []Warning {
+	return r.warnings
+}
+
+// ParseTreeFile reads an RTF file into a tree of groups without interpreting
+// it. See ParseTree.
+func (r *RtfParser) ParseTreeFile(filePath string) (*Group, error) {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ParseTree(string(buf))
+}
+
+// ParseTree reads RTF content into a tree of groups without interpreting it,
+// preserving the full group structure of the document. The returned group is
+// the outermost group of the content, normally the \rtf1 group.
+func (r *RtfParser) ParseTree(content string) (*Group, error) {
+	return buildTree(NewLexer(strings.NewReader(content)))
+}
+
+func (r *RtfParser) parse(root *Group) (RtfDocument, error) {
+	r.checkTree(root)
+
 	doc := RtfDocument{}
-	doc.Header = r.parseHeader()
-	doc.InformationGroup = r.parseInformationGroup()
+	doc.Header = r.parseHeader(root)
+
+	r.codePage = doc.Header.codePage()
+	r.fontTable = doc.Header.FontTable
+	doc.InformationGroup = r.parseInformationGroup(root)
 
 	r.pushPainter(Painter{})
-	for _, tkn := range r.tokens {
-		switch tkn.tokenType() {
-		case tokenTypeGroup:
-			r.pushPainter(Painter{})
+	r.pushParagraph(ParagraphFormat{})
+	r.pushUnicodeSkip(1)
+	r.row = RowFormat{}
+	r.cell = CellFormat{}
+	r.section = SectionFormat{}
+	r.annotationInitials, r.annotationAuthor = "", ""
+	r.annotationAnchors = map[string]int{}
+
+	if isBodyGroup(root) {
+		r.parseBody(&doc, root)
+	}
 
-		case tokenTypeGroupEnd:
-			r.popPainter()
+	r.removeUnresolvedAnchors(&doc)
 
-		case tokenTypeControlWord:
-			currentPainter := r.lastPainter()
-			controlWord := tkn.(controlWordToken)
+	return doc, nil
+}
 
-			switch controlWord.controlWordType {
-			case controlWordTypeFontNumber:
-				currentPainter.FontRef = TableRef(controlWord.parameter)
-			case controlWordTypeBold:
-				currentPainter.Bold = controlWord.parameter != 0
-			case controlWordTypeItalic:
-				currentPainter.Italic = controlWord.parameter != 0
-			case controlWordTypeUnderline:
-				currentPainter.Underline = controlWord.parameter != 0
-			case controlWordTypeUnderlineNone:
-				currentPainter.Underline = false
+// parseBody appends the text of a group to the body of the document, along
+// with that of every nested group that is not a destination.
+func (r *RtfParser) parseBody(doc *RtfDocument, g *Group) {
+	r.pushPainter(*r.lastPainter())
+	r.pushParagraph(*r.lastParagraph())
+	r.pushUnicodeSkip(r.lastUnicodeSkip())
+	r.pendingSkip = 0
+	r.flushPartialCharacter(doc)
+
+	// whether the character standing for an attachment is still to be
+	// skipped
+	afterAttachment := false
+
+	for _, child := range g.Children {
+		switch node := child.(type) {
+		case *Group:
+			afterAttachment = node.Destination == "NeXTGraphic"
+
+			if node.Destination == "field" && !node.Ignorable {
+				r.parseField(doc, node)
+			} else if isBodyGroup(node) {
+				r.parseBody(doc, node)
+			} else {
+				r.parseBodyDestination(doc, node)
 			}
 
-		case tokenTypeText:
-			currentPainter := r.lastPainter()
-			tt := tkn.(textToken)
+		case Token:
+			if afterAttachment {
+				if node.Kind == TokenKindText && stripNewlines(node.Text) == "" {
+					continue
+				}
+				afterAttachment = false
+				rest, ok := skipAttachmentCharacter(node)
+				if !ok {
+					continue
+				}
+				node = rest
+			}
+
+			r.offset = node.Offset
+			s := scanner{tokens: []token{}}
+			s.scanToken(node)
 
+			for _, tkn := range s.tokens {
+				r.parseBodyToken(doc, tkn)
+			}
+		}
+	}
+
+	r.popPainter()
+	r.popParagraph()
+	r.popUnicodeSkip()
+	r.pendingSkip = 0
+	r.flushPartialCharacter(doc)
+}
+
+// parseBodyDestination reads the destinations found in the body that are
+// kept in the document, such as pictures.
+func (r *RtfParser) parseBodyDestination(doc *RtfDocument, g *Group) {
+	switch g.Destination {
+	case "pict":
+		r.addPicture(doc, r.parsePicture(g))
+	case "shppict":
+		// the picture of a Word 97 shape, followed by a {\nonshppict} copy
+		// for older readers which is skipped
+		for _, picture := range g.FindAll("pict") {
+			r.addPicture(doc, r.parsePicture(picture))
+		}
+	case "footnote":
+		r.addFootnote(doc, g)
+	case "atnid":
+		r.annotationInitials = g.Text()
+	case "atnauthor":
+		r.annotationAuthor = g.Text()
+	case "atrfstart", "atrfend":
+		r.addAnnotationAnchor(doc, g)
+	case "annotation":
+		r.addAnnotation(doc, g)
+	case "bkmkstart", "bkmkend":
+		r.addBookmark(doc, g)
+	case "object":
+		r.addObject(doc, g)
+	case "shp":
+		r.addShape(doc, g)
+	case "NeXTGraphic":
+		r.addAttachment(doc, g)
+	default:
+		if kind, ok := headerFooterKinds[g.Destination]; ok {
+			r.addHeaderFooter(doc, g, kind)
+		}
+	}
+}
+
+func (r *RtfParser) addPicture(doc *RtfDocument, picture Picture) {
+	doc.Pictures = append(doc.Pictures, picture)
+	doc.pushToBody(StyleBlock{
+		Painter:      *r.lastPainter(),
+		Kind:         BlockKindPicture,
+		PictureIndex: len(doc.Pictures) - 1,
+	})
+}
+
+// isBodyGroup reports whether the content of a group belongs to the body of
+// the document, as opposed to a destination such as a header table.
+func isBodyGroup(g *Group) bool {
+	if g.Ignorable {
+		return false
+	}
+
+	switch g.Destination {
+	case "", "rtf", "field", "fldrslt":
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *RtfParser) parseBodyToken(doc *RtfDocument, tkn token) {
+	switch tkn.tokenType() {
+	case tokenTypeControlWord:
+		currentPainter := r.lastPainter()
+		controlWord := tkn.(controlWordToken)
+
+		switch controlWord.controlWordType {
+		case controlWordTypeParagraph:
+			r.pushParagraphMark(doc, BlockKindParagraph)
+		case controlWordTypeCell:
+			r.pushParagraphMark(doc, BlockKindCell)
+		case controlWordTypeLine, controlWordTypePage:
+			kind := BlockKindLine
+			if controlWord.controlWordType == controlWordTypePage {
+				kind = BlockKindPage
+			}
+
+			doc.pushToBody(StyleBlock{
+				Painter: *currentPainter,
+				Text:    "\n",
+				Kind:    kind,
+			})
+		case controlWordTypeRow:
+			row := r.row.clone()
 			doc.pushToBody(StyleBlock{
 				Painter: *currentPainter,
-				Text:    tt.value,
+				Text:    blockKindText(BlockKindRow),
+				Kind:    BlockKindRow,
+				Row:     &row,
 			})
+		case controlWordTypeSection:
+			section := r.section
+			doc.pushToBody(StyleBlock{
+				Painter: *currentPainter,
+				Text:    blockKindText(BlockKindSection),
+				Kind:    BlockKindSection,
+				Section: &section,
+			})
+		case controlWordTypeFontNumber,
+			controlWordTypeFontSize,
+			controlWordTypeBold,
+			controlWordTypeItalic,
+			controlWordTypeUnderline,
+			controlWordTypeUnderlineNone,
+			controlWordTypeStrikethrough,
+			controlWordTypeSuperscript,
+			controlWordTypeSubscript,
+			controlWordTypeNoSuperSub,
+			controlWordTypeSmallcaps,
+			controlWordTypeHidden,
+			controlWordTypePlain,
+			controlWordTypeForegroundColor,
+			controlWordTypeBackgroundColor,
+			controlWordTypeHighlight,
+			controlWordTypeRevised,
+			controlWordTypeRevisionAuthor,
+			controlWordTypeRevisionTime,
+			controlWordTypeDeleted,
+			controlWordTypeDeletionAuthor,
+			controlWordTypeDeletionTime:
+			applyCharacterFormat(currentPainter, controlWord)
+		case controlWordTypeParagraphDefault,
+			controlWordTypeStyleParagraph,
+			controlWordTypeParagraphAlignment,
+			controlWordTypeLeftIndent,
+			controlWordTypeRightIndent,
+			controlWordTypeFirstLineIndent,
+			controlWordTypeSpaceBefore,
+			controlWordTypeSpaceAfter,
+			controlWordTypeLineSpacing,
+			controlWordTypeLineSpacingMultiple,
+			controlWordTypeKeepTogether,
+			controlWordTypeKeepWithNext,
+			controlWordTypePageBreakBefore,
+			controlWordTypeInTable,
+			controlWordTypeListOverrideLs,
+			controlWordTypeParagraphListLevel,
+			controlWordTypeParagraphRevisionAuthor,
+			controlWordTypeParagraphRevisionTime,
+			controlWordTypeNumberingRevisionAuthor,
+			controlWordTypeNumberingRevisionTime,
+			controlWordTypeTabAlignment,
+			controlWordTypeTabLeader,
+			controlWordTypeTabPosition,
+			controlWordTypeBarTab:
+			applyParagraphFormat(r.lastParagraph(), controlWord)
+		case controlWordTypeRowDefault,
+			controlWordTypeRowAlignment,
+			controlWordTypeRowLeftIndent,
+			controlWordTypeRowGap,
+			controlWordTypeRowHeader,
+			controlWordTypeCellBoundary,
+			controlWordTypeCellHorizontalMerge,
+			controlWordTypeCellVerticalMerge,
+			controlWordTypeCellBackground:
+			r.applyRowFormat(controlWord)
+		case controlWordTypeSectionDefault,
+			controlWordTypeSectionBreak,
+			controlWordTypeSectionColumns,
+			controlWordTypeSectionLandscape,
+			controlWordTypeSectionPageWidth,
+			controlWordTypeSectionPageHeight,
+			controlWordTypeSectionMarginLeft,
+			controlWordTypeSectionMarginRight,
+			controlWordTypeSectionMarginTop,
+			controlWordTypeSectionMarginBottom:
+			applySectionFormat(&r.section, controlWord)
+		case controlWordTypeTab:
+			doc.pushToBody(StyleBlock{
+				Painter: *currentPainter,
+				Text:    "\t",
+			})
+		case controlWordTypeSpecialCharacter:
+			doc.pushToBody(StyleBlock{
+				Painter: *currentPainter,
+				Text:    specialCharacterFromToken(controlWord),
+			})
+		case controlWordTypeNoteNumber:
+			doc.pushToBody(StyleBlock{
+				Painter: *currentPainter,
+				Kind:    BlockKindNoteNumber,
+			})
+		case controlWordTypeUnicodeSkip:
+			r.setUnicodeSkip(max(controlWord.parameter, 0))
+		case controlWordTypeUnicode:
+			codePoint := rune(controlWord.parameter)
+			if codePoint < 0 {
+				codePoint += 65536
+			}
+			r.pendingSkip = r.lastUnicodeSkip()
+
+			// characters beyond the basic multilingual plane are written as
+			// the two \u of their surrogate pair
+			switch {
+			case codePoint >= 0xd800 && codePoint < 0xdc00:
+				r.flushPartialCharacter(doc)
+				r.highSurrogate = codePoint
+				return
+			case r.highSurrogate != 0 && codePoint >= 0xdc00 && codePoint < 0xe000:
+				codePoint = utf16.DecodeRune(r.highSurrogate, codePoint)
+				r.highSurrogate = 0
+			default:
+				r.flushPartialCharacter(doc)
+			}
+
+			doc.pushToBody(StyleBlock{
+				Painter: *currentPainter,
+				Text:    string(codePoint),
+			})
+		}
+
+	case tokenTypeCRLF:
+		// \<newline> is equivalent to \par
+		r.pushParagraphMark(doc, BlockKindParagraph)
+		r.pendingSkip = 0
+
+	case tokenTypeControlSymbol:
+		controlSymbol := tkn.(controlSymbolToken)
+		if controlSymbol.symbol == '\'' && r.pendingSkip > 0 {
+			r.pendingSkip -= 1
+			return
+		}
+		if controlSymbol.symbol == '\'' && controlSymbol.parameter >= 0 {
+			r.pushByte(doc, byte(controlSymbol.parameter))
+			return
+		}
+
+		text := controlSymbolText(controlSymbol, r.currentCodePage())
+		if text != "" {
+			r.flushPartialCharacter(doc)
+			doc.pushToBody(StyleBlock{
+				Painter: *r.lastPainter(),
+				Text:    text,
+			})
+		}
+
+	case tokenTypeText:
+		currentPainter := r.lastPainter()
+		tt := tkn.(textToken)
+
+		text := tt.value
+		for r.pendingSkip > 0 && text != "" {
+			_, size := utf8.DecodeRuneInString(text)
+			text = text[size:]
+			r.pendingSkip -= 1
+		}
+
+		// trail bytes in the ASCII range are written as they are
+		if r.leadByte != 0 && text != "" {
+			doc.pushToBody(StyleBlock{
+				Painter: *currentPainter,
+				Text:    string(decodeDoubleByte(r.currentCodePage(), r.leadByte, text[0])),
+			})
+			r.leadByte = 0
+			text = text[1:]
+		}
+
+		if text == "" {
+			return
 		}
 
+		r.flushPartialCharacter(doc)
+
+		doc.pushToBody(StyleBlock{
+			Painter: *currentPainter,
+			Text:    text,
+		})
 	}
+}
 
-	return doc, nil
+// pushByte adds the character of a \'hh escape, decoded with the code page
+// of the current font. The lead bytes of double-byte characters are kept
+// until their trail byte is read.
+func (r *RtfParser) pushByte(doc *RtfDocument, b byte) {
+	codePage := r.currentCodePage()
+	if !isCodePageSupported(codePage) && !r.undecodedCodePages[codePage] {
+		if r.undecodedCodePages == nil {
+			r.undecodedCodePages = map[int]bool{}
+		}
+		r.undecodedCodePages[codePage] = true
+		r.warn(r.offset, "code page "+strconv.Itoa(codePage)+" cannot be decoded, Windows-1252 is used instead")
+	}
+
+	character := utf8.RuneError
+	switch {
+	case r.leadByte != 0:
+		character = decodeDoubleByte(codePage, r.leadByte, b)
+		r.leadByte = 0
+	case isLeadByte(codePage, b):
+		r.flushPartialCharacter(doc)
+		r.leadByte = b
+		return
+	default:
+		r.flushPartialCharacter(doc)
+		character = decodeCodePage(codePage, b)
+	}
+
+	doc.pushToBody(StyleBlock{
+		Painter: *r.lastPainter(),
+		Text:    string(character),
+	})
 }
 
-func (r *RtfParser) parseHeader() RtfHeader {
-	r.cursor = 0
+// flushPartialCharacter adds the replacement character for the first half of
+// a surrogate pair whose second half is missing, or for a lead byte without
+// its trail byte.
+func (r *RtfParser) flushPartialCharacter(doc *RtfDocument) {
+	if r.highSurrogate == 0 && r.leadByte == 0 {
+		return
+	}
+
+	r.highSurrogate, r.leadByte = 0, 0
+	doc.pushToBody(StyleBlock{
+		Painter: *r.lastPainter(),
+		Text:    string(utf8.RuneError),
+	})
+}
+
+// pushParagraphMark ends the current paragraph with a paragraph or cell
+// mark carrying its format.
+func (r *RtfParser) pushParagraphMark(doc *RtfDocument, kind BlockKind) {
+	r.flushPartialCharacter(doc)
+
+	paragraph := *r.lastParagraph()
+	doc.pushToBody(StyleBlock{
+		Painter:   *r.lastPainter(),
+		Text:      blockKindText(kind),
+		Kind:      kind,
+		Paragraph: &paragraph,
+	})
+}
+
+// currentCodePage returns the code page of the current font, or that of the
+// document if the font does not specify one.
+func (r *RtfParser) currentCodePage() int {
+	font, ok := r.fontTable[r.lastPainter().FontRef]
+	if ok && font.CodePage != 0 {
+		return font.CodePage
+	}
+
+	return r.codePage
+}
+
+// textFromGroup returns the plain text of a group as it would appear in the
+// body of a document.
+func (r *RtfParser) textFromGroup(g *Group, codePage int) string {
+	doc := RtfDocument{}
+
+	previousCodePage := r.codePage
+	r.codePage = codePage
+	r.pushPainter(Painter{})
+	r.pushParagraph(ParagraphFormat{})
+	r.pushUnicodeSkip(1)
+	r.parseBody(&doc, g)
+	r.popPainter()
+	r.popParagraph()
+	r.popUnicodeSkip()
+	r.codePage = previousCodePage
+
+	text, _ := doc.ToText()
+	return text
+}
+
+func (r *RtfParser) parseHeader(root *Group) RtfHeader {
 	header := RtfHeader{Charset: CharacterSetAnsi}
 
-	for !r.isAtEnd() {
-		currentToken := r.advance()
-		nextToken := r.peek()
-
-		if currentToken.tokenType() == tokenTypeGroup && nextToken.tokenType() == tokenTypeControlWord {
-			controlWord := nextToken.(controlWordToken)
-
-			headerTableFound := false
-			if controlWord.controlWordType == controlWordTypeFontTable {
-				headerTableFound = true
-				fontTableTokens := r.consumeTokensUntilMatchingBracket()
-				header.FontTable = r.parseFontTable(fontTableTokens)
-			} else if controlWord.controlWordType == controlWordTypeColorTable {
-				headerTableFound = true
-				colorTableTokens := r.consumeTokensUntilMatchingBracket()
-				header.ColorTable = r.parseColorTable(colorTableTokens)
-			} else if controlWord.controlWordType == controlWordTypeStylesheet {
-				headerTableFound = true
-				stylesheetTokens := r.consumeTokensUntilMatchingBracket()
-				header.Stylesheet = r.parseStylesheet(stylesheetTokens)
-			}
+	for _, child := range root.Children {
+		tkn, ok := child.(Token)
+		if !ok || tkn.Kind != TokenKindControlWord {
+			continue
+		}
 
-			if headerTableFound {
-				if r.areMoreHeaderTablePresent() {
-					continue
-				} else {
-					break
-				}
-			}
+		controlWord := controlWordFromToken(tkn)
+
+		charset := characterSetFromToken(controlWord)
+		if charset != CharacterSetNone {
+			header.Charset = charset
+		}
+
+		switch controlWord.controlWordType {
+		case controlWordTypeCodePage:
+			header.CodePage = controlWord.parameter
+		case controlWordTypeDefaultTab:
+			header.DefaultTab = max(controlWord.parameter, 0)
 		}
+	}
+
+	r.codePage = header.codePage()
+
+	if fontTable := root.Find("fonttbl"); fontTable != nil {
+		header.FontTable = r.parseFontTable(fontTable)
+	}
 
-		if currentToken != nil {
-			charset := characterSetFromToken(currentToken)
-			if charset != CharacterSetNone {
-				header.Charset = charset
+	if colorTable := root.Find("colortbl"); colorTable != nil {
+		scheme := defaultColorScheme
+		if themeData := root.Find("themedata"); themeData != nil {
+			if themeScheme := parseThemeData(r.textFromGroup(themeData, r.codePage)); themeScheme != nil {
+				scheme = themeScheme
 			}
 		}
 
-		if currentToken == nil && nextToken == nil {
-			break
+		header.ColorTable = r.parseColorTable(colorTable, scheme)
+
+		if expandedColorTable := root.Find("expandedcolortbl"); expandedColorTable != nil {
+			r.parseExpandedColorTable(expandedColorTable, header.ColorTable)
 		}
 	}
 
+	if stylesheet := root.Find("stylesheet"); stylesheet != nil {
+		header.Stylesheet = r.parseStylesheet(stylesheet)
+	}
+
+	if listTable := root.Find("listtable"); listTable != nil {
+		header.Lists = r.parseListTable(listTable, root.Find("listoverridetable"))
+	}
+
+	if revisionTable := root.Find("revtbl"); revisionTable != nil {
+		header.RevisionAuthors = r.parseRevisionTable(revisionTable)
+	}
+
+	header.Cocoa = parseCocoaMetadata(root)
+
 	return header
 }
 
-func (r *RtfParser) parseFontTable(fontTableTokens []token) FontTable {
+// parseFontTable reads the font table, whose entries are either each
+// enclosed in their own group or simply follow one another, every entry
+// starting with its \f control word.
+func (r *RtfParser) parseFontTable(fontTable *Group) FontTable {
 	table := make(FontTable)
-	var currentKey TableRef = 0
-	currentFont := Font{}
+	entry := []Node{}
+
+	for _, child := range fontTable.Children {
+		switch node := child.(type) {
+		case *Group:
+			if node.Destination == "" {
+				r.parseFontTableEntry(table, entry)
+				r.parseFontTableEntry(table, node.Children)
+				entry = []Node{}
+				continue
+			}
+
+		case Token:
+			if node.Kind == TokenKindControlWord && node.Name == "f" {
+				r.parseFontTableEntry(table, entry)
+				entry = []Node{}
+			}
+		}
+
+		entry = append(entry, child)
+	}
 
-	for _, tkn := range fontTableTokens {
-		switch tkn.tokenType() {
-		case tokenTypeControlWord:
-			controlWord := tkn.(controlWordToken)
+	r.parseFontTableEntry(table, entry)
+
+	return table
+}
+
+// parseFontTableEntry adds the font described by the nodes of a single font
+// table entry to the table. Entries without a font number are ignored.
+func (r *RtfParser) parseFontTableEntry(table FontTable, entry []Node) {
+	font := Font{Charset: FontCharsetDefault}
+	key := -1
+
+	for _, child := range entry {
+		switch node := child.(type) {
+		case Token:
+			if node.Kind != TokenKindControlWord {
+				continue
+			}
+
+			controlWord := controlWordFromToken(node)
 
 			switch controlWord.controlWordType {
 			case controlWordTypeFontNumber:
-				table[currentKey] = currentFont
-				currentKey = TableRef(controlWord.parameter)
-
+				key = controlWord.parameter
 			case controlWordTypeFontFamily:
-				fontFamily := fontFamilyFromToken(tkn)
-				currentFont.FontFamily = fontFamily
+				font.FontFamily = fontFamilyFromToken(controlWord)
+			case controlWordTypeFontCharset:
+				font.Charset = FontCharset(controlWord.parameter)
+			case controlWordTypeFontCodePage:
+				font.CodePage = controlWord.parameter
+			case controlWordTypeFontPitch:
+				font.Pitch = FontPitch(controlWord.parameter)
+			case controlWordTypeFontBias:
+				font.Bias = controlWord.parameter
+			case controlWordTypeFontTheme:
+				font.Theme = fontThemeFromToken(controlWord)
+			}
+
+		case *Group:
+			switch getControlWordTypeFromPrefix(`\` + node.Destination) {
+			case controlWordTypeFontAlternative:
+				font.AlternateName = fontNameFromText(r.textFromGroup(node, r.codePage))
+			case controlWordTypeFontName:
+				font.NonTaggedName = fontNameFromText(r.textFromGroup(node, r.codePage))
+			case controlWordTypeFontPanose:
+				panose, err := hex.DecodeString(strings.TrimSpace(r.textFromGroup(node, r.codePage)))
+				if err == nil {
+					font.Panose = panose
+				}
 			}
-		case tokenTypeText:
-			tt := tkn.(textToken)
-			currentFont.Name = strings.TrimSuffix(tt.value, ";")
-		case tokenTypeGroupEnd:
-			table[currentKey] = currentFont
 		}
 	}
 
-	return table
+	if key < 0 {
+		return
+	}
+
+	if font.CodePage == 0 {
+		font.CodePage = font.Charset.CodePage()
+	}
+
+	codePage := r.codePage
+	if font.CodePage != 0 {
+		codePage = font.CodePage
+	}
+
+	font.Name = fontNameFromText(r.textFromGroup(&Group{Children: entry}, codePage))
+	table[TableRef(key)] = font
+}
+
+// fontNameFromText extracts a font name from the text of a font table entry,
+// which ends with a semicolon.
+func fontNameFromText(text string) string {
+	name, _, _ := strings.Cut(text, ";")
+	return strings.TrimSpace(name)
 }
 
-func (r *RtfParser) parseColorTable(colorTableTokens []token) ColorTable {
+// parseColorTable reads the color table, whose entries are each terminated
+// by a semicolon. Entries without any color component stand for the auto
+// color; theme colors without components are resolved against the scheme.
+func (r *RtfParser) parseColorTable(colorTable *Group, scheme colorScheme) ColorTable {
 	table := make(ColorTable)
-	var currentKey TableRef = 1
-	var currentColor = Color{-1, -1, -1}
+	var key TableRef
+	color := Color{}
+	hasComponents, hasTint, hasShade := false, false, false
 
-	for _, tkn := range colorTableTokens {
-		switch tkn.tokenType() {
-		case tokenTypeControlWord:
-			controlWord := tkn.(controlWordToken)
+	for _, tkn := range colorTable.tokens() {
+		switch tkn.Kind {
+		case TokenKindControlWord:
+			controlWord := controlWordFromToken(tkn)
 
 			switch controlWord.controlWordType {
 			case controlWordTypeColorRed:
-				currentColor.R = controlWord.parameter
+				color.R = controlWord.parameter
+				hasComponents = true
 			case controlWordTypeColorGreen:
-				currentColor.G = controlWord.parameter
+				color.G = controlWord.parameter
+				hasComponents = true
 			case controlWordTypeColorBlue:
-				currentColor.B = controlWord.parameter
+				color.B = controlWord.parameter
+				hasComponents = true
+			case controlWordTypeColorTheme:
+				color.Theme = colorThemeFromToken(controlWord)
+			case controlWordTypeColorTint:
+				color.Tint = controlWord.parameter
+				hasTint = true
+			case controlWordTypeColorShade:
+				color.Shade = controlWord.parameter
+				hasShade = true
 			}
 
-			if currentColor.valid() {
-				table[currentKey] = currentColor
-				currentKey += 1
-				currentColor = Color{-1, -1, -1}
+		case TokenKindText:
+			for i := strings.Count(tkn.Text, ";"); i > 0; i-- {
+				table[key] = resolveColor(color, hasComponents, hasTint, hasShade, scheme)
+				key += 1
+				color = Color{}
+				hasComponents, hasTint, hasShade = false, false, false
 			}
 		}
 	}
@@ -227,218 +850,281 @@ func (r *RtfParser) parseColorTable(colorTableTokens []token) ColorTable {
 	return table
 }
 
-func (r *RtfParser) parseStylesheet(stylesheetTokens []token) Stylesheet {
-	stylesheet := make(Stylesheet)
-
-	currentStyle := Style{}
-
-	for _, tkn := range stylesheetTokens {
-		switch tkn.tokenType() {
-		case tokenTypeText:
-			tt := tkn.(textToken)
-			currentStyle.Name = strings.TrimSuffix(tt.value, ";")
+// resolveColor completes a color table entry once all of its control words
+// have been read. Theme colors without \ctint or \cshade are left unchanged
+// by them, while \ctint0 and \cshade0 make them white and black.
+func resolveColor(color Color, hasComponents, hasTint, hasShade bool, scheme colorScheme) Color {
+	if color.Theme == ColorThemeNone {
+		if !hasComponents {
+			return Color{Auto: true}
 		}
-	}
 
-	return stylesheet
-}
-
-func (r *RtfParser) areMoreHeaderTablePresent() bool {
-	nextToken := r.peek()
-	nextToNextToken := r.peekN(1)
+		color.Tint = 0
+		color.Shade = 0
+		return color
+	}
 
-	if nextToken.tokenType() == tokenTypeGroup && nextToNextToken.tokenType() == tokenTypeControlWord {
-		controlWord := nextToNextToken.(controlWordToken)
+	if hasTint {
+		color.Tint = min(max(color.Tint, 0), 255)
+	} else {
+		color.Tint = 255
+	}
+	if hasShade {
+		color.Shade = min(max(color.Shade, 0), 255)
+	} else {
+		color.Shade = 255
+	}
 
-		switch controlWord.controlWordType {
-		case controlWordTypeFontTable, controlWordTypeColorTable:
-			return true
-		default:
-			return false
-		}
+	if hasComponents {
+		// Word writes the components of theme colors with the tint and
+		// shade already applied
+		return color
 	}
 
-	return false
+	base := scheme.resolve(color.Theme)
+	color.R = applyShade(applyTint(base.R, color.Tint), color.Shade)
+	color.G = applyShade(applyTint(base.G, color.Tint), color.Shade)
+	color.B = applyShade(applyTint(base.B, color.Tint), color.Shade)
+
+	return color
 }
 
-func (r *RtfParser) parseInformationGroup() RtfInformationGroup {
-	r.cursor = 0
+// parseExpandedColorTable adds the color space data of the expanded color
+// table to the matching entries of the color table. Its entries are
+// terminated by semicolons like those of the color table, starting with the
+// auto color.
+func (r *RtfParser) parseExpandedColorTable(expandedColorTable *Group, table ColorTable) {
+	var key TableRef
+	expanded := ExpandedColor{}
+	readingName := false
 
-	// don't use advance here because we don't know if there is an information group yet
-	currentToken := r.peek()
-	nextToken := r.peekN(1)
+	for _, tkn := range expandedColorTable.tokens() {
+		switch tkn.Kind {
+		case TokenKindControlWord:
+			controlWord := controlWordFromToken(tkn)
 
-	if currentToken == nil || nextToken == nil {
-		return RtfInformationGroup{}
-	}
+			switch controlWord.controlWordType {
+			case controlWordTypeColorSpace:
+				expanded.Space = colorSpaceFromToken(controlWord)
+			case controlWordTypeColorComponent:
+				expanded.Components = append(expanded.Components, float64(controlWord.parameter)/100000)
+			case controlWordTypeColorName:
+				readingName = true
+			}
 
-	if currentToken.tokenType() == tokenTypeGroup && nextToken.tokenType() == tokenTypeControlWord {
-		controlWord := nextToken.(controlWordToken)
+		case TokenKindText:
+			entries := strings.Split(stripNewlines(tkn.Text), ";")
+			for i, text := range entries {
+				if readingName {
+					expanded.Name += text
+				}
 
-		if controlWord.controlWordType != controlWordTypeInfo {
-			return RtfInformationGroup{}
-		}
+				if i == len(entries)-1 {
+					break
+				}
 
-		// advance into the information group
-		r.advance()
-	} else {
-		return RtfInformationGroup{}
-	}
-
-	informationGroup := RtfInformationGroup{}
-	for !r.isAtEnd() {
-		currentToken := r.advance()
-		nextToken := r.peek()
-
-		if currentToken.tokenType() == tokenTypeGroup && nextToken.tokenType() == tokenTypeControlWord {
-			controlWord := nextToken.(controlWordToken)
-			tokens := r.consumeTokensUntilMatchingBracket()
-
-			if controlWord.controlWordType == controlWordTypeInfoVersion {
-				informationGroup.Version = controlWord.parameter
-			} else if len(tokens) == 3 {
-				textToken := tokens[1].(textToken)
-
-				switch controlWord.controlWordType {
-				case controlWordTypeInfoTitle:
-					informationGroup.Title = textToken.value
-				case controlWordTypeInfoSubject:
-					informationGroup.Subject = textToken.value
-				case controlWordTypeInfoAuthor:
-					informationGroup.Author = textToken.value
-				case controlWordTypeInfoManager:
-					informationGroup.Manager = textToken.value
-				case controlWordTypeInfoCompany:
-					informationGroup.Company = textToken.value
-				case controlWordTypeInfoOperator:
-					informationGroup.Operator = textToken.value
-				case controlWordTypeInfoCategory:
-					informationGroup.Category = textToken.value
-				case controlWordTypeInfoKeywords:
-					informationGroup.Keywords = textToken.value
-				case controlWordTypeInfoComment:
-					informationGroup.Comment = textToken.value
-				case controlWordTypeInfoDoccom:
-					informationGroup.DocumentComment = textToken.value
-				case controlWordTypeInfoHlinkBase:
-					informationGroup.BaseAddress = textToken.value
+				color, ok := table[key]
+				if ok && (expanded.Space != ColorSpaceNone || expanded.Name != "") {
+					entry := expanded
+					entry.Name = strings.TrimSpace(entry.Name)
+					color.Expanded = &entry
+					table[key] = color
 				}
+
+				key += 1
+				expanded = ExpandedColor{}
+				readingName = false
 			}
+		}
+	}
+}
+
+// parseStylesheet reads the paragraph styles of the stylesheet. Character,
+// section and table styles are skipped.
+func (r *RtfParser) parseStylesheet(stylesheet *Group) Stylesheet {
+	styles := make(Stylesheet)
 
+	for _, entry := range stylesheet.Groups() {
+		if entry.Destination != "" {
+			continue
 		}
 
-		if currentToken.tokenType() == tokenTypeGroupEnd {
-			break
+		style, ok := r.parseStyle(entry)
+		if ok {
+			styles[style.Name] = style
 		}
 	}
 
-	return informationGroup
+	return styles
 }
 
-func (r *RtfParser) consumeTokensUntilMatchingBracket() []token {
-	tokens := []token{}
-	count := 0
+func (r *RtfParser) parseStyle(entry *Group) (Style, bool) {
+	style := Style{}
 
-	for !r.isAtEnd() {
-		currentToken := r.advance()
+	for _, tkn := range entry.tokens() {
+		if tkn.Kind == TokenKindControlWord {
+			controlWord := controlWordFromToken(tkn)
 
-		switch currentToken.tokenType() {
-		case tokenTypeGroup:
-			count += 1
-		case tokenTypeGroupEnd:
-			count -= 1
-		}
+			switch controlWord.controlWordType {
+			case controlWordTypeStyleCharacter, controlWordTypeStyleSection:
+				return Style{}, false
+			case controlWordTypeStyleParagraph:
+				style.Number = max(controlWord.parameter, 0)
+			}
 
-		tokens = append(tokens, currentToken)
+			if tkn.Name == "ts" {
+				return Style{}, false
+			}
 
-		if count < 0 {
-			break
+			applyCharacterFormat(&style.Painter, controlWord)
+			applyParagraphFormat(&style.Paragraph, controlWord)
 		}
 	}
 
-	return tokens
+	name := strings.TrimSpace(r.textFromGroup(entry, r.codePage))
+	style.Name = strings.TrimSpace(strings.TrimSuffix(name, ";"))
+	style.Paragraph.Style = style.Number
+
+	return style, style.Name != ""
 }
 
-// advance returns the token at the cursor location
-// and removes the token from the list of tokens
-func (r *RtfParser) advance() token {
-	if len(r.tokens) == 0 {
-		panic("no tokens")
-	}
+func (r *RtfParser) pushPainter(p Painter) {
+	r.painterStack = append(r.painterStack, &p)
+}
 
-	t := r.tokens[r.cursor]
+func (r *RtfParser) popPainter() Painter {
+	if len(r.painterStack) == 0 {
+		panic("too many group endings")
+	}
 
-	r.tokens = append(r.tokens[:r.cursor], r.tokens[r.cursor+1:]...)
+	index := len(r.painterStack) - 1
+	element := r.painterStack[index]
+	r.painterStack = r.painterStack[:index]
 
-	return t
+	return *element
 }
 
-func (r *RtfParser) advanceN(n int) {
-	if len(r.tokens) < n {
-		panic(fmt.Sprintf("only %d tokens left", len(r.tokens)))
+func (r *RtfParser) lastPainter() *Painter {
+	topIndex := len(r.painterStack) - 1
+
+	if topIndex < 0 {
+		panic("malformed painter stack")
 	}
 
-	r.tokens = r.tokens[r.cursor+n:]
+	return r.painterStack[topIndex]
+}
+
+func (r *RtfParser) pushParagraph(p ParagraphFormat) {
+	r.paragraphStack = append(r.paragraphStack, &p)
 }
 
-func (r *RtfParser) peek() token {
-	if r.isAtEnd() {
-		return nil
+func (r *RtfParser) popParagraph() ParagraphFormat {
+	if len(r.paragraphStack) == 0 {
+		panic("too many group endings")
 	}
 
-	return r.tokens[r.cursor]
+	index := len(r.paragraphStack) - 1
+	element := r.paragraphStack[index]
+	r.paragraphStack = r.paragraphStack[:index]
+
+	return *element
 }
 
-func (r *RtfParser) peekN(n int) token {
-	if r.cursor >= len(r.tokens)-n {
-		return nil
+func (r *RtfParser) lastParagraph() *ParagraphFormat {
+	topIndex := len(r.paragraphStack) - 1
+
+	if topIndex < 0 {
+		panic("malformed paragraph stack")
 	}
 
-	return r.tokens[r.cursor+n]
+	return r.paragraphStack[topIndex]
 }
 
-func (r *RtfParser) isAtEnd() bool {
-	return r.cursor >= len(r.tokens)
+func (r *RtfParser) pushUnicodeSkip(n int) {
+	r.unicodeSkipStack = append(r.unicodeSkipStack, n)
 }
 
-func (r *RtfParser) popToken() token {
-	if len(r.tokens) == 0 {
+func (r *RtfParser) popUnicodeSkip() int {
+	if len(r.unicodeSkipStack) == 0 {
 		panic("too many group endings")
 	}
 
-	index := len(r.tokens) - 1
-	element := r.tokens[index]
-	r.tokens = r.tokens[:index]
+	index := len(r.unicodeSkipStack) - 1
+	element := r.unicodeSkipStack[index]
+	r.unicodeSkipStack = r.unicodeSkipStack[:index]
 
 	return element
 }
 
-func (r *RtfParser) pushPainter(p Painter) {
-	r.painterStack = append(r.painterStack, &p)
-}
+func (r *RtfParser) lastUnicodeSkip() int {
+	topIndex := len(r.unicodeSkipStack) - 1
 
-func (r *RtfParser) popPainter() Painter {
-	if len(r.painterStack) == 0 {
-		panic("too many group endings")
+	if topIndex < 0 {
+		panic("malformed unicode skip stack")
 	}
 
-	index := len(r.painterStack) - 1
-	element := r.painterStack[index]
-	r.painterStack = r.painterStack[:index]
-
-	return *element
+	return r.unicodeSkipStack[topIndex]
 }
 
-func (r *RtfParser) lastPainter() *Painter {
-	topIndex := len(r.painterStack) - 1
+func (r *RtfParser) setUnicodeSkip(n int) {
+	topIndex := len(r.unicodeSkipStack) - 1
 
 	if topIndex < 0 {
-		panic("malformed painter stack")
+		panic("malformed unicode skip stack")
 	}
 
-	return r.painterStack[topIndex]
+	r.unicodeSkipStack[topIndex] = n
 }
 
-func (r *RtfParser) readInformationGroup() {
+// specialCharacterFromToken returns the text produced by a control word such
+// as \emdash or \ldblquote.
+func specialCharacterFromToken(controlWord controlWordToken) string {
+	switch controlWord.name {
+	case `\emdash`:
+		return "\u2014"
+	case `\endash`:
+		return "\u2013"
+	case `\emspace`:
+		return "\u2003"
+	case `\enspace`:
+		return "\u2002"
+	case `\qmspace`:
+		return "\u2005"
+	case `\bullet`:
+		return "\u2022"
+	case `\lquote`:
+		return "\u2018"
+	case `\rquote`:
+		return "\u2019"
+	case `\ldblquote`:
+		return "\u201c"
+	case `\rdblquote`:
+		return "\u201d"
+	case `\zwj`:
+		return "\u200d"
+	case `\zwnj`:
+		return "\u200c"
+	default:
+		return ""
+	}
+}
 
+// controlSymbolText returns the text a control symbol stands for in the
+// document body.
+func controlSymbolText(c controlSymbolToken, codePage int) string {
+	switch c.symbol {
+	case '\'':
+		if c.parameter < 0 {
+			return ""
+		}
+		return string(decodeCodePage(codePage, byte(c.parameter)))
+	case '~':
+		return "\u00a0"
+	case '_':
+		return "\u2011"
+	default:
+		// \- (optional hyphen), \| and \: (formulas and index entries)
+		// produce no text of their own
+		return ""
+	}
 }
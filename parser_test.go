@@ -3,6 +3,7 @@ package gortf
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,13 +22,14 @@ func TestRTFParser(t *testing.T) {
 			FontTable: map[TableRef]Font{
 				0: Font{
 					Name:       "Helvetica",
-					Charset:    CharacterSetNone,
+					Charset:    FontCharsetDefault,
 					FontFamily: FontFamilySwiss,
 				},
 			},
 			ColorTable: map[TableRef]Color{
-				1: Color{0, 0, 0},
-				2: Color{255, 255, 255},
+				0: Color{Auto: true},
+				1: Color{R: 0, G: 0, B: 0},
+				2: Color{R: 255, G: 255, B: 255},
 			},
 		},
 		InformationGroup: RtfInformationGroup{},
@@ -46,6 +48,12 @@ func TestRTFParser(t *testing.T) {
 				Painter: Painter{},
 				Text:    " text.",
 			},
+			StyleBlock{
+				Painter:   Painter{},
+				Text:      "\n",
+				Kind:      BlockKindParagraph,
+				Paragraph: &ParagraphFormat{},
+			},
 		},
 	}
 
@@ -58,10 +66,10 @@ func TestParseIgnoreGroup(t *testing.T) {
 	content := `{\*\expandedcolortbl;;}`
 
 	parser := NewRtfParser()
-	parser.ParseContent(content)
+	doc, _ := parser.ParseContent(content)
 
-	if len(parser.tokens) != 0 {
-		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 0, len(parser.tokens))
+	if len(doc.Body) != 0 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 0, len(doc.Body))
 	}
 }
 
@@ -72,7 +80,7 @@ func TestRTFToText(t *testing.T) {
 	doc, _ := parser.ParseContent(content)
 	text, _ := doc.ToText()
 
-	expected := "Voici du texte en gras."
+	expected := "Voici du texte en gras.\n"
 
 	if text != expected {
 		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, text)
@@ -95,7 +103,28 @@ func TestRTFToHTML(t *testing.T) {
 		t.Error(err)
 	}
 
-	expected := "This is some <bold>bold</bold> text."
+	expected := "This is some <bold>bold</bold> text.\n"
+
+	if html != expected {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, html)
+	}
+}
+
+func TestRTFToHTMLEscapes(t *testing.T) {
+	content := `{\rtf1\ansi\pard if a < b && {\b c > d}, <script>\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := doc.ToHTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "if a &lt; b &amp;&amp; <bold>c &gt; d</bold>, &lt;script&gt;\n"
 
 	if html != expected {
 		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, html)
@@ -114,7 +143,7 @@ func TestParseFileMinimal(t *testing.T) {
 		t.Error(err)
 	}
 
-	expected := "this is a test file"
+	expected := "This is a test file\n\n"
 
 	if txt != expected {
 		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, txt)
@@ -135,3 +164,187 @@ func TestRTFToHTMLMinimal(t *testing.T) {
 
 	fmt.Println(html)
 }
+
+func TestRTFToTextEscapes(t *testing.T) {
+	content := `{\rtf1\ansi\ansicpg1252{\fonttbl\f0\fswiss Helvetica;}\f0 caf\'e9 co\~op \uc1\u8364?\u8364\'80}`
+
+	parser := NewRtfParser()
+	doc, _ := parser.ParseContent(content)
+	text, _ := doc.ToText()
+
+	expected := "café co\u00a0op €€"
+
+	if text != expected {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, text)
+	}
+}
+
+func TestRTFToTextWhitespace(t *testing.T) {
+	content := "{\\rtf1\\ansi{\\fonttbl\\f0\\fswiss Helvetica;}\\f0\\pard\r\n"
+	content += "{  leading}{\\b trailing  }\r\n"
+	content += "split\r\nword\\tab tabbed\tend\\line\r\n"
+	content += "next\\\r\n"
+	content += "last\\par\r\n}"
+
+	parser := NewRtfParser()
+	doc, _ := parser.ParseContent(content)
+	text, _ := doc.ToText()
+
+	expected := "  leadingtrailing  splitword\ttabbed\tend\nnext\nlast\n"
+
+	if text != expected {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, text)
+	}
+}
+
+func TestParseFontTable(t *testing.T) {
+	content := `{\rtf1\ansi\ansicpg1252{\fonttbl`
+	content += `{\f0\froman\fcharset0\fprq2{\*\panose 02020603050405020304}Times New Roman;}`
+	content += `{\f1\fswiss\fcharset204\fprq2 Arial {\b Cyr}{\*\falt Helvetica};}`
+	content += `{\f2\fnil\fcharset238\fprq0{\*\fname Verdana;}Verdana CE;}`
+	content += `{\flomajor\f31500\froman\fcharset0\fprq2 Times New Roman;}`
+	content += `{\f3\fnil\fcharset0 Caf\'e9 \{Sans\};}`
+	content += `{\f4\fnil\fcharset204\cpg1251 \'cf\'f0\'e8\'ec\'e5\'f0;}}`
+	content += `\f1 \'cf\'f0\'e8\'e2\'e5\'f2}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := FontTable{
+		0: Font{
+			Name:       "Times New Roman",
+			Charset:    FontCharsetAnsi,
+			CodePage:   1252,
+			FontFamily: FontFamilyRoman,
+			Pitch:      FontPitchVariable,
+			Panose:     []byte{0x02, 0x02, 0x06, 0x03, 0x05, 0x04, 0x05, 0x02, 0x03, 0x04},
+		},
+		1: Font{
+			Name:          "Arial Cyr",
+			Charset:       FontCharsetRussian,
+			CodePage:      1251,
+			FontFamily:    FontFamilySwiss,
+			Pitch:         FontPitchVariable,
+			AlternateName: "Helvetica",
+		},
+		2: Font{
+			Name:          "Verdana CE",
+			Charset:       FontCharsetEasternEurope,
+			CodePage:      1250,
+			FontFamily:    FontFamilyNil,
+			Pitch:         FontPitchDefault,
+			NonTaggedName: "Verdana",
+		},
+		31500: Font{
+			Name:       "Times New Roman",
+			Charset:    FontCharsetAnsi,
+			CodePage:   1252,
+			FontFamily: FontFamilyRoman,
+			Pitch:      FontPitchVariable,
+			Theme:      FontThemeMajorLatin,
+		},
+		3: Font{
+			Name:     "Café {Sans}",
+			Charset:  FontCharsetAnsi,
+			CodePage: 1252,
+		},
+		4: Font{
+			Name:     "Пример",
+			Charset:  FontCharsetRussian,
+			CodePage: 1251,
+		},
+	}
+
+	if !reflect.DeepEqual(doc.Header.FontTable, expected) {
+		t.Errorf("\n\nexpected: %+v\n\nactual\t: %+v", expected, doc.Header.FontTable)
+	}
+
+	text, _ := doc.ToText()
+	if text != "Привет" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Привет", text)
+	}
+}
+
+func TestParseFontTableMinimal(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseFile("./testfiles/minimal.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := FontTable{
+		0: Font{Name: "Helvetica", Charset: FontCharsetAnsi, CodePage: 1252, FontFamily: FontFamilySwiss},
+		1: Font{Name: "CourierNewPSMT", Charset: FontCharsetAnsi, CodePage: 1252, FontFamily: FontFamilyModern},
+		2: Font{Name: "CourierNewPS-ItalicMT", Charset: FontCharsetAnsi, CodePage: 1252, FontFamily: FontFamilyModern},
+		3: Font{Name: "CourierNewPS-BoldMT", Charset: FontCharsetAnsi, CodePage: 1252, FontFamily: FontFamilyModern},
+	}
+
+	if !reflect.DeepEqual(doc.Header.FontTable, expected) {
+		t.Errorf("\n\nexpected: %+v\n\nactual\t: %+v", expected, doc.Header.FontTable)
+	}
+}
+
+func TestRTFToTextDoubleByteCodePages(t *testing.T) {
+	content := `{\rtf1\ansi\ansicpg1252{\fonttbl{\f0\fnil\fcharset128 MS Mincho;}{\f1\fnil\fcharset134 SimSun;}` +
+		`{\f2\fnil\fcharset129 Batang;}{\f3\fnil\fcharset136 MingLiU;}}` +
+		`\f0 \'82\'a0\'83A\'b1 \uc2\u12354\'82\'a0\'82\par` +
+		`\f1 \'d6\'d0\par\f2 \'c7\'d1\par\f3 \'a4\'a4\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "あアｱ あ\ufffd\n中\n한\n中\n"
+	if text, _ := doc.ToText(); text != expected {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, text)
+	}
+	if warnings := parser.Warnings(); len(warnings) != 0 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no warnings", warnings)
+	}
+
+	// Johab cannot be decoded
+	doc, err = parser.ParseContent(`{\rtf1\ansi{\fonttbl{\f0\fnil\fcharset130 Gulim;}}\f0 \'88\'61\'88\'61\par}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings := parser.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0].String(), "code page 1361 cannot be decoded") {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a warning about code page 1361", warnings)
+	}
+}
+
+func TestRTFToTextSurrogatePairs(t *testing.T) {
+	content := `{\rtf1\ansi\uc1 smile \u-10179?\u-8704? lone \u-10179?\par\uc2 skip \u8364éé two}`
+
+	parser := NewRtfParser()
+	doc, _ := parser.ParseContent(content)
+	text, _ := doc.ToText()
+
+	expected := "smile \U0001f600 lone �\nskip € two"
+	if text != expected {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, text)
+	}
+
+	b := NewBuilder()
+	b.Paragraph().Text("Faces \U0001f600 and \U0001f642")
+	built, err := b.Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := built.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err = parser.ParseContent(written)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := doc.ToText(); text != "Faces \U0001f600 and \U0001f642\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "Faces \U0001f600 and \U0001f642\n", text)
+	}
+}
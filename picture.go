@@ -0,0 +1,400 @@
+package gortf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+)
+
+// PictureFormat is the format of the data of a picture, as given by the
+// control word following \pict.
+type PictureFormat int
+
+const (
+	PictureFormatUnknown PictureFormat = iota
+	PictureFormatEMF
+	PictureFormatPNG
+	PictureFormatJPEG
+	PictureFormatMacPICT
+	PictureFormatWMF
+	PictureFormatDIB
+	PictureFormatBitmap
+)
+
+func (p PictureFormat) String() string {
+	switch p {
+	case PictureFormatEMF:
+		return "EMF"
+	case PictureFormatPNG:
+		return "PNG"
+	case PictureFormatJPEG:
+		return "JPEG"
+	case PictureFormatMacPICT:
+		return "MacPICT"
+	case PictureFormatWMF:
+		return "WMF"
+	case PictureFormatDIB:
+		return "DIB"
+	case PictureFormatBitmap:
+		return "Bitmap"
+	default:
+		return "Unknown"
+	}
+}
+
+// Extension returns the usual file name extension for the format, without a
+// leading dot.
+func (p PictureFormat) Extension() string {
+	switch p {
+	case PictureFormatEMF:
+		return "emf"
+	case PictureFormatPNG:
+		return "png"
+	case PictureFormatJPEG:
+		return "jpg"
+	case PictureFormatMacPICT:
+		return "pict"
+	case PictureFormatWMF:
+		return "wmf"
+	case PictureFormatDIB:
+		return "dib"
+	default:
+		return "bin"
+	}
+}
+
+// MIMEType returns the media type of the format.
+func (p PictureFormat) MIMEType() string {
+	switch p {
+	case PictureFormatEMF:
+		return "image/emf"
+	case PictureFormatPNG:
+		return "image/png"
+	case PictureFormatJPEG:
+		return "image/jpeg"
+	case PictureFormatMacPICT:
+		return "image/x-pict"
+	case PictureFormatWMF:
+		return "image/wmf"
+	case PictureFormatDIB:
+		return "image/bmp"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func pictureFormatFromToken(tkn token) PictureFormat {
+	if tkn.tokenType() == tokenTypeControlWord {
+		controlWord := tkn.(controlWordToken)
+
+		switch controlWord.name {
+		case `\emfblip`:
+			return PictureFormatEMF
+		case `\pngblip`:
+			return PictureFormatPNG
+		case `\jpegblip`:
+			return PictureFormatJPEG
+		case `\macpict`:
+			return PictureFormatMacPICT
+		case `\wmetafile`:
+			return PictureFormatWMF
+		case `\dibitmap`:
+			return PictureFormatDIB
+		case `\wbitmap`:
+			return PictureFormatBitmap
+		}
+	}
+
+	return PictureFormatUnknown
+}
+
+// Picture is an image embedded in the document with \pict.
+type Picture struct {
+	Format PictureFormat `json:"format"`
+
+	// Width and Height are the size of the picture in pixels, or in
+	// hundredths of millimeters for metafiles.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// GoalWidth and GoalHeight are the desired size of the picture in twips,
+	// before scaling.
+	GoalWidth  int `json:"goalWidth"`
+	GoalHeight int `json:"goalHeight"`
+
+	// ScaleX and ScaleY are the horizontal and vertical scaling in percent.
+	ScaleX int `json:"scaleX"`
+	ScaleY int `json:"scaleY"`
+
+	Data []byte `json:"data"`
+
+	// Name is the file name of a picture attached to an RTFD bundle.
+	Name string `json:"name,omitempty"`
+}
+
+// parsePicture reads a \pict group. The picture data is either hexadecimal
+// text or binary data introduced by \bin.
+func (r *RtfParser) parsePicture(g *Group) Picture {
+	picture := Picture{ScaleX: 100, ScaleY: 100}
+
+	for _, tkn := range g.tokens() {
+		if tkn.Kind != TokenKindControlWord {
+			continue
+		}
+
+		controlWord := controlWordFromToken(tkn)
+
+		switch controlWord.controlWordType {
+		case controlWordTypePictureFormat:
+			picture.Format = pictureFormatFromToken(controlWord)
+		case controlWordTypePictureWidth:
+			picture.Width = controlWord.parameter
+		case controlWordTypePictureHeight:
+			picture.Height = controlWord.parameter
+		case controlWordTypePictureGoalWidth:
+			picture.GoalWidth = controlWord.parameter
+		case controlWordTypePictureGoalHeight:
+			picture.GoalHeight = controlWord.parameter
+		case controlWordTypePictureScaleX:
+			picture.ScaleX = controlWord.parameter
+		case controlWordTypePictureScaleY:
+			picture.ScaleY = controlWord.parameter
+		}
+	}
+
+	picture.Data = r.groupData(g, "picture")
+
+	if picture.Format == PictureFormatUnknown {
+		r.warn(g.Offset, "picture of unknown format")
+	}
+
+	return picture
+}
+
+// groupData returns the data of a group holding binary data, such as a
+// picture, given either as hexadecimal text or introduced by \bin.
+func (r *RtfParser) groupData(g *Group, what string) []byte {
+	var data []byte
+	var digits strings.Builder
+
+	for _, tkn := range g.tokens() {
+		switch tkn.Kind {
+		case TokenKindText:
+			digits.WriteString(strings.Join(strings.Fields(tkn.Text), ""))
+		case TokenKindBinary:
+			data = append(data, tkn.Data...)
+		}
+	}
+
+	if digits.Len() > 0 {
+		text := digits.String()
+		if len(text)%2 != 0 {
+			r.warn(g.Offset, what+" data has an odd number of hexadecimal digits")
+			text = text[:len(text)-1]
+		}
+
+		decoded, err := hex.DecodeString(text)
+		if err != nil {
+			r.warn(g.Offset, what+" data is not valid hexadecimal")
+		}
+		data = append(data, decoded...)
+	}
+
+	return data
+}
+
+// size returns the size the picture is displayed at in twips. Without a goal
+// size, the size of metafiles is in hundredths of millimeters and that of
+// bitmaps in pixels at 96 dots per inch.
+func (p Picture) size() (width int, height int) {
+	width, height = p.GoalWidth, p.GoalHeight
+
+	switch p.Format {
+	case PictureFormatEMF, PictureFormatWMF, PictureFormatMacPICT:
+		if width <= 0 {
+			width = p.Width * 1440 / 2540
+		}
+		if height <= 0 {
+			height = p.Height * 1440 / 2540
+		}
+	default:
+		if width <= 0 {
+			width = p.Width * 15
+		}
+		if height <= 0 {
+			height = p.Height * 15
+		}
+	}
+
+	if p.ScaleX > 0 {
+		width = width * p.ScaleX / 100
+	}
+	if p.ScaleY > 0 {
+		height = height * p.ScaleY / 100
+	}
+
+	return width, height
+}
+
+// fileData returns the picture as the content of an image file, with the
+// file name extension and media type to store it with. Windows metafiles are
+// given the placeable header RTF leaves out and device-independent bitmaps
+// the header of BMP files. It fails for formats other programs cannot read.
+func (p Picture) fileData() (data []byte, extension string, mimeType string, ok bool) {
+	switch p.Format {
+	case PictureFormatPNG, PictureFormatJPEG, PictureFormatEMF:
+		return p.Data, p.Format.Extension(), p.Format.MIMEType(), len(p.Data) > 0
+	case PictureFormatWMF:
+		return placeableMetafile(p.Data, p.Width, p.Height), p.Format.Extension(), p.Format.MIMEType(), len(p.Data) > 0
+	case PictureFormatDIB:
+		data, ok := bitmapFile(p.Data)
+		return data, "bmp", p.Format.MIMEType(), ok
+	default:
+		return nil, "", "", false
+	}
+}
+
+// dataURI returns the picture as a data URI, for documents that embed it.
+func (p Picture) dataURI() (string, bool) {
+	data, _, mimeType, ok := p.fileData()
+	if !ok {
+		return "", false
+	}
+
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), true
+}
+
+// placeableMetafile prepends the placeable metafile header to a Windows
+// metafile whose size is given in hundredths of millimeters.
+func placeableMetafile(data []byte, width int, height int) []byte {
+	const placeableKey = 0x9ac6cdd7
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == placeableKey {
+		return data
+	}
+
+	header := make([]byte, 22)
+	binary.LittleEndian.PutUint32(header[0:], placeableKey)
+	binary.LittleEndian.PutUint16(header[10:], uint16(max(width, 0)))
+	binary.LittleEndian.PutUint16(header[12:], uint16(max(height, 0)))
+	// units per inch
+	binary.LittleEndian.PutUint16(header[14:], 2540)
+
+	var checksum uint16
+	for i := 0; i < 20; i += 2 {
+		checksum ^= binary.LittleEndian.Uint16(header[i:])
+	}
+	binary.LittleEndian.PutUint16(header[20:], checksum)
+
+	return append(header, data...)
+}
+
+// bitmapFile prepends the BMP file header to a device-independent bitmap,
+// locating the pixels after its header and color table.
+func bitmapFile(data []byte) ([]byte, bool) {
+	if len(data) < 40 {
+		return nil, false
+	}
+
+	headerSize := int(binary.LittleEndian.Uint32(data[0:]))
+	bitCount := int(binary.LittleEndian.Uint16(data[14:]))
+	compression := binary.LittleEndian.Uint32(data[16:])
+	colors := int(binary.LittleEndian.Uint32(data[32:]))
+	if colors == 0 && bitCount <= 8 {
+		colors = 1 << bitCount
+	}
+
+	offset := 14 + headerSize + colors*4
+	if headerSize == 40 && compression == 3 {
+		// BI_BITFIELDS color masks
+		offset += 12
+	}
+	if offset > 14+len(data) {
+		return nil, false
+	}
+
+	header := make([]byte, 14)
+	header[0], header[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(header[2:], uint32(14+len(data)))
+	binary.LittleEndian.PutUint32(header[10:], uint32(offset))
+
+	return append(header, data...), true
+}
+
+// pictureFromFile is the reverse of Picture.fileData: it makes a picture
+// displayed at the given size in twips from the content of an image file
+// with the given extension. Bitmaps given no size are displayed at 96 dots
+// per inch. It fails for formats RTF cannot hold.
+func pictureFromFile(data []byte, extension string, width int, height int) (Picture, bool) {
+	picture := Picture{GoalWidth: width, GoalHeight: height, ScaleX: 100, ScaleY: 100, Data: data}
+
+	// pixels at 96 dots per inch, and hundredths of millimeters
+	pixelWidth, pixelHeight := width/15, height/15
+	metricWidth, metricHeight := width*2540/1440, height*2540/1440
+
+	switch strings.ToLower(extension) {
+	case "png":
+		picture.Format = PictureFormatPNG
+		picture.Width, picture.Height = pixelWidth, pixelHeight
+		if len(data) >= 24 && string(data[12:16]) == "IHDR" {
+			picture.Width = int(binary.BigEndian.Uint32(data[16:]))
+			picture.Height = int(binary.BigEndian.Uint32(data[20:]))
+		}
+	case "jpg", "jpeg":
+		picture.Format = PictureFormatJPEG
+		picture.Width, picture.Height = pixelWidth, pixelHeight
+		if w, h, ok := jpegSize(data); ok {
+			picture.Width, picture.Height = w, h
+		}
+	case "emf":
+		picture.Format = PictureFormatEMF
+		picture.Width, picture.Height = metricWidth, metricHeight
+	case "wmf":
+		picture.Format = PictureFormatWMF
+		picture.Width, picture.Height = metricWidth, metricHeight
+		if len(data) >= 22 && binary.LittleEndian.Uint32(data) == 0x9ac6cdd7 {
+			picture.Data = data[22:]
+		}
+	case "bmp", "dib":
+		if len(data) < 14+40 || string(data[:2]) != "BM" {
+			return Picture{}, false
+		}
+		picture.Format = PictureFormatDIB
+		picture.Data = data[14:]
+		picture.Width = int(int32(binary.LittleEndian.Uint32(data[18:])))
+		picture.Height = int(int32(binary.LittleEndian.Uint32(data[22:])))
+		picture.Height = max(picture.Height, -picture.Height)
+	default:
+		return Picture{}, false
+	}
+
+	if width == 0 && height == 0 && picture.Format != PictureFormatEMF && picture.Format != PictureFormatWMF {
+		picture.GoalWidth, picture.GoalHeight = picture.Width*15, picture.Height*15
+	}
+
+	return picture, len(picture.Data) > 0
+}
+
+// jpegSize returns the size in pixels given by the start of frame segment of
+// a JPEG file.
+func jpegSize(data []byte) (width int, height int, ok bool) {
+	for i := 2; i+9 < len(data); {
+		if data[i] != 0xff {
+			return 0, 0, false
+		}
+
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		// start of frame markers, other than those of the DHT, JPG and DAC
+		// segments sharing their range
+		if marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc {
+			height = int(binary.BigEndian.Uint16(data[i+5:]))
+			width = int(binary.BigEndian.Uint16(data[i+7:]))
+			return width, height, true
+		}
+
+		i += 2 + length
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,94 @@
+package gortf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePictures(t *testing.T) {
+	content := `{\rtf1\ansi Before {\pict\pngblip\picw2\pich3\picwgoal30\pichgoal45\picscalex50` + "\n"
+	content += `89504e47` + "\n" + `0d0a1a0a}`
+	content += `{\*\shppict{\pict\jpegblip\bin4 ` + "\xff\xd8\xff\xe0" + `}}{\nonshppict{\pict\wmetafile8 0100}} after.}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Picture{
+		{
+			Format:     PictureFormatPNG,
+			Width:      2,
+			Height:     3,
+			GoalWidth:  30,
+			GoalHeight: 45,
+			ScaleX:     50,
+			ScaleY:     100,
+			Data:       []byte{0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a},
+		},
+		{
+			Format: PictureFormatJPEG,
+			ScaleX: 100,
+			ScaleY: 100,
+			Data:   []byte{0xff, 0xd8, 0xff, 0xe0},
+		},
+	}
+
+	if !reflect.DeepEqual(doc.Pictures, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Pictures)
+	}
+
+	text, _ := doc.ToText()
+	if text != "Before  after." {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "Before  after.", text)
+	}
+
+	if len(parser.Warnings()) != 0 {
+		t.Errorf("unexpected warnings: %v", parser.Warnings())
+	}
+}
+
+func TestParsePictureWarnings(t *testing.T) {
+	parser := NewRtfParser()
+	_, err := parser.ParseContent(`{\rtf1{\pict 0a0}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Warning{
+		{Offset: 6, Message: "picture data has an odd number of hexadecimal digits"},
+		{Offset: 6, Message: "picture of unknown format"},
+	}
+
+	if !reflect.DeepEqual(parser.Warnings(), expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, parser.Warnings())
+	}
+}
+
+func TestPictureFileData(t *testing.T) {
+	// a 1x1 device-independent bitmap of 1 bit per pixel, with its two
+	// palette entries and a row of pixels
+	dib := make([]byte, 40+8+4)
+	dib[0], dib[14] = 40, 1
+
+	data, extension, _, ok := Picture{Format: PictureFormatDIB, Data: dib}.fileData()
+	if !ok || extension != "bmp" || string(data[:2]) != "BM" || data[10] != 14+40+8 || data[2] != byte(14+len(dib)) {
+		t.Errorf("unexpected bitmap file: %v %v %v", ok, extension, data[:14])
+	}
+
+	data, _, _, _ = Picture{Format: PictureFormatWMF, Width: 2540, Height: 1270, Data: []byte{1, 0}}.fileData()
+	expected := []byte{0xd7, 0xcd, 0xc6, 0x9a, 0, 0, 0, 0, 0, 0, 0xec, 0x09, 0xf6, 0x04, 0xec, 0x09, 0, 0, 0, 0}
+	if !reflect.DeepEqual(data[:20], expected) || len(data) != 24 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, data)
+	}
+
+	if _, _, _, ok := (Picture{Format: PictureFormatMacPICT, Data: []byte{1}}).fileData(); ok {
+		t.Errorf("expected Mac PICT pictures to be left out")
+	}
+
+	width, height := Picture{Format: PictureFormatPNG, Width: 10, Height: 20, ScaleX: 50, ScaleY: 100}.size()
+	if width != 75 || height != 300 {
+		t.Errorf("\n\nexpected: 75 300\n\nactual\t: %v %v", width, height)
+	}
+}
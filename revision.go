@@ -0,0 +1,135 @@
+package gortf
+
+import (
+	"strings"
+	"time"
+)
+
+// RevisionTime is the time of a revision, in the DTTM format of Word, which
+// packs the minute, hour, day, month and year since 1900 in the bits of an
+// integer. It is 0 when unknown.
+type RevisionTime int
+
+// NewRevisionTime returns the revision time of t, to the minute.
+func NewRevisionTime(t time.Time) RevisionTime {
+	return RevisionTime(dttm(t))
+}
+
+// Time returns the time of the revision, or the zero time if it is unknown.
+func (r RevisionTime) Time() time.Time {
+	if t := parseDTTM(int(r)); t != nil {
+		return *t
+	}
+
+	return time.Time{}
+}
+
+// MarshalText writes the time in RFC 3339 format.
+func (r RevisionTime) MarshalText() ([]byte, error) {
+	return []byte(r.Time().Format(time.RFC3339)), nil
+}
+
+// UnmarshalText reads a time written by MarshalText.
+func (r *RevisionTime) UnmarshalText(text []byte) error {
+	t, err := time.Parse(time.RFC3339, string(text))
+	if err != nil {
+		return err
+	}
+
+	*r = NewRevisionTime(t)
+	return nil
+}
+
+// parseRevisionTable reads the names of the authors of revisions from the
+// {\*\revtbl} group, each entry in its own group ended by a semicolon.
+func (r *RtfParser) parseRevisionTable(g *Group) []string {
+	authors := []string{}
+
+	for _, entry := range g.Groups() {
+		text := strings.TrimSpace(r.textFromGroup(entry, r.codePage))
+		authors = append(authors, strings.TrimSuffix(text, ";"))
+	}
+
+	return authors
+}
+
+// RevisionAuthor returns the name of the author of the given index of the
+// revision table, or "" if there is none.
+func (r RtfHeader) RevisionAuthor(index int) string {
+	if index < 0 || index >= len(r.RevisionAuthors) {
+		return ""
+	}
+
+	return r.RevisionAuthors[index]
+}
+
+// AcceptRevisions returns the document as it is with every tracked revision
+// accepted: deleted content is left out and inserted content kept as any
+// other.
+func (r *RtfDocument) AcceptRevisions() RtfDocument {
+	return r.resolveRevisions(true)
+}
+
+// RejectRevisions returns the document as it was before the tracked
+// revisions: inserted content is left out and deleted content restored.
+func (r *RtfDocument) RejectRevisions() RtfDocument {
+	return r.resolveRevisions(false)
+}
+
+func (r *RtfDocument) resolveRevisions(accept bool) RtfDocument {
+	doc := *r
+
+	resolve := func(body []StyleBlock) []StyleBlock {
+		var resolved []StyleBlock
+		for _, block := range body {
+			removed := block.Painter.Inserted
+			if accept {
+				removed = block.Painter.Deleted
+			}
+
+			// cells, rows and sections hold tables and sections together
+			if removed && block.Kind != BlockKindCell && block.Kind != BlockKindRow && block.Kind != BlockKindSection {
+				continue
+			}
+
+			block.Painter.Inserted, block.Painter.InsertedBy, block.Painter.InsertedAt = false, 0, 0
+			block.Painter.Deleted, block.Painter.DeletedBy, block.Painter.DeletedAt = false, 0, 0
+			if block.Paragraph != nil {
+				format := *block.Paragraph
+				format.RevisedBy, format.RevisedAt = 0, 0
+				block.Paragraph = &format
+			}
+			resolved = appendRun(resolved, block)
+		}
+		return resolved
+	}
+
+	doc.Body = resolve(r.Body)
+
+	doc.Footnotes = append([]Footnote{}, r.Footnotes...)
+	for i := range doc.Footnotes {
+		doc.Footnotes[i].Body = resolve(doc.Footnotes[i].Body)
+	}
+
+	doc.HeadersFooters = append([]HeaderFooter{}, r.HeadersFooters...)
+	for i := range doc.HeadersFooters {
+		doc.HeadersFooters[i].Body = resolve(doc.HeadersFooters[i].Body)
+	}
+
+	doc.Annotations = append([]Annotation{}, r.Annotations...)
+	for i := range doc.Annotations {
+		doc.Annotations[i].Body = resolve(doc.Annotations[i].Body)
+	}
+
+	doc.Objects = append([]Object{}, r.Objects...)
+	for i := range doc.Objects {
+		doc.Objects[i].Result = resolve(doc.Objects[i].Result)
+	}
+
+	doc.Shapes = append([]Shape{}, r.Shapes...)
+	for i := range doc.Shapes {
+		doc.Shapes[i].Body = resolve(doc.Shapes[i].Body)
+	}
+
+	return doc
+}
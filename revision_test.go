@@ -0,0 +1,113 @@
+package gortf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const revisedRTF = `{\rtf1\ansi{\*\revtbl{Unknown;}{Ann Smith;}{Bob Jones;}}` +
+	`The {\deleted\revauthdel1\revdttmdel130239366 old}{\revised\revauth2\revdttm130239366 new} price.` +
+	`{\deleted\revauthdel1\par}Next\pard\prauth2\prdate130239366\par}`
+
+func TestParseRevisions(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(revisedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(doc.Header.RevisionAuthors, []string{"Unknown", "Ann Smith", "Bob Jones"}) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "three authors", doc.Header.RevisionAuthors)
+	}
+
+	at := NewRevisionTime(time.Date(2024, time.March, 9, 14, 6, 0, 0, time.UTC))
+	expected := []StyleBlock{
+		{Kind: BlockKindText, Text: "The "},
+		{Kind: BlockKindText, Text: "old", Painter: Painter{Deleted: true, DeletedBy: 1, DeletedAt: at}},
+		{Kind: BlockKindText, Text: "new", Painter: Painter{Inserted: true, InsertedBy: 2, InsertedAt: at}},
+		{Kind: BlockKindText, Text: " price."},
+		{Kind: BlockKindParagraph, Text: "\n", Painter: Painter{Deleted: true, DeletedBy: 1}, Paragraph: &ParagraphFormat{}},
+		{Kind: BlockKindText, Text: "Next"},
+		{Kind: BlockKindParagraph, Text: "\n", Paragraph: &ParagraphFormat{RevisedBy: 2, RevisedAt: at}},
+	}
+	if !reflect.DeepEqual(expected, doc.Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Body)
+	}
+
+	if text, _ := doc.ToText(); text != "The new price.Next\n" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "The new price.Next\n", text)
+	}
+}
+
+func TestResolveRevisions(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(revisedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	final := doc.AcceptRevisions()
+	expected := []StyleBlock{
+		{Kind: BlockKindText, Text: "The new price.Next"},
+		{Kind: BlockKindParagraph, Text: "\n", Paragraph: &ParagraphFormat{}},
+	}
+	if !reflect.DeepEqual(expected, final.Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, final.Body)
+	}
+
+	original := doc.RejectRevisions()
+	expected = []StyleBlock{
+		{Kind: BlockKindText, Text: "The old price."},
+		{Kind: BlockKindParagraph, Text: "\n", Paragraph: &ParagraphFormat{}},
+		{Kind: BlockKindText, Text: "Next"},
+		{Kind: BlockKindParagraph, Text: "\n", Paragraph: &ParagraphFormat{}},
+	}
+	if !reflect.DeepEqual(expected, original.Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, original.Body)
+	}
+
+	if !doc.Body[1].Painter.Deleted {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "the document left untouched", doc.Body)
+	}
+}
+
+func TestWriteRevisions(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(revisedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Body, parsed.Body) || !reflect.DeepEqual(doc.Header.RevisionAuthors, parsed.Header.RevisionAuthors) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc, parsed)
+	}
+}
+
+func TestRevisionsHTML(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(revisedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	markup, _ := doc.ToHTMLWithOptions(HTMLOptions{Revisions: true})
+	expected := `The <del title="Ann Smith" datetime="2024-03-09T14:06">old</del><ins title="Bob Jones" datetime="2024-03-09T14:06">new</ins> price.`
+	if !strings.Contains(markup, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, markup)
+	}
+
+	if plain, _ := doc.ToHTML(); strings.Contains(plain, "old") {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no deleted text", plain)
+	}
+}
@@ -0,0 +1,1207 @@
+package gortf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pictureDataLineLength is the number of hexadecimal digits written per line
+// of picture data.
+const pictureDataLineLength = 128
+
+// DocumentToRTF serializes a document as RTF.
+func DocumentToRTF(r *RtfDocument) (string, error) {
+	var sb strings.Builder
+	if err := WriteRTF(&sb, r); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// WriteRTF serializes a document as RTF to w. Parsing the output yields the
+// same document, except for the parts of the source that are not part of
+// the document model.
+func WriteRTF(w io.Writer, r *RtfDocument) error {
+	e := &rtfEncoder{writer: NewWriter(w)}
+
+	e.groupStart()
+	e.controlWord("rtf", 1)
+	e.writeHeader(r.Header)
+	e.writeInformationGroup(r.InformationGroup)
+	e.writeBody(r)
+	e.groupEnd()
+
+	return e.err
+}
+
+// rtfEncoder writes RTF tokens, keeping the first error that occurs.
+type rtfEncoder struct {
+	writer *Writer
+	err    error
+
+	// character formatting in effect in the body
+	painter Painter
+}
+
+func (e *rtfEncoder) write(tkn Token) {
+	if e.err == nil {
+		e.err = e.writer.WriteToken(tkn)
+	}
+}
+
+func (e *rtfEncoder) groupStart() {
+	e.write(Token{Kind: TokenKindGroupStart})
+}
+
+func (e *rtfEncoder) groupEnd() {
+	e.write(Token{Kind: TokenKindGroupEnd})
+}
+
+func (e *rtfEncoder) word(name string) {
+	e.write(Token{Kind: TokenKindControlWord, Name: name})
+}
+
+func (e *rtfEncoder) controlWord(name string, parameter int) {
+	e.write(Token{Kind: TokenKindControlWord, Name: name, Param: parameter, HasParam: true})
+}
+
+func (e *rtfEncoder) ignorable() {
+	e.write(Token{Kind: TokenKindControlSymbol, Name: "*"})
+}
+
+// text writes text, replacing tabs and line feeds with \tab and \line.
+func (e *rtfEncoder) text(text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			e.word("line")
+		}
+
+		for j, part := range strings.Split(line, "\t") {
+			if j > 0 {
+				e.word("tab")
+			}
+			if part != "" {
+				e.write(Token{Kind: TokenKindText, Text: part})
+			}
+		}
+	}
+}
+
+// destination writes a destination group holding text, such as {\title x}.
+func (e *rtfEncoder) destination(name string, ignorable bool, text string) {
+	e.groupStart()
+	if ignorable {
+		e.ignorable()
+	}
+	e.word(name)
+	e.text(text)
+	e.groupEnd()
+}
+
+func (e *rtfEncoder) writeHeader(header RtfHeader) {
+	switch header.Charset {
+	case CharacterSetMac:
+		e.word("mac")
+	case CharacterSetPc:
+		e.word("pc")
+	case CharacterSetPca:
+		e.word("pca")
+	default:
+		e.word("ansi")
+	}
+
+	if header.CodePage != 0 {
+		e.controlWord("ansicpg", header.CodePage)
+	}
+
+	if cocoa := header.Cocoa; cocoa != nil {
+		e.controlWord("cocoartf", cocoa.Version)
+		e.controlWord("cocoatextscaling", cocoa.TextScaling)
+		e.controlWord("cocoaplatform", cocoa.Platform)
+		if cocoa.Subversion != 0 {
+			e.controlWord("cocoasubrtf", cocoa.Subversion)
+		}
+	}
+
+	if header.FontTable != nil {
+		e.groupStart()
+		e.word("fonttbl")
+		for _, key := range sortedTableRefs(header.FontTable) {
+			e.writeFont(key, header.FontTable[key])
+		}
+		e.groupEnd()
+	}
+
+	if header.ColorTable != nil {
+		e.writeColorTable(header.ColorTable)
+	}
+
+	if header.Stylesheet != nil {
+		styles := []Style{}
+		for _, style := range header.Stylesheet {
+			styles = append(styles, style)
+		}
+		sort.Slice(styles, func(i, j int) bool { return styles[i].Number < styles[j].Number })
+
+		e.groupStart()
+		e.word("stylesheet")
+		for _, style := range styles {
+			e.writeStyle(style)
+		}
+		e.groupEnd()
+	}
+
+	if header.Lists != nil {
+		e.writeListTable(header.Lists)
+	}
+
+	if header.RevisionAuthors != nil {
+		e.groupStart()
+		e.ignorable()
+		e.word("revtbl")
+		for _, author := range header.RevisionAuthors {
+			e.groupStart()
+			e.text(author + ";")
+			e.groupEnd()
+		}
+		e.groupEnd()
+	}
+
+	if header.DefaultTab != 0 {
+		e.controlWord("deftab", header.DefaultTab)
+	}
+}
+
+func (e *rtfEncoder) writeStyle(style Style) {
+	e.groupStart()
+
+	paragraph := style.Paragraph
+	paragraph.Style = style.Number
+	e.writeParagraphFormat(paragraph)
+
+	e.painter = Painter{}
+	e.writePainter(style.Painter)
+	e.painter = Painter{}
+
+	e.text(style.Name + ";")
+	e.groupEnd()
+}
+
+// writeListTable writes the list table and the list override table, in
+// which every entry of the table refers to its list.
+func (e *rtfEncoder) writeListTable(table ListTable) {
+	keys := sortedTableRefs(table)
+
+	e.groupStart()
+	e.ignorable()
+	e.word("listtable")
+	written := map[int]bool{}
+	for _, key := range keys {
+		list := table[key]
+		if written[list.ID] {
+			continue
+		}
+		written[list.ID] = true
+
+		e.groupStart()
+		e.word("list")
+		for _, level := range list.Levels {
+			e.writeListLevel(level)
+		}
+		e.controlWord("listid", list.ID)
+		e.groupEnd()
+	}
+	e.groupEnd()
+
+	e.groupStart()
+	e.ignorable()
+	e.word("listoverridetable")
+	for _, key := range keys {
+		e.groupStart()
+		e.word("listoverride")
+		e.controlWord("listid", table[key].ID)
+		e.controlWord("listoverridecount", 0)
+		e.controlWord("ls", int(key))
+		e.groupEnd()
+	}
+	e.groupEnd()
+}
+
+func (e *rtfEncoder) writeListLevel(level ListLevel) {
+	e.groupStart()
+	e.word("listlevel")
+	e.controlWord("levelnfc", int(level.Format))
+	e.controlWord("levelnfcn", int(level.Format))
+	e.controlWord("levelstartat", level.Start)
+
+	// the text starts with its length, and the numbers of the levels are
+	// written as the characters 0 to 8
+	characters := []rune(level.Text)
+	text := []Token{}
+	length := 0
+	for i := 0; i < len(characters); i++ {
+		c := characters[i]
+		if c == '%' && i+1 < len(characters) && characters[i+1] >= '1' && characters[i+1] <= '9' {
+			text = append(text, Token{Kind: TokenKindControlSymbol, Name: "'", Param: int(characters[i+1] - '1'), HasParam: true})
+			i++
+		} else {
+			text = append(text, Token{Kind: TokenKindText, Text: string(c)})
+		}
+		length++
+	}
+
+	e.groupStart()
+	e.word("leveltext")
+	e.write(Token{Kind: TokenKindControlSymbol, Name: "'", Param: length, HasParam: true})
+	for _, tkn := range text {
+		e.write(tkn)
+	}
+	e.text(";")
+	e.groupEnd()
+
+	if level.FirstLineIndent != 0 {
+		e.controlWord("fi", level.FirstLineIndent)
+	}
+	if level.LeftIndent != 0 {
+		e.controlWord("li", level.LeftIndent)
+	}
+	e.groupEnd()
+}
+
+func (e *rtfEncoder) writeFont(key TableRef, font Font) {
+	e.groupStart()
+	e.controlWord("f", int(key))
+
+	if name, ok := fontThemeWords[font.Theme]; ok {
+		e.word(name)
+	}
+
+	e.word(fontFamilyWords[font.FontFamily])
+
+	if font.Charset != FontCharsetDefault {
+		e.controlWord("fcharset", int(font.Charset))
+	}
+	if font.CodePage != 0 && font.CodePage != font.Charset.CodePage() {
+		e.controlWord("cpg", font.CodePage)
+	}
+	if font.Pitch != FontPitchDefault {
+		e.controlWord("fprq", int(font.Pitch))
+	}
+	if font.Bias != 0 {
+		e.controlWord("fbias", font.Bias)
+	}
+
+	if len(font.Panose) > 0 {
+		e.destination("panose", true, hex.EncodeToString(font.Panose))
+	}
+	if font.NonTaggedName != "" {
+		e.destination("fname", true, font.NonTaggedName+";")
+	}
+	if font.AlternateName != "" {
+		e.destination("falt", true, font.AlternateName)
+	}
+
+	e.text(font.Name + ";")
+	e.groupEnd()
+}
+
+var fontFamilyWords = map[FontFamily]string{
+	FontFamilyNil: "fnil", FontFamilyRoman: "froman", FontFamilySwiss: "fswiss",
+	FontFamilyModern: "fmodern", FontFamilyScript: "fscript", FontFamilyDecor: "fdecor",
+	FontFamilyTech: "ftech", FontFamilyBidi: "fbidi",
+}
+
+var fontThemeWords = map[FontTheme]string{
+	FontThemeMajorLatin: "flomajor", FontThemeMajorHighAnsi: "fhimajor",
+	FontThemeMajorEastAsian: "fdbmajor", FontThemeMajorBidi: "fbimajor",
+	FontThemeMinorLatin: "flominor", FontThemeMinorHighAnsi: "fhiminor",
+	FontThemeMinorEastAsian: "fdbminor", FontThemeMinorBidi: "fbiminor",
+}
+
+var colorThemeWords = map[ColorTheme]string{
+	ColorThemeMainDarkOne: "cmaindarkone", ColorThemeMainLightOne: "cmainlightone",
+	ColorThemeMainDarkTwo: "cmaindarktwo", ColorThemeMainLightTwo: "cmainlighttwo",
+	ColorThemeAccentOne: "caccentone", ColorThemeAccentTwo: "caccenttwo",
+	ColorThemeAccentThree: "caccentthree", ColorThemeAccentFour: "caccentfour",
+	ColorThemeAccentFive: "caccentfive", ColorThemeAccentSix: "caccentsix",
+	ColorThemeHyperlink: "chyperlink", ColorThemeFollowedHyperlink: "cfollowedhyperlink",
+	ColorThemeBackgroundOne: "cbackgroundone", ColorThemeTextOne: "ctextone",
+	ColorThemeBackgroundTwo: "cbackgroundtwo", ColorThemeTextTwo: "ctexttwo",
+}
+
+var colorSpaceWords = map[ColorSpace]string{
+	ColorSpaceGray: "csgray", ColorSpaceGenericRGB: "csgenericrgb", ColorSpaceSRGB: "cssrgb",
+}
+
+// writeColorTable writes the color table and, if any color has color space
+// data, the expanded color table. Entries missing from the table are written
+// as auto colors.
+func (e *rtfEncoder) writeColorTable(table ColorTable) {
+	keys := sortedTableRefs(table)
+	last := keys[len(keys)-1]
+	expanded := false
+
+	e.groupStart()
+	e.word("colortbl")
+	for i := 0; i <= int(last); i++ {
+		color, ok := table[TableRef(i)]
+		if ok && !color.Auto {
+			if name, ok := colorThemeWords[color.Theme]; ok {
+				e.word(name)
+				e.controlWord("ctint", color.Tint)
+				e.controlWord("cshade", color.Shade)
+			}
+
+			e.controlWord("red", color.R)
+			e.controlWord("green", color.G)
+			e.controlWord("blue", color.B)
+		}
+		e.text(";")
+
+		expanded = expanded || color.Expanded != nil
+	}
+	e.groupEnd()
+
+	if !expanded {
+		return
+	}
+
+	e.groupStart()
+	e.ignorable()
+	e.word("expandedcolortbl")
+	for i := 0; i <= int(last); i++ {
+		if color := table[TableRef(i)]; color.Expanded != nil {
+			if name, ok := colorSpaceWords[color.Expanded.Space]; ok {
+				e.word(name)
+			}
+			for _, component := range color.Expanded.Components {
+				e.controlWord("c", int(math.Round(component*100000)))
+			}
+			if color.Expanded.Name != "" {
+				e.word("cname")
+				e.text(color.Expanded.Name)
+			}
+		}
+		e.text(";")
+	}
+	e.groupEnd()
+}
+
+func (e *rtfEncoder) writeInformationGroup(info RtfInformationGroup) {
+	// an information group without any entry is left out
+	entries := info
+	entries.UserProperties = nil
+	if reflect.DeepEqual(entries, RtfInformationGroup{}) {
+		e.writeUserProperties(info.UserProperties)
+		return
+	}
+
+	e.groupStart()
+	e.word("info")
+
+	for _, entry := range []struct {
+		name string
+		text string
+	}{
+		{"title", info.Title},
+		{"subject", info.Subject},
+		{"author", info.Author},
+		{"manager", info.Manager},
+		{"company", info.Company},
+		{"operator", info.Operator},
+		{"category", info.Category},
+		{"keywords", info.Keywords},
+		{"comment", info.Comment},
+		{"doccom", info.DocumentComment},
+		{"hlinkbase", info.BaseAddress},
+	} {
+		if entry.text != "" {
+			e.destination(entry.name, false, entry.text)
+		}
+	}
+
+	for _, entry := range []struct {
+		name string
+		time *time.Time
+	}{
+		{"creatim", info.CreationTime},
+		{"revtim", info.RevisionTime},
+		{"printim", info.LastPrintTime},
+		{"buptim", info.BackupTime},
+	} {
+		if entry.time == nil {
+			continue
+		}
+
+		t := entry.time.UTC()
+		e.groupStart()
+		e.word(entry.name)
+		e.controlWord("yr", t.Year())
+		e.controlWord("mo", int(t.Month()))
+		e.controlWord("dy", t.Day())
+		e.controlWord("hr", t.Hour())
+		e.controlWord("min", t.Minute())
+		e.controlWord("sec", t.Second())
+		e.groupEnd()
+	}
+
+	for _, entry := range []struct {
+		name  string
+		value int
+	}{
+		{"version", info.Version},
+		{"edmins", info.EditingMinutes},
+		{"nofpages", info.NumberOfPages},
+		{"nofwords", info.NumberOfWords},
+		{"nofchars", info.NumberOfCharacters},
+		{"nofcharsws", info.NumberOfCharactersWithSpaces},
+		{"id", info.InternalID},
+	} {
+		if entry.value != 0 {
+			e.controlWord(entry.name, entry.value)
+		}
+	}
+
+	e.groupEnd()
+
+	e.writeUserProperties(info.UserProperties)
+}
+
+func (e *rtfEncoder) writeUserProperties(properties []UserProperty) {
+	if len(properties) == 0 {
+		return
+	}
+
+	e.groupStart()
+	e.ignorable()
+	e.word("userprops")
+	for _, property := range properties {
+		e.destination("propname", false, property.Name)
+		e.controlWord("proptype", int(property.Type))
+		e.destination("staticval", false, userPropertyText(property.Value))
+		if property.Link != "" {
+			e.destination("linkval", false, property.Link)
+		}
+	}
+	e.groupEnd()
+}
+
+// userPropertyText is the reverse of userPropertyValue.
+func userPropertyText(value interface{}) string {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
+
+func (e *rtfEncoder) writeBody(r *RtfDocument) {
+	for i, section := range r.Sections() {
+		if i > 0 || section.Format != (SectionFormat{}) {
+			e.writeSectionFormat(section.Format)
+		}
+
+		for _, headerFooter := range r.HeadersFooters {
+			if headerFooter.Section == i {
+				e.writeHeaderFooter(r, headerFooter)
+			}
+		}
+
+		e.writeElements(r, section.Elements)
+
+		if section.Mark != nil {
+			e.writePainter(*section.Mark)
+			e.word("sect")
+		}
+	}
+}
+
+func (e *rtfEncoder) writeElements(r *RtfDocument, elements []BodyElement) {
+	for _, element := range elements {
+		switch el := element.(type) {
+		case *Paragraph:
+			e.writeParagraph(r, *el, "par")
+		case *Table:
+			for _, row := range el.Rows {
+				e.writeRow(r, row)
+			}
+		}
+	}
+}
+
+// writeSubdocument writes the content of a footnote or header within its
+// destination group, restoring the formatting in effect afterwards.
+func (e *rtfEncoder) writeSubdocument(r *RtfDocument, body []StyleBlock) {
+	painter := e.painter
+	for _, section := range sectionsOf(body) {
+		e.writeElements(r, section.Elements)
+	}
+	e.painter = painter
+}
+
+var headerFooterWords = map[HeaderFooterKind]string{
+	HeaderFooterKindHeader: "header", HeaderFooterKindHeaderLeft: "headerl",
+	HeaderFooterKindHeaderRight: "headerr", HeaderFooterKindHeaderFirst: "headerf",
+	HeaderFooterKindFooter: "footer", HeaderFooterKindFooterLeft: "footerl",
+	HeaderFooterKindFooterRight: "footerr", HeaderFooterKindFooterFirst: "footerf",
+}
+
+func (e *rtfEncoder) writeHeaderFooter(r *RtfDocument, headerFooter HeaderFooter) {
+	name, ok := headerFooterWords[headerFooter.Kind]
+	if !ok {
+		return
+	}
+
+	e.groupStart()
+	e.word(name)
+	e.writeSubdocument(r, headerFooter.Body)
+	e.groupEnd()
+}
+
+// writeFootnote writes the reference to a note followed by the note, whose
+// number is added at its start if its body lacks one.
+func (e *rtfEncoder) writeFootnote(r *RtfDocument, footnote Footnote) {
+	e.writeNoteNumber()
+
+	body := footnote.Body
+	numbered := false
+	for _, block := range body {
+		numbered = numbered || block.Kind == BlockKindNoteNumber
+	}
+	if !numbered && len(body) > 0 {
+		body = append([]StyleBlock{{Painter: body[0].Painter, Kind: BlockKindNoteNumber}}, body...)
+	}
+
+	e.groupStart()
+	e.word("footnote")
+	if footnote.Endnote {
+		e.word("ftnalt")
+	}
+	e.writeSubdocument(r, body)
+	e.groupEnd()
+}
+
+// writeNoteNumber writes the automatic number of a note as a superscript.
+func (e *rtfEncoder) writeNoteNumber() {
+	e.groupStart()
+	e.word("super")
+	e.word("chftn")
+	e.groupEnd()
+}
+
+// writeAnnotation writes a comment, which refers to its anchors by the
+// number following its index.
+func (e *rtfEncoder) writeAnnotation(r *RtfDocument, index int) {
+	annotation := r.Annotations[index]
+
+	e.destination("atnid", true, annotation.Initials)
+	e.destination("atnauthor", true, annotation.Author)
+	e.word("chatn")
+
+	e.groupStart()
+	e.ignorable()
+	e.word("annotation")
+	e.destination("atnref", true, strconv.Itoa(index+1))
+	if annotation.Date != nil {
+		e.destination("atndate", true, strconv.Itoa(dttm(*annotation.Date)))
+	}
+	e.writeSubdocument(r, annotation.Body)
+	e.groupEnd()
+}
+
+func (e *rtfEncoder) writeSectionFormat(section SectionFormat) {
+	e.word("sectd")
+
+	switch section.Break {
+	case SectionBreakNone:
+		e.word("sbknone")
+	case SectionBreakColumn:
+		e.word("sbkcol")
+	case SectionBreakEven:
+		e.word("sbkeven")
+	case SectionBreakOdd:
+		e.word("sbkodd")
+	}
+
+	if section.Columns != 0 {
+		e.controlWord("cols", section.Columns)
+	}
+	if section.Landscape {
+		e.word("lndscpsxn")
+	}
+
+	for _, property := range []struct {
+		name  string
+		value int
+	}{
+		{"pgwsxn", section.PageWidth},
+		{"pghsxn", section.PageHeight},
+		{"marglsxn", section.MarginLeft},
+		{"margrsxn", section.MarginRight},
+		{"margtsxn", section.MarginTop},
+		{"margbsxn", section.MarginBottom},
+	} {
+		if property.value != 0 {
+			e.controlWord(property.name, property.value)
+		}
+	}
+}
+
+func (e *rtfEncoder) writeRow(r *RtfDocument, row TableRow) {
+	e.word("trowd")
+
+	format := row.Format
+	switch format.Alignment {
+	case AlignmentCenter:
+		e.word("trqc")
+	case AlignmentRight:
+		e.word("trqr")
+	}
+	if format.Gap != 0 {
+		e.controlWord("trgaph", format.Gap)
+	}
+	if format.LeftIndent != 0 {
+		e.controlWord("trleft", format.LeftIndent)
+	}
+	if format.Header {
+		e.word("trhdr")
+	}
+
+	for _, cell := range format.Cells {
+		switch cell.HorizontalMerge {
+		case CellMergeFirst:
+			e.word("clmgf")
+		case CellMergePrevious:
+			e.word("clmrg")
+		}
+		switch cell.VerticalMerge {
+		case CellMergeFirst:
+			e.word("clvmgf")
+		case CellMergePrevious:
+			e.word("clvmrg")
+		}
+		if cell.Background != 0 {
+			e.controlWord("clcbpat", int(cell.Background))
+		}
+		e.controlWord("cellx", cell.Right)
+	}
+
+	for _, cell := range row.Cells {
+		for i, paragraph := range cell.Paragraphs {
+			mark := "par"
+			if i == len(cell.Paragraphs)-1 {
+				mark = "cell"
+			}
+			e.writeParagraph(r, paragraph, mark)
+		}
+	}
+
+	if row.Mark != nil {
+		e.writePainter(*row.Mark)
+		e.word("row")
+	}
+}
+
+// writeParagraph writes a paragraph, ended by the given mark if it has one.
+func (e *rtfEncoder) writeParagraph(r *RtfDocument, paragraph Paragraph, mark string) {
+	e.writeParagraphFormat(paragraph.Format)
+
+	// formatting in effect outside of the field in progress
+	var outside *Painter
+
+	for _, run := range paragraph.Runs {
+		if outside != nil && (run.Painter.Link != e.painter.Link || run.Painter.Field != e.painter.Field) {
+			e.groupEnd()
+			e.groupEnd()
+			e.painter = *outside
+			outside = nil
+		}
+
+		if outside == nil && (run.Painter.Link != "" || run.Painter.Field != "") {
+			painter := e.painter
+			outside = &painter
+			e.writeFieldStart(fieldInstruction(run.Painter))
+			e.painter.Link, e.painter.Field = run.Painter.Link, run.Painter.Field
+		}
+
+		// the number of a note is written in a group of its own
+		if run.Kind != BlockKindNoteNumber {
+			e.writePainter(run.Painter)
+		}
+
+		switch run.Kind {
+		case BlockKindText:
+			e.text(run.Text)
+		case BlockKindLine:
+			e.word("line")
+		case BlockKindPage:
+			e.word("page")
+		case BlockKindPicture:
+			if run.PictureIndex >= 0 && run.PictureIndex < len(r.Pictures) {
+				e.writePicture(r.Pictures[run.PictureIndex])
+			}
+		case BlockKindFootnote:
+			if run.FootnoteIndex >= 0 && run.FootnoteIndex < len(r.Footnotes) {
+				e.writeFootnote(r, r.Footnotes[run.FootnoteIndex])
+			}
+		case BlockKindNoteNumber:
+			e.writeNoteNumber()
+		case BlockKindAnnotation:
+			if run.AnnotationIndex >= 0 && run.AnnotationIndex < len(r.Annotations) {
+				e.writeAnnotation(r, run.AnnotationIndex)
+			}
+		case BlockKindAnnotationStart:
+			e.destination("atrfstart", true, strconv.Itoa(run.AnnotationIndex+1))
+		case BlockKindAnnotationEnd:
+			e.destination("atrfend", true, strconv.Itoa(run.AnnotationIndex+1))
+		case BlockKindBookmarkStart:
+			e.destination("bkmkstart", true, run.Bookmark)
+		case BlockKindBookmarkEnd:
+			e.destination("bkmkend", true, run.Bookmark)
+		case BlockKindObject:
+			if run.ObjectIndex >= 0 && run.ObjectIndex < len(r.Objects) {
+				e.writeObject(r, r.Objects[run.ObjectIndex])
+			}
+		case BlockKindShape:
+			if run.ShapeIndex >= 0 && run.ShapeIndex < len(r.Shapes) {
+				e.writeShape(r, r.Shapes[run.ShapeIndex])
+			}
+		}
+	}
+
+	if outside != nil {
+		e.groupEnd()
+		e.groupEnd()
+		e.painter = *outside
+	}
+
+	if paragraph.Mark != nil {
+		e.writePainter(*paragraph.Mark)
+		e.word(mark)
+	}
+}
+
+func (e *rtfEncoder) writeParagraphFormat(paragraph ParagraphFormat) {
+	e.word("pard")
+
+	if paragraph.Style != 0 {
+		e.controlWord("s", paragraph.Style)
+	}
+
+	switch paragraph.Alignment {
+	case AlignmentCenter:
+		e.word("qc")
+	case AlignmentRight:
+		e.word("qr")
+	case AlignmentJustify:
+		e.word("qj")
+	case AlignmentDistribute:
+		e.word("qd")
+	}
+
+	for _, property := range []struct {
+		name  string
+		value int
+	}{
+		{"li", paragraph.LeftIndent},
+		{"ri", paragraph.RightIndent},
+		{"fi", paragraph.FirstLineIndent},
+		{"sb", paragraph.SpaceBefore},
+		{"sa", paragraph.SpaceAfter},
+	} {
+		if property.value != 0 {
+			e.controlWord(property.name, property.value)
+		}
+	}
+
+	if paragraph.LineSpacing != 0 {
+		e.controlWord("sl", paragraph.LineSpacing)
+		if paragraph.LineSpacingMultiple {
+			e.controlWord("slmult", 1)
+		} else {
+			e.controlWord("slmult", 0)
+		}
+	}
+
+	if paragraph.KeepTogether {
+		e.word("keep")
+	}
+	if paragraph.KeepWithNext {
+		e.word("keepn")
+	}
+	if paragraph.PageBreakBefore {
+		e.word("pagebb")
+	}
+	if paragraph.InTable {
+		e.word("intbl")
+	}
+	if paragraph.List != 0 {
+		e.controlWord("ls", int(paragraph.List))
+	}
+	if paragraph.ListLevel != 0 {
+		e.controlWord("ilvl", paragraph.ListLevel)
+	}
+	if paragraph.RevisedBy != 0 || paragraph.RevisedAt != 0 {
+		e.controlWord("prauth", paragraph.RevisedBy)
+		e.controlWord("prdate", int(paragraph.RevisedAt))
+	}
+
+	for _, tab := range paragraph.Tabs {
+		if word, ok := tabAlignmentWords[tab.Alignment]; ok {
+			e.word(word)
+		}
+		if word, ok := tabLeaderWords[tab.Leader]; ok {
+			e.word(word)
+		}
+		if tab.Alignment == TabAlignmentBar {
+			e.controlWord("tb", tab.Position)
+		} else {
+			e.controlWord("tx", tab.Position)
+		}
+	}
+}
+
+// writeFieldStart opens a field with the given instruction and its result
+// group, both of which are left to be closed.
+func (e *rtfEncoder) writeFieldStart(instruction string) {
+	e.groupStart()
+	e.word("field")
+	e.groupStart()
+	e.ignorable()
+	e.word("fldinst")
+	e.text(instruction)
+	e.groupEnd()
+	e.groupStart()
+	e.word("fldrslt")
+}
+
+// fieldInstruction returns the instruction of the field a painter's text is
+// the result of, if any.
+func fieldInstruction(painter Painter) string {
+	if painter.Link != "" {
+		return fmt.Sprintf(`HYPERLINK "%s"`, escapeFieldArgument(painter.Link))
+	}
+
+	return painter.Field
+}
+
+// escapeFieldArgument is the reverse of the unquoting done by
+// splitFieldInstruction.
+func escapeFieldArgument(argument string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(argument)
+}
+
+// writePainter writes the control words that change the character
+// formatting in effect to that of painter. Properties that can only be
+// cleared by \plain are handled by resetting everything.
+func (e *rtfEncoder) writePainter(painter Painter) {
+	// fields are written by writeParagraph
+	current := e.painter
+	current.Link, current.Field = painter.Link, painter.Field
+	if current == painter {
+		return
+	}
+
+	if current.FontSize != 0 && painter.FontSize == 0 {
+		e.word("plain")
+		current = Painter{Link: painter.Link, Field: painter.Field}
+	}
+
+	if painter.FontRef != current.FontRef || current == (Painter{Link: painter.Link, Field: painter.Field}) {
+		e.controlWord("f", int(painter.FontRef))
+	}
+	if painter.FontSize != current.FontSize {
+		e.controlWord("fs", painter.FontSize)
+	}
+
+	for _, toggle := range []struct {
+		name    string
+		off     string
+		value   bool
+		current bool
+	}{
+		{"b", "b0", painter.Bold, current.Bold},
+		{"i", "i0", painter.Italic, current.Italic},
+		{"ul", "ulnone", painter.Underline, current.Underline},
+		{"strike", "strike0", painter.Strikethrough, current.Strikethrough},
+		{"scaps", "scaps0", painter.SmallCaps, current.SmallCaps},
+		{"v", "v0", painter.Hidden, current.Hidden},
+		{"revised", "revised0", painter.Inserted, current.Inserted},
+		{"deleted", "deleted0", painter.Deleted, current.Deleted},
+	} {
+		switch {
+		case toggle.value && !toggle.current:
+			e.word(toggle.name)
+		case !toggle.value && toggle.current:
+			e.writeToggleOff(toggle.off)
+		}
+	}
+
+	if painter.Superscript != current.Superscript || painter.Subscript != current.Subscript {
+		switch {
+		case painter.Superscript:
+			e.word("super")
+		case painter.Subscript:
+			e.word("sub")
+		default:
+			e.word("nosupersub")
+		}
+	}
+
+	if painter.ForegroundColor != current.ForegroundColor {
+		e.controlWord("cf", int(painter.ForegroundColor))
+	}
+	if painter.BackgroundColor != current.BackgroundColor {
+		e.controlWord("cb", int(painter.BackgroundColor))
+	}
+	if painter.Highlight != current.Highlight {
+		e.controlWord("highlight", int(painter.Highlight))
+	}
+
+	for _, revision := range []struct {
+		name    string
+		value   int
+		current int
+	}{
+		{"revauth", painter.InsertedBy, current.InsertedBy},
+		{"revdttm", int(painter.InsertedAt), int(current.InsertedAt)},
+		{"revauthdel", painter.DeletedBy, current.DeletedBy},
+		{"revdttmdel", int(painter.DeletedAt), int(current.DeletedAt)},
+	} {
+		if revision.value != revision.current {
+			e.controlWord(revision.name, revision.value)
+		}
+	}
+
+	e.painter = painter
+}
+
+// writeToggleOff writes a control word that turns a property off, either
+// one with a zero parameter such as \b0 or one of its own such as \ulnone.
+func (e *rtfEncoder) writeToggleOff(name string) {
+	if strings.HasSuffix(name, "0") {
+		e.controlWord(strings.TrimSuffix(name, "0"), 0)
+		return
+	}
+
+	e.word(name)
+}
+
+func (e *rtfEncoder) writePicture(picture Picture) {
+	e.groupStart()
+	e.word("pict")
+
+	if name, ok := pictureFormatWords[picture.Format]; ok {
+		e.word(name)
+	}
+
+	for _, property := range []struct {
+		name  string
+		value int
+	}{
+		{"picw", picture.Width},
+		{"pich", picture.Height},
+		{"picwgoal", picture.GoalWidth},
+		{"pichgoal", picture.GoalHeight},
+	} {
+		if property.value != 0 {
+			e.controlWord(property.name, property.value)
+		}
+	}
+
+	if picture.ScaleX != 100 {
+		e.controlWord("picscalex", picture.ScaleX)
+	}
+	if picture.ScaleY != 100 {
+		e.controlWord("picscaley", picture.ScaleY)
+	}
+
+	e.hexData(picture.Data)
+
+	e.groupEnd()
+}
+
+// hexData writes binary data as lines of hexadecimal digits.
+func (e *rtfEncoder) hexData(data []byte) {
+	digits := hex.EncodeToString(data)
+	for len(digits) > 0 {
+		line := digits[:min(len(digits), pictureDataLineLength)]
+		digits = digits[len(line):]
+		e.write(Token{Kind: TokenKindText, Text: "\n" + line})
+	}
+}
+
+func (e *rtfEncoder) writeObject(r *RtfDocument, object Object) {
+	e.groupStart()
+	e.word("object")
+
+	if name, ok := objectTypeWords[object.Type]; ok {
+		e.word(name)
+	}
+	if object.Width != 0 {
+		e.controlWord("objw", object.Width)
+	}
+	if object.Height != 0 {
+		e.controlWord("objh", object.Height)
+	}
+	if object.ScaleX != 100 {
+		e.controlWord("objscalex", object.ScaleX)
+	}
+	if object.ScaleY != 100 {
+		e.controlWord("objscaley", object.ScaleY)
+	}
+
+	if object.Class != "" {
+		e.destination("objclass", true, object.Class)
+	}
+	if object.Name != "" {
+		e.destination("objname", true, object.Name)
+	}
+
+	if len(object.Data) > 0 {
+		e.groupStart()
+		e.ignorable()
+		e.word("objdata")
+		e.hexData(object.Data)
+		e.groupEnd()
+	}
+
+	if len(object.Result) > 0 {
+		e.groupStart()
+		e.word("result")
+		e.writeSubdocument(r, object.Result)
+		e.groupEnd()
+	}
+
+	e.groupEnd()
+}
+
+// writeShape writes a shape, whose anchors to characters and lines are only
+// given by the posrelh and posrelv properties.
+func (e *rtfEncoder) writeShape(r *RtfDocument, shape Shape) {
+	e.groupStart()
+	e.word("shp")
+	e.groupStart()
+	e.ignorable()
+	e.word("shpinst")
+
+	e.controlWord("shpleft", shape.Left)
+	e.controlWord("shptop", shape.Top)
+	e.controlWord("shpright", shape.Right)
+	e.controlWord("shpbottom", shape.Bottom)
+
+	properties := map[string]string{}
+	for name, value := range shape.Properties {
+		properties[name] = value
+	}
+
+	for _, axis := range []struct {
+		prefix, property string
+		anchor           ShapeAnchor
+	}{
+		{"shpbx", "posrelh", shape.HorizontalAnchor},
+		{"shpby", "posrelv", shape.VerticalAnchor},
+	} {
+		word := "ignore"
+		for name, anchor := range shapeAnchorWords {
+			if anchor == axis.anchor {
+				word = name
+			}
+		}
+		e.word(axis.prefix + word)
+
+		if _, ok := properties[axis.property]; !ok && word != "ignore" {
+			continue
+		}
+		for value, anchor := range shapeAnchorProperties[axis.property] {
+			if anchor == axis.anchor {
+				properties[axis.property] = strconv.Itoa(value)
+			}
+		}
+	}
+
+	for value, wrap := range shapeWrapValues {
+		if wrap == shape.Wrap {
+			e.controlWord("shpwr", value)
+		}
+	}
+	behindText := 0
+	if shape.BehindText {
+		behindText = 1
+	}
+	e.controlWord("shpfblwtxt", behindText)
+	e.controlWord("shpz", shape.ZOrder)
+	if shape.ID != 0 {
+		e.controlWord("shplid", shape.ID)
+	}
+
+	names := []string{}
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e.groupStart()
+		e.word("sp")
+		e.destination("sn", false, name)
+		e.destination("sv", false, properties[name])
+		e.groupEnd()
+	}
+
+	if shape.PictureIndex >= 0 && shape.PictureIndex < len(r.Pictures) {
+		e.groupStart()
+		e.word("sp")
+		e.destination("sn", false, "pib")
+		e.groupStart()
+		e.word("sv")
+		e.writePicture(r.Pictures[shape.PictureIndex])
+		e.groupEnd()
+		e.groupEnd()
+	}
+
+	if shape.Body != nil {
+		e.groupStart()
+		e.word("shptxt")
+		e.writeSubdocument(r, shape.Body)
+		e.groupEnd()
+	}
+
+	e.groupEnd()
+	e.groupEnd()
+}
+
+var pictureFormatWords = map[PictureFormat]string{
+	PictureFormatEMF: "emfblip", PictureFormatPNG: "pngblip", PictureFormatJPEG: "jpegblip",
+	PictureFormatMacPICT: "macpict", PictureFormatWMF: "wmetafile", PictureFormatDIB: "dibitmap",
+	PictureFormatBitmap: "wbitmap",
+}
+
+func sortedTableRefs[V any](table map[TableRef]V) []TableRef {
+	keys := []TableRef{}
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
@@ -0,0 +1,44 @@
+package gortf
+
+import (
+	"testing"
+)
+
+func TestDocumentToRTF(t *testing.T) {
+	doc := RtfDocument{
+		Header: RtfHeader{
+			Charset:    CharacterSetAnsi,
+			FontTable:  FontTable{0: {Name: "Helvetica", FontFamily: FontFamilySwiss}},
+			ColorTable: ColorTable{0: {Auto: true}, 1: {R: 255}},
+		},
+		InformationGroup: RtfInformationGroup{Title: "Notes"},
+	}
+	doc.SetSections([]Section{{
+		Elements: []BodyElement{
+			&Paragraph{
+				Format: ParagraphFormat{Alignment: AlignmentCenter},
+				Runs: []StyleBlock{
+					{Painter: Painter{FontSize: 24, Bold: true}, Text: "Hello"},
+					{Painter: Painter{FontSize: 24}, Text: ", {world}"},
+				},
+				Mark: &Painter{FontSize: 24},
+			},
+			&Paragraph{
+				Runs: []StyleBlock{{Painter: Painter{ForegroundColor: 1}, Text: "Red"}},
+				Mark: &Painter{},
+			},
+		},
+	}})
+
+	expected := `{\rtf1\ansi{\fonttbl{\f0\fswiss\fcharset0Helvetica;}}{\colortbl;\red255\green0\blue0;}{\info{\title Notes}}`
+	expected += `\pard\qc\f0\fs24\b Hello\b0, \{world\}\par\pard\plain\f0\cf1Red\cf0\par}`
+
+	actual, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual != expected {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+}
@@ -0,0 +1,130 @@
+package gortf
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// RTFDDocumentName is the name of the RTF document within an RTFD bundle.
+const RTFDDocumentName = "TXT.rtf"
+
+// attachmentCharacter is the character following a {\NeXTGraphic} group,
+// standing for the attachment in the text.
+const attachmentCharacter = 0xac
+
+// CocoaMetadata is what the Cocoa text system of macOS and iOS records about
+// the documents it writes, such as those of TextEdit.
+type CocoaMetadata struct {
+	// Version and Subversion are the version of the Cocoa RTF writer
+	// (\cocoartf, \cocoasubrtf).
+	Version    int `json:"version"`
+	Subversion int `json:"subversion,omitempty"`
+
+	// TextScaling and Platform tell how the text was scaled and where it was
+	// written: 0 for macOS and 1 for iOS (\cocoatextscaling,
+	// \cocoaplatform).
+	TextScaling int `json:"textScaling"`
+	Platform    int `json:"platform"`
+}
+
+func parseCocoaMetadata(root *Group) *CocoaMetadata {
+	version, ok := root.ControlWord("cocoartf")
+	if !ok {
+		return nil
+	}
+
+	cocoa := &CocoaMetadata{Version: version.Param}
+	for name, value := range map[string]*int{
+		"cocoasubrtf":      &cocoa.Subversion,
+		"cocoatextscaling": &cocoa.TextScaling,
+		"cocoaplatform":    &cocoa.Platform,
+	} {
+		if tkn, ok := root.ControlWord(name); ok {
+			*value = tkn.Param
+		}
+	}
+
+	return cocoa
+}
+
+// ParseRTFD reads an RTFD bundle, the directory TextEdit saves documents
+// with attachments as: the RTF document TXT.rtf, and the files of the
+// attachments it refers to with {\NeXTGraphic} groups, which become
+// pictures of the document.
+func (r *RtfParser) ParseRTFD(dir string) (RtfDocument, error) {
+	return r.ParseRTFDFS(os.DirFS(dir))
+}
+
+// ParseRTFDFS reads an RTFD bundle from a file system whose root is the
+// bundle. See ParseRTFD.
+func (r *RtfParser) ParseRTFDFS(bundle fs.FS) (RtfDocument, error) {
+	content, err := fs.ReadFile(bundle, RTFDDocumentName)
+	if err != nil {
+		return RtfDocument{}, err
+	}
+
+	r.attachments = bundle
+	defer func() { r.attachments = nil }()
+
+	return r.ParseContent(string(content))
+}
+
+// addAttachment adds the picture of a {\NeXTGraphic name \width \height}
+// group, read from the attachment file of the bundle being parsed. Files of
+// formats that cannot be displayed are kept as pictures of unknown format.
+func (r *RtfParser) addAttachment(doc *RtfDocument, g *Group) {
+	name := strings.TrimSpace(r.textFromGroup(g, r.codePage))
+	if name == "" {
+		return
+	}
+
+	if r.attachments == nil {
+		r.warn(g.Offset, "attachment "+strconv.Quote(name)+" is outside of an RTFD bundle")
+		return
+	}
+
+	data, err := fs.ReadFile(r.attachments, name)
+	if err != nil {
+		r.warn(g.Offset, "attachment "+strconv.Quote(name)+" cannot be read")
+		return
+	}
+
+	width, height := 0, 0
+	if tkn, ok := g.ControlWord("width"); ok {
+		width = tkn.Param
+	}
+	if tkn, ok := g.ControlWord("height"); ok {
+		height = tkn.Param
+	}
+
+	picture, ok := pictureFromFile(data, strings.TrimPrefix(path.Ext(name), "."), width, height)
+	if !ok {
+		picture = Picture{GoalWidth: width, GoalHeight: height, ScaleX: 100, ScaleY: 100, Data: data}
+	}
+	picture.Name = name
+
+	r.addPicture(doc, picture)
+}
+
+// skipAttachmentCharacter removes the character standing for an attachment
+// from the token following it, reporting whether anything is left of the
+// token.
+func skipAttachmentCharacter(tkn Token) (Token, bool) {
+	switch tkn.Kind {
+	case TokenKindControlSymbol:
+		return tkn, tkn.Name != "'" || tkn.Param != attachmentCharacter
+	case TokenKindText:
+		for _, character := range []string{string(rune(attachmentCharacter)), string([]byte{attachmentCharacter})} {
+			if strings.HasPrefix(tkn.Text, character) {
+				tkn.Text = tkn.Text[len(character):]
+				break
+			}
+		}
+		return tkn, tkn.Text != ""
+	default:
+		return tkn, true
+	}
+}
@@ -0,0 +1,141 @@
+package gortf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// rtfdDocument is the TXT.rtf of a bundle saved by TextEdit, whose
+// attachments are followed by the character standing for them, either
+// escaped or not.
+const rtfdDocument = "{\\rtf1\\ansi\\ansicpg1252\\cocoartf2759\\cocoasubrtf600\n" +
+	"\\cocoatextscaling0\\cocoaplatform0{\\fonttbl\\f0\\fswiss\\fcharset0 Helvetica;}\n" +
+	"\\pard\\f0 Chart: {{\\NeXTGraphic chart.png \\width2880 \\height1440 \\appleattachmentpadding0 \\appleembedtype0 \\appleaqc\n" +
+	"}\\'ac}, scan: {{\\NeXTGraphic scan.tiff \\width1440 \\height1440\n" +
+	"}\u00ac} and {{\\NeXTGraphic missing.png \\width720 \\height720\n" +
+	"}\u00ac}.\\par}"
+
+var rtfdPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR\x00\x00\x00\x02\x00\x00\x00\x01")
+
+func TestParseRTFD(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string][]byte{
+		RTFDDocumentName: []byte(rtfdDocument),
+		"chart.png":      rtfdPNG,
+		"scan.tiff":      []byte("II*\x00"),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseRTFD(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRuns := []StyleBlock{
+		{Kind: BlockKindText, Text: "Chart: "},
+		{Kind: BlockKindPicture},
+		{Kind: BlockKindText, Text: ", scan: "},
+		{Kind: BlockKindPicture, PictureIndex: 1},
+		{Kind: BlockKindText, Text: " and ."},
+	}
+	actualRuns := doc.Paragraphs()[0].Runs
+	if !reflect.DeepEqual(expectedRuns, actualRuns) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedRuns, actualRuns)
+	}
+
+	expected := []Picture{
+		{Format: PictureFormatPNG, Width: 2, Height: 1, GoalWidth: 2880, GoalHeight: 1440, ScaleX: 100, ScaleY: 100, Data: rtfdPNG, Name: "chart.png"},
+		{Format: PictureFormatUnknown, GoalWidth: 1440, GoalHeight: 1440, ScaleX: 100, ScaleY: 100, Data: []byte("II*\x00"), Name: "scan.tiff"},
+	}
+	if !reflect.DeepEqual(expected, doc.Pictures) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Pictures)
+	}
+
+	expectedCocoa := &CocoaMetadata{Version: 2759, Subversion: 600}
+	if !reflect.DeepEqual(expectedCocoa, doc.Header.Cocoa) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedCocoa, doc.Header.Cocoa)
+	}
+
+	warnings := parser.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0].String(), `"missing.png" cannot be read`) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a warning about missing.png", warnings)
+	}
+}
+
+func TestParseRTFDFS(t *testing.T) {
+	bundle := fstest.MapFS{
+		RTFDDocumentName: {Data: []byte(rtfdDocument)},
+		"chart.png":      {Data: rtfdPNG},
+	}
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseRTFDFS(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Pictures) != 1 || doc.Pictures[0].Name != "chart.png" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "chart.png", doc.Pictures)
+	}
+
+	// attachments are only read from bundles
+	doc, err = parser.ParseContent(rtfdDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Pictures) != 0 || len(parser.Warnings()) != 3 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no pictures", parser.Warnings())
+	}
+
+	if _, err := parser.ParseRTFDFS(fstest.MapFS{}); err == nil {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "an error", err)
+	}
+}
+
+func TestCocoaMetadata(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseFile(filepath.Join("testfiles", "minimal.rtf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &CocoaMetadata{Version: 2759}
+	if !reflect.DeepEqual(expected, doc.Header.Cocoa) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Header.Cocoa)
+	}
+
+	content, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, written.Header.Cocoa) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, written.Header.Cocoa)
+	}
+
+	data, err := doc.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"cocoa":{"version":2759,"textScaling":0,"platform":0}`) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %s", "Cocoa metadata", data)
+	}
+
+	var decoded RtfDocument
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, decoded.Header.Cocoa) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, decoded.Header.Cocoa)
+	}
+}
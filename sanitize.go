@@ -0,0 +1,206 @@
+package gortf
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// SanitizeOptions selects what Sanitize removes from a document.
+type SanitizeOptions struct {
+	// StripMetadata clears the information group: the author, operator,
+	// company, comments, dates and statistics, and the user properties. The
+	// names of the reviewers of annotations and of the authors of revisions,
+	// and their dates, are cleared too.
+	StripMetadata bool
+
+	// StripHidden removes hidden text.
+	StripHidden bool
+
+	// StripAnnotations removes the comments of reviewers.
+	StripAnnotations bool
+
+	// StripObjects removes pictures, shapes and OLE objects, along with the
+	// files packaged in them.
+	StripObjects bool
+
+	// Redact lists expressions whose matches are masked in the text of the
+	// body, footnotes, headers and footers, and in link targets, field
+	// instructions and bookmark names. Each character of a match is replaced
+	// with RedactionCharacter, or with DefaultRedactionCharacter if it is not
+	// set, keeping its formatting.
+	Redact             []*regexp.Regexp
+	RedactionCharacter rune
+}
+
+// DefaultRedactionCharacter is the character masking redacted text.
+const DefaultRedactionCharacter = '█'
+
+// Sanitize removes personal data from the document, as selected by options.
+//
+// Parts of RTF documents that are not kept by the parser, such as RSIDs,
+// never reach RTF written from a document, so sanitizing a parsed document
+// and writing it back leaves them out whatever the options.
+func (r *RtfDocument) Sanitize(options SanitizeOptions) {
+	if options.StripMetadata {
+		r.InformationGroup = RtfInformationGroup{}
+		r.Header.RevisionAuthors = nil
+	}
+
+	if options.StripObjects {
+		r.Pictures = nil
+		r.Objects = nil
+		r.Shapes = nil
+	}
+
+	if options.StripAnnotations {
+		r.Annotations = nil
+	}
+
+	sanitize := func(body []StyleBlock) []StyleBlock {
+		if options.StripHidden || options.StripObjects || options.StripAnnotations {
+			body = stripBlocks(body, options)
+		}
+		if options.StripMetadata {
+			body = stripRevisionAuthors(body)
+		}
+		for _, re := range options.Redact {
+			body = redact(body, re, options.redactionCharacter())
+		}
+		return body
+	}
+
+	r.Body = sanitize(r.Body)
+	for i := range r.Footnotes {
+		r.Footnotes[i].Body = sanitize(r.Footnotes[i].Body)
+	}
+	for i := range r.HeadersFooters {
+		r.HeadersFooters[i].Body = sanitize(r.HeadersFooters[i].Body)
+	}
+	for i := range r.Objects {
+		r.Objects[i].Result = sanitize(r.Objects[i].Result)
+	}
+	for i := range r.Shapes {
+		r.Shapes[i].Body = sanitize(r.Shapes[i].Body)
+	}
+	for i := range r.Annotations {
+		r.Annotations[i].Body = sanitize(r.Annotations[i].Body)
+		if options.StripMetadata {
+			r.Annotations[i].Initials, r.Annotations[i].Author, r.Annotations[i].Date = "", "", nil
+		}
+	}
+}
+
+// SanitizeRTF parses an RTF document, sanitizes it and returns its RTF. See
+// RtfDocument.Sanitize.
+func SanitizeRTF(content string, options SanitizeOptions) (string, error) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		return "", err
+	}
+
+	doc.Sanitize(options)
+
+	return DocumentToRTF(&doc)
+}
+
+func (o SanitizeOptions) redactionCharacter() rune {
+	if o.RedactionCharacter == 0 {
+		return DefaultRedactionCharacter
+	}
+
+	return o.RedactionCharacter
+}
+
+// stripBlocks removes the hidden content, annotations and objects of a body,
+// as selected by options. Marks are kept, so that paragraphs and tables stay whole.
+func stripBlocks(body []StyleBlock, options SanitizeOptions) []StyleBlock {
+	stripped := []StyleBlock{}
+
+	for _, block := range body {
+		switch block.Kind {
+		case BlockKindParagraph, BlockKindCell, BlockKindRow, BlockKindSection:
+		case BlockKindPicture, BlockKindObject, BlockKindShape:
+			if options.StripObjects || (options.StripHidden && block.Painter.Hidden) {
+				continue
+			}
+		case BlockKindAnnotation, BlockKindAnnotationStart, BlockKindAnnotationEnd:
+			if options.StripAnnotations || (options.StripHidden && block.Painter.Hidden) {
+				continue
+			}
+		default:
+			if options.StripHidden && block.Painter.Hidden {
+				continue
+			}
+		}
+		stripped = append(stripped, block)
+	}
+
+	return stripped
+}
+
+// stripRevisionAuthors clears the authors and times of the revisions of a
+// body, keeping the revisions.
+func stripRevisionAuthors(body []StyleBlock) []StyleBlock {
+	stripped := append([]StyleBlock{}, body...)
+
+	for i := range stripped {
+		painter := &stripped[i].Painter
+		painter.InsertedBy, painter.InsertedAt, painter.DeletedBy, painter.DeletedAt = 0, 0, 0, 0
+		if stripped[i].Paragraph != nil {
+			format := *stripped[i].Paragraph
+			format.RevisedBy, format.RevisedAt = 0, 0
+			stripped[i].Paragraph = &format
+		}
+	}
+
+	return stripped
+}
+
+// redact masks the matches of an expression in the text, link targets,
+// field instructions and bookmark names of a body.
+func redact(body []StyleBlock, re *regexp.Regexp, mask rune) []StyleBlock {
+	redacted := append([]StyleBlock{}, body...)
+
+	for _, segment := range textSegments(body) {
+		for _, indexes := range re.FindAllStringIndex(segment.text, -1) {
+			begin := 0
+			for i, end := range segment.ends {
+				if start, stop := max(indexes[0], begin), min(indexes[1], end); start < stop {
+					block := &redacted[segment.start+i]
+					// matches are masked in order, so the text masked so far
+					// is before the match
+					text := block.Text
+					offset := len(text) - len(segment.blocks[i].Text)
+					start, stop = start-begin+offset, stop-begin+offset
+					block.Text = text[:start] + maskText(text[start:stop], mask) + text[stop:]
+				}
+				begin = end
+			}
+		}
+	}
+
+	// field instructions and bookmark names would tell what was masked
+	replace := func(text string) string {
+		return re.ReplaceAllStringFunc(text, func(match string) string {
+			return maskText(match, mask)
+		})
+	}
+	for i := range redacted {
+		redacted[i].Painter.Link = replace(redacted[i].Painter.Link)
+		redacted[i].Painter.Field = replace(redacted[i].Painter.Field)
+		redacted[i].Bookmark = replace(redacted[i].Bookmark)
+	}
+
+	return redacted
+}
+
+// maskText replaces each character of text with mask.
+func maskText(text string, mask rune) string {
+	masked := make([]rune, utf8.RuneCountInString(text))
+	for i := range masked {
+		masked[i] = mask
+	}
+
+	return string(masked)
+}
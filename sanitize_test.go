@@ -0,0 +1,99 @@
+package gortf
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	content := `{\rtf1\ansi{\info{\author Ann Smith}{\company Acme}{\*\userprops{\propname Client}\proptype30{\staticval Bob}}}` +
+		`{\*\revtbl{Unknown;}{Ann Smith;}}{\*\rsidtbl \rsid123}` +
+		`Call {\b 555-}0199 or {\field{\*\fldinst HYPERLINK "mailto:ann@acme.com"}{\fldrslt ann@acme.com}}.` +
+		`{\v secret}{\pict\pngblip\picw1\pich1 89504e47}\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.Sanitize(SanitizeOptions{
+		StripMetadata:      true,
+		StripHidden:        true,
+		StripObjects:       true,
+		Redact:             []*regexp.Regexp{regexp.MustCompile(`\d{3}-\d{4}`), regexp.MustCompile(`\w+@\w+\.com`)},
+		RedactionCharacter: '*',
+	})
+
+	expected := []StyleBlock{
+		{Kind: BlockKindText, Text: "Call "},
+		{Kind: BlockKindText, Text: "****", Painter: Painter{Bold: true}},
+		{Kind: BlockKindText, Text: "**** or "},
+		{Kind: BlockKindText, Text: "************", Painter: Painter{Link: "mailto:************"}},
+		{Kind: BlockKindText, Text: "."},
+	}
+	actual := doc.Paragraphs()[0].Runs
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	if !reflect.DeepEqual(doc.InformationGroup, RtfInformationGroup{}) || len(doc.Pictures) != 0 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no metadata nor pictures", doc)
+	}
+}
+
+func TestSanitizeRTF(t *testing.T) {
+	content := `{\rtf1\ansi{\info{\operator ann}}{\*\revtbl{Unknown;}{Ann Smith;}}{\*\rsidtbl \rsid123}Hi Ann\par}`
+
+	sanitized, err := SanitizeRTF(content, SanitizeOptions{StripMetadata: true, Redact: []*regexp.Regexp{regexp.MustCompile(`Ann`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, personal := range []string{"Ann", "ann", "revtbl", "rsid", "operator"} {
+		if strings.Contains(sanitized, personal) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no "+personal, sanitized)
+		}
+	}
+}
+
+func TestSanitizeRTFFieldsAndBookmarks(t *testing.T) {
+	content := `{\rtf1\ansi{\info{\operator someone}}Dear {\field{\*\fldinst MERGEFIELD Ann}{\fldrslt Ann}}, ` +
+		`{\*\bkmkstart Ann}see {\field{\*\fldinst HYPERLINK "https://example.com/Ann"}{\fldrslt here}}{\*\bkmkend Ann}\par}`
+
+	sanitized, err := SanitizeRTF(content, SanitizeOptions{StripMetadata: true, Redact: []*regexp.Regexp{regexp.MustCompile(`Ann`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, personal := range []string{"Ann", `\info`} {
+		if strings.Contains(sanitized, personal) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no "+personal, sanitized)
+		}
+	}
+	for _, kept := range []string{`\fldinst MERGEFIELD \u9608`, `\bkmkstart\u9608`, `\bkmkend\u9608`} {
+		if !strings.Contains(sanitized, kept) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", kept, sanitized)
+		}
+	}
+}
+
+func TestSanitizeAnnotations(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(annotatedRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.Sanitize(SanitizeOptions{StripAnnotations: true})
+
+	sanitized, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Annotations) != 0 || strings.Contains(sanitized, "Ann Smith") || strings.Contains(sanitized, "atrfstart") {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "no annotations", sanitized)
+	}
+}
@@ -4,154 +4,103 @@ import (
 	"strings"
 )
 
+// scanner turns the output of a Lexer into the tokens used by the parser,
+// resolving escaped characters and discarding insignificant newlines.
 type scanner struct {
-	start   int
-	current int
-	source  string
-	tokens  []token
+	lexer  *Lexer
+	tokens []token
 }
 
 func newScanner(source string) scanner {
 	return scanner{
-		start:   0,
-		current: 0,
-		source:  source,
-		tokens:  []token{},
+		lexer:  NewLexer(strings.NewReader(source)),
+		tokens: []token{},
 	}
 }
 
 func (s *scanner) scanTokens() {
-	for !s.isAtEnd() {
-		s.start = s.current
-		s.scanToken()
+	for {
+		tkn, err := s.lexer.Next()
+		if err != nil {
+			// io.EOF, or binary data cut short at the end of the source
+			return
+		}
+
+		s.scanToken(tkn)
 	}
 }
 
-func (s *scanner) scanToken() {
-	c := s.advance()
-
-	switch c {
-	case '{':
+func (s *scanner) scanToken(tkn Token) {
+	switch tkn.Kind {
+	case TokenKindGroupStart:
 		s.addToken(newGroupToken())
-		for s.peek() != '\\' && s.peek() != '{' && s.peek() != '}' {
-			s.advance()
-		}
 
-	case '}':
+	case TokenKindGroupEnd:
 		s.addToken(newGroupEndToken())
 
-	case '*':
-		// TODO: handle known ignorables
-		s.ignoreCurrentGroup()
-
-	case '\\':
-		pc := s.peek()
+	case TokenKindControlWord:
+		s.addToken(controlWordFromToken(tkn))
 
-		if pc == '\\' || pc == '{' || pc == '}' { // escaped characters
-			// move past the escape character in the current index
-			s.advance()
+	case TokenKindBinary:
+		s.addToken(newControlWordToken(`\`+tkn.Name, tkn.Param))
+		s.addToken(NewBinaryToken(tkn.Data))
 
-			for s.peek() != '\\' && s.peek() != '{' && s.peek() != '}' {
-				s.advance()
-			}
-
-			s.addToken(newTextToken(s.source[s.start+1 : s.current]))
-		} else if pc == '\n' { // CRLF
-			s.addToken(newCrlfToken())
-			s.advance()
-		} else if isAlphaLower(pc) { // control word
-			for s.peek() != '\\' && s.peek() != '{' && s.peek() != '}' {
-				s.advance()
-			}
-
-			slice := s.source[s.start:s.current]
-			head, tail := splitAtFirstWhitespace(slice)
-
-			if strings.HasSuffix(head, ";") {
-				head = strings.TrimSuffix(head, ";")
-			}
-
-			cwt, err := newControlWordToken(head)
-			if err != nil {
-				break
-			}
-
-			s.addToken(cwt)
-
-			tail = strings.Trim(tail, "\n")
-			if tail != "" {
-				s.addToken(newTextToken(tail))
-			}
-		}
-
-	default:
-		for s.peek() != '\\' && s.peek() != '{' && s.peek() != '}' {
-			s.advance()
-		}
-		slice := strings.Trim(s.source[s.start:s.current], "\n")
+	case TokenKindControlSymbol:
+		s.scanControlSymbol(tkn)
 
+	case TokenKindText:
+		// raw carriage returns and line feeds carry no meaning in RTF; only
+		// \<newline> does, and is scanned as a control symbol
+		slice := stripNewlines(tkn.Text)
 		if slice != "" {
 			s.addToken(newTextToken(slice))
 		}
 	}
 }
 
-func (s *scanner) addToken(token token) {
-	s.tokens = append(s.tokens, token)
-}
+func (s *scanner) scanControlSymbol(tkn Token) {
+	switch tkn.Name {
+	case `\`, "{", "}": // escaped characters
+		s.addToken(newTextToken(tkn.Name))
 
-func (s *scanner) popToken() token {
-	index := len(s.tokens) - 1
-	tkn := s.tokens[index]
-	s.tokens = s.tokens[:index]
+	case "\r", "\n": // CRLF
+		s.addToken(newCrlfToken())
 
-	return tkn
-}
+	case "*":
+		s.addToken(newIgnorableToken())
 
-func (s *scanner) peek() byte {
-	if s.isAtEnd() {
-		return 0
-	}
+	case "'":
+		parameter := -1
+		if tkn.HasParam {
+			parameter = tkn.Param
+		}
 
-	return s.source[s.current]
-}
+		s.addToken(newControlSymbolToken('\'', parameter))
 
-func (s *scanner) peekNext() byte {
-	if (s.current + 1) >= len(s.source) {
-		return 0
+	default:
+		s.addToken(newControlSymbolToken(tkn.Name[0], -1))
 	}
-
-	return s.source[s.current+1]
 }
 
-func (s *scanner) isAtEnd() bool {
-	return s.current >= len(s.source)
-}
+// scanGroup converts a group of the document tree back into a flat token
+// stream, delimiters included. Nested ignorable destinations are left out.
+func (s *scanner) scanGroup(g *Group) {
+	s.addToken(newGroupToken())
 
-func (s *scanner) advance() byte {
-	currentChar := s.source[s.current]
-
-	s.current += 1
+	for _, child := range g.Children {
+		switch node := child.(type) {
+		case *Group:
+			if !node.Ignorable {
+				s.scanGroup(node)
+			}
+		case Token:
+			s.scanToken(node)
+		}
+	}
 
-	return currentChar
+	s.addToken(newGroupEndToken())
 }
 
-func (s *scanner) ignoreCurrentGroup() {
-	s.popToken()
-
-	count := 0
-
-	for !s.isAtEnd() {
-		currentChar := s.advance()
-		switch currentChar {
-		case '{':
-			count += 1
-		case '}':
-			count -= 1
-		}
-
-		if count < 0 {
-			break
-		}
-	}
+func (s *scanner) addToken(token token) {
+	s.tokens = append(s.tokens, token)
 }
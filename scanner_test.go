@@ -2,6 +2,7 @@ package gortf
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -41,20 +42,22 @@ func TestScanEntireFile(t *testing.T) {
 		groupEndToken{},
 		groupToken{},
 		controlWordToken{`\colortbl`, controlWordTypeColorTable, -1},
+		textToken{";"},
 		controlWordToken{`\red`, controlWordTypeColorRed, 0},
 		controlWordToken{`\green`, controlWordTypeColorGreen, 0},
 		controlWordToken{`\blue`, controlWordTypeColorBlue, 0},
+		textToken{";"},
 		groupEndToken{},
 		groupToken{},
 		controlWordToken{`\stylesheet`, controlWordTypeStylesheet, -1},
 		groupToken{},
 		controlWordToken{`\s`, controlWordTypeStyleParagraph, 0},
 		controlWordToken{`\snext`, controlWordTypeStyleNext, 0},
-		controlWordToken{`\ql`, controlWordTypeUnknown, -1},
+		controlWordToken{`\ql`, controlWordTypeParagraphAlignment, -1},
 		controlWordToken{`\nowidctlpar`, controlWordTypeUnknown, -1},
 		controlWordToken{`\hyphpar`, controlWordTypeUnknown, 0},
 		controlWordToken{`\ltrpar`, controlWordTypeUnknown, -1},
-		controlWordToken{`\cf`, controlWordTypeUnknown, 17},
+		controlWordToken{`\cf`, controlWordTypeForegroundColor, 17},
 		controlWordToken{`\dbch`, controlWordTypeUnknown, -1},
 		controlWordToken{`\af`, controlWordTypeUnknown, 9},
 		controlWordToken{`\langfe`, controlWordTypeUnknown, 2052},
@@ -71,14 +74,14 @@ func TestScanEntireFile(t *testing.T) {
 		groupEndToken{},
 		groupEndToken{},
 		controlWordToken{`\f`, controlWordTypeFontNumber, 0},
-		controlWordToken{`\pard`, controlWordTypeUnknown, -1},
+		controlWordToken{`\pard`, controlWordTypeParagraphDefault, -1},
 		textToken{"Voici du texte en "},
 		groupToken{},
 		controlWordToken{`\b`, controlWordTypeBold, -1},
 		textToken{"gras"},
 		groupEndToken{},
 		textToken{"."},
-		controlWordToken{`\par`, controlWordTypeUnknown, -1},
+		controlWordToken{`\par`, controlWordTypeParagraph, -1},
 		groupEndToken{},
 	}
 
@@ -102,7 +105,7 @@ func TestEscapedText(t *testing.T) {
 		groupToken{},
 		controlWordToken{`\f`, controlWordTypeFontNumber, 0},
 		controlWordToken{`\fs`, controlWordTypeFontSize, 24},
-		controlWordToken{`\cf`, controlWordTypeUnknown, 0},
+		controlWordToken{`\cf`, controlWordTypeForegroundColor, 0},
 		textToken{"test de code "},
 		crlfToken{},
 		textToken{"if (a == b) "},
@@ -110,7 +113,8 @@ func TestEscapedText(t *testing.T) {
 		crlfToken{},
 		textToken{"    test();"},
 		crlfToken{},
-		textToken{"} else "},
+		textToken{"}"},
+		textToken{" else "},
 		textToken{"{"},
 		crlfToken{},
 		textToken{"    return;"},
@@ -130,14 +134,20 @@ func TestIgnorableDestination(t *testing.T) {
 	scanner := newScanner(content)
 	scanner.scanTokens()
 
-	expected := []token{}
+	expected := []token{
+		groupToken{},
+		ignorableToken{},
+		controlWordToken{`\expandedcolortbl`, controlWordTypeExpandedColorTable, -1},
+		textToken{";;"},
+		groupEndToken{},
+	}
 
 	if !reflect.DeepEqual(scanner.tokens, expected) {
 		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, scanner.tokens)
 	}
 }
 
-func TestShouldParseControlSymbolEndingSemicolon(t *testing.T) {
+func TestShouldParseControlWordEndingSemicolon(t *testing.T) {
 	content := `{\red255\blue255;}`
 
 	scanner := newScanner(content)
@@ -147,6 +157,216 @@ func TestShouldParseControlSymbolEndingSemicolon(t *testing.T) {
 		groupToken{},
 		controlWordToken{`\red`, controlWordTypeColorRed, 255},
 		controlWordToken{`\blue`, controlWordTypeColorBlue, 255},
+		textToken{";"},
+		groupEndToken{},
+	}
+
+	if !reflect.DeepEqual(scanner.tokens, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, scanner.tokens)
+	}
+}
+
+func TestControlWordConformance(t *testing.T) {
+	corpus := []struct {
+		name     string
+		content  string
+		expected []token
+	}{
+		{
+			name:    "parameter followed by text",
+			content: `\b0text`,
+			expected: []token{
+				controlWordToken{`\b`, controlWordTypeBold, 0},
+				textToken{"text"},
+			},
+		},
+		{
+			name:    "adjacent control words",
+			content: `\fs24\b`,
+			expected: []token{
+				controlWordToken{`\fs`, controlWordTypeFontSize, 24},
+				controlWordToken{`\b`, controlWordTypeBold, -1},
+			},
+		},
+		{
+			name:    "control word followed by group end",
+			content: `{\par}`,
+			expected: []token{
+				groupToken{},
+				controlWordToken{`\par`, controlWordTypeParagraph, -1},
+				groupEndToken{},
+			},
+		},
+		{
+			name:    "non-alphanumeric delimiter is kept as text",
+			content: `\f0;`,
+			expected: []token{
+				controlWordToken{`\f`, controlWordTypeFontNumber, 0},
+				textToken{";"},
+			},
+		},
+		{
+			name:    "name ending in letters before the parameter",
+			content: `\ansicpg1252`,
+			expected: []token{
+				controlWordToken{`\ansicpg`, controlWordTypeCodePage, 1252},
+			},
+		},
+		{
+			name:    "negative parameter",
+			content: `\li-360 x`,
+			expected: []token{
+				controlWordToken{`\li`, controlWordTypeLeftIndent, -360},
+				textToken{"x"},
+			},
+		},
+		{
+			name:    "hyphen not followed by a digit is text",
+			content: `\b-x`,
+			expected: []token{
+				controlWordToken{`\b`, controlWordTypeBold, -1},
+				textToken{"-x"},
+			},
+		},
+		{
+			name:    "only a single delimiting space is consumed",
+			content: `\b  two`,
+			expected: []token{
+				controlWordToken{`\b`, controlWordTypeBold, -1},
+				textToken{" two"},
+			},
+		},
+		{
+			name:    "uppercase letters",
+			content: `\NeXTGraphic x`,
+			expected: []token{
+				controlWordToken{`\NeXTGraphic`, controlWordTypeUnknown, -1},
+				textToken{"x"},
+			},
+		},
+		{
+			name:    "name longer than 32 letters",
+			content: `\` + strings.Repeat("a", 33),
+			expected: []token{
+				controlWordToken{`\` + strings.Repeat("a", 32), controlWordTypeUnknown, -1},
+				textToken{"a"},
+			},
+		},
+		{
+			name:    "parameter longer than 10 digits",
+			content: `\fs12345678901`,
+			expected: []token{
+				controlWordToken{`\fs`, controlWordTypeFontSize, 1234567890},
+				textToken{"1"},
+			},
+		},
+		{
+			name:    "hexadecimal character",
+			content: `caf\'e9s`,
+			expected: []token{
+				textToken{"caf"},
+				controlSymbolToken{'\'', 0xe9},
+				textToken{"s"},
+			},
+		},
+		{
+			name:    "malformed hexadecimal character",
+			content: `\'zz`,
+			expected: []token{
+				controlSymbolToken{'\'', -1},
+				textToken{"zz"},
+			},
+		},
+		{
+			name:    "control symbols",
+			content: `a\~b\-c\_d`,
+			expected: []token{
+				textToken{"a"},
+				controlSymbolToken{'~', -1},
+				textToken{"b"},
+				controlSymbolToken{'-', -1},
+				textToken{"c"},
+				controlSymbolToken{'_', -1},
+				textToken{"d"},
+			},
+		},
+		{
+			name:    "control symbols do not consume a space",
+			content: `\~ x`,
+			expected: []token{
+				controlSymbolToken{'~', -1},
+				textToken{" x"},
+			},
+		},
+		{
+			name:    "unicode character with fallback",
+			content: `\uc1\u-3913 ?`,
+			expected: []token{
+				controlWordToken{`\uc`, controlWordTypeUnicodeSkip, 1},
+				controlWordToken{`\u`, controlWordTypeUnicode, -3913},
+				textToken{"?"},
+			},
+		},
+		{
+			name:    "binary data",
+			content: `\bin3 {}\x`,
+			expected: []token{
+				controlWordToken{`\bin`, controlWordTypeBinary, 3},
+				binaryToken{[]byte(`{}\`)},
+				textToken{"x"},
+			},
+		},
+		{
+			name:    "ignorable destination",
+			content: `{\*\panose 02}`,
+			expected: []token{
+				groupToken{},
+				ignorableToken{},
+				controlWordToken{`\panose`, controlWordTypeFontPanose, -1},
+				textToken{"02"},
+				groupEndToken{},
+			},
+		},
+		{
+			name:    "text right after an opening brace",
+			content: `{text}`,
+			expected: []token{
+				groupToken{},
+				textToken{"text"},
+				groupEndToken{},
+			},
+		},
+		{
+			name:    "trailing backslash",
+			content: `a\`,
+			expected: []token{
+				textToken{"a"},
+				textToken{`\`},
+			},
+		},
+	}
+
+	for _, c := range corpus {
+		scanner := newScanner(c.content)
+		scanner.scanTokens()
+
+		if !reflect.DeepEqual(scanner.tokens, c.expected) {
+			t.Errorf("%s\n\nexpected: %v\n\nactual\t: %v", c.name, c.expected, scanner.tokens)
+		}
+	}
+}
+
+func TestRawNewlinesAreIgnored(t *testing.T) {
+	content := "{Hello\r\n wor\nld\\\r\n\tend }"
+
+	scanner := newScanner(content)
+	scanner.scanTokens()
+
+	expected := []token{
+		groupToken{},
+		textToken{"Hello world"},
+		crlfToken{},
+		textToken{"\tend "},
 		groupEndToken{},
 	}
 
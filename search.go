@@ -0,0 +1,170 @@
+package gortf
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TextPosition is a position in the body of a document, the index of a
+// block and a byte offset in its text.
+type TextPosition struct {
+	Block  int
+	Offset int
+}
+
+// Match is text found in the body of a document. It runs from Start to End,
+// exclusive, which may lie in different blocks when text is split across
+// runs of different formatting.
+type Match struct {
+	Start TextPosition
+	End   TextPosition
+	Text  string
+
+	// Submatches holds the text of the groups of regular expressions.
+	Submatches []string
+}
+
+// Find returns every occurrence of text in the body of the document.
+func (r *RtfDocument) Find(text string) []Match {
+	return r.FindRegexp(regexp.MustCompile(regexp.QuoteMeta(text)))
+}
+
+// FindRegexp returns every match of a regular expression in the body of the
+// document. Text is matched within consecutive text blocks, so that matches
+// span runs but not paragraphs, breaks or pictures.
+func (r *RtfDocument) FindRegexp(re *regexp.Regexp) []Match {
+	matches := []Match{}
+
+	for _, segment := range textSegments(r.Body) {
+		for _, indexes := range re.FindAllStringSubmatchIndex(segment.text, -1) {
+			match := Match{
+				Start: segment.position(indexes[0], false),
+				End:   segment.position(indexes[1], true),
+				Text:  segment.text[indexes[0]:indexes[1]],
+			}
+			for i := 2; i < len(indexes); i += 2 {
+				submatch := ""
+				if indexes[i] >= 0 {
+					submatch = segment.text[indexes[i]:indexes[i+1]]
+				}
+				match.Submatches = append(match.Submatches, submatch)
+			}
+			matches = append(matches, match)
+		}
+	}
+
+	return matches
+}
+
+// Replace replaces every occurrence of old in the body of the document with
+// new, returning the number of occurrences replaced.
+func (r *RtfDocument) Replace(old, new string) int {
+	return r.ReplaceRegexp(regexp.MustCompile(regexp.QuoteMeta(old)), strings.ReplaceAll(new, "$", "$$"), nil)
+}
+
+// ReplaceRegexp replaces every match of a regular expression in the body of
+// the document, returning the number of matches replaced. Within replacement,
+// $1 or ${name} stand for the text of groups, as with Regexp.Expand. The
+// replacement takes the formatting of painter, or if it is nil, that of the
+// run where the match starts. The rest of the document is left as it is.
+func (r *RtfDocument) ReplaceRegexp(re *regexp.Regexp, replacement string, painter *Painter) int {
+	count := 0
+	body := []StyleBlock{}
+	next := 0
+
+	for _, segment := range textSegments(r.Body) {
+		matches := re.FindAllStringSubmatchIndex(segment.text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		count += len(matches)
+
+		body = append(body, r.Body[next:segment.start]...)
+		cursor := 0
+		for _, indexes := range matches {
+			body = segment.appendRuns(body, cursor, indexes[0])
+
+			run := r.Body[segment.position(indexes[0], false).Block]
+			if painter != nil {
+				run.Painter = *painter
+			}
+			run.Text = string(re.ExpandString(nil, replacement, segment.text, indexes))
+			if run.Text != "" {
+				body = append(body, run)
+			}
+			cursor = indexes[1]
+		}
+		body = segment.appendRuns(body, cursor, len(segment.text))
+		next = segment.start + len(segment.blocks)
+	}
+
+	if count > 0 {
+		r.Body = append(body, r.Body[next:]...)
+	}
+
+	return count
+}
+
+// textSegment is a sequence of consecutive text blocks of a body, with their
+// text joined.
+type textSegment struct {
+	start  int
+	blocks []StyleBlock
+	text   string
+
+	// ends holds the offset in text at which each block ends.
+	ends []int
+}
+
+func textSegments(body []StyleBlock) []textSegment {
+	segments := []textSegment{}
+
+	for i := 0; i < len(body); {
+		if body[i].Kind != BlockKindText {
+			i++
+			continue
+		}
+
+		segment := textSegment{start: i}
+		var text strings.Builder
+		for ; i < len(body) && body[i].Kind == BlockKindText; i++ {
+			text.WriteString(body[i].Text)
+			segment.blocks = append(segment.blocks, body[i])
+			segment.ends = append(segment.ends, text.Len())
+		}
+		segment.text = text.String()
+		segments = append(segments, segment)
+	}
+
+	return segments
+}
+
+// position returns the position of an offset in the text of a segment. The
+// ends of blocks belong to the following block, unless end is set.
+func (s *textSegment) position(offset int, end bool) TextPosition {
+	begin := 0
+	for i, blockEnd := range s.ends {
+		if offset < blockEnd || (end && offset == blockEnd) || i == len(s.ends)-1 {
+			return TextPosition{Block: s.start + i, Offset: offset - begin}
+		}
+		begin = blockEnd
+	}
+
+	return TextPosition{Block: s.start}
+}
+
+// appendRuns appends the parts of the blocks of a segment between two
+// offsets of its text, keeping their formatting.
+func (s *textSegment) appendRuns(body []StyleBlock, from, to int) []StyleBlock {
+	begin := 0
+	for i, block := range s.blocks {
+		end := s.ends[i]
+		if start, stop := max(from, begin), min(to, end); start < stop {
+			block.Text = block.Text[start-begin : stop-begin]
+			body = append(body, block)
+		}
+		begin = end
+	}
+
+	return body
+}
@@ -0,0 +1,70 @@
+package gortf
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseFile("./testfiles/minimal.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := doc.Find("This is")
+	if len(matches) != 1 || matches[0].Text != "This is" {
+		t.Fatalf("\n\nexpected: %v\n\nactual\t: %v", "one match", matches)
+	}
+
+	start, end := doc.Body[matches[0].Start.Block], doc.Body[matches[0].End.Block]
+	if start.Text[matches[0].Start.Offset:] != "This" || end.Text[:matches[0].End.Offset] != "is" || !end.Painter.Italic {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a match from This to the italic is", matches[0])
+	}
+
+	matches = doc.FindRegexp(regexp.MustCompile(`a (\w+) (f)ile`))
+	if len(matches) != 1 || !reflect.DeepEqual(matches[0].Submatches, []string{"test", "f"}) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", []string{"test", "f"}, matches)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	bold := Painter{Bold: true}
+	italic := Painter{Italic: true}
+	doc := RtfDocument{Body: []StyleBlock{
+		{Kind: BlockKindText, Text: "Dear Mr", Painter: bold},
+		{Kind: BlockKindText, Text: ". Smith"},
+		{Kind: BlockKindParagraph, Text: "\n"},
+		{Kind: BlockKindText, Text: "Mr. Jones", Painter: italic},
+	}}
+
+	if count := doc.Replace("Mr. ", "Dr. "); count != 2 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 2, count)
+	}
+
+	expected := []StyleBlock{
+		{Kind: BlockKindText, Text: "Dear ", Painter: bold},
+		{Kind: BlockKindText, Text: "Dr. ", Painter: bold},
+		{Kind: BlockKindText, Text: "Smith"},
+		{Kind: BlockKindParagraph, Text: "\n"},
+		{Kind: BlockKindText, Text: "Dr. ", Painter: italic},
+		{Kind: BlockKindText, Text: "Jones", Painter: italic},
+	}
+	if !reflect.DeepEqual(expected, doc.Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Body)
+	}
+
+	underline := Painter{Underline: true}
+	doc.ReplaceRegexp(regexp.MustCompile(`Dr\. (\w+)`), "$1", &underline)
+
+	expected = []StyleBlock{
+		{Kind: BlockKindText, Text: "Dear ", Painter: bold},
+		{Kind: BlockKindText, Text: "Smith", Painter: underline},
+		{Kind: BlockKindParagraph, Text: "\n"},
+		{Kind: BlockKindText, Text: "Jones", Painter: underline},
+	}
+	if !reflect.DeepEqual(expected, doc.Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Body)
+	}
+}
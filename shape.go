@@ -0,0 +1,281 @@
+package gortf
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ShapeAnchor is what the position of a shape is relative to.
+type ShapeAnchor int
+
+const (
+	// ShapeAnchorMargin is the page within its margins (\shpbxmargin,
+	// \shpbymargin).
+	ShapeAnchorMargin ShapeAnchor = iota
+	// ShapeAnchorPage is the edge of the page (\shpbxpage, \shpbypage).
+	ShapeAnchorPage
+	// ShapeAnchorColumn is the column of the shape, horizontally only
+	// (\shpbxcolumn).
+	ShapeAnchorColumn
+	// ShapeAnchorParagraph is the paragraph of the shape, vertically only
+	// (\shpbypara).
+	ShapeAnchorParagraph
+	// ShapeAnchorCharacter and ShapeAnchorLine are the character and the
+	// line the shape is anchored to, given by the posrelh and posrelv
+	// properties.
+	ShapeAnchorCharacter
+	ShapeAnchorLine
+)
+
+func (s ShapeAnchor) String() string {
+	switch s {
+	case ShapeAnchorMargin:
+		return "Margin"
+	case ShapeAnchorPage:
+		return "Page"
+	case ShapeAnchorColumn:
+		return "Column"
+	case ShapeAnchorParagraph:
+		return "Paragraph"
+	case ShapeAnchorCharacter:
+		return "Character"
+	case ShapeAnchorLine:
+		return "Line"
+	default:
+		return "Unknown"
+	}
+}
+
+// shapeAnchorWords are the control words of the anchors following \shpbx or
+// \shpby.
+var shapeAnchorWords = map[string]ShapeAnchor{
+	"margin": ShapeAnchorMargin, "page": ShapeAnchorPage,
+	"column": ShapeAnchorColumn, "para": ShapeAnchorParagraph,
+}
+
+// shapeAnchorProperties are the anchors given by the posrelh and posrelv
+// properties, which take over from \shpbxignore and \shpbyignore.
+var shapeAnchorProperties = map[string][]ShapeAnchor{
+	"posrelh": {ShapeAnchorMargin, ShapeAnchorPage, ShapeAnchorColumn, ShapeAnchorCharacter},
+	"posrelv": {ShapeAnchorMargin, ShapeAnchorPage, ShapeAnchorParagraph, ShapeAnchorLine},
+}
+
+// ShapeWrap is how text flows around a shape.
+type ShapeWrap int
+
+const (
+	// ShapeWrapAround wraps text around the box of the shape (\shpwr2).
+	ShapeWrapAround ShapeWrap = iota
+	// ShapeWrapTopBottom leaves the sides of the shape empty (\shpwr1).
+	ShapeWrapTopBottom
+	// ShapeWrapNone places the shape in front of or behind the text,
+	// following Shape.BehindText (\shpwr3).
+	ShapeWrapNone
+	// ShapeWrapTight wraps text around the outline of the shape (\shpwr4),
+	// and ShapeWrapThrough within its holes too (\shpwr5).
+	ShapeWrapTight
+	ShapeWrapThrough
+)
+
+func (s ShapeWrap) String() string {
+	switch s {
+	case ShapeWrapAround:
+		return "Around"
+	case ShapeWrapTopBottom:
+		return "TopBottom"
+	case ShapeWrapNone:
+		return "None"
+	case ShapeWrapTight:
+		return "Tight"
+	case ShapeWrapThrough:
+		return "Through"
+	default:
+		return "Unknown"
+	}
+}
+
+// shapeWrapValues are the wrappings given by the parameter of \shpwr.
+var shapeWrapValues = map[int]ShapeWrap{
+	1: ShapeWrapTopBottom, 2: ShapeWrapAround, 3: ShapeWrapNone, 4: ShapeWrapTight, 5: ShapeWrapThrough,
+}
+
+// Shape types of the shapeType property that exporters render.
+const (
+	ShapeTypePictureFrame = 75
+	ShapeTypeTextBox      = 202
+)
+
+// Shape is a drawing object, such as a picture or a text box, from a {\shp}
+// group. The body refers to it with a block of kind BlockKindShape.
+type Shape struct {
+	// ID identifies the shape within the document (\shplid).
+	ID int
+
+	// Left, Top, Right and Bottom are the positions of the sides of the
+	// shape in twips, relative to its anchors (\shpleft, \shptop, \shpright,
+	// \shpbottom).
+	Left   int
+	Top    int
+	Right  int
+	Bottom int
+
+	HorizontalAnchor ShapeAnchor
+	VerticalAnchor   ShapeAnchor
+
+	Wrap ShapeWrap
+	// BehindText is set for shapes placed behind the text rather than in
+	// front of it (\shpfblwtxt).
+	BehindText bool
+	// ZOrder orders overlapping shapes, those with higher numbers being in
+	// front (\shpz).
+	ZOrder int
+
+	// Properties are the drawing properties of the shape by name, such as
+	// "shapeType" or "fillColor", as given by {\sp{\sn name}{\sv value}}
+	// pairs. The picture of the pib property is kept apart.
+	Properties map[string]string
+
+	// PictureIndex is the index in RtfDocument.Pictures of the picture of
+	// the shape (pib), or -1 if it has none.
+	PictureIndex int
+
+	// Body is the content of a text box (\shptxt).
+	Body []StyleBlock
+}
+
+// Width returns the width of the shape in twips.
+func (s Shape) Width() int {
+	return s.Right - s.Left
+}
+
+// Height returns the height of the shape in twips.
+func (s Shape) Height() int {
+	return s.Bottom - s.Top
+}
+
+// Type returns the shape type given by the shapeType property, such as
+// ShapeTypeTextBox, or 0 if it is missing.
+func (s Shape) Type() int {
+	shapeType, _ := strconv.Atoi(s.Properties["shapeType"])
+	return shapeType
+}
+
+// IsTextBox reports whether the shape is a text box, shown by exporters with
+// its content.
+func (s Shape) IsTextBox() bool {
+	return s.Body != nil || s.Type() == ShapeTypeTextBox
+}
+
+// shapeContent returns the runs shown in place of a shape by formats that
+// cannot position it: its picture followed by the text of its text box, whose
+// paragraphs become lines.
+func (r *RtfDocument) shapeContent(index int) []StyleBlock {
+	if index < 0 || index >= len(r.Shapes) {
+		return nil
+	}
+	shape := r.Shapes[index]
+
+	runs := []StyleBlock{}
+	if shape.PictureIndex >= 0 && shape.PictureIndex < len(r.Pictures) {
+		runs = append(runs, StyleBlock{Kind: BlockKindPicture, PictureIndex: shape.PictureIndex})
+	}
+
+	for i, block := range shape.Body {
+		switch block.Kind {
+		case BlockKindParagraph, BlockKindCell, BlockKindRow, BlockKindSection, BlockKindLine, BlockKindPage:
+			if i < len(shape.Body)-1 {
+				runs = append(runs, StyleBlock{Painter: block.Painter, Kind: BlockKindLine, Text: blockKindText(BlockKindLine)})
+			}
+		case BlockKindObject, BlockKindShape:
+		default:
+			runs = append(runs, block)
+		}
+	}
+
+	return runs
+}
+
+// addShape adds the shape of a {\shp} group. Its {\shprslt} fallback, for
+// programs that cannot read shapes, is skipped since the shape itself is
+// kept.
+func (r *RtfParser) addShape(doc *RtfDocument, g *Group) {
+	instructions := g
+	if group := g.Find("shpinst"); group != nil {
+		instructions = group
+	}
+
+	shape := Shape{Properties: map[string]string{}, PictureIndex: -1}
+
+	for _, tkn := range instructions.tokens() {
+		if tkn.Kind != TokenKindControlWord {
+			continue
+		}
+
+		controlWord := controlWordFromToken(tkn)
+
+		switch controlWord.controlWordType {
+		case controlWordTypeShapeLeft:
+			shape.Left = controlWord.parameter
+		case controlWordTypeShapeTop:
+			shape.Top = controlWord.parameter
+		case controlWordTypeShapeRight:
+			shape.Right = controlWord.parameter
+		case controlWordTypeShapeBottom:
+			shape.Bottom = controlWord.parameter
+		case controlWordTypeShapeHorizontalAnchor:
+			if anchor, ok := shapeAnchorWords[strings.TrimPrefix(tkn.Name, "shpbx")]; ok {
+				shape.HorizontalAnchor = anchor
+			}
+		case controlWordTypeShapeVerticalAnchor:
+			if anchor, ok := shapeAnchorWords[strings.TrimPrefix(tkn.Name, "shpby")]; ok {
+				shape.VerticalAnchor = anchor
+			}
+		case controlWordTypeShapeWrap:
+			if wrap, ok := shapeWrapValues[controlWord.parameter]; ok {
+				shape.Wrap = wrap
+			}
+		case controlWordTypeShapeBehindText:
+			shape.BehindText = controlWord.parameter == 1
+		case controlWordTypeShapeZ:
+			shape.ZOrder = controlWord.parameter
+		case controlWordTypeShapeID:
+			shape.ID = controlWord.parameter
+		}
+	}
+
+	for _, property := range instructions.FindAll("sp") {
+		name, value := property.Find("sn"), property.Find("sv")
+		if name == nil || value == nil {
+			continue
+		}
+
+		key := strings.TrimSpace(r.textFromGroup(name, r.codePage))
+		if key == "pib" {
+			if picture := value.Find("pict"); picture != nil {
+				doc.Pictures = append(doc.Pictures, r.parsePicture(picture))
+				shape.PictureIndex = len(doc.Pictures) - 1
+			}
+			continue
+		}
+
+		shape.Properties[key] = r.textFromGroup(value, r.codePage)
+	}
+
+	// anchors ignored by the control words are given by properties
+	for property, anchor := range map[string]*ShapeAnchor{"posrelh": &shape.HorizontalAnchor, "posrelv": &shape.VerticalAnchor} {
+		if value, err := strconv.Atoi(shape.Properties[property]); err == nil && value >= 0 && value < len(shapeAnchorProperties[property]) {
+			*anchor = shapeAnchorProperties[property][value]
+		}
+	}
+
+	if text := instructions.Find("shptxt"); text != nil {
+		shape.Body = r.parseSubdocument(doc, text)
+	}
+
+	doc.Shapes = append(doc.Shapes, shape)
+	doc.pushToBody(StyleBlock{
+		Painter:    *r.lastPainter(),
+		Kind:       BlockKindShape,
+		ShapeIndex: len(doc.Shapes) - 1,
+	})
+}
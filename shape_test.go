@@ -0,0 +1,200 @@
+package gortf
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const shapesRTF = `{\rtf1\ansi\pard Logo ` +
+	`{\shp{\*\shpinst\shpleft100\shptop200\shpright1540\shpbottom920\shpfhdr0\shpbxcolumn\shpbxignore\shpbypara\shpbyignore` +
+	`\shpwr3\shpwrk0\shpfblwtxt1\shpz2\shplid1025` +
+	`{\sp{\sn shapeType}{\sv 75}}{\sp{\sn fLayoutInCell}{\sv 1}}{\sp{\sn posrelh}{\sv 3}}` +
+	`{\sp{\sn pib}{\sv {\pict\pngblip\picw1\pich1\picwgoal1440\pichgoal720 89504e470d0a1a0a}}}}` +
+	`{\shprslt{\*\do\dobxcolumn\dodhgt8192}}}` +
+	` and box {\shp{\*\shpinst\shpleft0\shptop0\shpright2880\shpbottom1440\shpbxmargin\shpbypage\shpwr1\shpz3\shplid1026` +
+	`{\sp{\sn shapeType}{\sv 202}}{\shptxt \pard\plain Inside {\b the} box\par}}}` +
+	`.\par}`
+
+func TestParseShapes(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(shapesRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRuns := []StyleBlock{
+		{Kind: BlockKindText, Text: "Logo "},
+		{Kind: BlockKindShape},
+		{Kind: BlockKindText, Text: " and box "},
+		{Kind: BlockKindShape, ShapeIndex: 1},
+		{Kind: BlockKindText, Text: "."},
+	}
+	actualRuns := doc.Paragraphs()[0].Runs
+	if !reflect.DeepEqual(expectedRuns, actualRuns) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedRuns, actualRuns)
+	}
+
+	expected := []Shape{
+		{
+			ID: 1025, Left: 100, Top: 200, Right: 1540, Bottom: 920,
+			HorizontalAnchor: ShapeAnchorCharacter,
+			VerticalAnchor:   ShapeAnchorParagraph,
+			Wrap:             ShapeWrapNone,
+			BehindText:       true,
+			ZOrder:           2,
+			Properties:       map[string]string{"shapeType": "75", "fLayoutInCell": "1", "posrelh": "3"},
+			PictureIndex:     0,
+		},
+		{
+			ID: 1026, Right: 2880, Bottom: 1440,
+			HorizontalAnchor: ShapeAnchorMargin,
+			VerticalAnchor:   ShapeAnchorPage,
+			Wrap:             ShapeWrapTopBottom,
+			ZOrder:           3,
+			Properties:       map[string]string{"shapeType": "202"},
+			PictureIndex:     -1,
+			Body: []StyleBlock{
+				{Kind: BlockKindText, Text: "Inside "},
+				{Kind: BlockKindText, Text: "the", Painter: Painter{Bold: true}},
+				{Kind: BlockKindText, Text: " box"},
+				{Kind: BlockKindParagraph, Text: "\n", Paragraph: &ParagraphFormat{}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, doc.Shapes) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Shapes)
+	}
+
+	if len(doc.Pictures) != 1 || doc.Pictures[0].Format != PictureFormatPNG {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "a PNG picture", doc.Pictures)
+	}
+	if width, height := doc.Shapes[0].Width(), doc.Shapes[0].Height(); width != 1440 || height != 720 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "1440x720", []int{width, height})
+	}
+	if doc.Shapes[0].IsTextBox() || !doc.Shapes[1].IsTextBox() {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", []bool{false, true}, []bool{doc.Shapes[0].IsTextBox(), doc.Shapes[1].IsTextBox()})
+	}
+
+	if text, _ := doc.ToText(); text != "Logo  and box Inside the box.\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "Logo  and box Inside the box.\n", text)
+	}
+}
+
+func TestWriteShapes(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(shapesRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser = NewRtfParser()
+	written, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Shapes, written.Shapes) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Shapes, written.Shapes)
+	}
+	if !reflect.DeepEqual(doc.Pictures, written.Pictures) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Pictures, written.Pictures)
+	}
+}
+
+func TestExportShapes(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(shapesRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docx, err := doc.ToDOCX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := archiveFile(t, docx, "word/document.xml")
+	for _, expected := range []string{
+		`<v:shape id="Shape1" style="position:absolute;margin-left:5pt;margin-top:10pt;width:72pt;height:36pt;z-index:-3;` +
+			`mso-position-horizontal-relative:char;mso-position-vertical-relative:text" stroked="f"><v:imagedata r:id="rId`,
+		`<w:txbxContent><w:p><w:r><w:t xml:space="preserve">Inside </w:t></w:r>`,
+		`<w10:wrap type="topAndBottom"></w10:wrap>`,
+	} {
+		if !strings.Contains(document, expected) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, document)
+		}
+	}
+
+	odt, err := doc.ToODT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := archiveFile(t, odt, "content.xml")
+	for _, expected := range []string{
+		`<draw:image xlink:href="Pictures/image1.png"`,
+		`<draw:text-box><text:p`,
+		`style:wrap="none" style:run-through="foreground" style:horizontal-pos="from-left" style:horizontal-rel="page-content"`,
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, content)
+		}
+	}
+}
+
+func TestExportShapesAsText(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(`{\rtf1\ansi\pard Logo ` +
+		`{\shp{\*\shpinst\shpright1440\shpbottom720{\sp{\sn pib}{\sv {\pict\pngblip\picw1\pich1 89504e47}}}}}` +
+		` and {\shp{\*\shpinst{\shptxt \pard First {\b line}\par\pard Second\par}}}.\par}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image := "data:image/png;base64,iVBORw=="
+	for _, test := range []struct {
+		export   func() (string, error)
+		expected string
+	}{
+		{doc.ToText, "Logo  and First line\nSecond.\n"},
+		{doc.ToHTML, `Logo <img src="` + image + `" width="1" height="1" alt=""> and First <bold>line</bold>` + "\nSecond.\n"},
+		{doc.ToMarkdown, "Logo ![](" + image + ") and First **line**\n\nSecond.\n"},
+	} {
+		actual, err := test.export()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.expected != actual {
+			t.Errorf("\n\nexpected: %q\n\nactual\t: %q", test.expected, actual)
+		}
+	}
+}
+
+// archiveFile returns the content of a file of a ZIP archive.
+func archiveFile(t *testing.T, data []byte, name string) string {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := archive.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
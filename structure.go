@@ -0,0 +1,300 @@
+package gortf
+
+// BodyElement is an element of a section: either a *Paragraph or a *Table.
+type BodyElement interface {
+	isBodyElement()
+}
+
+func (p *Paragraph) isBodyElement() {}
+
+func (t *Table) isBodyElement() {}
+
+// Section is a part of the body ended by a section mark.
+type Section struct {
+	Format   SectionFormat
+	Elements []BodyElement
+
+	// Mark is the formatting of the section mark, nil for the last section
+	// of a document, which usually has none.
+	Mark *Painter
+}
+
+// Paragraph is a sequence of runs ended by a paragraph mark, or by a cell
+// mark for the last paragraph of a table cell. Runs are the text, line
+// break, page break and picture blocks of the paragraph.
+type Paragraph struct {
+	Format ParagraphFormat `json:"format"`
+	Runs   []StyleBlock    `json:"runs,omitempty"`
+
+	// Mark is the formatting of the mark ending the paragraph, nil if it
+	// has none.
+	Mark *Painter `json:"mark,omitempty"`
+}
+
+// Table is a sequence of consecutive table rows.
+type Table struct {
+	Rows []TableRow `json:"rows"`
+}
+
+// TableRow is a table row ended by a row mark.
+type TableRow struct {
+	Format RowFormat   `json:"format"`
+	Cells  []TableCell `json:"cells"`
+
+	// Mark is the formatting of the row mark, nil if the row has none.
+	Mark *Painter `json:"mark,omitempty"`
+}
+
+// TableCell holds the paragraphs of a table cell, the last of which is ended
+// by the cell mark.
+type TableCell struct {
+	Paragraphs []Paragraph `json:"paragraphs"`
+}
+
+// Sections groups the body of the document into sections, paragraphs and
+// tables. The body can be rebuilt from them with SetSections.
+func (r *RtfDocument) Sections() []Section {
+	return sectionsOf(r.Body)
+}
+
+// sectionsOf groups a body, or the content of a footnote or header, into
+// sections.
+func sectionsOf(body []StyleBlock) []Section {
+	builder := sectionBuilder{}
+
+	for _, block := range body {
+		builder.add(block)
+	}
+
+	return builder.finish()
+}
+
+// SetSections replaces the body of the document with the content of
+// sections.
+func (r *RtfDocument) SetSections(sections []Section) {
+	r.Body = bodyFromSections(sections)
+}
+
+// Paragraphs returns every paragraph of the document in order, including
+// those of table cells.
+func (r *RtfDocument) Paragraphs() []Paragraph {
+	paragraphs := []Paragraph{}
+
+	for _, section := range r.Sections() {
+		for _, element := range section.Elements {
+			switch e := element.(type) {
+			case *Paragraph:
+				paragraphs = append(paragraphs, *e)
+			case *Table:
+				for _, row := range e.Rows {
+					for _, cell := range row.Cells {
+						paragraphs = append(paragraphs, cell.Paragraphs...)
+					}
+				}
+			}
+		}
+	}
+
+	return paragraphs
+}
+
+// sectionBuilder groups the blocks of a body as they come.
+type sectionBuilder struct {
+	sections []Section
+	current  Section
+
+	// runs of the paragraph in progress
+	runs []StyleBlock
+
+	// paragraphs of the cell in progress and cells of the row in progress
+	cellParagraphs []Paragraph
+	cells          []TableCell
+}
+
+func (b *sectionBuilder) add(block StyleBlock) {
+	switch block.Kind {
+	case BlockKindParagraph:
+		paragraph := b.takeParagraph(block)
+		if paragraph.Format.InTable {
+			b.cellParagraphs = append(b.cellParagraphs, paragraph)
+			return
+		}
+
+		b.flushTable()
+		b.current.Elements = append(b.current.Elements, &paragraph)
+
+	case BlockKindCell:
+		paragraph := b.takeParagraph(block)
+		b.cells = append(b.cells, TableCell{
+			Paragraphs: append(b.cellParagraphs, paragraph),
+		})
+		b.cellParagraphs = nil
+
+	case BlockKindRow:
+		if len(b.runs) > 0 || len(b.cellParagraphs) > 0 {
+			// a cell left without its cell mark
+			b.cells = append(b.cells, TableCell{Paragraphs: b.takeCellParagraphs()})
+		}
+
+		row := TableRow{Cells: b.cells, Mark: painterOf(block)}
+		if block.Row != nil {
+			row.Format = block.Row.clone()
+		}
+		b.cells = nil
+		b.addRow(row)
+
+	case BlockKindSection:
+		b.flush()
+		if block.Section != nil {
+			b.current.Format = *block.Section
+		}
+		b.current.Mark = painterOf(block)
+		b.sections = append(b.sections, b.current)
+		b.current = Section{}
+
+	default:
+		b.runs = append(b.runs, block)
+	}
+}
+
+func painterOf(block StyleBlock) *Painter {
+	painter := block.Painter
+	return &painter
+}
+
+// takeParagraph returns the paragraph in progress, ended by block.
+func (b *sectionBuilder) takeParagraph(block StyleBlock) Paragraph {
+	paragraph := Paragraph{Runs: b.runs, Mark: painterOf(block)}
+	if block.Paragraph != nil {
+		paragraph.Format = *block.Paragraph
+	}
+	b.runs = nil
+
+	return paragraph
+}
+
+// takeCellParagraphs returns the paragraphs of the cell in progress,
+// including a last paragraph without mark if there are pending runs.
+func (b *sectionBuilder) takeCellParagraphs() []Paragraph {
+	paragraphs := b.cellParagraphs
+	if len(b.runs) > 0 {
+		paragraphs = append(paragraphs, Paragraph{Runs: b.runs})
+	}
+	b.cellParagraphs = nil
+	b.runs = nil
+
+	return paragraphs
+}
+
+func (b *sectionBuilder) addRow(row TableRow) {
+	elements := b.current.Elements
+	if len(elements) > 0 {
+		if table, ok := elements[len(elements)-1].(*Table); ok {
+			table.Rows = append(table.Rows, row)
+			return
+		}
+	}
+
+	b.current.Elements = append(elements, &Table{Rows: []TableRow{row}})
+}
+
+// flushTable ends the row in progress when content outside of the table
+// follows it. Paragraphs of a cell left without its cell mark are kept as
+// ordinary paragraphs.
+func (b *sectionBuilder) flushTable() {
+	if len(b.cells) > 0 {
+		b.addRow(TableRow{Cells: b.cells})
+		b.cells = nil
+	}
+
+	for i := range b.cellParagraphs {
+		b.current.Elements = append(b.current.Elements, &b.cellParagraphs[i])
+	}
+	b.cellParagraphs = nil
+}
+
+// flush ends everything in progress in the current section.
+func (b *sectionBuilder) flush() {
+	b.flushTable()
+
+	if len(b.runs) > 0 {
+		b.current.Elements = append(b.current.Elements, &Paragraph{Runs: b.runs})
+		b.runs = nil
+	}
+}
+
+func (b *sectionBuilder) finish() []Section {
+	b.flush()
+
+	if len(b.current.Elements) > 0 || len(b.sections) == 0 {
+		b.sections = append(b.sections, b.current)
+	}
+
+	return b.sections
+}
+
+// bodyFromSections is the reverse of RtfDocument.Sections.
+func bodyFromSections(sections []Section) []StyleBlock {
+	body := []StyleBlock{}
+
+	for _, section := range sections {
+		for _, element := range section.Elements {
+			switch e := element.(type) {
+			case *Paragraph:
+				body = appendParagraph(body, *e, BlockKindParagraph)
+			case *Table:
+				for _, row := range e.Rows {
+					for _, cell := range row.Cells {
+						for i, paragraph := range cell.Paragraphs {
+							kind := BlockKindParagraph
+							if i == len(cell.Paragraphs)-1 {
+								kind = BlockKindCell
+							}
+							body = appendParagraph(body, paragraph, kind)
+						}
+					}
+
+					if row.Mark != nil {
+						format := row.Format.clone()
+						body = append(body, StyleBlock{
+							Painter: *row.Mark,
+							Text:    blockKindText(BlockKindRow),
+							Kind:    BlockKindRow,
+							Row:     &format,
+						})
+					}
+				}
+			}
+		}
+
+		if section.Mark != nil {
+			format := section.Format
+			body = append(body, StyleBlock{
+				Painter: *section.Mark,
+				Text:    blockKindText(BlockKindSection),
+				Kind:    BlockKindSection,
+				Section: &format,
+			})
+		}
+	}
+
+	return body
+}
+
+// appendParagraph appends the runs of a paragraph to a body, followed by its
+// mark of the given kind if it has one.
+func appendParagraph(body []StyleBlock, paragraph Paragraph, kind BlockKind) []StyleBlock {
+	body = append(body, paragraph.Runs...)
+
+	if paragraph.Mark != nil {
+		format := paragraph.Format
+		body = append(body, StyleBlock{
+			Painter:   *paragraph.Mark,
+			Text:      blockKindText(kind),
+			Kind:      kind,
+			Paragraph: &format,
+		})
+	}
+
+	return body
+}
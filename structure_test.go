@@ -0,0 +1,53 @@
+package gortf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSections(t *testing.T) {
+	content := `{\rtf1\ansi\pard Intro\par`
+	content += `\trowd\cellx1000\cellx2000\pard\intbl A\cell B\cell\row`
+	content += `\sect\sectd\sbknone\pard\qr End}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := func(s string) StyleBlock { return StyleBlock{Text: s} }
+	inTable := ParagraphFormat{InTable: true}
+
+	expected := []Section{
+		{
+			Elements: []BodyElement{
+				&Paragraph{Runs: []StyleBlock{text("Intro")}, Mark: &Painter{}},
+				&Table{Rows: []TableRow{{
+					Format: RowFormat{Cells: []CellFormat{{Right: 1000}, {Right: 2000}}},
+					Cells: []TableCell{
+						{Paragraphs: []Paragraph{{Format: inTable, Runs: []StyleBlock{text("A")}, Mark: &Painter{}}}},
+						{Paragraphs: []Paragraph{{Format: inTable, Runs: []StyleBlock{text("B")}, Mark: &Painter{}}}},
+					},
+					Mark: &Painter{},
+				}}},
+			},
+			Mark: &Painter{},
+		},
+		{
+			Elements: []BodyElement{
+				&Paragraph{Runs: []StyleBlock{text("End")}},
+			},
+		},
+	}
+
+	actual := doc.Sections()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	body := bodyFromSections(actual)
+	if !reflect.DeepEqual(body, doc.Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Body, body)
+	}
+}
@@ -0,0 +1,123 @@
+package gortf
+
+// Footnote is the content of a {\footnote} group, referred to from the body
+// by a block of kind BlockKindFootnote.
+type Footnote struct {
+	// Endnote is set for notes placed at the end of the document (\ftnalt).
+	Endnote bool
+	Body    []StyleBlock
+}
+
+// HeaderFooterKind tells where a header or footer is used.
+type HeaderFooterKind int
+
+const (
+	// HeaderFooterKindHeader is the header of every page (\header).
+	HeaderFooterKindHeader HeaderFooterKind = iota
+	HeaderFooterKindHeaderLeft
+	HeaderFooterKindHeaderRight
+	HeaderFooterKindHeaderFirst
+	// HeaderFooterKindFooter is the footer of every page (\footer).
+	HeaderFooterKindFooter
+	HeaderFooterKindFooterLeft
+	HeaderFooterKindFooterRight
+	HeaderFooterKindFooterFirst
+)
+
+func (h HeaderFooterKind) String() string {
+	switch h {
+	case HeaderFooterKindHeader:
+		return "Header"
+	case HeaderFooterKindHeaderLeft:
+		return "HeaderLeft"
+	case HeaderFooterKindHeaderRight:
+		return "HeaderRight"
+	case HeaderFooterKindHeaderFirst:
+		return "HeaderFirst"
+	case HeaderFooterKindFooter:
+		return "Footer"
+	case HeaderFooterKindFooterLeft:
+		return "FooterLeft"
+	case HeaderFooterKindFooterRight:
+		return "FooterRight"
+	case HeaderFooterKindFooterFirst:
+		return "FooterFirst"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsFooter reports whether the kind is one of the footers.
+func (h HeaderFooterKind) IsFooter() bool {
+	return h >= HeaderFooterKindFooter
+}
+
+var headerFooterKinds = map[string]HeaderFooterKind{
+	"header": HeaderFooterKindHeader, "headerl": HeaderFooterKindHeaderLeft,
+	"headerr": HeaderFooterKindHeaderRight, "headerf": HeaderFooterKindHeaderFirst,
+	"footer": HeaderFooterKindFooter, "footerl": HeaderFooterKindFooterLeft,
+	"footerr": HeaderFooterKindFooterRight, "footerf": HeaderFooterKindFooterFirst,
+}
+
+// HeaderFooter is the content of a header or footer group.
+type HeaderFooter struct {
+	Kind HeaderFooterKind
+
+	// Section is the index of the section the header or footer was defined
+	// in. Like in RTF, it applies to the following sections too until they
+	// define one of the same kind.
+	Section int
+
+	Body []StyleBlock
+}
+
+// parseSubdocument parses the content of a destination such as a footnote
+// or a header, which has its own paragraphs and tables, apart from the body.
+func (r *RtfParser) parseSubdocument(doc *RtfDocument, g *Group) []StyleBlock {
+	body, row, cell := doc.Body, r.row, r.cell
+
+	doc.Body = nil
+	r.row, r.cell = RowFormat{}, CellFormat{}
+	r.pushParagraph(ParagraphFormat{})
+	r.parseBody(doc, g)
+	r.popParagraph()
+
+	content := doc.Body
+	doc.Body, r.row, r.cell = body, row, cell
+
+	return content
+}
+
+func (r *RtfParser) addFootnote(doc *RtfDocument, g *Group) {
+	// the number written before the note is its reference
+	if len(doc.Body) > 0 && doc.Body[len(doc.Body)-1].Kind == BlockKindNoteNumber {
+		doc.popFromBody()
+	}
+
+	_, endnote := g.ControlWord("ftnalt")
+	doc.Footnotes = append(doc.Footnotes, Footnote{
+		Endnote: endnote,
+		Body:    r.parseSubdocument(doc, g),
+	})
+
+	doc.pushToBody(StyleBlock{
+		Painter:       *r.lastPainter(),
+		Kind:          BlockKindFootnote,
+		FootnoteIndex: len(doc.Footnotes) - 1,
+	})
+}
+
+func (r *RtfParser) addHeaderFooter(doc *RtfDocument, g *Group, kind HeaderFooterKind) {
+	section := 0
+	for _, block := range doc.Body {
+		if block.Kind == BlockKindSection {
+			section++
+		}
+	}
+
+	doc.HeadersFooters = append(doc.HeadersFooters, HeaderFooter{
+		Kind:    kind,
+		Section: section,
+		Body:    r.parseSubdocument(doc, g),
+	})
+}
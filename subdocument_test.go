@@ -0,0 +1,97 @@
+package gortf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSubdocuments(t *testing.T) {
+	content := `{\rtf1\ansi{\headerl\pard Left\par}{\footer\pard\qc Page\par}` +
+		`\pard\b Text{\super\chftn}{\footnote\pard\plain A note.\par}\b0  and{\footnote\ftnalt\pard End\par}\par` +
+		`\sect{\header\pard Second\par}\pard More\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paragraph := func(format ParagraphFormat, text string) []StyleBlock {
+		return []StyleBlock{
+			{Text: text},
+			{Text: "\n", Kind: BlockKindParagraph, Paragraph: &format},
+		}
+	}
+
+	expectedFootnotes := []Footnote{
+		{Body: paragraph(ParagraphFormat{}, "A note.")},
+		{Endnote: true, Body: paragraph(ParagraphFormat{}, "End")},
+	}
+	if !reflect.DeepEqual(doc.Footnotes, expectedFootnotes) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedFootnotes, doc.Footnotes)
+	}
+
+	expectedHeadersFooters := []HeaderFooter{
+		{Kind: HeaderFooterKindHeaderLeft, Body: paragraph(ParagraphFormat{}, "Left")},
+		{Kind: HeaderFooterKindFooter, Body: paragraph(ParagraphFormat{Alignment: AlignmentCenter}, "Page")},
+		{Kind: HeaderFooterKindHeader, Section: 1, Body: paragraph(ParagraphFormat{}, "Second")},
+	}
+	if !reflect.DeepEqual(doc.HeadersFooters, expectedHeadersFooters) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedHeadersFooters, doc.HeadersFooters)
+	}
+
+	runs := doc.Paragraphs()[0].Runs
+	expectedRuns := []StyleBlock{
+		{Painter: Painter{Bold: true}, Text: "Text"},
+		{Painter: Painter{Bold: true}, Kind: BlockKindFootnote, FootnoteIndex: 0},
+		{Text: " and"},
+		{Kind: BlockKindFootnote, FootnoteIndex: 1},
+	}
+	if !reflect.DeepEqual(runs, expectedRuns) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedRuns, runs)
+	}
+}
+
+func TestWriteFootnoteNumbers(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(`{\rtf1\ansi\pard Text{\footnote\pard A note.\par}\par}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `Text{\super\chftn}{\footnote\pard{\super\chftn}A note.\par}`
+	if !strings.Contains(content, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, content)
+	}
+
+	written, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRuns := []StyleBlock{{Text: "Text"}, {Kind: BlockKindFootnote}}
+	if runs := written.Paragraphs()[0].Runs; !reflect.DeepEqual(expectedRuns, runs) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedRuns, runs)
+	}
+	expectedNote := []StyleBlock{
+		{Painter: Painter{Superscript: true}, Kind: BlockKindNoteNumber},
+		{Text: "A note."},
+		{Text: "\n", Kind: BlockKindParagraph, Paragraph: &ParagraphFormat{}},
+	}
+	if !reflect.DeepEqual(expectedNote, written.Footnotes[0].Body) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedNote, written.Footnotes[0].Body)
+	}
+
+	// notes keep their number where it is
+	again, err := written.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != content {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", content, again)
+	}
+}
@@ -0,0 +1,231 @@
+package gortf
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultTabWidth is the distance between default tab stops in twips when
+// the document gives none (\deftab).
+const defaultTabWidth = 720
+
+// TabAlignment tells how text is aligned on a tab stop.
+type TabAlignment int
+
+const (
+	// TabAlignmentLeft starts the text at the tab stop.
+	TabAlignmentLeft TabAlignment = iota
+	// TabAlignmentCenter centers the text on the tab stop (\tqc), and
+	// TabAlignmentRight ends it there (\tqr).
+	TabAlignmentCenter
+	TabAlignmentRight
+	// TabAlignmentDecimal aligns the decimal point of the text on the tab
+	// stop (\tqdec).
+	TabAlignmentDecimal
+	// TabAlignmentBar draws a vertical bar at the tab stop, which text does
+	// not stop at (\tb).
+	TabAlignmentBar
+)
+
+func (t TabAlignment) String() string {
+	switch t {
+	case TabAlignmentLeft:
+		return "Left"
+	case TabAlignmentCenter:
+		return "Center"
+	case TabAlignmentRight:
+		return "Right"
+	case TabAlignmentDecimal:
+		return "Decimal"
+	case TabAlignmentBar:
+		return "Bar"
+	default:
+		return "Unknown"
+	}
+}
+
+var tabAlignmentWords = map[TabAlignment]string{
+	TabAlignmentCenter: "tqc", TabAlignmentRight: "tqr", TabAlignmentDecimal: "tqdec",
+}
+
+// TabLeader is what fills the space before a tab stop.
+type TabLeader int
+
+const (
+	TabLeaderNone TabLeader = iota
+	// TabLeaderDot fills with dots (\tldot), and TabLeaderMiddleDot with
+	// centered dots (\tlmdot).
+	TabLeaderDot
+	TabLeaderMiddleDot
+	// TabLeaderHyphen fills with hyphens (\tlhyph).
+	TabLeaderHyphen
+	// TabLeaderUnderline and TabLeaderThickLine fill with a line (\tlul,
+	// \tlth).
+	TabLeaderUnderline
+	TabLeaderThickLine
+	// TabLeaderEqual fills with a double line (\tleq).
+	TabLeaderEqual
+)
+
+func (t TabLeader) String() string {
+	switch t {
+	case TabLeaderNone:
+		return "None"
+	case TabLeaderDot:
+		return "Dot"
+	case TabLeaderMiddleDot:
+		return "MiddleDot"
+	case TabLeaderHyphen:
+		return "Hyphen"
+	case TabLeaderUnderline:
+		return "Underline"
+	case TabLeaderThickLine:
+		return "ThickLine"
+	case TabLeaderEqual:
+		return "Equal"
+	default:
+		return "Unknown"
+	}
+}
+
+var tabLeaderWords = map[TabLeader]string{
+	TabLeaderDot: "tldot", TabLeaderMiddleDot: "tlmdot", TabLeaderHyphen: "tlhyph",
+	TabLeaderUnderline: "tlul", TabLeaderThickLine: "tlth", TabLeaderEqual: "tleq",
+}
+
+// tabLeaderCharacters are the characters leaders are drawn with in plain
+// text.
+var tabLeaderCharacters = map[TabLeader]string{
+	TabLeaderNone: " ", TabLeaderDot: ".", TabLeaderMiddleDot: "·", TabLeaderHyphen: "-",
+	TabLeaderUnderline: "_", TabLeaderThickLine: "_", TabLeaderEqual: "=",
+}
+
+// TabStop is a tab stop of a paragraph, given by its alignment and leader
+// followed by \tx, or by \tb for bar tabs.
+type TabStop struct {
+	// Position is the distance of the tab stop from the left margin in
+	// twips.
+	Position  int          `json:"position"`
+	Alignment TabAlignment `json:"alignment,omitempty"`
+	Leader    TabLeader    `json:"leader,omitempty"`
+}
+
+// applyTabStop updates the tab stops of a paragraph format with a tab
+// control word. The alignment and leader of a tab stop come before its
+// position, and are kept in the format until then.
+func applyTabStop(paragraph *ParagraphFormat, controlWord controlWordToken) {
+	switch controlWord.controlWordType {
+	case controlWordTypeTabAlignment:
+		for alignment, word := range tabAlignmentWords {
+			if controlWord.name == `\`+word {
+				paragraph.nextTab.Alignment = alignment
+			}
+		}
+	case controlWordTypeTabLeader:
+		for leader, word := range tabLeaderWords {
+			if controlWord.name == `\`+word {
+				paragraph.nextTab.Leader = leader
+			}
+		}
+	case controlWordTypeTabPosition, controlWordTypeBarTab:
+		tab := paragraph.nextTab
+		tab.Position = controlWord.parameter
+		if controlWord.controlWordType == controlWordTypeBarTab {
+			tab.Alignment = TabAlignmentBar
+		}
+
+		// the tab stops are shared with the copies of the format made for
+		// enclosing groups
+		paragraph.Tabs = append(paragraph.Tabs[:len(paragraph.Tabs):len(paragraph.Tabs)], tab)
+		paragraph.nextTab = TabStop{}
+	}
+}
+
+// tabExpander replaces the tabs of the text of a paragraph with spaces or
+// leaders, as a monospaced font of the given character width would show
+// them.
+type tabExpander struct {
+	characterWidth int
+	defaultTab     int
+}
+
+// expand returns the text of a paragraph with its tabs expanded. Lines start
+// at the indents of the paragraph, and tabs past its last tab stop go to the
+// next default tab stop.
+func (t tabExpander) expand(text string, format ParagraphFormat) string {
+	if !strings.Contains(text, "\t") {
+		return text
+	}
+
+	// a hanging indent stops tabs at the left indent too
+	stops := []TabStop{}
+	for _, tab := range format.Tabs {
+		if tab.Alignment != TabAlignmentBar {
+			stops = append(stops, tab)
+		}
+	}
+	if format.FirstLineIndent < 0 {
+		stops = append(stops, TabStop{Position: format.LeftIndent})
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		start := format.LeftIndent
+		if i == 0 {
+			start += format.FirstLineIndent
+		}
+		lines[i] = t.expandLine(line, start, stops)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (t tabExpander) expandLine(line string, start int, stops []TabStop) string {
+	segments := strings.Split(line, "\t")
+
+	var sb strings.Builder
+	sb.WriteString(segments[0])
+	column := utf8.RuneCountInString(segments[0])
+
+	for _, segment := range segments[1:] {
+		position := start + column*t.characterWidth
+		stop := t.nextStop(position, stops)
+
+		// the text following the tab up to the next one is placed on the
+		// stop according to its alignment
+		length := utf8.RuneCountInString(segment)
+		before := 0
+		switch stop.Alignment {
+		case TabAlignmentCenter:
+			before = length / 2
+		case TabAlignmentRight:
+			before = length
+		case TabAlignmentDecimal:
+			before = length
+			if point := strings.IndexAny(segment, ".,"); point >= 0 {
+				before = utf8.RuneCountInString(segment[:point])
+			}
+		}
+
+		fill := max((stop.Position-position+t.characterWidth-1)/t.characterWidth-before, 1)
+		sb.WriteString(strings.Repeat(tabLeaderCharacters[stop.Leader], fill))
+		sb.WriteString(segment)
+		column += fill + length
+	}
+
+	return sb.String()
+}
+
+// nextStop returns the first tab stop past a position, or the default tab
+// stop following it.
+func (t tabExpander) nextStop(position int, stops []TabStop) TabStop {
+	next := TabStop{Position: (position/t.defaultTab + 1) * t.defaultTab}
+	found := false
+	for _, stop := range stops {
+		if stop.Position > position && (!found || stop.Position < next.Position) {
+			next, found = stop, true
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,106 @@
+package gortf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const tabsRTF = `{\rtf1\ansi\deftab360 ` +
+	`\pard\tqr\tldot\tx2400\tb3000\tqdec\tx3600 Chapter\tab 12\tab 3.5\par` +
+	`\pard No\tab stops\par` +
+	`\pard\li720\fi-720 1.\tab Item\line\tab more\par}`
+
+func TestParseTabStops(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(tabsRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Header.DefaultTab != 360 || doc.Header.TabWidth() != 360 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 360, doc.Header.DefaultTab)
+	}
+	if width := (RtfHeader{}).TabWidth(); width != 720 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 720, width)
+	}
+
+	expected := []TabStop{
+		{Position: 2400, Alignment: TabAlignmentRight, Leader: TabLeaderDot},
+		{Position: 3000, Alignment: TabAlignmentBar},
+		{Position: 3600, Alignment: TabAlignmentDecimal},
+	}
+	paragraphs := doc.Paragraphs()
+	if !reflect.DeepEqual(expected, paragraphs[0].Format.Tabs) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, paragraphs[0].Format.Tabs)
+	}
+	if paragraphs[1].Format.Tabs != nil {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", nil, paragraphs[1].Format.Tabs)
+	}
+
+	if text, _ := doc.ToText(); text != "Chapter\t12\t3.5\nNo\tstops\n1.\tItem\n\tmore\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "Chapter\t12\t3.5\nNo\tstops\n1.\tItem\n\tmore\n", text)
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(tabsRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Chapter...........12         3.5\n" +
+		"No stops\n" +
+		"1.    Item\n" +
+		"   more\n"
+	actual, err := doc.ToTextWithOptions(TextOptions{CharacterWidth: 120})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected != actual {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", expected, actual)
+	}
+
+	if actual, _ := doc.ToTextWithOptions(TextOptions{}); actual != "Chapter\t12\t3.5\nNo\tstops\n1.\tItem\n\tmore\n" {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", "tabs left alone", actual)
+	}
+}
+
+func TestWriteTabStops(t *testing.T) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(tabsRTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := doc.ToRTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.Header.DefaultTab != 360 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 360, written.Header.DefaultTab)
+	}
+	if !reflect.DeepEqual(doc.Paragraphs()[0].Format, written.Paragraphs()[0].Format) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Paragraphs()[0].Format, written.Paragraphs()[0].Format)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded RtfDocument
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Header.DefaultTab != 360 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 360, decoded.Header.DefaultTab)
+	}
+	if !reflect.DeepEqual(doc.Paragraphs()[0].Format.Tabs, decoded.Paragraphs()[0].Format.Tabs) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", doc.Paragraphs()[0].Format.Tabs, decoded.Paragraphs()[0].Format.Tabs)
+	}
+}
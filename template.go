@@ -0,0 +1,632 @@
+package gortf
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// FillTemplate returns a copy of a document with its placeholders and merge
+// fields replaced with values from data, a map with string keys or a struct.
+//
+// Placeholders are written {{name}}, and may be split across runs of
+// different formatting, as word processors often do; the value takes the
+// formatting of the first run. Names may be paths such as {{customer.name}},
+// and {{.}} stands for the current value. MERGEFIELD fields are replaced the
+// same way with the value of the name they merge.
+//
+// Sections are written {{#name}}...{{/name}}: they are repeated for each item
+// of slices, with the item as the current value, kept once for other values
+// that are not empty, and left out for empty ones, which makes them
+// conditional. Inverted sections, written {{^name}}...{{/name}}, are kept only
+// for empty values. The MERGEFIELD fields TableStart:name and TableEnd:name
+// delimit sections too. Sections within a paragraph repeat part of it.
+// Sections spanning paragraphs repeat the paragraphs, and the tables, from
+// the one starting the section to the one ending it, the paragraphs holding
+// nothing but the delimiters being left out. Sections spanning the cells of
+// table rows repeat the rows.
+func FillTemplate(r *RtfDocument, data interface{}) (RtfDocument, error) {
+	doc := *r
+	t := &templateFiller{}
+	context := []reflect.Value{reflect.ValueOf(data)}
+
+	body, err := t.body(r.Body, context)
+	if err != nil {
+		return RtfDocument{}, err
+	}
+	doc.Body = body
+
+	doc.Footnotes = append([]Footnote{}, r.Footnotes...)
+	for i := range doc.Footnotes {
+		if doc.Footnotes[i].Body, err = t.body(doc.Footnotes[i].Body, context); err != nil {
+			return RtfDocument{}, err
+		}
+	}
+
+	doc.HeadersFooters = append([]HeaderFooter{}, r.HeadersFooters...)
+	for i := range doc.HeadersFooters {
+		if doc.HeadersFooters[i].Body, err = t.body(doc.HeadersFooters[i].Body, context); err != nil {
+			return RtfDocument{}, err
+		}
+	}
+
+	return doc, nil
+}
+
+// FillTemplateRTF fills an RTF template, returning the RTF of the document
+// filled. See FillTemplate.
+func FillTemplateRTF(content string, data interface{}) (string, error) {
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		return "", err
+	}
+
+	filled, err := FillTemplate(&doc, data)
+	if err != nil {
+		return "", err
+	}
+
+	return DocumentToRTF(&filled)
+}
+
+var templateTag = regexp.MustCompile(`\{\{\s*([#^/]?)\s*([^{}]*?)\s*\}\}`)
+
+// templateTagOf returns the kind, "" for values or one of "#", "^" and "/"
+// for sections, and the name of the tag a run is.
+func templateTagOf(run StyleBlock) (string, string, bool) {
+	if run.Kind != BlockKindText {
+		return "", "", false
+	}
+
+	match := templateTag.FindStringSubmatch(run.Text)
+	if match == nil || len(match[0]) != len(run.Text) {
+		return "", "", false
+	}
+
+	return match[1], match[2], true
+}
+
+type templateFiller struct{}
+
+// body fills the content of a body, a note or a header.
+func (t *templateFiller) body(body []StyleBlock, context []reflect.Value) ([]StyleBlock, error) {
+	sections := sectionsOf(body)
+
+	for i := range sections {
+		elements, err := t.elements(copyElements(sections[i].Elements), context)
+		if err != nil {
+			return nil, err
+		}
+		sections[i].Elements = elements
+	}
+
+	return bodyFromSections(sections), nil
+}
+
+// templatePosition is the position of a tag among paragraphs, or the cells
+// of table rows.
+type templatePosition struct {
+	element   int
+	cell      int
+	paragraph int
+	run       int
+	kind      string
+	name      string
+}
+
+// elements fills paragraphs and tables, repeating those of sections that
+// span several of them.
+func (t *templateFiller) elements(elements []BodyElement, context []reflect.Value) ([]BodyElement, error) {
+	positions := []templatePosition{}
+	for e, element := range elements {
+		if paragraph, ok := element.(*Paragraph); ok {
+			for r, run := range normalizeTemplateRuns(paragraph) {
+				if kind, name, ok := templateTagOf(run); ok && kind != "" {
+					positions = append(positions, templatePosition{element: e, run: r, kind: kind, name: name})
+				}
+			}
+		}
+	}
+
+	filled := []BodyElement{}
+	for e := 0; e < len(elements); e++ {
+		start, end, err := spanningSection(positions, e, func(p templatePosition) int { return p.element })
+		if err != nil {
+			return nil, err
+		}
+
+		if start == nil {
+			switch element := elements[e].(type) {
+			case *Paragraph:
+				runs, err := t.runs(element.Runs, context)
+				if err != nil {
+					return nil, err
+				}
+				element.Runs = runs
+				filled = append(filled, element)
+			case *Table:
+				table, err := t.table(element, context)
+				if err != nil {
+					return nil, err
+				}
+				filled = append(filled, table)
+			}
+			continue
+		}
+
+		// remove the delimiters, and the paragraphs holding nothing else
+		section := elements[start.element : end.element+1]
+		last := section[len(section)-1].(*Paragraph)
+		last.Runs = removeRun(last.Runs, end.run)
+		first := section[0].(*Paragraph)
+		first.Runs = removeRun(first.Runs, start.run)
+		if len(section) > 1 && isBlankParagraph(last) {
+			section = section[:len(section)-1]
+		}
+		if isBlankParagraph(first) {
+			section = section[1:]
+		}
+
+		err = t.section(*start, context, func(context []reflect.Value) error {
+			elements, err := t.elements(copyElements(section), context)
+			filled = append(filled, elements...)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		e = end.element
+	}
+
+	return filled, nil
+}
+
+// table fills the rows of a table, repeating those of sections that span
+// several cells.
+func (t *templateFiller) table(table *Table, context []reflect.Value) (*Table, error) {
+	positions := []templatePosition{}
+	for r, row := range table.Rows {
+		for c, cell := range row.Cells {
+			for p := range cell.Paragraphs {
+				for i, run := range normalizeTemplateRuns(&cell.Paragraphs[p]) {
+					if kind, name, ok := templateTagOf(run); ok && kind != "" {
+						positions = append(positions, templatePosition{element: r, cell: c, paragraph: p, run: i, kind: kind, name: name})
+					}
+				}
+			}
+		}
+	}
+
+	filled := &Table{}
+	for r := 0; r < len(table.Rows); r++ {
+		start, end, err := spanningSection(positions, r, func(p templatePosition) int { return p.element*1000 + p.cell })
+		if err != nil {
+			return nil, err
+		}
+
+		if start == nil {
+			row := table.Rows[r]
+			for c := range row.Cells {
+				paragraphs, err := t.paragraphs(row.Cells[c].Paragraphs, context)
+				if err != nil {
+					return nil, err
+				}
+				row.Cells[c].Paragraphs = paragraphs
+			}
+			filled.Rows = append(filled.Rows, row)
+			continue
+		}
+
+		rows := table.Rows[start.element : end.element+1]
+		endParagraph := &rows[len(rows)-1].Cells[end.cell].Paragraphs[end.paragraph]
+		endParagraph.Runs = removeRun(endParagraph.Runs, end.run)
+		startParagraph := &rows[0].Cells[start.cell].Paragraphs[start.paragraph]
+		startParagraph.Runs = removeRun(startParagraph.Runs, start.run)
+
+		err = t.section(*start, context, func(context []reflect.Value) error {
+			section, err := t.table(cloneTable(&Table{Rows: rows}), context)
+			if err == nil {
+				filled.Rows = append(filled.Rows, section.Rows...)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		r = end.element
+	}
+
+	return filled, nil
+}
+
+// paragraphs fills the paragraphs of a table cell, which keeps at least one.
+func (t *templateFiller) paragraphs(paragraphs []Paragraph, context []reflect.Value) ([]Paragraph, error) {
+	elements := []BodyElement{}
+	for i := range paragraphs {
+		elements = append(elements, &paragraphs[i])
+	}
+
+	elements, err := t.elements(elements, context)
+	if err != nil {
+		return nil, err
+	}
+
+	filled := []Paragraph{}
+	for _, element := range elements {
+		filled = append(filled, *element.(*Paragraph))
+	}
+	if len(filled) == 0 && len(paragraphs) > 0 {
+		last := paragraphs[len(paragraphs)-1]
+		last.Runs = nil
+		filled = append(filled, last)
+	}
+
+	return filled, nil
+}
+
+// spanningSection returns the delimiters of the first section starting in
+// the element given that ends in another, as told by container, or nothing
+// if there is none.
+func spanningSection(positions []templatePosition, element int, container func(templatePosition) int) (*templatePosition, *templatePosition, error) {
+	for i, start := range positions {
+		if start.element != element || start.kind == "/" {
+			continue
+		}
+
+		depth := 0
+		for j := i; j < len(positions); j++ {
+			end := positions[j]
+			if end.name != start.name {
+				continue
+			}
+			if end.kind != "/" {
+				depth++
+				continue
+			}
+			if depth--; depth > 0 {
+				continue
+			}
+
+			// sections within a paragraph, or a cell, are filled with it
+			if container(end) == container(start) {
+				break
+			}
+			return &positions[i], &positions[j], nil
+		}
+		if depth > 0 {
+			return nil, nil, fmt.Errorf("gortf: template section %q is not closed", start.name)
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// runs fills the runs of a paragraph, repeating those of sections within it.
+func (t *templateFiller) runs(runs []StyleBlock, context []reflect.Value) ([]StyleBlock, error) {
+	filled := []StyleBlock{}
+
+	for i := 0; i < len(runs); i++ {
+		run := runs[i]
+		kind, name, ok := templateTagOf(run)
+		if run.Painter.Link != "" {
+			run.Painter.Link = t.replace(run.Painter.Link, context)
+		}
+
+		switch {
+		case !ok:
+			filled = appendRun(filled, run)
+
+		case kind == "":
+			value, _ := lookupTemplateValue(context, name)
+			run.Text = templateText(value)
+			if run.Text != "" {
+				filled = appendRun(filled, run)
+			}
+
+		case kind == "/":
+			return nil, fmt.Errorf("gortf: template section %q is not opened", name)
+
+		default:
+			end, depth := -1, 0
+			for j := i; j < len(runs) && end < 0; j++ {
+				if k, n, ok := templateTagOf(runs[j]); ok && n == name && k != "" {
+					if k != "/" {
+						depth++
+					} else if depth--; depth == 0 {
+						end = j
+					}
+				}
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("gortf: template section %q is not closed", name)
+			}
+
+			section := runs[i+1 : end]
+			err := t.section(templatePosition{kind: kind, name: name}, context, func(context []reflect.Value) error {
+				runs, err := t.runs(section, context)
+				for _, run := range runs {
+					filled = appendRun(filled, run)
+				}
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		}
+	}
+
+	return filled, nil
+}
+
+// section calls fill with the context of each repetition of a section.
+func (t *templateFiller) section(start templatePosition, context []reflect.Value, fill func([]reflect.Value) error) error {
+	value, _ := lookupTemplateValue(context, start.name)
+	empty := isEmptyTemplateValue(value)
+
+	if start.kind == "^" {
+		if !empty {
+			return nil
+		}
+		return fill(context)
+	}
+
+	if empty {
+		return nil
+	}
+
+	value = indirectTemplateValue(value)
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		for i := 0; i < value.Len(); i++ {
+			if err := fill(append(context[:len(context):len(context)], value.Index(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fill(append(context[:len(context):len(context)], value))
+}
+
+// replace replaces the placeholders of text such as link targets.
+func (t *templateFiller) replace(text string, context []reflect.Value) string {
+	return templateTag.ReplaceAllStringFunc(text, func(tag string) string {
+		match := templateTag.FindStringSubmatch(tag)
+		if match[1] != "" {
+			return tag
+		}
+		value, _ := lookupTemplateValue(context, match[2])
+		return templateText(value)
+	})
+}
+
+// normalizeTemplateRuns turns the merge fields of a paragraph into tags and
+// gives each tag a run of its own, in the formatting of the run it starts
+// in. The runs are returned.
+func normalizeTemplateRuns(paragraph *Paragraph) []StyleBlock {
+	runs := []StyleBlock{}
+	for i := 0; i < len(paragraph.Runs); i++ {
+		run := paragraph.Runs[i]
+		name, ok := mergeFieldName(run.Painter.Field)
+		if !ok {
+			runs = append(runs, run)
+			continue
+		}
+
+		for i+1 < len(paragraph.Runs) && paragraph.Runs[i+1].Painter.Field == run.Painter.Field {
+			i++
+		}
+		run.Painter.Field = ""
+		run.Kind, run.Text = BlockKindText, "{{"+name+"}}"
+		runs = append(runs, run)
+	}
+
+	normalized := []StyleBlock{}
+	for i := 0; i < len(runs); {
+		if runs[i].Kind != BlockKindText {
+			normalized = append(normalized, runs[i])
+			i++
+			continue
+		}
+
+		// the text of consecutive text runs, with the run of each byte
+		end := i
+		var text strings.Builder
+		owners := []int{}
+		for ; end < len(runs) && runs[end].Kind == BlockKindText; end++ {
+			text.WriteString(runs[end].Text)
+			for k := 0; k < len(runs[end].Text); k++ {
+				owners = append(owners, end)
+			}
+		}
+		joined := text.String()
+
+		cursor := 0
+		emit := func(from, to int) {
+			for from < to {
+				owner := owners[from]
+				next := from
+				for next < to && owners[next] == owner {
+					next++
+				}
+				run := runs[owner]
+				run.Text = joined[from:next]
+				normalized = append(normalized, run)
+				from = next
+			}
+		}
+		for _, match := range templateTag.FindAllStringIndex(joined, -1) {
+			emit(cursor, match[0])
+			tag := runs[owners[match[0]]]
+			tag.Text = joined[match[0]:match[1]]
+			normalized = append(normalized, tag)
+			cursor = match[1]
+		}
+		emit(cursor, len(joined))
+
+		i = end
+	}
+
+	paragraph.Runs = normalized
+	return normalized
+}
+
+// mergeFieldName returns the tag standing for a MERGEFIELD field instruction.
+func mergeFieldName(instruction string) (string, bool) {
+	arguments := splitFieldInstruction(instruction)
+	if len(arguments) < 2 || !strings.EqualFold(arguments[0], "MERGEFIELD") {
+		return "", false
+	}
+
+	name := arguments[1]
+	for prefix, kind := range map[string]string{"TableStart:": "#", "TableEnd:": "/", "BeginGroup:": "#", "EndGroup:": "/"} {
+		if len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			return kind + name[len(prefix):], true
+		}
+	}
+
+	return name, true
+}
+
+// lookupTemplateValue returns the value of a name, looked up in the
+// innermost value of the context that has it.
+func lookupTemplateValue(context []reflect.Value, name string) (reflect.Value, bool) {
+	if name == "." {
+		return context[len(context)-1], true
+	}
+
+	path := strings.Split(name, ".")
+	for i := len(context) - 1; i >= 0; i-- {
+		value, ok := templateMember(context[i], path[0])
+		if !ok {
+			continue
+		}
+		for _, member := range path[1:] {
+			if value, ok = templateMember(value, member); !ok {
+				return reflect.Value{}, false
+			}
+		}
+		return value, true
+	}
+
+	return reflect.Value{}, false
+}
+
+// templateMember returns the entry of a map or the field of a struct of the
+// given name, matched regardless of case if need be.
+func templateMember(value reflect.Value, name string) (reflect.Value, bool) {
+	value = indirectTemplateValue(value)
+
+	switch value.Kind() {
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		key := reflect.ValueOf(name).Convert(value.Type().Key())
+		if entry := value.MapIndex(key); entry.IsValid() {
+			return entry, true
+		}
+		for _, key := range value.MapKeys() {
+			if strings.EqualFold(key.String(), name) {
+				return value.MapIndex(key), true
+			}
+		}
+
+	case reflect.Struct:
+		if field := value.FieldByName(name); field.IsValid() && field.CanInterface() {
+			return field, true
+		}
+		field := value.FieldByNameFunc(func(field string) bool { return strings.EqualFold(field, name) })
+		if field.IsValid() && field.CanInterface() {
+			return field, true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func indirectTemplateValue(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) {
+		value = value.Elem()
+	}
+
+	return value
+}
+
+// isEmptyTemplateValue reports whether a value leaves sections out: missing
+// values, false, zero numbers, and empty strings, slices and maps.
+func isEmptyTemplateValue(value reflect.Value) bool {
+	value = indirectTemplateValue(value)
+	if !value.IsValid() {
+		return true
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+		return value.Len() == 0
+	case reflect.Struct:
+		return false
+	default:
+		return value.IsZero()
+	}
+}
+
+func templateText(value reflect.Value) string {
+	value = indirectTemplateValue(value)
+	if !value.IsValid() {
+		return ""
+	}
+
+	return fmt.Sprint(value.Interface())
+}
+
+func removeRun(runs []StyleBlock, i int) []StyleBlock {
+	return append(runs[:i:i], runs[i+1:]...)
+}
+
+func isBlankParagraph(paragraph *Paragraph) bool {
+	for _, run := range paragraph.Runs {
+		if run.Kind != BlockKindText || strings.TrimSpace(run.Text) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// copyElements copies paragraphs and tables, so that filling them leaves the
+// originals untouched.
+func copyElements(elements []BodyElement) []BodyElement {
+	copied := make([]BodyElement, len(elements))
+	for i, element := range elements {
+		switch element := element.(type) {
+		case *Paragraph:
+			paragraph := *element
+			paragraph.Runs = append([]StyleBlock{}, element.Runs...)
+			copied[i] = &paragraph
+		case *Table:
+			copied[i] = cloneTable(element)
+		}
+	}
+
+	return copied
+}
+
+func cloneTable(table *Table) *Table {
+	copied := &Table{Rows: append([]TableRow{}, table.Rows...)}
+	for r := range copied.Rows {
+		row := &copied.Rows[r]
+		row.Cells = append([]TableCell{}, row.Cells...)
+		for c := range row.Cells {
+			cell := &row.Cells[c]
+			cell.Paragraphs = append([]Paragraph{}, cell.Paragraphs...)
+			for p := range cell.Paragraphs {
+				cell.Paragraphs[p].Runs = append([]StyleBlock{}, cell.Paragraphs[p].Runs...)
+			}
+		}
+	}
+
+	return copied
+}
@@ -0,0 +1,129 @@
+package gortf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFillTemplateRuns(t *testing.T) {
+	bold := Painter{Bold: true}
+	paragraph := &Paragraph{Runs: []StyleBlock{
+		{Kind: BlockKindText, Text: "Dear "},
+		{Kind: BlockKindText, Text: "{{na", Painter: bold},
+		{Kind: BlockKindText, Text: "me}}, you owe "},
+		{Kind: BlockKindText, Text: "«Total»", Painter: Painter{Italic: true, Field: `MERGEFIELD Total \* MERGEFORMAT`}},
+		{Kind: BlockKindText, Text: ".{{#late}} Late!{{/late}}{{^late}} Thanks.{{/late}}"},
+	}}
+
+	data := map[string]interface{}{"Name": "Ann", "total": 12.5, "late": false}
+
+	runs, err := (&templateFiller{}).runs(normalizeTemplateRuns(paragraph), []reflect.Value{reflect.ValueOf(data)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []StyleBlock{
+		{Kind: BlockKindText, Text: "Dear "},
+		{Kind: BlockKindText, Text: "Ann", Painter: bold},
+		{Kind: BlockKindText, Text: ", you owe "},
+		{Kind: BlockKindText, Text: "12.5", Painter: Painter{Italic: true}},
+		{Kind: BlockKindText, Text: ". Thanks."},
+	}
+	if !reflect.DeepEqual(expected, runs) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, runs)
+	}
+}
+
+func TestFillTemplate(t *testing.T) {
+	type item struct {
+		Name  string
+		Price string
+	}
+	data := struct {
+		Customer map[string]string
+		Items    []item
+		Notes    []string
+		Overdue  bool
+	}{
+		Customer: map[string]string{"name": "Ann"},
+		Items:    []item{{"Pen", "1.00"}, {"Ink", "2.50"}},
+		Notes:    []string{"first", "second"},
+	}
+
+	content := `{\rtf1\ansi{\fonttbl{\f0 Arial;}}` +
+		`Dear {\b \{\{customer.}{\i name\}\}}\par ` +
+		`\{\{#notes\}\}\par ` +
+		`Note: \{\{.\}\}\par ` +
+		`\{\{/notes\}\}\par ` +
+		`\{\{#overdue\}\}Overdue!\{\{/overdue\}\}\par ` +
+		`\trowd\cellx2000\cellx4000 Item\cell Price\cell\row ` +
+		`\trowd\cellx2000\cellx4000 {\field{\*\fldinst MERGEFIELD TableStart:Items}{\fldrslt x}}\{\{name\}\}\cell \{\{price\}\}{\field{\*\fldinst MERGEFIELD TableEnd:Items}{\fldrslt y}}\cell\row ` +
+		`Bye\par}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filled, err := FillTemplate(&doc, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := []string{}
+	for _, paragraph := range filled.Paragraphs() {
+		text := ""
+		for _, run := range paragraph.Runs {
+			text += run.Text
+		}
+		actual = append(actual, text)
+	}
+
+	expected := []string{"Dear Ann", "Note: first", "Note: second", "", "Item", "Price", "Pen", "1.00", "Ink", "2.50", "Bye"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, actual)
+	}
+
+	if run := filled.Paragraphs()[0].Runs[1]; run.Text != "Ann" || !run.Painter.Bold || run.Painter.Italic {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "the formatting of the first run", run)
+	}
+
+	if paragraphs := doc.Paragraphs(); len(paragraphs) != 10 || !strings.Contains(paragraphs[2].Runs[0].Text, "{{.}}") {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "the template left untouched", paragraphs)
+	}
+}
+
+func TestFillTemplateRTF(t *testing.T) {
+	content := `{\rtf1\ansi Hello {\field{\*\fldinst MERGEFIELD Name}{\fldrslt {\b \'abName\'bb}}}!\par}`
+
+	filled, err := FillTemplateRTF(content, map[string]string{"Name": "Bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(filled)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, _ := doc.ToText(); !strings.Contains(actual, "Hello Bob!") {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "Hello Bob!", actual)
+	}
+}
+
+func TestFillTemplateErrors(t *testing.T) {
+	tests := []string{
+		`{\rtf1\ansi \{\{#a\}\}\par b\par}`,
+		`{\rtf1\ansi a\{\{/a\}\}\par}`,
+		`{\rtf1\ansi \{\{#a\}\}b\par}`,
+	}
+
+	for _, content := range tests {
+		if _, err := FillTemplateRTF(content, map[string]bool{"a": true}); err == nil {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "an error", err)
+		}
+	}
+}
@@ -1,9 +1,7 @@
 package gortf
 
 import (
-	"encoding/json"
 	"fmt"
-	"strconv"
 )
 
 type tokenType int
@@ -16,6 +14,8 @@ const (
 	tokenTypeControlWord
 	tokenTypeCRLF
 	tokenTypeIgnorable
+	tokenTypeControlSymbol
+	tokenTypeBinary
 )
 
 type controlWordType int
@@ -28,6 +28,14 @@ const (
 
 	// character set
 	controlWordTypeCharacterSet
+	controlWordTypeCodePage
+
+	// unicode
+	controlWordTypeUnicode
+	controlWordTypeUnicodeSkip
+
+	// binary data
+	controlWordTypeBinary
 
 	// font table
 	controlWordTypeFontTable
@@ -40,6 +48,8 @@ const (
 	controlWordTypeFontPanose
 	controlWordTypeFontName
 	controlWordTypeFontBias
+	controlWordTypeFontCodePage
+	controlWordTypeFontTheme
 
 	// file table
 	controlWordTypeFileTable
@@ -58,6 +68,13 @@ const (
 	controlWordTypeColorRed
 	controlWordTypeColorGreen
 	controlWordTypeColorBlue
+	controlWordTypeColorTint
+	controlWordTypeColorShade
+	controlWordTypeColorTheme
+	controlWordTypeExpandedColorTable
+	controlWordTypeColorSpace
+	controlWordTypeColorComponent
+	controlWordTypeColorName
 
 	// stylesheet
 	controlWordTypeStylesheet
@@ -127,6 +144,65 @@ const (
 	controlWordTypeInfoVersion
 	controlWordTypeInfoDoccom
 	controlWordTypeInfoHlinkBase
+	controlWordTypeInfoCreationTime
+	controlWordTypeInfoRevisionTime
+	controlWordTypeInfoPrintTime
+	controlWordTypeInfoBackupTime
+	controlWordTypeInfoYear
+	controlWordTypeInfoMonth
+	controlWordTypeInfoDay
+	controlWordTypeInfoHour
+	controlWordTypeInfoMinute
+	controlWordTypeInfoSecond
+	controlWordTypeInfoEditingMinutes
+	controlWordTypeInfoNumberOfPages
+	controlWordTypeInfoNumberOfWords
+	controlWordTypeInfoNumberOfCharacters
+	controlWordTypeInfoNumberOfCharactersWithSpaces
+	controlWordTypeInfoInternalID
+
+	// user properties
+	controlWordTypeUserProperties
+	controlWordTypeUserPropertyName
+	controlWordTypeUserPropertyType
+	controlWordTypeUserPropertyStaticValue
+	controlWordTypeUserPropertyLinkValue
+
+	// pictures
+	controlWordTypePicture
+	controlWordTypePictureFormat
+	controlWordTypePictureWidth
+	controlWordTypePictureHeight
+	controlWordTypePictureGoalWidth
+	controlWordTypePictureGoalHeight
+	controlWordTypePictureScaleX
+	controlWordTypePictureScaleY
+
+	// objects
+	controlWordTypeObjectType
+	controlWordTypeObjectWidth
+	controlWordTypeObjectHeight
+	controlWordTypeObjectScaleX
+	controlWordTypeObjectScaleY
+
+	// shapes
+	controlWordTypeShapeLeft
+	controlWordTypeShapeTop
+	controlWordTypeShapeRight
+	controlWordTypeShapeBottom
+	controlWordTypeShapeHorizontalAnchor
+	controlWordTypeShapeVerticalAnchor
+	controlWordTypeShapeWrap
+	controlWordTypeShapeBehindText
+	controlWordTypeShapeZ
+	controlWordTypeShapeID
+
+	// special characters
+	controlWordTypeParagraph
+	controlWordTypeLine
+	controlWordTypeTab
+	controlWordTypeSpecialCharacter
+	controlWordTypeNoteNumber
 
 	// character formatting
 	controlWordTypeItalic
@@ -137,6 +213,72 @@ const (
 	controlWordTypeSubscript
 	controlWordTypeSmallcaps
 	controlWordTypeStrikethrough
+	controlWordTypeNoSuperSub
+	controlWordTypeHidden
+	controlWordTypePlain
+	controlWordTypeForegroundColor
+	controlWordTypeBackgroundColor
+	controlWordTypeHighlight
+	controlWordTypeRevised
+	controlWordTypeRevisionAuthor
+	controlWordTypeRevisionTime
+	controlWordTypeDeleted
+	controlWordTypeDeletionAuthor
+	controlWordTypeDeletionTime
+
+	// paragraph formatting
+	controlWordTypeParagraphDefault
+	controlWordTypeParagraphAlignment
+	controlWordTypeLeftIndent
+	controlWordTypeRightIndent
+	controlWordTypeFirstLineIndent
+	controlWordTypeSpaceBefore
+	controlWordTypeSpaceAfter
+	controlWordTypeLineSpacing
+	controlWordTypeLineSpacingMultiple
+	controlWordTypeKeepTogether
+	controlWordTypeKeepWithNext
+	controlWordTypePageBreakBefore
+	controlWordTypeInTable
+	controlWordTypeParagraphListLevel
+	controlWordTypeParagraphRevisionAuthor
+	controlWordTypeParagraphRevisionTime
+	controlWordTypeNumberingRevisionAuthor
+	controlWordTypeNumberingRevisionTime
+	controlWordTypeTabAlignment
+	controlWordTypeTabLeader
+	controlWordTypeTabPosition
+	controlWordTypeBarTab
+
+	// tables
+	controlWordTypeRowDefault
+	controlWordTypeRowAlignment
+	controlWordTypeRowLeftIndent
+	controlWordTypeRowGap
+	controlWordTypeRowHeader
+	controlWordTypeCellBoundary
+	controlWordTypeCellHorizontalMerge
+	controlWordTypeCellVerticalMerge
+	controlWordTypeCellBackground
+	controlWordTypeCell
+	controlWordTypeRow
+
+	// sections
+	controlWordTypePage
+	controlWordTypeSection
+	controlWordTypeSectionDefault
+	controlWordTypeSectionBreak
+	controlWordTypeSectionColumns
+	controlWordTypeSectionLandscape
+	controlWordTypeSectionPageWidth
+	controlWordTypeSectionPageHeight
+	controlWordTypeSectionMarginLeft
+	controlWordTypeSectionMarginRight
+	controlWordTypeSectionMarginTop
+	controlWordTypeSectionMarginBottom
+
+	// document formatting
+	controlWordTypeDefaultTab
 )
 
 func (c controlWordType) String() string {
@@ -148,6 +290,18 @@ func (c controlWordType) String() string {
 	// character set
 	case controlWordTypeCharacterSet:
 		return "characterset"
+	case controlWordTypeCodePage:
+		return "ansicpg"
+
+	// unicode
+	case controlWordTypeUnicode:
+		return "u"
+	case controlWordTypeUnicodeSkip:
+		return "uc"
+
+	// binary data
+	case controlWordTypeBinary:
+		return "bin"
 
 	// font table
 	case controlWordTypeFontTable:
@@ -168,6 +322,10 @@ func (c controlWordType) String() string {
 		return "fname"
 	case controlWordTypeFontAlternative:
 		return "falt"
+	case controlWordTypeFontCodePage:
+		return "cpg"
+	case controlWordTypeFontTheme:
+		return "fonttheme"
 
 	// color table
 	case controlWordTypeColorTable:
@@ -178,6 +336,20 @@ func (c controlWordType) String() string {
 		return "green"
 	case controlWordTypeColorBlue:
 		return "blue"
+	case controlWordTypeColorTint:
+		return "ctint"
+	case controlWordTypeColorShade:
+		return "cshade"
+	case controlWordTypeColorTheme:
+		return "colortheme"
+	case controlWordTypeExpandedColorTable:
+		return "expandedcolortbl"
+	case controlWordTypeColorSpace:
+		return "colorspace"
+	case controlWordTypeColorComponent:
+		return "c"
+	case controlWordTypeColorName:
+		return "cname"
 
 	// stylesheet
 	case controlWordTypeStylesheet:
@@ -215,6 +387,70 @@ func (c controlWordType) String() string {
 	case controlWordTypeStyleFunctionKey:
 		return "fn"
 
+	// list table
+	case controlWordTypeListTable:
+		return "listtable"
+	case controlWordTypeList:
+		return "list"
+	case controlWordTypeListID:
+		return "listid"
+	case controlWordTypeListTemplateID:
+		return "listtemplateid"
+	case controlWordTypeListSimple:
+		return "listsimple"
+	case controlWordTypeListHybrid:
+		return "listhybrid"
+	case controlWordTypeListRestartSection:
+		return "listrestarthdn"
+	case controlWordTypeListName:
+		return "listname"
+	case controlWordTypeListLevel:
+		return "listlevel"
+	case controlWordTypeListLevelStartAt:
+		return "levelstartat"
+	case controlWordTypeListLevelNfc:
+		return "levelnfc"
+	case controlWordTypeListLevelJc:
+		return "leveljc"
+	case controlWordTypeListLevelNfcn:
+		return "levelnfcn"
+	case controlWordTypeListLevelJcn:
+		return "leveljcn"
+	case controlWordTypeListLevelOld:
+		return "levelold"
+	case controlWordTypeListLevelPrev:
+		return "levelprev"
+	case controlWordTypeListPrevSpace:
+		return "levelprevspace"
+	case controlWordTypeListLevelIndent:
+		return "levelindent"
+	case controlWordTypeListLevelSpace:
+		return "levelspace"
+	case controlWordTypeListLevelText:
+		return "leveltext"
+	case controlWordTypeListLevelNumbers:
+		return "levelnumbers"
+	case controlWordTypeListLevelFollow:
+		return "levelfollow"
+	case controlWordTypeListLevelNoRestart:
+		return "levelnorestart"
+
+	// list override table
+	case controlWordListOverrideTable:
+		return "listoverridetable"
+	case controlWordTypeListOverride:
+		return "listoverride"
+	case controlWordTypeListOverrideCount:
+		return "listoverridecount"
+	case controlWordTypeListOverrideLs:
+		return "ls"
+	case controlWordTypeListOverrideLevel:
+		return "lfolevel"
+	case controlWordTypeListOverrideLevelStartAt:
+		return "listoverridestartat"
+	case controlWordTypeListOverrideLevelFormat:
+		return "listoverrideformat"
+
 	// information group
 	case controlWordTypeInfo:
 		return "info"
@@ -242,6 +478,114 @@ func (c controlWordType) String() string {
 		return "doccom"
 	case controlWordTypeInfoHlinkBase:
 		return "hlinkbase"
+	case controlWordTypeInfoCreationTime:
+		return "creatim"
+	case controlWordTypeInfoRevisionTime:
+		return "revtim"
+	case controlWordTypeInfoPrintTime:
+		return "printim"
+	case controlWordTypeInfoBackupTime:
+		return "buptim"
+	case controlWordTypeInfoYear:
+		return "yr"
+	case controlWordTypeInfoMonth:
+		return "mo"
+	case controlWordTypeInfoDay:
+		return "dy"
+	case controlWordTypeInfoHour:
+		return "hr"
+	case controlWordTypeInfoMinute:
+		return "min"
+	case controlWordTypeInfoSecond:
+		return "sec"
+	case controlWordTypeInfoEditingMinutes:
+		return "edmins"
+	case controlWordTypeInfoNumberOfPages:
+		return "nofpages"
+	case controlWordTypeInfoNumberOfWords:
+		return "nofwords"
+	case controlWordTypeInfoNumberOfCharacters:
+		return "nofchars"
+	case controlWordTypeInfoNumberOfCharactersWithSpaces:
+		return "nofcharsws"
+	case controlWordTypeInfoInternalID:
+		return "id"
+
+	// user properties
+	case controlWordTypeUserProperties:
+		return "userprops"
+	case controlWordTypeUserPropertyName:
+		return "propname"
+	case controlWordTypeUserPropertyType:
+		return "proptype"
+	case controlWordTypeUserPropertyStaticValue:
+		return "staticval"
+	case controlWordTypeUserPropertyLinkValue:
+		return "linkval"
+
+	// pictures
+	case controlWordTypePicture:
+		return "pict"
+	case controlWordTypePictureFormat:
+		return "pictureformat"
+	case controlWordTypePictureWidth:
+		return "picw"
+	case controlWordTypePictureHeight:
+		return "pich"
+	case controlWordTypePictureGoalWidth:
+		return "picwgoal"
+	case controlWordTypePictureGoalHeight:
+		return "pichgoal"
+	case controlWordTypePictureScaleX:
+		return "picscalex"
+	case controlWordTypePictureScaleY:
+		return "picscaley"
+
+	// objects
+	case controlWordTypeObjectType:
+		return "objecttype"
+	case controlWordTypeObjectWidth:
+		return "objw"
+	case controlWordTypeObjectHeight:
+		return "objh"
+	case controlWordTypeObjectScaleX:
+		return "objscalex"
+	case controlWordTypeObjectScaleY:
+		return "objscaley"
+
+	// shapes
+	case controlWordTypeShapeLeft:
+		return "shpleft"
+	case controlWordTypeShapeTop:
+		return "shptop"
+	case controlWordTypeShapeRight:
+		return "shpright"
+	case controlWordTypeShapeBottom:
+		return "shpbottom"
+	case controlWordTypeShapeHorizontalAnchor:
+		return "shpbx"
+	case controlWordTypeShapeVerticalAnchor:
+		return "shpby"
+	case controlWordTypeShapeWrap:
+		return "shpwr"
+	case controlWordTypeShapeBehindText:
+		return "shpfblwtxt"
+	case controlWordTypeShapeZ:
+		return "shpz"
+	case controlWordTypeShapeID:
+		return "shplid"
+
+	// special characters
+	case controlWordTypeParagraph:
+		return "par"
+	case controlWordTypeLine:
+		return "line"
+	case controlWordTypeTab:
+		return "tab"
+	case controlWordTypeSpecialCharacter:
+		return "specialcharacter"
+	case controlWordTypeNoteNumber:
+		return "chftn"
 
 	// character formatting
 	case controlWordTypeItalic:
@@ -260,6 +604,130 @@ func (c controlWordType) String() string {
 		return "scaps"
 	case controlWordTypeStrikethrough:
 		return "strike"
+	case controlWordTypeNoSuperSub:
+		return "nosupersub"
+	case controlWordTypeHidden:
+		return "v"
+	case controlWordTypePlain:
+		return "plain"
+	case controlWordTypeForegroundColor:
+		return "cf"
+	case controlWordTypeBackgroundColor:
+		return "cb"
+	case controlWordTypeHighlight:
+		return "highlight"
+	case controlWordTypeRevised:
+		return "revised"
+	case controlWordTypeRevisionAuthor:
+		return "revauth"
+	case controlWordTypeRevisionTime:
+		return "revdttm"
+	case controlWordTypeDeleted:
+		return "deleted"
+	case controlWordTypeDeletionAuthor:
+		return "revauthdel"
+	case controlWordTypeDeletionTime:
+		return "revdttmdel"
+
+	// paragraph formatting
+	case controlWordTypeParagraphDefault:
+		return "pard"
+	case controlWordTypeParagraphAlignment:
+		return "alignment"
+	case controlWordTypeLeftIndent:
+		return "li"
+	case controlWordTypeRightIndent:
+		return "ri"
+	case controlWordTypeFirstLineIndent:
+		return "fi"
+	case controlWordTypeSpaceBefore:
+		return "sb"
+	case controlWordTypeSpaceAfter:
+		return "sa"
+	case controlWordTypeLineSpacing:
+		return "sl"
+	case controlWordTypeLineSpacingMultiple:
+		return "slmult"
+	case controlWordTypeKeepTogether:
+		return "keep"
+	case controlWordTypeKeepWithNext:
+		return "keepn"
+	case controlWordTypePageBreakBefore:
+		return "pagebb"
+	case controlWordTypeInTable:
+		return "intbl"
+	case controlWordTypeParagraphListLevel:
+		return "ilvl"
+	case controlWordTypeParagraphRevisionAuthor:
+		return "prauth"
+	case controlWordTypeParagraphRevisionTime:
+		return "prdate"
+	case controlWordTypeNumberingRevisionAuthor:
+		return "pnrauth"
+	case controlWordTypeNumberingRevisionTime:
+		return "pnrdate"
+	case controlWordTypeTabAlignment:
+		return "tabalignment"
+	case controlWordTypeTabLeader:
+		return "tableader"
+	case controlWordTypeTabPosition:
+		return "tx"
+	case controlWordTypeBarTab:
+		return "tb"
+
+	// tables
+	case controlWordTypeRowDefault:
+		return "trowd"
+	case controlWordTypeRowAlignment:
+		return "rowalignment"
+	case controlWordTypeRowLeftIndent:
+		return "trleft"
+	case controlWordTypeRowGap:
+		return "trgaph"
+	case controlWordTypeRowHeader:
+		return "trhdr"
+	case controlWordTypeCellBoundary:
+		return "cellx"
+	case controlWordTypeCellHorizontalMerge:
+		return "clmgf"
+	case controlWordTypeCellVerticalMerge:
+		return "clvmgf"
+	case controlWordTypeCellBackground:
+		return "clcbpat"
+	case controlWordTypeCell:
+		return "cell"
+	case controlWordTypeRow:
+		return "row"
+
+	// sections
+	case controlWordTypePage:
+		return "page"
+	case controlWordTypeSection:
+		return "sect"
+	case controlWordTypeSectionDefault:
+		return "sectd"
+	case controlWordTypeSectionBreak:
+		return "sectionbreak"
+	case controlWordTypeSectionColumns:
+		return "cols"
+	case controlWordTypeSectionLandscape:
+		return "lndscpsxn"
+	case controlWordTypeSectionPageWidth:
+		return "pgwsxn"
+	case controlWordTypeSectionPageHeight:
+		return "pghsxn"
+	case controlWordTypeSectionMarginLeft:
+		return "marglsxn"
+	case controlWordTypeSectionMarginRight:
+		return "margrsxn"
+	case controlWordTypeSectionMarginTop:
+		return "margtsxn"
+	case controlWordTypeSectionMarginBottom:
+		return "margbsxn"
+
+	// document formatting
+	case controlWordTypeDefaultTab:
+		return "deftab"
 
 	default:
 		return "unknown"
@@ -275,7 +743,11 @@ type binaryToken struct {
 }
 
 func (b binaryToken) tokenType() tokenType {
-	return tokenTypeText
+	return tokenTypeBinary
+}
+
+func (b binaryToken) String() string {
+	return fmt.Sprintf("{Binary %d bytes}", len(b.value))
 }
 
 func NewBinaryToken(value []byte) binaryToken {
@@ -340,49 +812,26 @@ func (c controlWordToken) tokenType() tokenType {
 }
 
 func (c controlWordToken) String() string {
-	b, _ := json.Marshal(c)
-	return string(b)
+	return fmt.Sprintf("{ControlWord %s %d}", c.name, c.parameter)
 }
 
-func newControlWordToken(input string) (controlWordToken, error) {
-	prefix := ""
-	suffix := ""
-	param := -1
-
-	suffixIndex := getSuffixIndex(input)
-	if suffixIndex == -1 {
-		prefix = input
-	} else {
-		prefix = input[:suffixIndex]
-		suffix = input[suffixIndex:]
-
-		p, err := strconv.Atoi(suffix)
-		if err != nil {
-			return controlWordToken{}, err
-		}
-
-		param = p
+func newControlWordToken(name string, parameter int) controlWordToken {
+	return controlWordToken{
+		name:            name,
+		controlWordType: getControlWordTypeFromPrefix(name),
+		parameter:       parameter,
 	}
+}
 
-	controlType := getControlWordTypeFromPrefix(prefix)
-
-	return controlWordToken{
-		name:            prefix,
-		controlWordType: controlType,
-		parameter:       param,
-	}, nil
-}
-
-func getSuffixIndex(text string) int {
-	suffixIndex := -1
-	for idx := range text {
-		if isNumber(text[idx]) || text[idx] == '-' {
-			suffixIndex = idx
-			break
-		}
+// controlWordFromToken converts a control word of the tree, giving words
+// without a parameter the parameter -1 as the scanner does.
+func controlWordFromToken(tkn Token) controlWordToken {
+	parameter := -1
+	if tkn.HasParam {
+		parameter = tkn.Param
 	}
 
-	return suffixIndex
+	return newControlWordToken(`\`+tkn.Name, parameter)
 }
 
 func getControlWordTypeFromPrefix(prefix string) controlWordType {
@@ -394,6 +843,18 @@ func getControlWordTypeFromPrefix(prefix string) controlWordType {
 	// character set
 	case `\ansi`, `\mac`, `\pc`, `\pca`:
 		return controlWordTypeCharacterSet
+	case `\ansicpg`:
+		return controlWordTypeCodePage
+
+	// unicode
+	case `\u`:
+		return controlWordTypeUnicode
+	case `\uc`:
+		return controlWordTypeUnicodeSkip
+
+	// binary data
+	case `\bin`:
+		return controlWordTypeBinary
 
 	// font table
 	case `\fonttbl`:
@@ -410,12 +871,16 @@ func getControlWordTypeFromPrefix(prefix string) controlWordType {
 		return controlWordTypeFontAlternative
 	case `\fprq`:
 		return controlWordTypeFontPitch
-	case `\*\panose`:
+	case `\panose`:
 		return controlWordTypeFontPanose
-	case `\*\fname`:
+	case `\fname`:
 		return controlWordTypeFontName
 	case `\fbias`:
 		return controlWordTypeFontBias
+	case `\cpg`:
+		return controlWordTypeFontCodePage
+	case `\flomajor`, `\fhimajor`, `\fdbmajor`, `\fbimajor`, `\flominor`, `\fhiminor`, `\fdbminor`, `\fbiminor`:
+		return controlWordTypeFontTheme
 
 	case `\colortbl`:
 		return controlWordTypeColorTable
@@ -425,10 +890,26 @@ func getControlWordTypeFromPrefix(prefix string) controlWordType {
 		return controlWordTypeColorGreen
 	case `\blue`:
 		return controlWordTypeColorBlue
+	case `\ctint`:
+		return controlWordTypeColorTint
+	case `\cshade`:
+		return controlWordTypeColorShade
+	case `\cmaindarkone`, `\cmainlightone`, `\cmaindarktwo`, `\cmainlighttwo`,
+		`\caccentone`, `\caccenttwo`, `\caccentthree`, `\caccentfour`, `\caccentfive`, `\caccentsix`,
+		`\chyperlink`, `\cfollowedhyperlink`, `\cbackgroundone`, `\ctextone`, `\cbackgroundtwo`, `\ctexttwo`:
+		return controlWordTypeColorTheme
+	case `\expandedcolortbl`:
+		return controlWordTypeExpandedColorTable
+	case `\csgray`, `\csgenericrgb`, `\cssrgb`:
+		return controlWordTypeColorSpace
+	case `\c`:
+		return controlWordTypeColorComponent
+	case `\cname`:
+		return controlWordTypeColorName
 
 	case `\stylesheet`:
 		return controlWordTypeStylesheet
-	case `\*\cs`:
+	case `\cs`:
 		return controlWordTypeStyleCharacter
 	case `\s`:
 		return controlWordTypeStyleParagraph
@@ -461,6 +942,70 @@ func getControlWordTypeFromPrefix(prefix string) controlWordType {
 	case `\fn`:
 		return controlWordTypeStyleFunctionKey
 
+	// list table
+	case `\listtable`:
+		return controlWordTypeListTable
+	case `\list`:
+		return controlWordTypeList
+	case `\listid`:
+		return controlWordTypeListID
+	case `\listtemplateid`:
+		return controlWordTypeListTemplateID
+	case `\listsimple`:
+		return controlWordTypeListSimple
+	case `\listhybrid`:
+		return controlWordTypeListHybrid
+	case `\listrestarthdn`:
+		return controlWordTypeListRestartSection
+	case `\listname`:
+		return controlWordTypeListName
+	case `\listlevel`:
+		return controlWordTypeListLevel
+	case `\levelstartat`:
+		return controlWordTypeListLevelStartAt
+	case `\levelnfc`:
+		return controlWordTypeListLevelNfc
+	case `\leveljc`:
+		return controlWordTypeListLevelJc
+	case `\levelnfcn`:
+		return controlWordTypeListLevelNfcn
+	case `\leveljcn`:
+		return controlWordTypeListLevelJcn
+	case `\levelold`:
+		return controlWordTypeListLevelOld
+	case `\levelprev`:
+		return controlWordTypeListLevelPrev
+	case `\levelprevspace`:
+		return controlWordTypeListPrevSpace
+	case `\levelindent`:
+		return controlWordTypeListLevelIndent
+	case `\levelspace`:
+		return controlWordTypeListLevelSpace
+	case `\leveltext`:
+		return controlWordTypeListLevelText
+	case `\levelnumbers`:
+		return controlWordTypeListLevelNumbers
+	case `\levelfollow`:
+		return controlWordTypeListLevelFollow
+	case `\levelnorestart`:
+		return controlWordTypeListLevelNoRestart
+
+	// list override table
+	case `\listoverridetable`:
+		return controlWordListOverrideTable
+	case `\listoverride`:
+		return controlWordTypeListOverride
+	case `\listoverridecount`:
+		return controlWordTypeListOverrideCount
+	case `\ls`:
+		return controlWordTypeListOverrideLs
+	case `\lfolevel`:
+		return controlWordTypeListOverrideLevel
+	case `\listoverridestartat`:
+		return controlWordTypeListOverrideLevelStartAt
+	case `\listoverrideformat`:
+		return controlWordTypeListOverrideLevelFormat
+
 		// information group
 	case `\info`:
 		return controlWordTypeInfo
@@ -488,6 +1033,115 @@ func getControlWordTypeFromPrefix(prefix string) controlWordType {
 		return controlWordTypeInfoDoccom
 	case `\hlinkbase`:
 		return controlWordTypeInfoHlinkBase
+	case `\creatim`:
+		return controlWordTypeInfoCreationTime
+	case `\revtim`:
+		return controlWordTypeInfoRevisionTime
+	case `\printim`:
+		return controlWordTypeInfoPrintTime
+	case `\buptim`:
+		return controlWordTypeInfoBackupTime
+	case `\yr`:
+		return controlWordTypeInfoYear
+	case `\mo`:
+		return controlWordTypeInfoMonth
+	case `\dy`:
+		return controlWordTypeInfoDay
+	case `\hr`:
+		return controlWordTypeInfoHour
+	case `\min`:
+		return controlWordTypeInfoMinute
+	case `\sec`:
+		return controlWordTypeInfoSecond
+	case `\edmins`:
+		return controlWordTypeInfoEditingMinutes
+	case `\nofpages`:
+		return controlWordTypeInfoNumberOfPages
+	case `\nofwords`:
+		return controlWordTypeInfoNumberOfWords
+	case `\nofchars`:
+		return controlWordTypeInfoNumberOfCharacters
+	case `\nofcharsws`:
+		return controlWordTypeInfoNumberOfCharactersWithSpaces
+	case `\id`:
+		return controlWordTypeInfoInternalID
+
+	// user properties
+	case `\userprops`:
+		return controlWordTypeUserProperties
+	case `\propname`:
+		return controlWordTypeUserPropertyName
+	case `\proptype`:
+		return controlWordTypeUserPropertyType
+	case `\staticval`:
+		return controlWordTypeUserPropertyStaticValue
+	case `\linkval`:
+		return controlWordTypeUserPropertyLinkValue
+
+	// pictures
+	case `\pict`:
+		return controlWordTypePicture
+	case `\emfblip`, `\pngblip`, `\jpegblip`, `\macpict`, `\wmetafile`, `\dibitmap`, `\wbitmap`:
+		return controlWordTypePictureFormat
+	case `\picw`:
+		return controlWordTypePictureWidth
+	case `\pich`:
+		return controlWordTypePictureHeight
+	case `\picwgoal`:
+		return controlWordTypePictureGoalWidth
+	case `\pichgoal`:
+		return controlWordTypePictureGoalHeight
+	case `\picscalex`:
+		return controlWordTypePictureScaleX
+	case `\picscaley`:
+		return controlWordTypePictureScaleY
+
+	// objects
+	case `\objemb`, `\objlink`, `\objautlink`, `\objsub`, `\objpub`, `\objicemb`, `\objhtml`, `\objocx`:
+		return controlWordTypeObjectType
+	case `\objw`:
+		return controlWordTypeObjectWidth
+	case `\objh`:
+		return controlWordTypeObjectHeight
+	case `\objscalex`:
+		return controlWordTypeObjectScaleX
+	case `\objscaley`:
+		return controlWordTypeObjectScaleY
+
+	// shapes
+	case `\shpleft`:
+		return controlWordTypeShapeLeft
+	case `\shptop`:
+		return controlWordTypeShapeTop
+	case `\shpright`:
+		return controlWordTypeShapeRight
+	case `\shpbottom`:
+		return controlWordTypeShapeBottom
+	case `\shpbxpage`, `\shpbxmargin`, `\shpbxcolumn`, `\shpbxignore`:
+		return controlWordTypeShapeHorizontalAnchor
+	case `\shpbypage`, `\shpbymargin`, `\shpbypara`, `\shpbyignore`:
+		return controlWordTypeShapeVerticalAnchor
+	case `\shpwr`:
+		return controlWordTypeShapeWrap
+	case `\shpfblwtxt`:
+		return controlWordTypeShapeBehindText
+	case `\shpz`:
+		return controlWordTypeShapeZ
+	case `\shplid`:
+		return controlWordTypeShapeID
+
+	// special characters
+	case `\par`:
+		return controlWordTypeParagraph
+	case `\line`:
+		return controlWordTypeLine
+	case `\tab`:
+		return controlWordTypeTab
+	case `\chftn`:
+		return controlWordTypeNoteNumber
+	case `\emdash`, `\endash`, `\emspace`, `\enspace`, `\qmspace`, `\bullet`,
+		`\lquote`, `\rquote`, `\ldblquote`, `\rdblquote`, `\zwj`, `\zwnj`:
+		return controlWordTypeSpecialCharacter
 
 	// character formatting
 	case `\i`:
@@ -506,6 +1160,130 @@ func getControlWordTypeFromPrefix(prefix string) controlWordType {
 		return controlWordTypeSmallcaps
 	case `\strike`:
 		return controlWordTypeStrikethrough
+	case `\nosupersub`:
+		return controlWordTypeNoSuperSub
+	case `\v`:
+		return controlWordTypeHidden
+	case `\plain`:
+		return controlWordTypePlain
+	case `\cf`:
+		return controlWordTypeForegroundColor
+	case `\cb`, `\chcbpat`:
+		return controlWordTypeBackgroundColor
+	case `\highlight`:
+		return controlWordTypeHighlight
+	case `\revised`:
+		return controlWordTypeRevised
+	case `\revauth`:
+		return controlWordTypeRevisionAuthor
+	case `\revdttm`:
+		return controlWordTypeRevisionTime
+	case `\deleted`:
+		return controlWordTypeDeleted
+	case `\revauthdel`:
+		return controlWordTypeDeletionAuthor
+	case `\revdttmdel`:
+		return controlWordTypeDeletionTime
+
+	// paragraph formatting
+	case `\pard`:
+		return controlWordTypeParagraphDefault
+	case `\ql`, `\qc`, `\qr`, `\qj`, `\qd`:
+		return controlWordTypeParagraphAlignment
+	case `\li`:
+		return controlWordTypeLeftIndent
+	case `\ri`:
+		return controlWordTypeRightIndent
+	case `\fi`:
+		return controlWordTypeFirstLineIndent
+	case `\sb`:
+		return controlWordTypeSpaceBefore
+	case `\sa`:
+		return controlWordTypeSpaceAfter
+	case `\sl`:
+		return controlWordTypeLineSpacing
+	case `\slmult`:
+		return controlWordTypeLineSpacingMultiple
+	case `\keep`:
+		return controlWordTypeKeepTogether
+	case `\keepn`:
+		return controlWordTypeKeepWithNext
+	case `\pagebb`:
+		return controlWordTypePageBreakBefore
+	case `\intbl`:
+		return controlWordTypeInTable
+	case `\ilvl`:
+		return controlWordTypeParagraphListLevel
+	case `\prauth`:
+		return controlWordTypeParagraphRevisionAuthor
+	case `\prdate`:
+		return controlWordTypeParagraphRevisionTime
+	case `\pnrauth`:
+		return controlWordTypeNumberingRevisionAuthor
+	case `\pnrdate`:
+		return controlWordTypeNumberingRevisionTime
+	case `\tqc`, `\tqr`, `\tqdec`:
+		return controlWordTypeTabAlignment
+	case `\tldot`, `\tlmdot`, `\tlhyph`, `\tlul`, `\tlth`, `\tleq`:
+		return controlWordTypeTabLeader
+	case `\tx`:
+		return controlWordTypeTabPosition
+	case `\tb`:
+		return controlWordTypeBarTab
+
+	// tables
+	case `\trowd`:
+		return controlWordTypeRowDefault
+	case `\trql`, `\trqc`, `\trqr`:
+		return controlWordTypeRowAlignment
+	case `\trleft`:
+		return controlWordTypeRowLeftIndent
+	case `\trgaph`:
+		return controlWordTypeRowGap
+	case `\trhdr`:
+		return controlWordTypeRowHeader
+	case `\cellx`:
+		return controlWordTypeCellBoundary
+	case `\clmgf`, `\clmrg`:
+		return controlWordTypeCellHorizontalMerge
+	case `\clvmgf`, `\clvmrg`:
+		return controlWordTypeCellVerticalMerge
+	case `\clcbpat`:
+		return controlWordTypeCellBackground
+	case `\cell`:
+		return controlWordTypeCell
+	case `\row`:
+		return controlWordTypeRow
+
+	// sections
+	case `\page`:
+		return controlWordTypePage
+	case `\sect`:
+		return controlWordTypeSection
+	case `\sectd`:
+		return controlWordTypeSectionDefault
+	case `\sbknone`, `\sbkcol`, `\sbkpage`, `\sbkeven`, `\sbkodd`:
+		return controlWordTypeSectionBreak
+	case `\cols`:
+		return controlWordTypeSectionColumns
+	case `\lndscpsxn`:
+		return controlWordTypeSectionLandscape
+	case `\pgwsxn`:
+		return controlWordTypeSectionPageWidth
+	case `\pghsxn`:
+		return controlWordTypeSectionPageHeight
+	case `\marglsxn`:
+		return controlWordTypeSectionMarginLeft
+	case `\margrsxn`:
+		return controlWordTypeSectionMarginRight
+	case `\margtsxn`:
+		return controlWordTypeSectionMarginTop
+	case `\margbsxn`:
+		return controlWordTypeSectionMarginBottom
+
+	// document formatting
+	case `\deftab`:
+		return controlWordTypeDefaultTab
 
 	default:
 		return controlWordTypeUnknown
@@ -529,3 +1307,41 @@ func newTextToken(value string) textToken {
 		value: value,
 	}
 }
+
+type ignorableToken struct {
+}
+
+func (i ignorableToken) tokenType() tokenType {
+	return tokenTypeIgnorable
+}
+
+func (i ignorableToken) String() string {
+	return "{Ignorable}"
+}
+
+func newIgnorableToken() ignorableToken {
+	return ignorableToken{}
+}
+
+// controlSymbolToken is a backslash followed by a single non-letter
+// character, such as \~ or \-. The \'hh hexadecimal escape is a control
+// symbol whose parameter holds the decoded byte, or -1 if it is malformed.
+type controlSymbolToken struct {
+	symbol    byte
+	parameter int
+}
+
+func (c controlSymbolToken) tokenType() tokenType {
+	return tokenTypeControlSymbol
+}
+
+func (c controlSymbolToken) String() string {
+	return fmt.Sprintf("{ControlSymbol %c %d}", c.symbol, c.parameter)
+}
+
+func newControlSymbolToken(symbol byte, parameter int) controlSymbolToken {
+	return controlSymbolToken{
+		symbol:    symbol,
+		parameter: parameter,
+	}
+}
@@ -0,0 +1,338 @@
+package gortf
+
+import (
+	"io"
+	"strings"
+)
+
+// Node is an element of a document tree: either a *Group or a Token that is
+// not a group delimiter.
+type Node interface {
+	isNode()
+}
+
+func (t Token) isNode() {}
+
+func (g *Group) isNode() {}
+
+// Group is a brace-delimited RTF group along with everything it contains,
+// including nested groups.
+type Group struct {
+	// Destination is the name of the destination the group introduces, such
+	// as "fonttbl" or "author". It is empty for groups that only scope
+	// formatting.
+	Destination string
+
+	// Ignorable is set for destinations marked with \*, which readers that
+	// do not understand them must skip.
+	Ignorable bool
+
+	Children []Node
+
+	// Offset and End are the byte offsets of the group in the source, End
+	// being exclusive.
+	Offset int
+	End    int
+
+	// set when the source ended before the group was closed, so that it is
+	// written back without a closing brace
+	unterminated bool
+
+	// set on the root returned for a source without any group, which has no
+	// braces of its own
+	synthetic bool
+
+	// nodes found before and after the root group of a document, kept so
+	// that the tree serializes back to its exact source
+	leading  []Node
+	trailing []Node
+}
+
+// destinations are the control words that introduce a destination when they
+// start a group, in addition to any control word following \*.
+var destinations = map[string]bool{
+	"rtf": true, "fonttbl": true, "filetbl": true, "colortbl": true, "stylesheet": true,
+	"listtable": true, "list": true, "listname": true, "listoverridetable": true,
+	"listoverride": true, "listtext": true, "leveltext": true, "levelnumbers": true,
+	"revtbl": true, "rsidtbl": true, "info": true, "title": true, "subject": true,
+	"author": true, "manager": true, "company": true, "operator": true,
+	"category": true, "keywords": true, "comment": true, "doccom": true,
+	"hlinkbase": true, "creatim": true, "revtim": true, "printim": true,
+	"buptim": true, "userprops": true, "propname": true, "staticval": true,
+	"linkval": true, "falt": true, "panose": true, "fname": true, "fontemb": true,
+	"fontfile": true,
+	"header":   true, "headerl": true, "headerr": true, "headerf": true,
+	"footer": true, "footerl": true, "footerr": true, "footerf": true,
+	"footnote": true, "field": true, "fldinst": true, "fldrslt": true,
+	"pict": true, "object": true, "objdata": true, "objclass": true,
+	"objname": true, "result": true, "shp": true, "shpinst": true,
+	"shprslt": true, "shptxt": true, "sp": true, "sn": true, "sv": true,
+	"shppict": true, "nonshppict": true, "annotation": true, "atnid": true,
+	"atnauthor": true, "atndate": true, "atnref": true, "atrfstart": true,
+	"atrfend": true, "bkmkstart": true, "bkmkend": true, "pn": true,
+	"pntext": true, "pntxta": true, "pntxtb": true, "txe": true, "xe": true,
+	"tc": true, "generator": true, "themedata": true, "colorschememapping": true,
+	"datastore": true, "latentstyles": true, "xmlnstbl": true,
+	"NeXTGraphic": true,
+}
+
+// buildTree reads every token from the lexer and nests them into groups. The
+// first top-level group becomes the root; anything around it, such as the
+// groups Word writes after it, is kept aside.
+// Groups left open at the end of the source are closed implicitly.
+func buildTree(lexer *Lexer) (*Group, error) {
+	var root *Group
+	leading := []Node{}
+	trailing := []Node{}
+	stack := []*Group{}
+
+	for {
+		tkn, err := lexer.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case tkn.Kind == TokenKindGroupStart:
+			group := &Group{Offset: tkn.Offset, End: tkn.End}
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, group)
+			case root == nil:
+				root = group
+			default:
+				trailing = append(trailing, group)
+			}
+			stack = append(stack, group)
+
+		case tkn.Kind == TokenKindGroupEnd && len(stack) > 0:
+			group := stack[len(stack)-1]
+			group.End = tkn.End
+			group.Destination, group.Ignorable = destinationOf(group)
+			stack = stack[:len(stack)-1]
+
+		case len(stack) > 0:
+			group := stack[len(stack)-1]
+			group.Children = append(group.Children, tkn)
+			group.End = tkn.End
+
+		case root == nil:
+			leading = append(leading, tkn)
+
+		default:
+			trailing = append(trailing, tkn)
+		}
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		group := stack[i]
+		group.unterminated = true
+		group.Destination, group.Ignorable = destinationOf(group)
+		if i > 0 {
+			stack[i-1].End = group.End
+		}
+	}
+
+	if root == nil {
+		root = &Group{synthetic: true}
+	}
+	root.leading = leading
+	root.trailing = trailing
+
+	return root, nil
+}
+
+// destinationOf determines the destination a group introduces from its
+// leading \* control symbol and control word.
+func destinationOf(g *Group) (string, bool) {
+	ignorable := false
+
+	for _, child := range g.Children {
+		tkn, ok := child.(Token)
+		if !ok {
+			return "", false
+		}
+
+		switch {
+		case tkn.Kind == TokenKindControlSymbol && tkn.Name == "*" && !ignorable:
+			ignorable = true
+		case tkn.Kind == TokenKindControlWord:
+			if ignorable || destinations[tkn.Name] {
+				return tkn.Name, ignorable
+			}
+			return "", false
+		case tkn.Kind == TokenKindText && strings.TrimSpace(stripNewlines(tkn.Text)) == "":
+			// whitespace between \* and the control word
+		default:
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+// Groups returns the groups directly contained in the group.
+func (g *Group) Groups() []*Group {
+	groups := []*Group{}
+	for _, child := range g.Children {
+		if group, ok := child.(*Group); ok {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// Find returns the first group matching a slash-separated path of
+// destination names relative to g, such as "info/author", or nil if there is
+// none. Each path element matches a directly nested group.
+func (g *Group) Find(path string) *Group {
+	matches := g.FindAll(path)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	return matches[0]
+}
+
+// FindAll returns every group matching a slash-separated path of destination
+// names relative to g, in document order.
+func (g *Group) FindAll(path string) []*Group {
+	current := []*Group{g}
+
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		next := []*Group{}
+		for _, group := range current {
+			for _, child := range group.Groups() {
+				if child.Destination == name {
+					next = append(next, child)
+				}
+			}
+		}
+
+		current = next
+	}
+
+	return current
+}
+
+// ControlWord returns the first control word with the given name, without
+// its backslash, directly contained in the group.
+func (g *Group) ControlWord(name string) (Token, bool) {
+	for _, child := range g.Children {
+		tkn, ok := child.(Token)
+		if ok && tkn.Kind == TokenKindControlWord && tkn.Name == name {
+			return tkn, true
+		}
+	}
+
+	return Token{}, false
+}
+
+// tokens returns the tokens of the group in document order, including those
+// of nested groups that only scope formatting but not of nested destinations.
+func (g *Group) tokens() []Token {
+	tokens := []Token{}
+
+	for _, child := range g.Children {
+		switch node := child.(type) {
+		case *Group:
+			if node.Destination == "" {
+				tokens = append(tokens, node.tokens()...)
+			}
+		case Token:
+			tokens = append(tokens, node)
+		}
+	}
+
+	return tokens
+}
+
+// Text returns the plain text of the group, as it would appear in the body of
+// a document, skipping nested destinations.
+func (g *Group) Text() string {
+	parser := NewRtfParser()
+	return parser.textFromGroup(g, codePageWindowsLatin1)
+}
+
+// WriteTo serializes the group as RTF. A tree obtained from
+// RtfParser.ParseTree is written back byte for byte as long as none of its
+// tokens were modified.
+func (g *Group) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{writer: w}
+	err := g.write(NewWriter(counter), true)
+
+	return counter.count, err
+}
+
+func (g *Group) String() string {
+	var sb strings.Builder
+	g.WriteTo(&sb)
+
+	return sb.String()
+}
+
+func (g *Group) write(w *Writer, outermost bool) error {
+	if outermost {
+		for _, node := range g.leading {
+			if err := writeNode(w, node); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !g.synthetic {
+		if err := w.WriteToken(Token{Kind: TokenKindGroupStart}); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range g.Children {
+		if err := writeNode(w, child); err != nil {
+			return err
+		}
+	}
+
+	if !g.unterminated && !g.synthetic {
+		if err := w.WriteToken(Token{Kind: TokenKindGroupEnd}); err != nil {
+			return err
+		}
+	}
+
+	if outermost {
+		for _, node := range g.trailing {
+			if err := writeNode(w, node); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeNode(w *Writer, node Node) error {
+	switch n := node.(type) {
+	case *Group:
+		return n.write(w, false)
+	case Token:
+		return w.WriteToken(n)
+	default:
+		return nil
+	}
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.count += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,141 @@
+package gortf
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseTreeRoundTrip(t *testing.T) {
+	content, err := os.ReadFile("./testfiles/minimal.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser := NewRtfParser()
+	root, err := parser.ParseTree(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.String() != string(content) {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", string(content), root.String())
+	}
+}
+
+func TestParseTreeUnbalanced(t *testing.T) {
+	content := `prefix{\rtf1{\b bold}}} trailing`
+
+	parser := NewRtfParser()
+	root, err := parser.ParseTree(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.String() != content {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", content, root.String())
+	}
+
+	content = `{\rtf1{\b bold`
+	root, err = parser.ParseTree(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.String() != content {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", content, root.String())
+	}
+}
+
+func TestParseTreeTrailingGroups(t *testing.T) {
+	content := `{\rtf1 text}{\*\themedata 504b}{\*\colorschememapping 3c3f}` + "\n"
+
+	parser := NewRtfParser()
+	root, err := parser.ParseTree(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	destinations := []string{}
+	for _, node := range root.trailing {
+		if group, ok := node.(*Group); ok {
+			destinations = append(destinations, group.Destination)
+		}
+	}
+
+	expected := []string{"themedata", "colorschememapping"}
+	if !reflect.DeepEqual(expected, destinations) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, destinations)
+	}
+
+	if root.String() != content {
+		t.Errorf("\n\nexpected: %q\n\nactual\t: %q", content, root.String())
+	}
+}
+
+func TestParseTreeDestinations(t *testing.T) {
+	parser := NewRtfParser()
+	tree, err := parser.ParseTreeFile("./testfiles/minimal.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.Destination != "rtf" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "rtf", tree.Destination)
+	}
+
+	destinations := []string{}
+	ignorable := []bool{}
+	for _, group := range tree.Groups() {
+		destinations = append(destinations, group.Destination)
+		ignorable = append(ignorable, group.Ignorable)
+	}
+
+	expectedDestinations := []string{"fonttbl", "colortbl", "expandedcolortbl", "info"}
+	if !reflect.DeepEqual(destinations, expectedDestinations) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedDestinations, destinations)
+	}
+
+	expectedIgnorable := []bool{false, false, true, false}
+	if !reflect.DeepEqual(ignorable, expectedIgnorable) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expectedIgnorable, ignorable)
+	}
+
+	author := tree.Find("info/author")
+	if author == nil {
+		t.Fatal("info/author not found")
+	}
+
+	if author.Text() != "word" {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", "word", author.Text())
+	}
+
+	if tree.Find("info/title") != nil {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", nil, tree.Find("info/title"))
+	}
+
+	cocoa, ok := tree.ControlWord("cocoartf")
+	if !ok || cocoa.Param != 2759 {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", 2759, cocoa)
+	}
+}
+
+func TestParseNestedFormatting(t *testing.T) {
+	content := `{\rtf1{\fonttbl\f0\fswiss Helvetica;}\f0 {\b bold {\i both} bold}}`
+
+	parser := NewRtfParser()
+	doc, err := parser.ParseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []StyleBlock{
+		{Painter: Painter{Bold: true}, Text: "bold "},
+		{Painter: Painter{Bold: true, Italic: true}, Text: "both"},
+		{Painter: Painter{Bold: true}, Text: " bold"},
+	}
+
+	if !reflect.DeepEqual(doc.Body, expected) {
+		t.Errorf("\n\nexpected: %v\n\nactual\t: %v", expected, doc.Body)
+	}
+}
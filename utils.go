@@ -1,33 +1,19 @@
 package gortf
 
-func splitAtFirstWhitespace(text string) (string, string) {
-	var head string
-	var tail string
+import "strings"
 
-	whitespaceIndex := -1
-	for idx := range text {
-		if isWhitespace(text[idx]) {
-			whitespaceIndex = idx
-			break
-		}
-	}
+var newlineStripper = strings.NewReplacer("\r", "", "\n", "")
 
-	if whitespaceIndex > -1 {
-		head = text[:whitespaceIndex]
-		tail = text[whitespaceIndex+1:]
-	} else {
-		head = text
-	}
-
-	return head, tail
+func stripNewlines(text string) string {
+	return newlineStripper.Replace(text)
 }
 
-func isWhitespace(ch byte) bool {
-	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\v' || ch == '\f'
+func isDelimiter(c byte) bool {
+	return c == '\\' || c == '{' || c == '}'
 }
 
-func isAlphaLower(c byte) bool {
-	return c >= 'a' && c <= 'z'
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
 }
 
 func isNumber(c byte) bool {
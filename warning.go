@@ -0,0 +1,80 @@
+package gortf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warning is a problem found in a document that did not prevent it from
+// being parsed, such as a group that is never closed.
+type Warning struct {
+	// Offset is the byte offset in the source the warning refers to.
+	Offset  int
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("offset %d: %s", w.Offset, w.Message)
+}
+
+// checkTree reports the structural problems of a document tree: content
+// outside of the document group, a missing \rtf header and unclosed groups.
+func (r *RtfParser) checkTree(root *Group) {
+	if root.synthetic {
+		r.warn(0, "document is not enclosed in a group")
+		return
+	}
+
+	if root.Destination != "rtf" {
+		r.warn(root.Offset, `document does not start with \rtf`)
+	}
+
+	for _, node := range root.leading {
+		if tkn, ok := node.(Token); ok && !isWhitespaceToken(tkn) {
+			r.warn(tkn.Offset, "content before the start of the document")
+			break
+		}
+	}
+
+	// groups after the root, such as the theme data of Word, are left alone
+	for _, node := range root.trailing {
+		tkn, ok := node.(Token)
+		if !ok || isWhitespaceToken(tkn) {
+			continue
+		}
+
+		if tkn.Kind == TokenKindGroupEnd {
+			r.warn(tkn.Offset, "unmatched closing brace")
+			break
+		}
+		if tkn.Kind == TokenKindText {
+			r.warn(tkn.Offset, "content after the end of the document")
+			break
+		}
+	}
+
+	r.checkGroup(root)
+	for _, node := range root.trailing {
+		if group, ok := node.(*Group); ok {
+			r.checkGroup(group)
+		}
+	}
+}
+
+func (r *RtfParser) checkGroup(g *Group) {
+	if g.unterminated {
+		r.warn(g.Offset, "group is not closed")
+	}
+
+	for _, group := range g.Groups() {
+		r.checkGroup(group)
+	}
+}
+
+func isWhitespaceToken(tkn Token) bool {
+	return tkn.Kind == TokenKindText && strings.TrimSpace(tkn.Text) == ""
+}
+
+func (r *RtfParser) warn(offset int, message string) {
+	r.warnings = append(r.warnings, Warning{Offset: offset, Message: message})
+}
@@ -0,0 +1,35 @@
+package gortf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []Warning
+	}{
+		{`{\rtf1 fine}` + "\n", nil},
+		{`{\rtf1 open {\b bold}`, []Warning{{Offset: 0, Message: "group is not closed"}}},
+		{`{\rtf1 extra}}`, []Warning{{Offset: 13, Message: "unmatched closing brace"}}},
+		{`{\rtf1 text} more`, []Warning{{Offset: 12, Message: "content after the end of the document"}}},
+		{`{\rtf1 text}{\*\themedata 504b}` + "\n", nil},
+		{`{\rtf1 text}{\*\themedata 504b} more`, []Warning{{Offset: 31, Message: "content after the end of the document"}}},
+		{`{\rtf1 text}{\*\themedata 504b`, []Warning{{Offset: 12, Message: "group is not closed"}}},
+		{`junk{\rtf1 text}`, []Warning{{Offset: 0, Message: "content before the start of the document"}}},
+		{`{\b text}`, []Warning{{Offset: 0, Message: `document does not start with \rtf`}}},
+		{`plain text`, []Warning{{Offset: 0, Message: "document is not enclosed in a group"}}},
+	}
+
+	for _, test := range tests {
+		parser := NewRtfParser()
+		if _, err := parser.ParseContent(test.content); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(parser.Warnings(), test.expected) {
+			t.Errorf("\n\nexpected: %v\n\nactual\t: %v", test.expected, parser.Warnings())
+		}
+	}
+}
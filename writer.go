@@ -0,0 +1,145 @@
+package gortf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Writer emits tokens as RTF. Tokens produced by a Lexer are written back
+// exactly as they appeared in the source unless they have been modified, so
+// copying every token from a Lexer to a Writer reproduces the input byte for
+// byte. Modified and newly created tokens are encoded from their fields.
+type Writer struct {
+	writer io.Writer
+
+	// the last control word written, if it was not delimited by a space, in
+	// which case some text must be separated from it
+	openControlWord string
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		writer: w,
+	}
+}
+
+// WriteToken writes a single token.
+func (w *Writer) WriteToken(t Token) error {
+	encoded := t.source.raw
+	if encoded == "" || !t.unmodified() {
+		encoded = encodeToken(t)
+	}
+
+	if w.openControlWord != "" && t.Kind == TokenKindText && extendsControlWord(w.openControlWord, encoded) {
+		if _, err := io.WriteString(w.writer, " "); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w.writer, encoded); err != nil {
+		return err
+	}
+
+	w.openControlWord = ""
+	if t.Kind == TokenKindControlWord && !strings.HasSuffix(encoded, " ") {
+		w.openControlWord = encoded
+	}
+
+	return nil
+}
+
+// WriteTokens writes each of the tokens in turn.
+func (w *Writer) WriteTokens(tokens []Token) error {
+	for _, t := range tokens {
+		if err := w.WriteToken(t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extendsControlWord reports whether text written right after a control
+// word would be read as part of it: a letter as part of its name unless it
+// is as long as names get, a digit or a minus sign as part of its parameter
+// unless it has all of its digits, and a space as its delimiter.
+func extendsControlWord(word string, text string) bool {
+	if text == "" {
+		return false
+	}
+
+	name := strings.TrimRight(word, "0123456789")
+	digits := len(word) - len(name)
+	name = strings.TrimSuffix(name, "-")
+
+	switch c := text[0]; {
+	case c == ' ':
+		return true
+	case isAlpha(c):
+		return digits == 0 && len(name)-1 < maxControlWordLength
+	case isNumber(c):
+		return digits < maxControlWordParameterLength
+	case c == '-':
+		return digits == 0 && (len(text) == 1 || isNumber(text[1]))
+	default:
+		return false
+	}
+}
+
+func encodeToken(t Token) string {
+	switch t.Kind {
+	case TokenKindGroupStart:
+		return "{"
+	case TokenKindGroupEnd:
+		return "}"
+	case TokenKindControlWord:
+		if t.HasParam {
+			return `\` + t.Name + strconv.Itoa(t.Param)
+		}
+		return `\` + t.Name
+	case TokenKindControlSymbol:
+		if t.Name == "'" && t.HasParam {
+			return fmt.Sprintf(`\'%02x`, t.Param&0xff)
+		}
+		return `\` + t.Name
+	case TokenKindText:
+		return encodeText(t.Text)
+	case TokenKindBinary:
+		return `\bin` + strconv.Itoa(len(t.Data)) + " " + string(t.Data)
+	default:
+		return ""
+	}
+}
+
+// encodeText escapes the RTF special characters in text and encodes non-ASCII
+// characters as \u control words with a question mark as fallback.
+func encodeText(text string) string {
+	var sb strings.Builder
+
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r < 0x80:
+			sb.WriteRune(r)
+		default:
+			units := []rune{r}
+			if r > 0xffff {
+				units = []rune{}
+				r1, r2 := utf16.EncodeRune(r)
+				units = append(units, r1, r2)
+			}
+
+			for _, unit := range units {
+				// \u takes a signed 16-bit parameter
+				sb.WriteString(`\u` + strconv.Itoa(int(int16(uint16(unit)))) + "?")
+			}
+		}
+	}
+
+	return sb.String()
+}